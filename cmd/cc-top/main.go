@@ -8,19 +8,25 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/nixlim/cc-top/internal/alerts"
+	"github.com/nixlim/cc-top/internal/budget"
 	"github.com/nixlim/cc-top/internal/burnrate"
 	"github.com/nixlim/cc-top/internal/config"
 	"github.com/nixlim/cc-top/internal/correlator"
+	"github.com/nixlim/cc-top/internal/enforce"
 	"github.com/nixlim/cc-top/internal/events"
+	"github.com/nixlim/cc-top/internal/gitctx"
 	"github.com/nixlim/cc-top/internal/receiver"
 	"github.com/nixlim/cc-top/internal/scanner"
 	"github.com/nixlim/cc-top/internal/state"
 	"github.com/nixlim/cc-top/internal/stats"
+	"github.com/nixlim/cc-top/internal/transcript"
 	"github.com/nixlim/cc-top/internal/tui"
 )
 
@@ -51,12 +57,24 @@ func main() {
 	// Create the state store.
 	store := state.NewMemoryStore()
 
-	// Create the process scanner.
+	// Create the process scanner. With discovery = "auto", exec/exit
+	// notifications drive discovery and full scans become a fallback; if
+	// no watcher is available the scanner keeps polling.
 	proc := scanner.NewDefaultScanner(cfg.Scanner.IntervalSeconds)
+	if cfg.Scanner.Discovery == "auto" {
+		fallback := time.Duration(cfg.Scanner.FallbackIntervalSeconds) * time.Second
+		if _, err := proc.EnableEventDiscovery(fallback); err != nil {
+			fmt.Fprintf(os.Stderr, "cc-top: warning: process events unavailable, polling every %ds instead: %v\n",
+				cfg.Scanner.IntervalSeconds, err)
+		}
+	}
 
-	// Create the correlator for PID-to-session mapping.
+	// Create the correlator for PID-to-session mapping, fed by process
+	// lifecycle events from the scanner.
 	portMapper := correlator.NewScannerPortMapper(proc.API())
 	corr := correlator.NewCorrelator(portMapper, cfg.Receiver.GRPCPort)
+	bridge := &processBridge{scanner: proc, corr: corr, store: store, git: gitctx.NewResolver()}
+	proc.OnProcessEvent(bridge.handle)
 
 	// Set up OTEL debug logging if --debug flag is provided.
 	var recvOpts []receiver.ReceiverOption
@@ -71,7 +89,7 @@ func main() {
 	}
 
 	// Create the OTLP receiver (gRPC + HTTP).
-	recv := receiver.New(cfg.Receiver, store, &portMapperAdapter{corr: corr}, recvOpts...)
+	recv := receiver.New(cfg.Receiver, store, &portMapperAdapter{corr: corr, bridge: bridge}, recvOpts...)
 
 	// Create the event buffer and formatter bridge.
 	eventBuf := events.NewRingBuffer(cfg.Display.EventBufferSize)
@@ -83,14 +101,61 @@ func main() {
 	})
 
 	// Create the burn rate calculator.
-	brCalc := burnrate.NewCalculator(burnrate.Thresholds{
-		GreenBelow:  cfg.Display.CostColorGreenBelow,
-		YellowBelow: cfg.Display.CostColorYellowBelow,
-	})
+	brCalc := burnrate.NewCalculator(
+		burnrate.Thresholds{
+			GreenBelow:  cfg.Display.CostColorGreenBelow,
+			YellowBelow: cfg.Display.CostColorYellowBelow,
+		},
+		burnrate.WithRateWindow(time.Duration(cfg.BurnRate.RateWindowMinutes)*time.Minute),
+		burnrate.WithTrendWindow(time.Duration(cfg.BurnRate.TrendWindowMinutes)*time.Minute),
+	)
 
-	// Create the alert engine.
-	notifier := alerts.NewPlatformNotifier(cfg.Alerts.Notifications.SystemNotify)
-	alertEngine := alerts.NewEngine(store, cfg, brCalc, alerts.WithNotifier(notifier))
+	// Create the alert engine. Alerts go to the desktop and to any
+	// configured webhooks and commands. Desktop notification buttons act
+	// through the engine and enforcer, set below before any alert fires.
+	actions := &notificationActions{}
+	notifiers := alerts.NewCompositeNotifier()
+	defer notifiers.Close()
+	notifiers.Add("desktop", alerts.NewPlatformNotifier(cfg.Alerts.Notifications.SystemNotify, actions))
+	for _, wc := range cfg.Alerts.Notifications.Webhooks {
+		webhook, err := alerts.NewWebhookNotifier(wc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cc-top: %v\n", err)
+			os.Exit(1)
+		}
+		notifiers.Add(wc.Name, webhook)
+	}
+	for _, ec := range cfg.Alerts.Notifications.Exec {
+		notifiers.Add(ec.Name, alerts.NewExecNotifier(ec, store))
+	}
+	// The budget tracker is shared by the Budget alert rule, the enforcer
+	// and the budgets panel.
+	budgetTracker := budget.NewTracker(cfg.Budgets, brCalc)
+	alertEngine := alerts.NewEngine(store, cfg, brCalc,
+		alerts.WithNotifier(notifiers), alerts.WithBudgetTracker(budgetTracker))
+
+	// Create the enforcer that acts on breaches of any configured policies
+	// and pauses sessions on request. Policies are only enabled when their
+	// actions can be audited. A session is only signalled while its PID
+	// still belongs to the process it started in.
+	enforceOpts := []enforce.EnforcerOption{enforce.WithStartTimeLookup(func(pid int) (time.Time, error) {
+		stat, err := proc.API().GetProcessStat(pid)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return stat.StartTime, nil
+	})}
+	if len(cfg.Enforcement.Policies) > 0 {
+		auditLog, err := enforce.OpenAuditLog(cfg.Enforcement.AuditLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cc-top: enforcement: %v\n", err)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+		enforceOpts = append(enforceOpts, enforce.WithAuditLog(auditLog))
+	}
+	enforcer := enforce.NewEnforcer(store, cfg, budgetTracker, enforceOpts...)
+	actions.engine, actions.enforcer = alertEngine, enforcer
 
 	// Create the stats calculator.
 	statsCalc := stats.NewCalculator(cfg.Pricing)
@@ -127,23 +192,49 @@ func main() {
 	proc.Scan()
 	proc.StartPeriodicScan()
 
-	// Start the alert engine.
+	// Start the alert engine and enforcement.
 	alertEngine.Start(ctx)
+	enforcer.Start(ctx)
+
+	// Keep the git context of live sessions current.
+	go bridge.watchGit(ctx)
+
+	// Backfill sessions without OTLP telemetry from their transcripts.
+	scanAdapter := &scannerAdapter{scanner: proc, cfg: cfg, store: store}
+	var ingester *transcript.Ingester
+	if cfg.Transcripts.Enabled {
+		ingester = transcript.NewIngester(store, cfg.Transcripts.Dir, cfg.Pricing,
+			transcript.WithInterval(time.Duration(cfg.Transcripts.PollIntervalSeconds)*time.Second),
+			transcript.WithFilter(func(_, cwd string) bool { return !scanAdapter.expectsOTLP(cwd) }),
+		)
+		ingester.Start(ctx)
+	}
+	stopBackground := func() {
+		alertEngine.Stop()
+		enforcer.Stop()
+		if ingester != nil {
+			ingester.Stop()
+		}
+	}
 
 	// Create the TUI model with all providers wired up.
-	model := tui.NewModel(cfg,
+	opts := []tui.ModelOption{
 		tui.WithStateProvider(store),
-		tui.WithScannerProvider(&scannerAdapter{scanner: proc, cfg: cfg, store: store}),
+		tui.WithScannerProvider(scanAdapter),
 		tui.WithBurnRateProvider(&burnRateAdapter{calc: brCalc, store: store}),
+		tui.WithBudgetProvider(&budgetAdapter{tracker: budgetTracker, store: store}),
 		tui.WithEventProvider(&eventAdapter{buf: eventBuf}),
 		tui.WithAlertProvider(&alertAdapter{engine: alertEngine}),
+		tui.WithEnforcementProvider(enforcer),
+		tui.WithDeliveryStatusProvider(notifiers),
 		tui.WithStatsProvider(&statsAdapter{calc: statsCalc, store: store}),
 		tui.WithStartView(tui.ViewStartup),
 		tui.WithOnShutdown(func() {
-			alertEngine.Stop()
+			stopBackground()
 			_ = shutdownMgr.Shutdown()
 		}),
-	)
+	}
+	model := tui.NewModel(cfg, opts...)
 
 	// Create and run the Bubble Tea program.
 	p := tea.NewProgram(model,
@@ -154,7 +245,7 @@ func main() {
 	go func() {
 		select {
 		case <-sigCh:
-			alertEngine.Stop()
+			stopBackground()
 			_ = shutdownMgr.Shutdown()
 			p.Quit()
 		case <-ctx.Done():
@@ -171,11 +262,126 @@ func main() {
 
 // portMapperAdapter bridges correlator.Correlator to receiver.PortMapper.
 type portMapperAdapter struct {
-	corr *correlator.Correlator
+	corr   *correlator.Correlator
+	bridge *processBridge
 }
 
 func (a *portMapperAdapter) RecordSourcePort(sourcePort int, sessionID string) {
 	a.corr.RecordConnection(sourcePort, sessionID)
+	if a.corr.GetPIDForSession(sessionID) == 0 {
+		a.bridge.correlateThrottled()
+	}
+}
+
+// correlateInterval limits how often telemetry from uncorrelated sessions
+// triggers a correlation pass (each pass inspects sockets of every
+// uncorrelated Claude Code process).
+const correlateInterval = time.Second
+
+// gitRefreshInterval is how often the git context of live sessions is
+// refreshed. The resolver's cache bounds how often .git is actually read.
+const gitRefreshInterval = 5 * time.Second
+
+// processBridge feeds scanner process events into the correlator and the
+// state store, so sessions are linked to their PID (and its working
+// directory and git context) as soon as the process appears and marked
+// exited as soon as it goes away.
+type processBridge struct {
+	scanner *scanner.Scanner
+	corr    *correlator.Correlator
+	store   *state.MemoryStore
+	git     *gitctx.Resolver
+
+	mu            sync.Mutex
+	lastCorrelate time.Time
+}
+
+func (b *processBridge) handle(ev scanner.ProcessEvent) {
+	switch ev.Type {
+	case scanner.ProcessStarted:
+		b.corr.RecordPID(ev.Process.PID, ev.Process.StartTime)
+	case scanner.ProcessExited:
+		b.corr.RemovePID(ev.Process.PID)
+		b.store.MarkExited(ev.Process.PID, ev.Process.StartTime)
+	}
+	b.correlate()
+}
+
+// correlateThrottled runs correlate unless it ran within correlateInterval.
+func (b *processBridge) correlateThrottled() {
+	b.mu.Lock()
+	if time.Since(b.lastCorrelate) < correlateInterval {
+		b.mu.Unlock()
+		return
+	}
+	b.lastCorrelate = time.Now()
+	b.mu.Unlock()
+
+	b.correlate()
+}
+
+// correlate matches live Claude Code processes to sessions and records
+// each correlated process (PID, start time and CWD) on its session in the
+// store.
+func (b *processBridge) correlate() {
+	var active []int
+	live := make(map[int]scanner.ProcessInfo)
+	for _, p := range b.scanner.GetProcesses() {
+		if !p.Exited {
+			active = append(active, p.PID)
+			live[p.PID] = p
+		}
+	}
+	b.corr.Correlate(active)
+
+	for pid, sessionID := range b.corr.GetCorrelation() {
+		p, ok := live[pid]
+		if !ok {
+			continue
+		}
+		b.store.UpdatePID(sessionID, pid, p.StartTime)
+		b.store.UpdateInvocation(sessionID, string(p.Mode), p.ParentApp)
+		if p.CWD != "" {
+			b.store.UpdateCWD(sessionID, p.CWD)
+			b.updateGit(sessionID, p.CWD)
+		}
+	}
+}
+
+// watchGit refreshes the git context of live sessions until ctx is done.
+func (b *processBridge) watchGit(ctx context.Context) {
+	ticker := time.NewTicker(gitRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, s := range b.store.ListSessions() {
+				if s.CWD != "" && !s.Exited {
+					b.updateGit(s.SessionID, s.CWD)
+				}
+			}
+		}
+	}
+}
+
+// updateGit resolves the git context of cwd and stores it on the session.
+// A directory outside any repository clears it.
+func (b *processBridge) updateGit(sessionID, cwd string) {
+	info, err := b.git.Resolve(cwd)
+	if err != nil {
+		b.store.UpdateGit(sessionID, state.GitInfo{})
+		return
+	}
+	b.store.UpdateGit(sessionID, state.GitInfo{
+		RepoRoot:  info.Root,
+		Repo:      info.Repo,
+		Branch:    info.Branch,
+		Head:      info.Head,
+		Dirty:     info.Dirty,
+		CommonDir: info.CommonDir,
+	})
 }
 
 // scannerAdapter bridges scanner.Scanner to tui.ScannerProvider.
@@ -195,13 +401,34 @@ func (a *scannerAdapter) GetTelemetryStatus(p scanner.ProcessInfo) scanner.Statu
 	hasData := false
 	if a.store != nil {
 		for _, s := range a.store.ListSessions() {
-			if s.PID == p.PID {
+			if s.PID == p.PID && (s.PIDStartTime.IsZero() || p.StartTime.IsZero() || s.PIDStartTime.Equal(p.StartTime)) {
 				hasData = true
 				break
 			}
 		}
 	}
-	return scanner.ClassifyTelemetry(p, a.cfg.Receiver.GRPCPort, hasData)
+	ports := scanner.ReceiverPorts{
+		GRPC: a.cfg.Receiver.GRPCPort,
+		HTTP: a.cfg.Receiver.HTTPPort,
+		Bind: a.cfg.Receiver.Bind,
+	}
+	return scanner.ClassifyTelemetry(p, ports, hasData)
+}
+
+// expectsOTLP reports whether a live Claude Code process running in cwd
+// is configured to send telemetry to cc-top. Such sessions are left to
+// OTLP rather than backfilled from their transcripts.
+func (a *scannerAdapter) expectsOTLP(cwd string) bool {
+	for _, p := range a.scanner.GetProcesses() {
+		if p.Exited || p.CWD != cwd {
+			continue
+		}
+		switch a.GetTelemetryStatus(p).Status {
+		case scanner.TelemetryConnected, scanner.TelemetryWaiting:
+			return true
+		}
+	}
+	return false
 }
 
 func (a *scannerAdapter) Rescan() {
@@ -215,14 +442,19 @@ type burnRateAdapter struct {
 }
 
 func (a *burnRateAdapter) Get(sessionID string) burnrate.BurnRate {
-	// For now, compute global. Per-session burn rate would need a filtered store.
-	return a.calc.Compute(a.store)
+	a.calc.Compute(a.store)
+	br, _ := a.calc.Session(sessionID)
+	return br
 }
 
 func (a *burnRateAdapter) GetGlobal() burnrate.BurnRate {
 	return a.calc.Compute(a.store)
 }
 
+func (a *burnRateAdapter) Series(span time.Duration) []burnrate.Point {
+	return a.calc.Series(span)
+}
+
 // eventAdapter bridges events.RingBuffer to tui.EventProvider.
 type eventAdapter struct {
 	buf *events.RingBuffer
@@ -250,11 +482,11 @@ type alertAdapter struct {
 }
 
 func (a *alertAdapter) Active() []alerts.Alert {
-	return a.engine.Alerts()
+	return a.engine.Active()
 }
 
 func (a *alertAdapter) ActiveForSession(sessionID string) []alerts.Alert {
-	all := a.engine.Alerts()
+	all := a.engine.Active()
 	var result []alerts.Alert
 	for _, alert := range all {
 		if alert.SessionID == sessionID || alert.SessionID == "" {
@@ -264,6 +496,38 @@ func (a *alertAdapter) ActiveForSession(sessionID string) []alerts.Alert {
 	return result
 }
 
+func (a *alertAdapter) Acknowledge(id uint64) error {
+	return a.engine.Acknowledge(id)
+}
+
+func (a *alertAdapter) Snooze(id uint64, until time.Time) error {
+	return a.engine.Snooze(id, until)
+}
+
+// notificationActions carries out the buttons on desktop notifications.
+type notificationActions struct {
+	engine   *alerts.Engine
+	enforcer *enforce.Enforcer
+}
+
+func (a *notificationActions) PauseSession(sessionID string) error {
+	return a.enforcer.Pause(sessionID, "paused from desktop notification")
+}
+
+func (a *notificationActions) Snooze(id uint64, until time.Time) error {
+	return a.engine.Snooze(id, until)
+}
+
+// budgetAdapter bridges budget.Tracker to tui.BudgetProvider.
+type budgetAdapter struct {
+	tracker *budget.Tracker
+	store   *state.MemoryStore
+}
+
+func (a *budgetAdapter) Budgets() []budget.Status {
+	return a.tracker.Evaluate(a.store, time.Now())
+}
+
 // statsAdapter bridges stats.Calculator to tui.StatsProvider.
 type statsAdapter struct {
 	calc  *stats.Calculator
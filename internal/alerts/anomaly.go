@@ -0,0 +1,220 @@
+package alerts
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/burnrate"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+const (
+	// anomalySampleInterval is how often each project's activity is
+	// sampled into its baseline.
+	anomalySampleInterval = time.Minute
+	// anomalyMinRelStd floors a baseline's standard deviation at this
+	// fraction of its mean, so a perfectly steady baseline does not make
+	// every small change look infinitely unusual.
+	anomalyMinRelStd = 0.05
+)
+
+// Quantities the Anomaly rule learns a baseline of.
+const (
+	metricCostPerMinute = iota
+	metricTokensPerTurn
+	metricToolCallsPerMinute
+	numAnomalyMetrics
+)
+
+// ewma is an exponentially weighted moving mean and variance.
+type ewma struct {
+	n        int // samples seen
+	mean     float64
+	variance float64
+}
+
+// add folds x into the mean and variance with weight alpha.
+func (e *ewma) add(x, alpha float64) {
+	if e.n == 0 {
+		e.mean = x
+	} else {
+		diff := x - e.mean
+		incr := alpha * diff
+		e.mean += incr
+		e.variance = (1 - alpha) * (e.variance + diff*incr)
+	}
+	e.n++
+}
+
+// zScore returns how many standard deviations x is above the mean.
+func (e *ewma) zScore(x float64) float64 {
+	std := max(math.Sqrt(e.variance), math.Abs(e.mean)*anomalyMinRelStd, 1e-9)
+	return (x - e.mean) / std
+}
+
+// projectBaseline is what the Anomaly rule has learned about a project.
+type projectBaseline struct {
+	metrics    [numAnomalyMetrics]ewma
+	lastSample time.Time
+	// excursions describe the metrics that were anomalous at the last
+	// sample, and sessionID the project's most recently active session,
+	// which the alert is raised for.
+	excursions []string
+	sessionID  string
+}
+
+// anomalyRule learns a baseline per project of cost per minute, tokens per
+// turn (API request) and tool calls per minute, and fires while the last
+// minute of a project's activity is far above it. Baselines are EWMAs fed
+// once a minute while the project is active; idle minutes are left out so
+// they do not drag the baseline to zero. Nothing fires until a baseline
+// has warmed up.
+type anomalyRule struct {
+	sensitivity float64 // z-score at which a sample is anomalous
+	warmup      int     // samples before a baseline can alert
+	alpha       float64 // EWMA weight of each new sample
+	calculator  *burnrate.Calculator
+	projects    map[string]*projectBaseline // by project, see projectOf
+}
+
+func newAnomalyRule(cfg config.AlertsConfig, calculator *burnrate.Calculator) *anomalyRule {
+	halfLife := float64(max(cfg.Anomaly.HalfLifeMinutes, 1))
+	return &anomalyRule{
+		sensitivity: cfg.Anomaly.Sensitivity,
+		warmup:      max(cfg.Anomaly.WarmupSamples, 2),
+		alpha:       1 - math.Exp2(-1/halfLife),
+		calculator:  calculator,
+		projects:    make(map[string]*projectBaseline),
+	}
+}
+
+// Evaluate samples each project with live sessions once a minute and fires
+// one alert per project whose last sample was anomalous, for its most
+// recently active session. Baselines outlive their sessions, so a project
+// is judged against its history when work on it resumes.
+func (r *anomalyRule) Evaluate(store state.Store, now time.Time) []Alert {
+	rates := map[string]burnrate.BurnRate{}
+	if r.calculator != nil {
+		r.calculator.ComputeWithTime(store, now)
+		rates = r.calculator.Sessions()
+	}
+
+	byProject := make(map[string][]state.SessionData)
+	for _, s := range store.ListSessions() {
+		if p := projectOf(s); p != "" && !s.Exited {
+			byProject[p] = append(byProject[p], s)
+		}
+	}
+
+	var alerts []Alert
+	for _, project := range slices.Sorted(maps.Keys(byProject)) {
+		b := r.projects[project]
+		if b == nil {
+			b = &projectBaseline{}
+			r.projects[project] = b
+		}
+		if now.Sub(b.lastSample) >= anomalySampleInterval {
+			r.sample(b, byProject[project], rates, now)
+		}
+		if len(b.excursions) > 0 {
+			alerts = append(alerts, Alert{
+				Rule:      RuleAnomaly,
+				Severity:  SeverityWarning,
+				Message:   fmt.Sprintf("Anomaly in %s: %s", project, strings.Join(b.excursions, "; ")),
+				SessionID: b.sessionID,
+				FiredAt:   now,
+			})
+		}
+	}
+	for project, b := range r.projects {
+		if byProject[project] == nil {
+			b.excursions = nil
+		}
+	}
+	return alerts
+}
+
+// sample measures the project's last minute of activity, records which
+// measurements are anomalous against the baseline, and then adds them to
+// it.
+func (r *anomalyRule) sample(b *projectBaseline, sessions []state.SessionData, rates map[string]burnrate.BurnRate, now time.Time) {
+	since := now.Add(-anomalySampleInterval)
+	b.lastSample = now
+	b.excursions = nil
+
+	var costPerMinute float64
+	var turns, toolCalls int
+	var tokens int64
+	var latest time.Time
+	for _, s := range sessions {
+		costPerMinute += rates[s.SessionID].HourlyRate / 60
+		for _, evt := range s.Events {
+			if !evt.Timestamp.After(since) || evt.Timestamp.After(now) {
+				continue
+			}
+			switch evt.Name {
+			case "claude_code.api_request":
+				turns++
+				tokens += attrInt(evt, "input_tokens") + attrInt(evt, "output_tokens")
+			case "claude_code.tool_result":
+				toolCalls++
+			}
+		}
+		if b.sessionID == "" || s.LastEventAt.After(latest) {
+			latest, b.sessionID = s.LastEventAt, s.SessionID
+		}
+	}
+	if turns == 0 {
+		return
+	}
+
+	values := [numAnomalyMetrics]float64{
+		metricCostPerMinute:      costPerMinute,
+		metricTokensPerTurn:      float64(tokens) / float64(turns),
+		metricToolCallsPerMinute: float64(toolCalls) / anomalySampleInterval.Minutes(),
+	}
+	for m, x := range values {
+		e := &b.metrics[m]
+		if e.n >= r.warmup {
+			if z := e.zScore(x); z >= r.sensitivity {
+				b.excursions = append(b.excursions, describeExcursion(m, x, e.mean, z))
+			}
+		}
+		e.add(x, r.alpha)
+	}
+}
+
+// describeExcursion describes an anomalous measurement of metric m.
+func describeExcursion(m int, x, mean, z float64) string {
+	switch m {
+	case metricCostPerMinute:
+		return fmt.Sprintf("cost $%.2f/min vs baseline $%.2f/min (z=%.1f)", x, mean, z)
+	case metricTokensPerTurn:
+		return fmt.Sprintf("%.0f tokens/turn vs baseline %.0f (z=%.1f)", x, mean, z)
+	default:
+		return fmt.Sprintf("%.1f tool calls/min vs baseline %.1f (z=%.1f)", x, mean, z)
+	}
+}
+
+// projectOf identifies the project a session works on: its git repository
+// name, or its working directory outside a repository. It is empty if
+// neither is known yet.
+func projectOf(s state.SessionData) string {
+	if s.Metadata.Git.Repo != "" {
+		return s.Metadata.Git.Repo
+	}
+	return s.CWD
+}
+
+// attrInt returns an integer event attribute, or 0 if it is missing or
+// malformed.
+func attrInt(evt state.Event, key string) int64 {
+	n, _ := strconv.ParseInt(evt.Attributes[key], 10, 64)
+	return n
+}
@@ -0,0 +1,66 @@
+package alerts
+
+import "slices"
+
+// CompositeNotifier fans each alert out to several named notifiers, such
+// as the desktop notifier alongside webhooks and commands. It is a Router:
+// rules can route their alerts to some of the notifiers by name.
+type CompositeNotifier struct {
+	notifiers []namedNotifier
+}
+
+// namedNotifier is a member of a CompositeNotifier.
+type namedNotifier struct {
+	name string
+	Notifier
+}
+
+// NewCompositeNotifier creates a notifier with no members. Add adds them.
+func NewCompositeNotifier() *CompositeNotifier {
+	return &CompositeNotifier{}
+}
+
+// Add adds a notifier under name, by which rules route alerts to it. Nil
+// notifiers are skipped. It must not be called once alerts are being sent.
+func (c *CompositeNotifier) Add(name string, n Notifier) {
+	if n != nil {
+		c.notifiers = append(c.notifiers, namedNotifier{name: name, Notifier: n})
+	}
+}
+
+// Notify passes alert to every notifier. Like them, it does not block.
+func (c *CompositeNotifier) Notify(alert Alert) {
+	for _, n := range c.notifiers {
+		n.Notify(alert)
+	}
+}
+
+// NotifyTo passes alert to the notifiers with one of the given names.
+func (c *CompositeNotifier) NotifyTo(alert Alert, names []string) {
+	for _, n := range c.notifiers {
+		if slices.Contains(names, n.name) {
+			n.Notify(alert)
+		}
+	}
+}
+
+// DeliveryStatus collects the status of the notifiers that track their
+// deliveries.
+func (c *CompositeNotifier) DeliveryStatus() []DeliveryStatus {
+	var statuses []DeliveryStatus
+	for _, n := range c.notifiers {
+		if r, ok := n.Notifier.(StatusReporter); ok {
+			statuses = append(statuses, r.DeliveryStatus()...)
+		}
+	}
+	return statuses
+}
+
+// Close stops the notifiers that run in the background.
+func (c *CompositeNotifier) Close() {
+	for _, n := range c.notifiers {
+		if closer, ok := n.Notifier.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package alerts
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/alerts/expr"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// customRule is an [[alerts.custom]] rule: an expression evaluated per
+// session or across all sessions over a window.
+type customRule struct {
+	name     string
+	expr     *expr.Expr
+	window   time.Duration
+	global   bool
+	severity string
+	message  string
+}
+
+// newCustomRules compiles the configured custom rules. Rules whose
+// expression does not compile are skipped; config validation reports them.
+func newCustomRules(cfgs []config.CustomRuleConfig) []Rule {
+	var rules []Rule
+	for _, c := range cfgs {
+		e, err := expr.Compile(c.Expr)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, &customRule{
+			name:     c.Name,
+			expr:     e,
+			window:   time.Duration(c.WindowMinutes) * time.Minute,
+			global:   c.Scope == "global",
+			severity: c.Severity,
+			message:  c.Message,
+		})
+	}
+	return rules
+}
+
+func (r *customRule) Evaluate(store state.Store, now time.Time) []Alert {
+	sessions := store.ListSessions()
+	if r.global {
+		if fired, value := r.expr.Eval(sessions, now, r.window); fired {
+			return []Alert{r.alert("", value, now)}
+		}
+		return nil
+	}
+
+	var alerts []Alert
+	for i := range sessions {
+		if fired, value := r.expr.Eval(sessions[i:i+1], now, r.window); fired {
+			alerts = append(alerts, r.alert(sessions[i].SessionID, value, now))
+		}
+	}
+	return alerts
+}
+
+// alert builds the rule's alert, filling in the message placeholders.
+func (r *customRule) alert(sessionID string, value float64, now time.Time) Alert {
+	msg := strings.NewReplacer(
+		"{rule}", r.name,
+		"{value}", expr.FormatValue(value),
+		"{window}", r.window.String(),
+		"{session}", sessionID,
+	).Replace(r.message)
+	return Alert{
+		Rule:      r.name,
+		Severity:  r.severity,
+		SessionID: sessionID,
+		Message:   msg,
+		FiredAt:   now,
+	}
+}
@@ -0,0 +1,71 @@
+package alerts
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/events"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// unlistedDomainRule fires when a session contacts an external domain,
+// as found by events.EgressDomains, that is not on the egress allowlist.
+// Each domain is reported once per session, in an alert keyed by the
+// domain so that domains contacted close together are not deduplicated.
+// The rule is off while the allowlist is empty.
+type unlistedDomainRule struct {
+	allowlist []string
+
+	mu            sync.Mutex
+	reported      map[string]map[string]bool // sessionID -> domains already alerted on
+	lastProcessed map[string]int             // sessionID -> number of events already processed
+}
+
+func newUnlistedDomainRule(cfg config.EgressConfig) *unlistedDomainRule {
+	return &unlistedDomainRule{
+		allowlist:     cfg.Allowlist,
+		reported:      make(map[string]map[string]bool),
+		lastProcessed: make(map[string]int),
+	}
+}
+
+// Holds reports false: an alert is about the contacts that raised it, so
+// it resolves on its own and later contacts raise a new one.
+func (r *unlistedDomainRule) Holds(Alert) bool {
+	return false
+}
+
+func (r *unlistedDomainRule) Evaluate(store state.Store, now time.Time) []Alert {
+	if len(r.allowlist) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var alerts []Alert
+	for _, session := range store.ListSessions() {
+		start := r.lastProcessed[session.SessionID]
+		for _, evt := range session.Events[min(start, len(session.Events)):] {
+			for _, d := range events.EgressDomains(evt) {
+				if r.reported[session.SessionID][d] || events.DomainAllowed(d, r.allowlist) {
+					continue
+				}
+				if r.reported[session.SessionID] == nil {
+					r.reported[session.SessionID] = make(map[string]bool)
+				}
+				r.reported[session.SessionID][d] = true
+				alerts = append(alerts, Alert{
+					Rule:      RuleUnlistedDomain,
+					Severity:  SeverityWarning,
+					SessionID: session.SessionID,
+					Subject:   d,
+					Message:   "Unlisted domain contacted: " + d,
+					FiredAt:   now,
+				})
+			}
+		}
+		r.lastProcessed[session.SessionID] = len(session.Events)
+	}
+	return alerts
+}
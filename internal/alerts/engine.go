@@ -5,32 +5,72 @@ package alerts
 
 import (
 	"context"
+	"errors"
+	"path"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/nixlim/cc-top/internal/budget"
 	"github.com/nixlim/cc-top/internal/burnrate"
 	"github.com/nixlim/cc-top/internal/config"
 	"github.com/nixlim/cc-top/internal/state"
 )
 
+// ErrNotActive is returned when acknowledging or snoozing an alert that
+// has been resolved or is no longer in the history.
+var ErrNotActive = errors.New("alert is not active")
+
 // Engine evaluates alert rules periodically against the state store.
-// It deduplicates alerts (same rule+session within 60s) and optionally
-// sends macOS notifications via the configured Notifier.
+//
+// An alert stays active while its condition holds: repeated evaluations
+// refresh it rather than raising it again. Once the condition has been
+// clear for the resolve-after period it is resolved, and the same rule can
+// only fire again for that session after the dedup window. Active alerts
+// can be acknowledged or snoozed. The engine keeps a bounded history and
+// optionally sends system notifications via the configured Notifier.
 type Engine struct {
 	store      state.Store
 	rules      []Rule
 	notifier   Notifier
 	interval   time.Duration
 	dedupTTL   time.Duration
+	resolveAfter time.Duration
+	historySize  int
+
+	// Which sessions each rule applies to.
+	scope RuleScope
+
+	// The tracker the Budget rule evaluates; shared with other users of
+	// the budgets when set with WithBudgetTracker.
+	budgets *budget.Tracker
+
+	// Per-rule overrides from [alerts.rules], keyed by rule name, and the
+	// longest dedup window any rule uses.
+	overrides map[string]config.RuleConfig
+	maxDedup  time.Duration
 
 	mu         sync.RWMutex
-	alerts     []Alert
+	alerts     []*Alert                // bounded history, in firing order
+	active     map[string]*activeAlert // alertKey -> unresolved alert
+	snoozed    map[string]time.Time    // alertKey -> snoozed until
+	nextID     uint64
 	lastFired  map[string]time.Time // alertKey -> last fire time for dedup
 
 	cancel     context.CancelFunc
 	done       chan struct{}
 }
 
+// activeAlert is the engine's record of an unresolved alert.
+type activeAlert struct {
+	alert *Alert
+	// holder reports on the condition of alerts from rules that fire once
+	// per transition; nil for rules that fire while their condition holds.
+	holder     conditionHolder
+	clearSince time.Time // when the condition was first seen clear
+}
+
 // EngineOption configures the alert engine.
 type EngineOption func(*Engine)
 
@@ -56,20 +96,37 @@ func WithDedupTTL(d time.Duration) EngineOption {
 	}
 }
 
+// WithBudgetTracker sets the tracker the Budget rule evaluates, so it can
+// be shared with the enforcer and the UI. By default the engine creates
+// its own from the config's budgets.
+func WithBudgetTracker(t *budget.Tracker) EngineOption {
+	return func(e *Engine) {
+		e.budgets = t
+	}
+}
+
 // NewEngine creates a new alert engine with all built-in rules configured
-// from the provided config. The calculator is used for cost/token rate rules.
+// from the provided config, followed by its [[alerts.custom]] rules. The
+// calculator is used for cost/token rate rules.
 func NewEngine(store state.Store, cfg config.Config, calculator *burnrate.Calculator, opts ...EngineOption) *Engine {
 	e := &Engine{
-		store:     store,
-		interval:  1 * time.Second,
-		dedupTTL:  60 * time.Second,
-		lastFired: make(map[string]time.Time),
-		done:      make(chan struct{}),
+		store:        store,
+		interval:     1 * time.Second,
+		dedupTTL:     60 * time.Second,
+		resolveAfter: time.Duration(cfg.Alerts.ResolveAfterSeconds) * time.Second,
+		historySize:  cfg.Alerts.HistorySize,
+		active:       make(map[string]*activeAlert),
+		snoozed:      make(map[string]time.Time),
+		lastFired:    make(map[string]time.Time),
+		done:         make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
+	if e.budgets == nil {
+		e.budgets = budget.NewTracker(cfg.Budgets, calculator)
+	}
 
 	normalizer := defaultNormalizer{}
 
@@ -82,6 +139,17 @@ func NewEngine(store state.Store, cfg config.Config, calculator *burnrate.Calcul
 		newContextPressureRule(cfg.Alerts, cfg.Models),
 		newHighRejectionRule(cfg.Alerts),
 		newSessionCostRule(cfg.Alerts),
+		newBudgetRule(e.budgets),
+		newAnomalyRule(cfg.Alerts, calculator),
+		newSecurityRule(cfg.Alerts.Security),
+		newUnlistedDomainRule(cfg.Alerts.Egress),
+	}
+	e.rules = append(e.rules, newCustomRules(cfg.Alerts.Custom)...)
+	e.scope = NewRuleScope(cfg.Alerts)
+	e.overrides = cfg.Alerts.Rules
+	e.maxDedup = e.dedupTTL
+	for _, rc := range e.overrides {
+		e.maxDedup = max(e.maxDedup, time.Duration(rc.DedupSeconds)*time.Second)
 	}
 
 	return e
@@ -116,32 +184,153 @@ func (e *Engine) Stop() {
 	}
 }
 
-// evaluate runs all rules and processes any triggered alerts.
-func (e *Engine) evaluate(now time.Time) {
-	var newAlerts []Alert
+// trigger is an alert raised by a rule in one evaluation.
+type trigger struct {
+	alert  Alert
+	holder conditionHolder
+}
 
+// evaluate runs all rules, updates the lifecycle of active alerts and
+// notifies of alerts that fire or come out of a snooze. Alerts from rules
+// disabled in [alerts.rules] are dropped and severity overrides applied
+// here, so every rule honors them.
+func (e *Engine) evaluate(now time.Time) {
+	var triggers []trigger
 	for _, rule := range e.rules {
-		triggered := rule.Evaluate(e.store, now)
-		for _, alert := range triggered {
-			if e.isDuplicate(alert) {
+		holder, _ := rule.(conditionHolder)
+		for _, alert := range rule.Evaluate(e.store, now) {
+			rc, ok := e.overrides[alert.Rule]
+			if ok && !rc.Enabled {
 				continue
 			}
-			e.recordFired(alert)
-			newAlerts = append(newAlerts, alert)
+			if rc.Severity != "" {
+				alert.Severity = rc.Severity
+			}
+			if e.inScope(alert) {
+				triggers = append(triggers, trigger{alert: alert, holder: holder})
+			}
+		}
+	}
+
+	e.mu.Lock()
+	var notify []Alert
+	held := make(map[string]bool, len(triggers))
+	for _, t := range triggers {
+		key := t.alert.alertKey()
+		held[key] = true
+		if a := e.active[key]; a != nil && a.holder == nil {
+			// The condition still holds: refresh the active alert.
+			a.alert.Message = t.alert.Message
+			a.alert.LastSeen = now
+			continue
+		}
+		if e.active[key] != nil {
+			// A rule that fires per transition has moved on (e.g. a higher
+			// budget tier): the new alert supersedes the previous one, and
+			// is not a duplicate of it however soon it comes.
+			e.resolve(key, now)
+		} else if e.isDuplicate(t.alert) {
+			continue
+		}
+		if alert := e.fire(t, now); alert.State == StateFiring {
+			notify = append(notify, alert)
 		}
 	}
 
-	if len(newAlerts) > 0 {
-		e.mu.Lock()
-		e.alerts = append(e.alerts, newAlerts...)
-		e.mu.Unlock()
+	for key, a := range e.active {
+		if held[key] || (a.holder != nil && a.holder.Holds(*a.alert)) {
+			a.clearSince = time.Time{}
+			continue
+		}
+		if a.clearSince.IsZero() {
+			a.clearSince = now
+		}
+		if now.Sub(a.clearSince) >= e.resolveAfter {
+			e.resolve(key, now)
+		}
+	}
 
-		if e.notifier != nil {
-			for _, alert := range newAlerts {
-				e.notifier.Notify(alert)
-			}
+	for _, a := range e.alerts {
+		if a.State == StateSnoozed && !now.Before(a.SnoozedUntil) {
+			a.State = StateFiring
+			a.SnoozedUntil = time.Time{}
+			notify = append(notify, *a)
 		}
 	}
+	for key, until := range e.snoozed {
+		if !now.Before(until) {
+			delete(e.snoozed, key)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, alert := range notify {
+		e.notify(alert)
+	}
+}
+
+// notify sends alert to the notifier. If the alert's rule routes its
+// notifications and the notifier is a Router, only the named notifiers
+// get it; other notifiers get it unless the route is empty.
+func (e *Engine) notify(alert Alert) {
+	if e.notifier == nil {
+		return
+	}
+	route := e.overrides[alert.Rule].Notify
+	switch r, ok := e.notifier.(Router); {
+	case route == nil:
+		e.notifier.Notify(alert)
+	case ok:
+		r.NotifyTo(alert, route)
+	case len(route) > 0:
+		e.notifier.Notify(alert)
+	}
+}
+
+// fire records a newly raised alert and returns it. Alerts whose key is
+// snoozed start out snoozed. Callers must hold e.mu.
+func (e *Engine) fire(t trigger, now time.Time) Alert {
+	alert := t.alert
+	key := alert.alertKey()
+	e.recordFired(alert)
+
+	e.nextID++
+	alert.ID = e.nextID
+	alert.State = StateFiring
+	alert.LastSeen = now
+	if until, ok := e.snoozed[key]; ok && now.Before(until) {
+		alert.State = StateSnoozed
+		alert.SnoozedUntil = until
+	}
+
+	a := &alert
+	e.alerts = append(e.alerts, a)
+	e.active[key] = &activeAlert{alert: a, holder: t.holder}
+	e.trimHistory()
+	return alert
+}
+
+// resolve marks the active alert with key as resolved. Callers must hold
+// e.mu.
+func (e *Engine) resolve(key string, now time.Time) {
+	a := e.active[key]
+	a.alert.State = StateResolved
+	a.alert.SnoozedUntil = time.Time{}
+	a.alert.ResolvedAt = now
+	delete(e.active, key)
+}
+
+// trimHistory drops alerts beyond the history size, the oldest resolved
+// ones first. Callers must hold e.mu.
+func (e *Engine) trimHistory() {
+	for len(e.alerts) > e.historySize {
+		i := slices.IndexFunc(e.alerts, func(a *Alert) bool { return !a.Active() })
+		if i < 0 {
+			i = 0
+			delete(e.active, e.alerts[0].alertKey())
+		}
+		e.alerts = slices.Delete(e.alerts, i, i+1)
+	}
 }
 
 // EvaluateNow runs a single evaluation cycle immediately. This is primarily
@@ -156,41 +345,212 @@ func (e *Engine) EvaluateAt(now time.Time) {
 	e.evaluate(now)
 }
 
-// Alerts returns a snapshot of all alerts that have been fired.
+// Alerts returns a snapshot of the alert history, oldest first.
 func (e *Engine) Alerts() []Alert {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
 	result := make([]Alert, len(e.alerts))
-	copy(result, e.alerts)
+	for i, a := range e.alerts {
+		result[i] = *a
+	}
 	return result
 }
 
-// isDuplicate checks whether the same alert (rule+session) was fired within
-// the dedup window.
-func (e *Engine) isDuplicate(alert Alert) bool {
+// Active returns a snapshot of the alerts that have not been resolved,
+// oldest first.
+func (e *Engine) Active() []Alert {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	var result []Alert
+	for _, a := range e.alerts {
+		if a.Active() {
+			result = append(result, *a)
+		}
+	}
+	return result
+}
+
+// Acknowledge marks an active alert as seen. It stays listed until its
+// condition clears but is no longer shown as firing.
+func (e *Engine) Acknowledge(id uint64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	a := e.activeByID(id)
+	if a == nil {
+		return ErrNotActive
+	}
+	delete(e.snoozed, a.alertKey())
+	a.State = StateAcknowledged
+	a.SnoozedUntil = time.Time{}
+	return nil
+}
+
+// Snooze silences an active alert until the given time, along with any
+// alert for the same rule and session that fires meanwhile. If the alert
+// is still active when the snooze ends, it fires again.
+func (e *Engine) Snooze(id uint64, until time.Time) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	a := e.activeByID(id)
+	if a == nil {
+		return ErrNotActive
+	}
+	e.snoozed[a.alertKey()] = until
+	a.State = StateSnoozed
+	a.SnoozedUntil = until
+	return nil
+}
+
+// activeByID returns the active alert with the given ID, or nil. Callers
+// must hold e.mu.
+func (e *Engine) activeByID(id uint64) *Alert {
+	for _, a := range e.active {
+		if a.alert.ID == id {
+			return a.alert
+		}
+	}
+	return nil
+}
+
+// inScope reports whether alert's rule applies to its session. Global
+// alerts are always in scope.
+func (e *Engine) inScope(alert Alert) bool {
+	if alert.SessionID == "" || !e.scope.scoped(alert.Rule) {
+		return true
+	}
+	return e.scope.inScope(alert.Rule, e.store.GetSession(alert.SessionID))
+}
+
+// RuleScope decides which sessions a rule applies to, from the same
+// configuration the engine honors: the rule's [alerts.rules] entry and the
+// mode scoping of [alerts.modes] and [alerts.suppress_modes].
+type RuleScope struct {
+	// rule name -> mode -> true
+	modes    map[string]map[string]bool
+	suppress map[string]map[string]bool
+
+	overrides map[string]config.RuleConfig
+}
+
+// NewRuleScope creates a RuleScope from the [alerts] configuration.
+func NewRuleScope(cfg config.AlertsConfig) RuleScope {
+	return RuleScope{
+		modes:     modeSets(cfg.Modes),
+		suppress:  modeSets(cfg.SuppressModes),
+		overrides: cfg.Rules,
+	}
+}
+
+// Applies reports whether rule is enabled and in scope for session s.
+func (r RuleScope) Applies(rule string, s *state.SessionData) bool {
+	if rc, ok := r.overrides[rule]; ok && !rc.Enabled {
+		return false
+	}
+	return r.inScope(rule, s)
+}
+
+// scoped reports whether anything limits the sessions rule applies to.
+func (r RuleScope) scoped(rule string) bool {
+	_, hasOverride := r.overrides[rule]
+	return r.modes[rule] != nil || r.suppress[rule] != nil || hasOverride
+}
+
+// inScope reports whether rule applies to session s by its mode scoping
+// and the filters of its [alerts.rules] entry. A nil session is in scope,
+// and a session is never filtered on a property not yet known.
+func (r RuleScope) inScope(rule string, s *state.SessionData) bool {
+	if s == nil {
+		return true
+	}
+	if s.Mode != "" {
+		if only := r.modes[rule]; only != nil && !only[s.Mode] {
+			return false
+		}
+		if r.suppress[rule][s.Mode] {
+			return false
+		}
+	}
+	rc := r.overrides[rule]
+	return filterAllows(rc.IncludeCWD, rc.ExcludeCWD, s.CWD, matchCWD) &&
+		filterAllows(rc.IncludeModels, rc.ExcludeModels, s.Model, matchGlob) &&
+		filterAllows(rc.IncludeModes, rc.ExcludeModes, s.Mode, func(mode, v string) bool { return mode == v })
+}
+
+// filterAllows reports whether value matches one of include, if there are
+// any, and none of exclude. An empty (unknown) value always passes.
+func filterAllows(include, exclude []string, value string, match func(pattern, value string) bool) bool {
+	if value == "" {
+		return true
+	}
+	matches := func(pattern string) bool { return match(pattern, value) }
+	if len(include) > 0 && !slices.ContainsFunc(include, matches) {
+		return false
+	}
+	return !slices.ContainsFunc(exclude, matches)
+}
+
+// matchGlob reports whether value matches pattern as a path.Match glob.
+func matchGlob(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// matchCWD reports whether pattern matches cwd or one of its parents. A
+// leading ~ in either is expanded to the home directory.
+func matchCWD(pattern, cwd string) bool {
+	pattern = resolvePath(pattern, "")
+	for dir := resolvePath(cwd, ""); ; dir = filepath.Dir(dir) {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// modeSets converts rule -> mode lists into rule -> mode sets.
+func modeSets(m map[string][]string) map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(m))
+	for rule, modes := range m {
+		set := make(map[string]bool, len(modes))
+		for _, mode := range modes {
+			set[mode] = true
+		}
+		sets[rule] = set
+	}
+	return sets
+}
+
+// isDuplicate checks whether the same alert (rule+session) was fired within
+// the dedup window, which the rule's [alerts.rules] entry may override.
+// Callers must hold e.mu.
+func (e *Engine) isDuplicate(alert Alert) bool {
 	key := alert.alertKey()
 	lastFired, ok := e.lastFired[key]
 	if !ok {
 		return false
 	}
-	return alert.FiredAt.Sub(lastFired) < e.dedupTTL
+	ttl := e.dedupTTL
+	if secs := e.overrides[alert.Rule].DedupSeconds; secs > 0 {
+		ttl = time.Duration(secs) * time.Second
+	}
+	return alert.FiredAt.Sub(lastFired) < ttl
 }
 
-// recordFired marks an alert as fired for deduplication purposes.
+// recordFired marks an alert as fired for deduplication purposes. Callers
+// must hold e.mu.
 func (e *Engine) recordFired(alert Alert) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	key := alert.alertKey()
 	e.lastFired[key] = alert.FiredAt
 
 	// Prune old dedup entries to prevent unbounded growth.
 	for k, t := range e.lastFired {
-		if alert.FiredAt.Sub(t) > 2*e.dedupTTL {
+		if alert.FiredAt.Sub(t) > 2*e.maxDedup {
 			delete(e.lastFired, k)
 		}
 	}
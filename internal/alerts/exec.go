@@ -0,0 +1,207 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// execWaitDelay bounds how long a killed command's leftover children may
+// hold its output open.
+const execWaitDelay = time.Second
+
+// execPayload is the alert as given to the command on stdin.
+type execPayload struct {
+	ID        uint64    `json:"id"`
+	Rule      string    `json:"rule"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	SessionID string    `json:"session_id,omitempty"`
+	CWD       string    `json:"cwd,omitempty"`
+	CostUSD   float64   `json:"cost_usd"` // the session's cost so far, 0 for global alerts
+	Budget    string    `json:"budget,omitempty"`
+	State     string    `json:"state"`
+	FiredAt   time.Time `json:"fired_at"`
+}
+
+// ExecNotifier runs a command for each alert, with the alert as JSON on
+// stdin and in CC_TOP_* environment variables. Notify queues the alert
+// and returns; a fixed number of workers run the command.
+type ExecNotifier struct {
+	name       string
+	command    string
+	severities map[string]bool
+	rules      map[string]bool
+	timeout    time.Duration
+	store      state.Store
+
+	queue  chan Alert
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	status DeliveryStatus
+}
+
+// NewExecNotifier creates an exec notifier from its configuration and
+// starts its workers. store, if not nil, supplies the CWD and cost of
+// alerted sessions. Close stops it.
+func NewExecNotifier(cfg config.ExecNotifierConfig, store state.Store) *ExecNotifier {
+	n := &ExecNotifier{
+		name:       cfg.Name,
+		command:    cfg.Command,
+		severities: stringSet(cfg.Severities),
+		rules:      stringSet(cfg.Rules),
+		timeout:    time.Duration(cfg.TimeoutSeconds) * time.Second,
+		store:      store,
+		queue:      make(chan Alert, max(cfg.QueueSize, 1)),
+		status:     DeliveryStatus{Kind: "exec", Name: cfg.Name},
+	}
+
+	var ctx context.Context
+	ctx, n.cancel = context.WithCancel(context.Background())
+	for range max(cfg.MaxConcurrent, 1) {
+		n.wg.Add(1)
+		go n.worker(ctx)
+	}
+	return n
+}
+
+// Notify queues an alert if it passes the severity and rule filters.
+// When the queue is full the alert is dropped.
+func (n *ExecNotifier) Notify(alert Alert) {
+	if (n.severities != nil && !n.severities[alert.Severity]) || (n.rules != nil && !n.rules[alert.Rule]) {
+		return
+	}
+	select {
+	case n.queue <- alert:
+	default:
+		n.mu.Lock()
+		n.status.Dropped++
+		n.mu.Unlock()
+	}
+}
+
+// Close stops the workers, killing running commands and abandoning the
+// alerts still queued.
+func (n *ExecNotifier) Close() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// DeliveryStatus reports the notifier's run counts and last outcome.
+func (n *ExecNotifier) DeliveryStatus() []DeliveryStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	st := n.status
+	st.Pending = len(n.queue)
+	return []DeliveryStatus{st}
+}
+
+// worker runs the command for queued alerts until ctx is cancelled.
+func (n *ExecNotifier) worker(ctx context.Context) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-n.queue:
+			err := n.run(ctx, alert)
+			if ctx.Err() != nil {
+				return
+			}
+			n.mu.Lock()
+			if err != nil {
+				n.status.Failed++
+				n.status.LastFailure = time.Now()
+				n.status.LastError = err.Error()
+			} else {
+				n.status.Sent++
+				n.status.LastSuccess = time.Now()
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+// run runs the command once for alert.
+func (n *ExecNotifier) run(ctx context.Context, alert Alert) error {
+	p := n.payload(alert)
+	stdin, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", n.command)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), execEnv(p)...)
+	cmd.WaitDelay = execWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("exec %s: timed out after %s", n.name, n.timeout)
+		}
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return fmt.Errorf("exec %s: %v: %s", n.name, err, msg)
+		}
+		return fmt.Errorf("exec %s: %v", n.name, err)
+	}
+	return nil
+}
+
+// payload builds the command's view of alert, adding the session's CWD
+// and cost from the store.
+func (n *ExecNotifier) payload(alert Alert) execPayload {
+	p := execPayload{
+		ID:        alert.ID,
+		Rule:      alert.Rule,
+		Severity:  alert.Severity,
+		Message:   alert.Message,
+		SessionID: alert.SessionID,
+		Budget:    alert.Budget,
+		State:     alert.State,
+		FiredAt:   alert.FiredAt,
+	}
+	if p.State == "" {
+		p.State = StateFiring
+	}
+	if n.store != nil && alert.SessionID != "" {
+		if s := n.store.GetSession(alert.SessionID); s != nil {
+			p.CWD = s.CWD
+			p.CostUSD = s.TotalCost
+		}
+	}
+	return p
+}
+
+// execEnv returns the CC_TOP_* environment variables for p.
+func execEnv(p execPayload) []string {
+	return []string{
+		"CC_TOP_ALERT_ID=" + strconv.FormatUint(p.ID, 10),
+		"CC_TOP_RULE=" + p.Rule,
+		"CC_TOP_SEVERITY=" + p.Severity,
+		"CC_TOP_MESSAGE=" + p.Message,
+		"CC_TOP_SESSION_ID=" + p.SessionID,
+		"CC_TOP_CWD=" + p.CWD,
+		"CC_TOP_COST_USD=" + strconv.FormatFloat(p.CostUSD, 'f', 2, 64),
+		"CC_TOP_BUDGET=" + p.Budget,
+		"CC_TOP_STATE=" + p.State,
+		"CC_TOP_FIRED_AT=" + p.FiredAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,184 @@
+package alerts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func newTestExec(t *testing.T, cfg config.ExecNotifierConfig, store state.Store) *ExecNotifier {
+	t.Helper()
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = 5
+	}
+	if cfg.MaxConcurrent == 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = 10
+	}
+	n := NewExecNotifier(cfg, store)
+	t.Cleanup(n.Close)
+	return n
+}
+
+func TestExecNotifier_PassesAlert(t *testing.T) {
+	dir := t.TempDir()
+	store := state.NewMemoryStore()
+	store.UpdateCWD(testWebhookAlert.SessionID, "/home/dev/project")
+	store.AddMetric(testWebhookAlert.SessionID, state.Metric{Name: "claude_code.cost.usage", Value: 12.5})
+
+	t.Setenv("OUT", dir)
+	n := newTestExec(t, config.ExecNotifierConfig{
+		Command: `cat > "$OUT/stdin.json" && env | grep '^CC_TOP_' | sort > "$OUT/env"`,
+	}, store)
+
+	alert := testWebhookAlert
+	alert.ID = 7
+	n.Notify(alert)
+	waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 1 })
+
+	data, err := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("stdin %q is not JSON: %v", data, err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	want := `{"cost_usd":12.5,"cwd":"/home/dev/project","fired_at":"2026-10-18T12:00:00Z","id":7,` +
+		`"message":"Cost surge: \"$120.00/hr\"","rule":"CostSurge","session_id":"sess-1234567890abcdef",` +
+		`"severity":"critical","state":"firing"}`
+	if string(gotJSON) != want {
+		t.Errorf("stdin = %s, want %s", gotJSON, want)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, "env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		"CC_TOP_ALERT_ID=7",
+		"CC_TOP_RULE=CostSurge",
+		"CC_TOP_SEVERITY=critical",
+		`CC_TOP_MESSAGE=Cost surge: "$120.00/hr"`,
+		"CC_TOP_SESSION_ID=sess-1234567890abcdef",
+		"CC_TOP_CWD=/home/dev/project",
+		"CC_TOP_COST_USD=12.50",
+		"CC_TOP_STATE=firing",
+		"CC_TOP_FIRED_AT=2026-10-18T12:00:00Z",
+	} {
+		if !strings.Contains(string(env), v+"\n") {
+			t.Errorf("environment missing %s:\n%s", v, env)
+		}
+	}
+}
+
+func TestExecNotifier_ConcurrencyLimit(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+	t.Setenv("LOG", log)
+	n := newTestExec(t, config.ExecNotifierConfig{
+		Command:       `echo start >> "$LOG"; sleep 0.2; echo end >> "$LOG"`,
+		MaxConcurrent: 2,
+	}, nil)
+
+	for range 5 {
+		n.Notify(testWebhookAlert)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for n.DeliveryStatus()[0].Sent < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, status %+v", n.DeliveryStatus()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, peak := 0, 0
+	for _, line := range strings.Fields(string(data)) {
+		if line == "start" {
+			running++
+			peak = max(peak, running)
+		} else {
+			running--
+		}
+	}
+	if peak != 2 {
+		t.Errorf("expected at most 2 commands at once and some overlap, peak was %d:\n%s", peak, data)
+	}
+}
+
+func TestExecNotifier_Timeout(t *testing.T) {
+	n := newTestExec(t, config.ExecNotifierConfig{Command: "sleep 5", TimeoutSeconds: 1}, nil)
+
+	start := time.Now()
+	n.Notify(testWebhookAlert)
+	for n.DeliveryStatus()[0].Failed == 0 {
+		if time.Since(start) > 4*time.Second {
+			t.Fatal("command was not killed at the timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := n.DeliveryStatus()[0]; !strings.Contains(st.LastError, "timed out after 1s") {
+		t.Errorf("LastError = %q, want a timeout", st.LastError)
+	}
+}
+
+func TestExecNotifier_FiltersAndFailures(t *testing.T) {
+	n := newTestExec(t, config.ExecNotifierConfig{
+		Command: `echo "$CC_TOP_RULE failed" >&2; exit 3`,
+		Rules:   []string{RuleErrorStorm},
+	}, nil)
+
+	n.Notify(Alert{Rule: RuleCostSurge, Severity: SeverityCritical})
+	n.Notify(Alert{Rule: RuleErrorStorm, Severity: SeverityWarning})
+	waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Failed == 1 })
+	time.Sleep(50 * time.Millisecond)
+
+	st := n.DeliveryStatus()[0]
+	if st.Failed != 1 || st.Sent != 0 || st.Kind != "exec" {
+		t.Errorf("only the ErrorStorm alert should run, status %+v", st)
+	}
+	if !strings.Contains(st.LastError, "exit status 3: ErrorStorm failed") {
+		t.Errorf("LastError = %q, want exit status and stderr", st.LastError)
+	}
+}
+
+func TestCompositeNotifier(t *testing.T) {
+	a, b := newTestNotifier(), newTestNotifier()
+	cmd := NewExecNotifier(config.ExecNotifierConfig{
+		Name: "true", Command: "true", TimeoutSeconds: 5, MaxConcurrent: 1, QueueSize: 10,
+	}, nil)
+	c := NewCompositeNotifier()
+	c.Add("a", a)
+	c.Add("none", nil)
+	c.Add("b", b)
+	c.Add("true", cmd)
+	defer c.Close()
+
+	c.Notify(testWebhookAlert)
+	if a.count() != 1 || b.count() != 1 {
+		t.Errorf("expected every notifier to get the alert, got %d and %d", a.count(), b.count())
+	}
+	c.NotifyTo(testWebhookAlert, []string{"b", "missing"})
+	if a.count() != 1 || b.count() != 2 {
+		t.Errorf("expected only b to get the routed alert, got %d and %d", a.count(), b.count())
+	}
+	st := waitForStatus(t, c, func(st DeliveryStatus) bool { return st.Sent == 1 })
+	if st.Kind != "exec" || st.Name != "true" || len(c.DeliveryStatus()) != 1 {
+		t.Errorf("DeliveryStatus = %+v, want only the exec notifier", c.DeliveryStatus())
+	}
+}
@@ -0,0 +1,429 @@
+// Package expr implements the expression language of custom alert rules
+// ([[alerts.custom]] in the config). An expression is a condition over
+// windowed aggregates of a session's (or all sessions') events and
+// metrics, for example
+//
+//	count(event == "tool_result" and tool_name == "WebFetch") > 20
+//	sum(metric == "cost.usage" and model ~ "claude-opus-*") > 0 and (hour() < 9 or hour() >= 18)
+//	ratio(event == "tool_result" and success == "false", event == "tool_result") > 0.5
+//
+// Aggregates cover the rule's window:
+//
+//	count(filter)         matching events, or metric data points
+//	sum(filter)           increase of the matching metric counters
+//	sum(field, filter)    total of a numeric attribute of matching events
+//	rate(...)             sum (for metrics or with a field) or count, per minute
+//	ratio(filter, filter) first total divided by the second, 0 if it is 0
+//
+// hour() and weekday() (0 is Sunday) give the evaluation time. Filters
+// compare event, metric (with or without the claude_code. prefix) or any
+// attribute with ==, != or the glob operators ~ and !~, combined with and,
+// or, not and parentheses. Numbers combine with + - * / and compare with
+// > >= < <= == !=; conditions combine with and, or and not.
+package expr
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// Error is a compile error at a 1-based column of the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos, e.Msg)
+}
+
+// Expr is a compiled expression.
+type Expr struct {
+	root node
+	// value is the left operand of the first comparison, reported as the
+	// rule's value in alert messages.
+	value node
+}
+
+// Compile parses and type-checks an expression, which must be a condition.
+func Compile(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: "unexpected " + t.describe()}
+	}
+	if root.kind() != kindBool {
+		return nil, &Error{Pos: 1, Msg: "expression must be a condition, e.g. count(event == \"api_error\") > 5"}
+	}
+	return &Expr{root: root, value: firstComparand(root)}, nil
+}
+
+// Eval evaluates the expression over sessions for the window ending at
+// now. It reports whether the condition holds and the rule's value.
+func (e *Expr) Eval(sessions []state.SessionData, now time.Time, window time.Duration) (bool, float64) {
+	c := &evalContext{sessions: sessions, since: now.Add(-window), now: now, window: window}
+	fired := e.root.eval(c) != 0
+	var value float64
+	if e.value != nil {
+		value = e.value.eval(c)
+	}
+	return fired, value
+}
+
+// FormatValue formats a value for messages: whole numbers without
+// decimals, others to two places.
+func FormatValue(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// firstComparand returns the left operand of the leftmost comparison.
+func firstComparand(n node) node {
+	switch n := n.(type) {
+	case *compareNode:
+		return n.left
+	case *logicalNode:
+		return firstComparand(n.left)
+	case *notNode:
+		return firstComparand(n.operand)
+	}
+	return nil
+}
+
+// evalContext is the data an expression is evaluated against.
+type evalContext struct {
+	sessions   []state.SessionData
+	since, now time.Time
+	window     time.Duration
+}
+
+// inWindow reports whether ts falls in the window. Data without a
+// timestamp counts as current.
+func (c *evalContext) inWindow(ts time.Time) bool {
+	return ts.IsZero() || (!ts.Before(c.since) && !ts.After(c.now))
+}
+
+// valueKind is the type of an expression node.
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindBool
+)
+
+// node is an expression AST node. Conditions evaluate to 1 or 0.
+type node interface {
+	kind() valueKind
+	eval(c *evalContext) float64
+}
+
+type numberNode float64
+
+func (n numberNode) kind() valueKind           { return kindNumber }
+func (n numberNode) eval(*evalContext) float64 { return float64(n) }
+
+type arithNode struct {
+	op          string
+	left, right node
+}
+
+func (n *arithNode) kind() valueKind { return kindNumber }
+
+func (n *arithNode) eval(c *evalContext) float64 {
+	l, r := n.left.eval(c), n.right.eval(c)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	default:
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	}
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) kind() valueKind { return kindBool }
+
+func (n *compareNode) eval(c *evalContext) float64 {
+	l, r := n.left.eval(c), n.right.eval(c)
+	var ok bool
+	switch n.op {
+	case ">":
+		ok = l > r
+	case ">=":
+		ok = l >= r
+	case "<":
+		ok = l < r
+	case "<=":
+		ok = l <= r
+	case "==":
+		ok = l == r
+	default:
+		ok = l != r
+	}
+	return boolValue(ok)
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) kind() valueKind { return kindBool }
+
+func (n *logicalNode) eval(c *evalContext) float64 {
+	l := n.left.eval(c) != 0
+	if n.op == "and" {
+		return boolValue(l && n.right.eval(c) != 0)
+	}
+	return boolValue(l || n.right.eval(c) != 0)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) kind() valueKind { return kindBool }
+
+func (n *notNode) eval(c *evalContext) float64 {
+	return boolValue(n.operand.eval(c) == 0)
+}
+
+// clockNode is hour() or weekday() at the evaluation time.
+type clockNode string
+
+func (n clockNode) kind() valueKind { return kindNumber }
+
+func (n clockNode) eval(c *evalContext) float64 {
+	if n == "hour" {
+		return float64(c.now.Hour())
+	}
+	return float64(c.now.Weekday())
+}
+
+// aggregateNode is count, sum, rate or ratio over the window.
+type aggregateNode struct {
+	fn  string
+	src source
+	den source // ratio denominator
+}
+
+func (n *aggregateNode) kind() valueKind { return kindNumber }
+
+func (n *aggregateNode) eval(c *evalContext) float64 {
+	switch n.fn {
+	case "count":
+		return n.src.count(c)
+	case "sum":
+		return n.src.sum(c)
+	case "rate":
+		minutes := c.window.Minutes()
+		if minutes <= 0 {
+			return 0
+		}
+		return n.src.total(c) / minutes
+	default:
+		den := n.den.total(c)
+		if den == 0 {
+			return 0
+		}
+		return n.src.total(c) / den
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// source is what an aggregate reads: the events or metric data points
+// matching a filter, and for events optionally an attribute to add up.
+type source struct {
+	filter  filter
+	metrics bool
+	field   string
+}
+
+// count returns the number of matching events or metric data points.
+func (s source) count(c *evalContext) float64 {
+	var n float64
+	for i := range c.sessions {
+		if s.metrics {
+			for _, m := range c.sessions[i].Metrics {
+				if c.inWindow(m.Timestamp) && s.filter.match("metric", m.Name, m.Attributes) {
+					n++
+				}
+			}
+			continue
+		}
+		for _, e := range c.sessions[i].Events {
+			if c.inWindow(e.Timestamp) && s.filter.match("event", e.Name, e.Attributes) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// sum returns the increase of the matching metric counters, or the total
+// of field over matching events.
+func (s source) sum(c *evalContext) float64 {
+	var total float64
+	for i := range c.sessions {
+		if s.metrics {
+			total += s.counterIncrease(c, c.sessions[i].Metrics)
+			continue
+		}
+		for _, e := range c.sessions[i].Events {
+			if !c.inWindow(e.Timestamp) || !s.filter.match("event", e.Name, e.Attributes) {
+				continue
+			}
+			if v, err := strconv.ParseFloat(e.Attributes[s.field], 64); err == nil {
+				total += v
+			}
+		}
+	}
+	return total
+}
+
+// total is sum for metrics or when a field is given, otherwise count.
+func (s source) total(c *evalContext) float64 {
+	if s.metrics || s.field != "" {
+		return s.sum(c)
+	}
+	return s.count(c)
+}
+
+// counterIncrease adds up the deltas of each matching counter series that
+// fall in the window. A counter that goes down is treated as reset.
+func (s source) counterIncrease(c *evalContext, metrics []state.Metric) float64 {
+	var total float64
+	prev := make(map[string]float64)
+	for _, m := range metrics {
+		if !s.filter.match("metric", m.Name, m.Attributes) {
+			continue
+		}
+		key := seriesKey(m.Name, m.Attributes)
+		last, seen := prev[key]
+		prev[key] = m.Value
+		delta := m.Value
+		if seen && m.Value >= last {
+			delta = m.Value - last
+		}
+		if c.inWindow(m.Timestamp) {
+			total += delta
+		}
+	}
+	return total
+}
+
+// seriesKey identifies a counter series by name and attributes.
+func seriesKey(name string, attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + attrs[k])
+	}
+	return b.String()
+}
+
+// filter is a predicate over an event or metric data point.
+type filter interface {
+	// match reports whether the item matches. what is "event" or
+	// "metric" and name is the item's name.
+	match(what, name string, attrs map[string]string) bool
+	// keys adds the keys the filter compares to set.
+	keys(set map[string]bool)
+}
+
+type filterMatch struct {
+	key, op, value string
+}
+
+func (f *filterMatch) match(what, name string, attrs map[string]string) bool {
+	actual, want := attrs[f.key], f.value
+	if f.key == "event" || f.key == "metric" {
+		if f.key != what {
+			return false
+		}
+		actual, want = shortName(name), shortName(want)
+	}
+	switch f.op {
+	case "==":
+		return actual == want
+	case "!=":
+		return actual != want
+	case "~":
+		ok, _ := path.Match(want, actual)
+		return ok
+	default:
+		ok, _ := path.Match(want, actual)
+		return !ok
+	}
+}
+
+func (f *filterMatch) keys(set map[string]bool) { set[f.key] = true }
+
+type filterLogic struct {
+	op          string
+	left, right filter
+}
+
+func (f *filterLogic) match(what, name string, attrs map[string]string) bool {
+	if f.op == "and" {
+		return f.left.match(what, name, attrs) && f.right.match(what, name, attrs)
+	}
+	return f.left.match(what, name, attrs) || f.right.match(what, name, attrs)
+}
+
+func (f *filterLogic) keys(set map[string]bool) {
+	f.left.keys(set)
+	f.right.keys(set)
+}
+
+type filterNot struct {
+	f filter
+}
+
+func (f *filterNot) match(what, name string, attrs map[string]string) bool {
+	return !f.f.match(what, name, attrs)
+}
+
+func (f *filterNot) keys(set map[string]bool) { f.f.keys(set) }
+
+// shortName strips the claude_code. prefix from event and metric names so
+// filters can use either form.
+func shortName(name string) string {
+	return strings.TrimPrefix(name, "claude_code.")
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func event(name string, at time.Time, attrs ...string) state.Event {
+	e := state.Event{Name: "claude_code." + name, Timestamp: at, Attributes: map[string]string{}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		e.Attributes[attrs[i]] = attrs[i+1]
+	}
+	return e
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`count(event == "api_error")`, "column 1: expression must be a condition"},
+		{`count(event == "api_error") >`, "column 30: unexpected end of expression"},
+		{`count(event = "x") > 1`, `column 13: unexpected character '='`},
+		{`count(event > "x") > 1`, `column 13: filters compare with ==, !=, ~ or !~, not ">"`},
+		{`cnt(event == "x") > 1`, `column 1: unknown function "cnt"`},
+		{`sum(event == "api_request") > 1`, "column 1: sum over events needs a field"},
+		{`count(cost_usd, event == "api_request") > 1`, `column 7: expected a filter, found field "cost_usd"`},
+		{`sum(metric == "cost.usage" and event == "x") > 1`, "column 5: a filter matches either events or metrics, not both"},
+		{`count(model ~ "claude-[") > 1`, `column 15: invalid pattern "claude-["`},
+		{`count(event == "x") > 1 and 5`, `column 25: "and" needs a condition on both sides`},
+		{`(count(event == "x") > 1) + 1 > 2`, `column 27: "+" needs numbers on both sides`},
+		{`count(event == "x) > 1`, "column 16: unterminated string"},
+		{`hour() > 9 extra`, `column 12: unexpected "extra"`},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Compile(%s) error = %v, want prefix %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestEval_CountAndFilters(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s := state.SessionData{Events: []state.Event{
+		event("tool_result", now.Add(-20*time.Minute), "tool_name", "WebFetch"), // outside the window
+		event("tool_result", now.Add(-5*time.Minute), "tool_name", "WebFetch"),
+		event("tool_result", now.Add(-4*time.Minute), "tool_name", "WebFetch", "success", "false"),
+		event("tool_result", now.Add(-3*time.Minute), "tool_name", "Bash"),
+		event("api_request", now.Add(-2*time.Minute), "model", "claude-opus-4-6", "cost_usd", "0.25"),
+		event("api_request", now.Add(-time.Minute), "model", "claude-haiku-4-5", "cost_usd", "0.05"),
+	}}
+	sessions := []state.SessionData{s}
+
+	tests := []struct {
+		src   string
+		fired bool
+		value float64
+	}{
+		{`count(event == "tool_result" and tool_name == "WebFetch") >= 2`, true, 2},
+		{`count(event == "claude_code.tool_result" and tool_name == "WebFetch") > 2`, false, 2},
+		{`count(event == "tool_result" and not (tool_name == "Bash" or success == "false")) == 1`, true, 1},
+		{`sum(cost_usd, event == "api_request" and model ~ "claude-opus-*") > 0.2`, true, 0.25},
+		{`rate(event == "tool_result") > 0.2`, true, 0.3},
+		{`ratio(event == "tool_result" and success == "false", event == "tool_result") < 0.5`, true, 1.0 / 3},
+		{`ratio(event == "api_error", event == "no_such_event") == 0`, true, 0},
+		{`count(model !~ "claude-opus-*" and event == "api_request") * 2 + 1 == 3`, true, 3},
+		{`hour() >= 9 and hour() < 18 and weekday() == 0`, true, 12},
+		{`-count(event == "api_request") < -1`, true, -2},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Fatalf("Compile(%s): %v", tt.src, err)
+		}
+		fired, value := e.Eval(sessions, now, 10*time.Minute)
+		if fired != tt.fired || value-tt.value > 1e-9 || tt.value-value > 1e-9 {
+			t.Errorf("%s = (%v, %v), want (%v, %v)", tt.src, fired, value, tt.fired, tt.value)
+		}
+	}
+}
+
+func TestEval_MetricCounters(t *testing.T) {
+	now := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	metric := func(model string, v float64, at time.Time) state.Metric {
+		return state.Metric{Name: "claude_code.cost.usage", Value: v, Timestamp: at,
+			Attributes: map[string]string{"model": model}}
+	}
+	sessions := []state.SessionData{
+		{Metrics: []state.Metric{
+			metric("claude-opus-4-6", 3, now.Add(-time.Hour)), // before the window: baseline only
+			metric("claude-opus-4-6", 4, now.Add(-5*time.Minute)),
+			metric("claude-haiku-4-5", 1, now.Add(-4*time.Minute)),
+			metric("claude-opus-4-6", 0.5, now.Add(-time.Minute)), // counter reset
+		}},
+		{Metrics: []state.Metric{metric("claude-opus-4-6", 2, now.Add(-2*time.Minute))}},
+	}
+
+	e, err := Compile(`sum(metric == "cost.usage" and model ~ "claude-opus-*") > 0 and (hour() < 9 or hour() >= 18)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fired, value := e.Eval(sessions, now, 10*time.Minute)
+	if !fired || value != 3.5 {
+		t.Errorf("opus after hours = (%v, %v), want (true, 3.5)", fired, value)
+	}
+	if fired, _ := e.Eval(sessions, now.Add(-10*time.Hour), 10*time.Minute); fired {
+		t.Error("should not fire during working hours")
+	}
+
+	e, _ = Compile(`count(metric == "cost.usage") == 4`)
+	if fired, _ := e.Eval(sessions, now, 10*time.Minute); !fired {
+		t.Error("count over metrics should count data points in the window")
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for v, want := range map[float64]string{20: "20", 0.333333: "0.33", -2: "-2", 1.5: "1.50"} {
+		if got := FormatValue(v); got != want {
+			t.Errorf("FormatValue(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
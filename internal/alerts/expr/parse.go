@@ -0,0 +1,472 @@
+package expr
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+// token is a lexed token. pos is its 1-based column.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe returns the token as it should appear in error messages.
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators lists the operator tokens, longest first so that ">=" is
+// matched before ">".
+var operators = []string{">=", "<=", "==", "!=", "!~", ">", "<", "~", "+", "-", "*", "/", "(", ")", ","}
+
+// lex splits src into tokens.
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, &Error{Pos: i + 1, Msg: "unterminated string"}
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, &Error{Pos: i + 1, Msg: "invalid string " + src[i:j+1]}
+			}
+			toks = append(toks, token{tokString, s, i + 1})
+			i = j + 1
+
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			if _, err := strconv.ParseFloat(src[i:j], 64); err != nil {
+				return nil, &Error{Pos: i + 1, Msg: "invalid number " + src[i:j]}
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i + 1})
+			i = j
+
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && isIdentChar(rune(src[j])) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], i + 1})
+			i = j
+
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Pos: i + 1, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			toks = append(toks, token{tokOp, op, i + 1})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src) + 1}), nil
+}
+
+// isIdentChar reports whether c can continue an identifier. Dots allow
+// attribute names such as organization.id.
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'
+}
+
+// parser is a recursive descent parser over a token list.
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator or keyword text.
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokOp || t.kind == tokIdent) && t.text == text {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator text or fails.
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+		return &Error{Pos: t.pos, Msg: fmt.Sprintf("expected %q, found %s", text, t.describe())}
+	}
+	return nil
+}
+
+// parseOr parses: and { "or" and }.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("or") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, &Error{Pos: t.pos, Msg: `"or" needs a condition on both sides`}
+		}
+		left = &logicalNode{op: "or", left: left, right: right}
+	}
+}
+
+// parseAnd parses: not { "and" not }.
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("and") {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, &Error{Pos: t.pos, Msg: `"and" needs a condition on both sides`}
+		}
+		left = &logicalNode{op: "and", left: left, right: right}
+	}
+}
+
+// parseNot parses: "not" not | comparison.
+func (p *parser) parseNot() (node, error) {
+	t := p.peek()
+	if !p.accept("not") {
+		return p.parseComparison()
+	}
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind() != kindBool {
+		return nil, &Error{Pos: t.pos, Msg: `"not" needs a condition`}
+	}
+	return &notNode{operand: operand}, nil
+}
+
+// parseComparison parses: additive [ cmp additive ].
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp {
+		return left, nil
+	}
+	switch t.text {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != kindNumber || right.kind() != kindNumber {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%q compares numbers, not conditions", t.text)}
+	}
+	return &compareNode{op: t.text, left: left, right: right}, nil
+}
+
+// parseAdditive parses: multiplicative { ("+"|"-") multiplicative }.
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "+" && t.text != "-") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arith(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMultiplicative parses: unary { ("*"|"/") unary }.
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "*" && t.text != "/") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arith(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// arith builds an arithmetic node, checking both operands are numbers.
+func arith(op token, left, right node) (node, error) {
+	if left.kind() != kindNumber || right.kind() != kindNumber {
+		return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("%q needs numbers on both sides", op.text)}
+	}
+	return &arithNode{op: op.text, left: left, right: right}, nil
+}
+
+// parseUnary parses: "-" unary | primary.
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if !p.accept("-") {
+		return p.parsePrimary()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind() != kindNumber {
+		return nil, &Error{Pos: t.pos, Msg: `"-" needs a number`}
+	}
+	return &arithNode{op: "-", left: numberNode(0), right: operand}, nil
+}
+
+// parsePrimary parses a number, a parenthesized expression or a call.
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		v, _ := strconv.ParseFloat(t.text, 64)
+		return numberNode(v), nil
+	case t.kind == tokOp && t.text == "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	case t.kind == tokIdent && !isKeyword(t.text):
+		return p.parseCall(t)
+	default:
+		return nil, &Error{Pos: t.pos, Msg: "unexpected " + t.describe()}
+	}
+}
+
+// parseCall parses the arguments of the function named by t.
+func (p *parser) parseCall(t token) (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	switch t.text {
+	case "hour", "weekday":
+		return clockNode(t.text), p.expect(")")
+
+	case "count":
+		src, err := p.parseSource(false)
+		if err != nil {
+			return nil, err
+		}
+		return &aggregateNode{fn: "count", src: src}, p.expect(")")
+
+	case "sum", "rate":
+		src, err := p.parseSource(true)
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "sum" && !src.metrics && src.field == "" {
+			return nil, &Error{Pos: t.pos, Msg: "sum over events needs a field to add up, e.g. sum(cost_usd, event == \"api_request\")"}
+		}
+		return &aggregateNode{fn: t.text, src: src}, p.expect(")")
+
+	case "ratio":
+		num, err := p.parseSource(false)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		den, err := p.parseSource(false)
+		if err != nil {
+			return nil, err
+		}
+		return &aggregateNode{fn: "ratio", src: num, den: den}, p.expect(")")
+
+	default:
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown function %q (want count, sum, rate, ratio, hour or weekday)", t.text)}
+	}
+}
+
+// parseSource parses an aggregate's filter, optionally preceded by the
+// event attribute to add up: [field ","] filter.
+func (p *parser) parseSource(allowField bool) (source, error) {
+	var src source
+	if t := p.peek(); t.kind == tokIdent && p.toks[p.i+1].kind == tokOp && p.toks[p.i+1].text == "," {
+		if !allowField {
+			return src, &Error{Pos: t.pos, Msg: "expected a filter, found field " + t.describe()}
+		}
+		p.next()
+		p.next()
+		src.field = t.text
+	}
+
+	start := p.peek()
+	f, err := p.parseFilterOr()
+	if err != nil {
+		return src, err
+	}
+	src.filter = f
+	keys := map[string]bool{}
+	f.keys(keys)
+	if keys["event"] && keys["metric"] {
+		return src, &Error{Pos: start.pos, Msg: "a filter matches either events or metrics, not both"}
+	}
+	src.metrics = keys["metric"]
+	if src.metrics && src.field != "" {
+		return src, &Error{Pos: start.pos, Msg: "a field can only be added up over events; sum(filter) adds up metric values"}
+	}
+	return src, nil
+}
+
+// parseFilterOr parses: filterAnd { "or" filterAnd }.
+func (p *parser) parseFilterOr() (filter, error) {
+	left, err := p.parseFilterAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseFilterAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogic{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFilterAnd parses: filterNot { "and" filterNot }.
+func (p *parser) parseFilterAnd() (filter, error) {
+	left, err := p.parseFilterNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parseFilterNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogic{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFilterNot parses: "not" filterNot | "(" filterOr ")" | key op value.
+func (p *parser) parseFilterNot() (filter, error) {
+	if p.accept("not") {
+		f, err := p.parseFilterNot()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{f}, nil
+	}
+	if p.accept("(") {
+		f, err := p.parseFilterOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	}
+
+	key := p.next()
+	if key.kind != tokIdent || isKeyword(key.text) {
+		return nil, &Error{Pos: key.pos, Msg: "expected an attribute name, event or metric, found " + key.describe()}
+	}
+	op := p.next()
+	switch {
+	case op.kind != tokOp:
+		return nil, &Error{Pos: op.pos, Msg: "expected ==, !=, ~ or !~, found " + op.describe()}
+	case op.text != "==" && op.text != "!=" && op.text != "~" && op.text != "!~":
+		return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("filters compare with ==, !=, ~ or !~, not %q", op.text)}
+	}
+	val := p.next()
+	if val.kind != tokString && val.kind != tokNumber {
+		return nil, &Error{Pos: val.pos, Msg: "expected a quoted value, found " + val.describe()}
+	}
+	if op.text == "~" || op.text == "!~" {
+		if _, err := path.Match(val.text, ""); err != nil {
+			return nil, &Error{Pos: val.pos, Msg: fmt.Sprintf("invalid pattern %q: %v", val.text, err)}
+		}
+	}
+	return &filterMatch{key: key.text, op: op.text, value: val.text}, nil
+}
+
+// isKeyword reports whether s is a reserved word.
+func isKeyword(s string) bool {
+	return s == "and" || s == "or" || s == "not"
+}
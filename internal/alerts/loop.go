@@ -0,0 +1,207 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// loopHistory is a session's recent tool calls, as the loop detector
+// tracks them.
+type loopHistory struct {
+	failures map[string][]time.Time // fingerprint -> failure timestamps
+	calls    map[string][]time.Time // fingerprint -> call timestamps
+	labels   map[string]string      // fingerprint -> description of the call
+	edits    map[string][]fileEdit  // file path -> successful edits
+	// oscillations holds, by file path, when an edit undid an earlier one.
+	oscillations map[string][]time.Time
+}
+
+// fileEdit is a change made to a file, identified by hashes of the text it
+// replaced and the text it wrote. For whole-file writes, from is the
+// content of the previous write, if any.
+type fileEdit struct {
+	from, to string
+	write    bool
+	at       time.Time
+}
+
+func newLoopHistory() *loopHistory {
+	return &loopHistory{
+		failures:     make(map[string][]time.Time),
+		calls:        make(map[string][]time.Time),
+		labels:       make(map[string]string),
+		edits:        make(map[string][]fileEdit),
+		oscillations: make(map[string][]time.Time),
+	}
+}
+
+// record adds a tool_result event to the history.
+func (h *loopHistory) record(evt state.Event, normalizer CommandNormalizer) {
+	raw := evt.Attributes["tool_parameters"]
+	var params map[string]any
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &params)
+	}
+	tool := evt.Attributes["tool_name"]
+	success := evt.Attributes["success"] == "true"
+
+	if tool == "Bash" {
+		// Commands are read from the raw parameters as before, so that
+		// equivalent commands share a fingerprint.
+		if hash := normalizer.Normalize(extractBashCommand(raw)); hash != "" {
+			h.add("Bash:"+hash, "same command", success, evt.Timestamp)
+		}
+		return
+	}
+	fp, label := toolFingerprint(tool, params)
+	if fp == "" {
+		return
+	}
+	h.add(fp, label, success, evt.Timestamp)
+	if success {
+		h.recordEdit(tool, params, evt.Timestamp)
+	}
+}
+
+func (h *loopHistory) add(fp, label string, success bool, at time.Time) {
+	h.labels[fp] = label
+	h.calls[fp] = append(h.calls[fp], at)
+	if !success {
+		h.failures[fp] = append(h.failures[fp], at)
+	}
+}
+
+// recordEdit records the change made by an Edit or Write call, noting an
+// oscillation if it reverses an earlier change to the same file: A to B,
+// then B back to A.
+func (h *loopHistory) recordEdit(tool string, params map[string]any, at time.Time) {
+	path := cleanPath(stringParam(params, "file_path"))
+	if path == "" {
+		return
+	}
+	var e fileEdit
+	switch tool {
+	case "Edit":
+		oldText, ok1 := params["old_string"].(string)
+		newText, ok2 := params["new_string"].(string)
+		if !ok1 || !ok2 {
+			return
+		}
+		e = fileEdit{from: hashString(oldText), to: hashString(newText)}
+	case "Write":
+		content, ok := params["content"].(string)
+		if !ok {
+			return
+		}
+		e = fileEdit{to: hashString(content), write: true}
+		for _, prev := range h.edits[path] {
+			if prev.write {
+				e.from = prev.to
+			}
+		}
+	default:
+		return
+	}
+	e.at = at
+
+	for _, prev := range h.edits[path] {
+		if prev.from != "" && prev.from == e.to && prev.to == e.from {
+			h.oscillations[path] = append(h.oscillations[path], at)
+			break
+		}
+	}
+	h.edits[path] = append(h.edits[path], e)
+}
+
+// prune forgets calls made before cutoff.
+func (h *loopHistory) prune(cutoff time.Time) {
+	pruneKeys(h.failures, cutoff)
+	pruneKeys(h.calls, cutoff)
+	pruneKeys(h.oscillations, cutoff)
+	for fp := range h.labels {
+		if _, ok := h.calls[fp]; !ok {
+			delete(h.labels, fp)
+		}
+	}
+	for path, edits := range h.edits {
+		n := 0
+		for _, e := range edits {
+			if !e.at.Before(cutoff) {
+				edits[n] = e
+				n++
+			}
+		}
+		if n == 0 {
+			delete(h.edits, path)
+		} else {
+			h.edits[path] = edits[:n]
+		}
+	}
+}
+
+// pruneKeys prunes each entry of m to the timestamps at or after cutoff,
+// dropping entries left empty.
+func pruneKeys(m map[string][]time.Time, cutoff time.Time) {
+	for k, timestamps := range m {
+		if pruned := pruneTimestamps(timestamps, cutoff); len(pruned) > 0 {
+			m[k] = pruned
+		} else {
+			delete(m, k)
+		}
+	}
+}
+
+// toolFingerprint identifies a non-Bash tool call by the tool and its key
+// parameter, and describes it for alert messages: MCP calls by server and
+// tool, file tools by the cleaned file path and web tools by the URL
+// without its fragment. Calls without a key parameter get no fingerprint,
+// as there is no telling whether two of them are the same.
+func toolFingerprint(tool string, params map[string]any) (fp, label string) {
+	if server, name := stringParam(params, "mcp_server_name"), stringParam(params, "mcp_tool_name"); server != "" && name != "" {
+		return "mcp:" + server + ":" + name, "MCP tool " + server + ":" + name
+	}
+	if tool == "" {
+		return "", ""
+	}
+	for _, key := range []string{"file_path", "notebook_path"} {
+		if path := cleanPath(stringParam(params, key)); path != "" {
+			return tool + ":" + path, tool + " " + path
+		}
+	}
+	if u := normalizeURL(stringParam(params, "url")); u != "" {
+		return tool + ":" + u, tool + " " + u
+	}
+	return "", ""
+}
+
+// stringParam returns a string tool parameter, or "" if it is missing or
+// not a string.
+func stringParam(params map[string]any, key string) string {
+	s, _ := params[key].(string)
+	return strings.TrimSpace(s)
+}
+
+func cleanPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+// normalizeURL lowercases the scheme and host of a URL and drops its
+// fragment. Unparseable URLs are returned as they are.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	return u.String()
+}
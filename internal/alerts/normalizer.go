@@ -33,6 +33,28 @@ var knownFamilies = []commandFamily{
 			"pnpm exec jest",
 		},
 	},
+	{
+		name: "jest",
+		prefixes: []string{
+			"jest",
+			"pnpm jest",
+			"bunx jest",
+			"node_modules/.bin/jest",
+			"./node_modules/.bin/jest",
+		},
+	},
+	{
+		name: "vitest",
+		prefixes: []string{
+			"vitest",
+			"pnpm vitest",
+			"pnpm exec vitest",
+			"npm exec vitest",
+			"bunx vitest",
+			"node_modules/.bin/vitest",
+			"./node_modules/.bin/vitest",
+		},
+	},
 	{
 		name: "pytest",
 		prefixes: []string{
@@ -48,14 +70,98 @@ var knownFamilies = []commandFamily{
 			"go test",
 		},
 	},
+	{
+		name: "cargo-test",
+		prefixes: []string{
+			"cargo test",
+			"cargo t",
+			"cargo nextest run",
+		},
+	},
+	{
+		name: "cargo-build",
+		prefixes: []string{
+			"cargo build",
+			"cargo b",
+			"cargo check",
+			"cargo c",
+		},
+	},
+	{
+		name: "make-test",
+		prefixes: []string{
+			"make test",
+			"make tests",
+			"make check",
+			"gmake test",
+			"gmake check",
+		},
+	},
+	{
+		name: "make",
+		prefixes: []string{
+			"make",
+			"gmake",
+		},
+	},
+	{
+		name: "gradle-test",
+		prefixes: []string{
+			"gradle test",
+			"gradle check",
+			"./gradlew test",
+			"./gradlew check",
+			"gradlew test",
+			"gradlew check",
+		},
+	},
+	{
+		name: "gradle-build",
+		prefixes: []string{
+			"gradle build",
+			"gradle assemble",
+			"./gradlew build",
+			"./gradlew assemble",
+			"gradlew build",
+			"gradlew assemble",
+		},
+	},
+	{
+		name: "maven-test",
+		prefixes: []string{
+			"mvn test",
+			"mvn verify",
+			"./mvnw test",
+			"./mvnw verify",
+			"mvnw test",
+			"mvnw verify",
+		},
+	},
+	{
+		name: "maven-build",
+		prefixes: []string{
+			"mvn compile",
+			"mvn package",
+			"mvn install",
+			"./mvnw compile",
+			"./mvnw package",
+			"./mvnw install",
+			"mvnw compile",
+			"mvnw package",
+			"mvnw install",
+		},
+	},
 }
 
 // NormalizeCommand groups semantically similar bash commands by prefix
 // matching against known command families, returning a stable SHA-256 hash.
 //
-// Known test-runner families (npm/npx/yarn test variants, pytest variants,
-// go test variants) are all mapped to the same hash within their family.
-// Unknown commands are hashed individually using their full command string.
+// Known test-runner and build families (npm/npx/yarn test variants, direct
+// jest and vitest runs, pytest, go test, cargo, make, gradle and maven
+// variants) are all mapped to the same hash within their family. Families
+// are tried in order, so "make test" is a make-test run while other make
+// targets share the make family. Unknown commands are hashed individually
+// using their full command string.
 //
 // An empty command returns an empty string. Large commands (e.g., 10KB+) are
 // hashed without error since SHA-256 handles arbitrary-length input.
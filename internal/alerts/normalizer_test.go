@@ -116,13 +116,41 @@ func TestCommandNormalizer_PrefixMatch(t *testing.T) {
 	})
 
 	t.Run("unknown command is distinct from known families", func(t *testing.T) {
-		unknown := NormalizeCommand("make test")
+		unknown := NormalizeCommand("rake test")
 		known := NormalizeCommand("npm test")
 		if unknown == known {
 			t.Error("unknown command should not match known family hash")
 		}
 	})
 
+	t.Run("build tool families map to the same hash", func(t *testing.T) {
+		families := [][]string{
+			{"jest", "jest --watchAll=false", "pnpm jest", "bunx jest src/", "./node_modules/.bin/jest"},
+			{"vitest", "vitest run", "pnpm exec vitest", "bunx vitest --reporter=dot"},
+			{"cargo test", "cargo test -p core", "cargo t", "cargo nextest run"},
+			{"cargo build", "cargo build --release", "cargo check", "cargo c"},
+			{"make test", "make check", "gmake test", "make tests"},
+			{"make", "make -j8", "make all", "gmake install"},
+			{"gradle test", "./gradlew test --info", "gradlew check"},
+			{"gradle build", "./gradlew build", "gradle assemble"},
+			{"mvn test", "mvn verify -DskipITs", "./mvnw test"},
+			{"mvn package", "mvn install -DskipTests", "./mvnw compile"},
+		}
+		seen := make(map[string]string)
+		for _, commands := range families {
+			first := NormalizeCommand(commands[0])
+			for _, cmd := range commands[1:] {
+				if got := NormalizeCommand(cmd); got != first {
+					t.Errorf("NormalizeCommand(%q) = %q, want %q (same as %q)", cmd, got, first, commands[0])
+				}
+			}
+			if other, ok := seen[first]; ok {
+				t.Errorf("%q and %q should be in different families", commands[0], other)
+			}
+			seen[first] = commands[0]
+		}
+	})
+
 	t.Run("prefix must match at word boundary", func(t *testing.T) {
 		// "go testing" should NOT match "go test" family
 		goTestHash := NormalizeCommand("go test")
@@ -1,5 +1,41 @@
 package alerts
 
+import "time"
+
+// DeliveryStatus summarizes how a notifier's deliveries have gone.
+type DeliveryStatus struct {
+	Kind        string // notifier type, e.g. "webhook"
+	Name        string
+	Sent        int
+	Failed      int // alerts given up on after retries
+	Dropped     int // alerts dropped because the queue was full
+	Pending     int // alerts waiting for delivery
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+// StatusReporter is implemented by notifiers that track their deliveries.
+type StatusReporter interface {
+	DeliveryStatus() []DeliveryStatus
+}
+
+// Router is implemented by notifiers made up of several named notifiers
+// that can deliver an alert to some of them, for rules whose
+// [alerts.rules] entry routes their notifications.
+type Router interface {
+	NotifyTo(alert Alert, names []string)
+}
+
+// NotificationActions carries out the actions offered as buttons on
+// desktop notifications, where the platform supports them.
+type NotificationActions interface {
+	// PauseSession pauses the session an alert is about.
+	PauseSession(sessionID string) error
+	// Snooze snoozes an alert until the given time.
+	Snooze(id uint64, until time.Time) error
+}
+
 // truncateSessionID shortens a session ID for display in notifications.
 func truncateSessionID(id string) string {
 	if len(id) <= 12 {
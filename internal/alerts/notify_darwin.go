@@ -70,7 +70,8 @@ func sendOSANotification(title, subtitle, message string) error {
 }
 
 // NewPlatformNotifier creates the platform-appropriate notifier for macOS.
-func NewPlatformNotifier(enabled bool) Notifier {
+// osascript notifications cannot carry buttons, so actions is unused.
+func NewPlatformNotifier(enabled bool, actions NotificationActions) Notifier {
 	return NewOSAScriptNotifier(enabled)
 }
 
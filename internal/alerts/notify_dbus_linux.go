@@ -0,0 +1,250 @@
+//go:build linux
+
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The freedesktop.org desktop notifications service.
+const (
+	notificationsName  = "org.freedesktop.Notifications"
+	notificationsPath  = dbus.ObjectPath("/org/freedesktop/Notifications")
+	notificationsIface = "org.freedesktop.Notifications"
+)
+
+// Keys of the action buttons on alert notifications.
+const (
+	actionPauseSession = "pause"
+	actionSnooze       = "snooze"
+)
+
+// desktopSnooze is how long the Snooze button snoozes an alert.
+const desktopSnooze = time.Hour
+
+// DBusNotifier shows alerts through the org.freedesktop.Notifications
+// service. Each alert key keeps one notification, which later alerts with
+// the same key replace rather than stacking up. If the server supports
+// actions, notifications offer "Pause session" and "Snooze" buttons that
+// call back into cc-top. Notify queues the alert and returns; a background
+// goroutine makes the D-Bus calls.
+type DBusNotifier struct {
+	conn       *dbus.Conn
+	obj        dbus.BusObject
+	actions    NotificationActions
+	hasActions bool // the server shows action buttons
+	queue      chan Alert
+	signals    chan *dbus.Signal
+	done       chan struct{}
+	wg         sync.WaitGroup
+
+	mu     sync.Mutex
+	ids    map[string]uint32 // alert key -> notification ID
+	shown  map[uint32]Alert  // notification ID -> alert it shows
+	status DeliveryStatus
+}
+
+// NewDBusNotifier creates a notifier that talks to the notification server
+// over conn, which it takes ownership of. actions, if not nil, carries out
+// the notification buttons. It fails if no notification server answers.
+// Close stops it.
+func NewDBusNotifier(conn *dbus.Conn, actions NotificationActions) (*DBusNotifier, error) {
+	obj := conn.Object(notificationsName, notificationsPath)
+	var caps []string
+	if err := obj.Call(notificationsIface+".GetCapabilities", 0).Store(&caps); err != nil {
+		return nil, fmt.Errorf("desktop notifications: %w", err)
+	}
+	err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(notificationsPath),
+		dbus.WithMatchInterface(notificationsIface),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("desktop notifications: %w", err)
+	}
+
+	n := &DBusNotifier{
+		conn:       conn,
+		obj:        obj,
+		actions:    actions,
+		hasActions: actions != nil && slices.Contains(caps, "actions"),
+		queue:      make(chan Alert, 32),
+		signals:    make(chan *dbus.Signal, 16),
+		done:       make(chan struct{}),
+		ids:        make(map[string]uint32),
+		shown:      make(map[uint32]Alert),
+		status:     DeliveryStatus{Kind: "desktop", Name: "dbus"},
+	}
+	conn.Signal(n.signals)
+
+	n.wg.Add(2)
+	go n.run()
+	go n.handleSignals()
+	return n, nil
+}
+
+// Notify queues an alert to be shown. When the queue is full the alert is
+// dropped.
+func (n *DBusNotifier) Notify(alert Alert) {
+	select {
+	case n.queue <- alert:
+	default:
+		n.mu.Lock()
+		n.status.Dropped++
+		n.mu.Unlock()
+	}
+}
+
+// Close stops the notifier and closes its connection. Notifications
+// already shown stay up, but their buttons no longer do anything.
+func (n *DBusNotifier) Close() {
+	close(n.done)
+	n.conn.Close()
+	n.wg.Wait()
+}
+
+// DeliveryStatus reports how many notifications have been shown.
+func (n *DBusNotifier) DeliveryStatus() []DeliveryStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	st := n.status
+	st.Pending = len(n.queue)
+	return []DeliveryStatus{st}
+}
+
+// run shows queued alerts until the notifier is closed.
+func (n *DBusNotifier) run() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.done:
+			return
+		case alert := <-n.queue:
+			err := n.show(alert)
+			n.mu.Lock()
+			if err != nil {
+				n.status.Failed++
+				n.status.LastFailure = time.Now()
+				n.status.LastError = err.Error()
+			} else {
+				n.status.Sent++
+				n.status.LastSuccess = time.Now()
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+// show shows alert, replacing the notification for an earlier alert with
+// the same key if it is still up.
+func (n *DBusNotifier) show(alert Alert) error {
+	key := alert.alertKey()
+	n.mu.Lock()
+	replaces := n.ids[key]
+	n.mu.Unlock()
+
+	title := fmt.Sprintf("cc-top: %s", alert.Rule)
+	body := alert.Message
+	if alert.SessionID != "" {
+		body = fmt.Sprintf("Session: %s\n%s", truncateSessionID(alert.SessionID), alert.Message)
+	}
+
+	// Urgency levels are 0 (low), 1 (normal) and 2 (critical).
+	urgency := byte(1)
+	if alert.Severity == SeverityCritical {
+		urgency = 2
+	}
+	hints := map[string]dbus.Variant{"urgency": dbus.MakeVariant(urgency)}
+
+	actions := []string{}
+	if n.hasActions {
+		if alert.SessionID != "" {
+			actions = append(actions, actionPauseSession, "Pause session")
+		}
+		if alert.ID != 0 {
+			actions = append(actions, actionSnooze, "Snooze 1h")
+		}
+	}
+
+	var id uint32
+	err := n.obj.Call(notificationsIface+".Notify", 0,
+		"cc-top", replaces, "", title, body, actions, hints, int32(-1)).Store(&id)
+	if err != nil {
+		return fmt.Errorf("desktop notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if replaces != 0 && replaces != id {
+		delete(n.shown, replaces)
+	}
+	n.ids[key] = id
+	n.shown[id] = alert
+	return nil
+}
+
+// handleSignals carries out invoked actions and forgets closed
+// notifications until the connection is closed.
+func (n *DBusNotifier) handleSignals() {
+	defer n.wg.Done()
+	for sig := range n.signals {
+		if sig.Path != notificationsPath || len(sig.Body) < 2 {
+			continue
+		}
+		id, ok := sig.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+		switch sig.Name {
+		case notificationsIface + ".ActionInvoked":
+			if action, ok := sig.Body[1].(string); ok {
+				n.invoke(id, action)
+			}
+		case notificationsIface + ".NotificationClosed":
+			n.forget(id)
+		}
+	}
+}
+
+// invoke carries out the action clicked on notification id.
+func (n *DBusNotifier) invoke(id uint32, action string) {
+	n.mu.Lock()
+	alert, ok := n.shown[id]
+	n.mu.Unlock()
+	if !ok || n.actions == nil {
+		return
+	}
+
+	var err error
+	switch action {
+	case actionPauseSession:
+		err = n.actions.PauseSession(alert.SessionID)
+	case actionSnooze:
+		err = n.actions.Snooze(alert.ID, time.Now().Add(desktopSnooze))
+	}
+	if err != nil {
+		log.Printf("WARNING: notification action %s for %s failed: %v", action, alert.Rule, err)
+	}
+}
+
+// forget drops a closed notification, so the next alert with its key
+// opens a new one.
+func (n *DBusNotifier) forget(id uint32) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	alert, ok := n.shown[id]
+	if !ok {
+		return
+	}
+	delete(n.shown, id)
+	if key := alert.alertKey(); n.ids[key] == id {
+		delete(n.ids, key)
+	}
+}
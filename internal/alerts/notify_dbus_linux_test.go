@@ -0,0 +1,310 @@
+//go:build linux
+
+package alerts
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-BUS Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%DIR%</listen>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startTestBus runs a private dbus-daemon for the test and returns its
+// address. The test is skipped if dbus-daemon is not installed.
+func startTestBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(conf, []byte(strings.ReplaceAll(testBusConfig, "%DIR%", dir)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+conf, "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	addr, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading bus address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+func connectTestBus(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatalf("connecting to test bus: %v", err)
+	}
+	return conn
+}
+
+// notifyCall is a Notify call received by fakeNotificationServer.
+type notifyCall struct {
+	replaces uint32
+	id       uint32
+	summary  string
+	actions  []string
+	urgency  byte
+}
+
+// fakeNotificationServer implements org.freedesktop.Notifications on the
+// test bus.
+type fakeNotificationServer struct {
+	conn *dbus.Conn
+	caps []string
+
+	mu     sync.Mutex
+	nextID uint32
+	calls  []notifyCall
+}
+
+func newFakeNotificationServer(t *testing.T, addr string, caps ...string) *fakeNotificationServer {
+	t.Helper()
+	s := &fakeNotificationServer{conn: connectTestBus(t, addr), caps: caps}
+	t.Cleanup(func() { s.conn.Close() })
+	if err := s.conn.Export(s, notificationsPath, notificationsIface); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := s.conn.RequestName(notificationsName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("RequestName = %v, %v", reply, err)
+	}
+	return s
+}
+
+func (s *fakeNotificationServer) GetCapabilities() ([]string, *dbus.Error) {
+	return s.caps, nil
+}
+
+func (s *fakeNotificationServer) Notify(app string, replaces uint32, icon, summary, body string,
+	actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := replaces
+	if id == 0 {
+		s.nextID++
+		id = s.nextID
+	}
+	urgency, _ := hints["urgency"].Value().(byte)
+	s.calls = append(s.calls, notifyCall{replaces, id, summary, actions, urgency})
+	return id, nil
+}
+
+// emit sends a signal from the server, as when the user clicks a button.
+func (s *fakeNotificationServer) emit(t *testing.T, name string, args ...any) {
+	t.Helper()
+	if err := s.conn.Emit(notificationsPath, notificationsIface+"."+name, args...); err != nil {
+		t.Fatalf("emitting %s: %v", name, err)
+	}
+}
+
+func (s *fakeNotificationServer) waitForCalls(t *testing.T, count int) []notifyCall {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		calls := append([]notifyCall(nil), s.calls...)
+		s.mu.Unlock()
+		if len(calls) >= count {
+			return calls
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d Notify calls, got %+v", count, calls)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// recordedActions records the notification actions carried out.
+type recordedActions struct {
+	mu     sync.Mutex
+	paused []string
+	snooze []uint64
+	until  time.Time
+}
+
+func (a *recordedActions) PauseSession(sessionID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.paused = append(a.paused, sessionID)
+	return nil
+}
+
+func (a *recordedActions) Snooze(id uint64, until time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.snooze = append(a.snooze, id)
+	a.until = until
+	return nil
+}
+
+func (a *recordedActions) wait(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		a.mu.Lock()
+		ok := done()
+		a.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the action")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestDBusNotifier(t *testing.T, addr string, actions NotificationActions) *DBusNotifier {
+	t.Helper()
+	n, err := NewDBusNotifier(connectTestBus(t, addr), actions)
+	if err != nil {
+		t.Fatalf("NewDBusNotifier: %v", err)
+	}
+	t.Cleanup(n.Close)
+	return n
+}
+
+func TestDBusNotifier_ReusesIDPerKey(t *testing.T) {
+	addr := startTestBus(t)
+	srv := newFakeNotificationServer(t, addr, "body", "actions")
+	n := newTestDBusNotifier(t, addr, &recordedActions{})
+
+	first := Alert{ID: 1, Rule: RuleCostSurge, Severity: SeverityCritical, SessionID: "sess-1", Message: "$120/hr"}
+	again := Alert{ID: 2, Rule: RuleCostSurge, Severity: SeverityCritical, SessionID: "sess-1", Message: "$140/hr"}
+	global := Alert{ID: 3, Rule: RuleErrorStorm, Severity: SeverityWarning, Message: "errors"}
+	n.Notify(first)
+	n.Notify(again)
+	n.Notify(global)
+	calls := srv.waitForCalls(t, 3)
+
+	if calls[0].replaces != 0 || calls[1].replaces != calls[0].id || calls[1].id != calls[0].id {
+		t.Errorf("the second CostSurge alert should replace the first notification, got %+v", calls[:2])
+	}
+	if calls[2].replaces != 0 || calls[2].id == calls[0].id {
+		t.Errorf("an alert with another key should open a new notification, got %+v", calls[2])
+	}
+	if calls[0].summary != "cc-top: CostSurge" || calls[0].urgency != 2 || calls[2].urgency != 1 {
+		t.Errorf("unexpected summary or urgency: %+v", calls)
+	}
+	if got := strings.Join(calls[0].actions, ","); got != "pause,Pause session,snooze,Snooze 1h" {
+		t.Errorf("session alert actions = %q", got)
+	}
+	if got := strings.Join(calls[2].actions, ","); got != "snooze,Snooze 1h" {
+		t.Errorf("global alert actions = %q, want snooze only", got)
+	}
+	if st := waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 3 }); st.Failed != 0 {
+		t.Errorf("DeliveryStatus = %+v", st)
+	}
+
+	// Once the notification is closed, the key gets a new one.
+	srv.emit(t, "NotificationClosed", calls[0].id, uint32(2))
+	time.Sleep(20 * time.Millisecond)
+	n.Notify(first)
+	if call := srv.waitForCalls(t, 4)[3]; call.replaces != 0 {
+		t.Errorf("alert after close should open a new notification, got %+v", call)
+	}
+}
+
+func TestDBusNotifier_Actions(t *testing.T) {
+	addr := startTestBus(t)
+	srv := newFakeNotificationServer(t, addr, "actions")
+	actions := &recordedActions{}
+	n := newTestDBusNotifier(t, addr, actions)
+
+	n.Notify(Alert{ID: 42, Rule: RuleLoopDetector, Severity: SeverityWarning, SessionID: "sess-1"})
+	id := srv.waitForCalls(t, 1)[0].id
+	waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 1 })
+
+	srv.emit(t, "ActionInvoked", id, actionPauseSession)
+	actions.wait(t, func() bool { return len(actions.paused) == 1 })
+	if actions.paused[0] != "sess-1" {
+		t.Errorf("paused %v, want sess-1", actions.paused)
+	}
+
+	start := time.Now()
+	srv.emit(t, "ActionInvoked", id, actionSnooze)
+	actions.wait(t, func() bool { return len(actions.snooze) == 1 })
+	if actions.snooze[0] != 42 || actions.until.Before(start.Add(desktopSnooze)) {
+		t.Errorf("snoozed %v until %v, want alert 42 for an hour", actions.snooze, actions.until)
+	}
+
+	// Unknown notifications are ignored.
+	srv.emit(t, "ActionInvoked", id+100, actionPauseSession)
+	time.Sleep(20 * time.Millisecond)
+	actions.mu.Lock()
+	defer actions.mu.Unlock()
+	if len(actions.paused) != 1 {
+		t.Errorf("action on an unknown notification was carried out: %v", actions.paused)
+	}
+}
+
+func TestDBusNotifier_NoActionsSupport(t *testing.T) {
+	addr := startTestBus(t)
+	srv := newFakeNotificationServer(t, addr, "body")
+	n := newTestDBusNotifier(t, addr, &recordedActions{})
+
+	n.Notify(Alert{ID: 1, Rule: RuleCostSurge, Severity: SeverityWarning, SessionID: "sess-1"})
+	if call := srv.waitForCalls(t, 1)[0]; len(call.actions) != 0 {
+		t.Errorf("server without actions support got actions %v", call.actions)
+	}
+}
+
+func TestDBusNotifier_NoServer(t *testing.T) {
+	addr := startTestBus(t)
+	conn := connectTestBus(t, addr)
+	defer conn.Close()
+
+	if _, err := NewDBusNotifier(conn, nil); err == nil {
+		t.Error("expected an error with no notification server on the bus")
+	}
+}
+
+func TestNewPlatformNotifier_FallsBackToNotifySend(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "missing"))
+	if _, ok := NewPlatformNotifier(true, nil).(*NotifySendNotifier); !ok {
+		t.Error("expected notify-send when D-Bus is unavailable")
+	}
+
+	addr := startTestBus(t)
+	newFakeNotificationServer(t, addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+	n, ok := NewPlatformNotifier(true, nil).(*DBusNotifier)
+	if !ok {
+		t.Fatal("expected the D-Bus notifier when a notification server is running")
+	}
+	n.Close()
+}
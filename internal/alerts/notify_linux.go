@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
+
+	"github.com/godbus/dbus/v5"
 )
 
 // NotifySendNotifier sends Linux desktop notifications via notify-send.
@@ -23,8 +25,24 @@ func NewNotifySendNotifier(enabled bool) *NotifySendNotifier {
 	return &NotifySendNotifier{enabled: enabled}
 }
 
-// NewPlatformNotifier creates the platform-appropriate notifier for Linux.
-func NewPlatformNotifier(enabled bool) Notifier {
+// NewPlatformNotifier creates the platform-appropriate notifier for Linux:
+// a DBusNotifier on the session bus, offering actions, or notify-send when
+// no notification server is reachable over D-Bus.
+func NewPlatformNotifier(enabled bool, actions NotificationActions) Notifier {
+	if !enabled {
+		return NewNotifySendNotifier(false)
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err == nil {
+		var n *DBusNotifier
+		if n, err = NewDBusNotifier(conn, actions); err == nil {
+			return n
+		}
+		conn.Close()
+	}
+	if _, lookErr := exec.LookPath("notify-send"); lookErr != nil {
+		log.Printf("WARNING: desktop notifications unavailable: %v; %v", err, lookErr)
+	}
 	return NewNotifySendNotifier(enabled)
 }
 
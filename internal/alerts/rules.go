@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/nixlim/cc-top/internal/budget"
 	"github.com/nixlim/cc-top/internal/burnrate"
 	"github.com/nixlim/cc-top/internal/config"
 	"github.com/nixlim/cc-top/internal/state"
@@ -19,6 +23,14 @@ type Rule interface {
 	Evaluate(store state.Store, now time.Time) []Alert
 }
 
+// conditionHolder is implemented by rules that fire once when a condition
+// is reached rather than on every evaluation while it holds. Holds reports
+// whether the condition behind one of the rule's alerts still holds; the
+// engine resolves the alert once it has not for a while.
+type conditionHolder interface {
+	Holds(alert Alert) bool
+}
+
 // costSurgeRule fires when the hourly cost rate exceeds a threshold.
 type costSurgeRule struct {
 	threshold  float64
@@ -32,17 +44,34 @@ func newCostSurgeRule(cfg config.AlertsConfig, calculator *burnrate.Calculator)
 	}
 }
 
+// Evaluate fires one alert per session whose own hourly rate exceeds the
+// threshold, and a global alert when the combined rate does but no single
+// session accounts for it.
 func (r *costSurgeRule) Evaluate(store state.Store, now time.Time) []Alert {
 	br := r.calculator.ComputeWithTime(store, now)
-	if br.HourlyRate >= r.threshold {
-		return []Alert{{
+	rates := r.calculator.Sessions()
+
+	var alerts []Alert
+	for _, id := range sortedSessionIDs(rates) {
+		if rate := rates[id].HourlyRate; rate >= r.threshold {
+			alerts = append(alerts, Alert{
+				Rule:      RuleCostSurge,
+				Severity:  SeverityCritical,
+				Message:   fmt.Sprintf("Cost surge: session at $%.2f/hr exceeds threshold $%.2f/hr", rate, r.threshold),
+				SessionID: id,
+				FiredAt:   now,
+			})
+		}
+	}
+	if len(alerts) == 0 && br.HourlyRate >= r.threshold {
+		alerts = append(alerts, Alert{
 			Rule:     RuleCostSurge,
 			Severity: SeverityCritical,
 			Message:  fmt.Sprintf("Cost surge: $%.2f/hr exceeds threshold $%.2f/hr", br.HourlyRate, r.threshold),
 			FiredAt:  now,
-		}}
+		})
 	}
-	return nil
+	return alerts
 }
 
 // runawayTokensRule fires when token velocity exceeds a threshold for a sustained period.
@@ -50,7 +79,7 @@ type runawayTokensRule struct {
 	velocityThreshold float64
 	sustainedMinutes  int
 	calculator        *burnrate.Calculator
-	exceededSince     map[string]time.Time
+	exceededSince     map[string]time.Time // session ID, or "" for all sessions combined
 }
 
 func newRunawayTokensRule(cfg config.AlertsConfig, calculator *burnrate.Calculator) *runawayTokensRule {
@@ -62,46 +91,92 @@ func newRunawayTokensRule(cfg config.AlertsConfig, calculator *burnrate.Calculat
 	}
 }
 
+// Evaluate tracks each session's token velocity separately and fires for
+// every session that has stayed above the threshold for the sustained
+// period. The combined velocity fires a global alert only when no single
+// session does.
 func (r *runawayTokensRule) Evaluate(store state.Store, now time.Time) []Alert {
 	br := r.calculator.ComputeWithTime(store, now)
-	key := "" // global key
-	if br.TokenVelocity >= r.velocityThreshold {
-		if _, ok := r.exceededSince[key]; !ok {
-			r.exceededSince[key] = now
+	rates := r.calculator.Sessions()
+
+	var alerts []Alert
+	for _, id := range sortedSessionIDs(rates) {
+		velocity := rates[id].TokenVelocity
+		if r.sustained(id, velocity, now) {
+			alerts = append(alerts, Alert{
+				Rule:      RuleRunawayTokens,
+				Severity:  SeverityWarning,
+				Message:   fmt.Sprintf("Runaway tokens: session at %.0f tokens/min exceeds threshold %.0f for %d+ min", velocity, r.velocityThreshold, r.sustainedMinutes),
+				SessionID: id,
+				FiredAt:   now,
+			})
 		}
-		if now.Sub(r.exceededSince[key]) >= time.Duration(r.sustainedMinutes)*time.Minute {
-			return []Alert{{
-				Rule:     RuleRunawayTokens,
-				Severity: SeverityWarning,
-				Message:  fmt.Sprintf("Runaway tokens: %.0f tokens/min exceeds threshold %.0f for %d+ min", br.TokenVelocity, r.velocityThreshold, r.sustainedMinutes),
-				FiredAt:  now,
-			}}
+	}
+	for key := range r.exceededSince {
+		if _, ok := rates[key]; key != "" && !ok {
+			delete(r.exceededSince, key)
 		}
-	} else {
+	}
+
+	if r.sustained("", br.TokenVelocity, now) && len(alerts) == 0 {
+		alerts = append(alerts, Alert{
+			Rule:     RuleRunawayTokens,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Runaway tokens: %.0f tokens/min exceeds threshold %.0f for %d+ min", br.TokenVelocity, r.velocityThreshold, r.sustainedMinutes),
+			FiredAt:  now,
+		})
+	}
+	return alerts
+}
+
+// sustained records whether velocity is above the threshold for key and
+// reports whether it has been continuously for the sustained period.
+func (r *runawayTokensRule) sustained(key string, velocity float64, now time.Time) bool {
+	if velocity < r.velocityThreshold {
 		delete(r.exceededSince, key)
+		return false
+	}
+	if _, ok := r.exceededSince[key]; !ok {
+		r.exceededSince[key] = now
 	}
-	return nil
+	return now.Sub(r.exceededSince[key]) >= time.Duration(r.sustainedMinutes)*time.Minute
 }
 
-// loopDetectorRule fires when the same command hash fails repeatedly within a time window.
+// sortedSessionIDs returns the keys of a per-session rate map in order, so
+// alerts are produced deterministically.
+func sortedSessionIDs(rates map[string]burnrate.BurnRate) []string {
+	ids := make([]string, 0, len(rates))
+	for id := range rates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// loopDetectorRule fires when a session goes round in circles within a time
+// window: the same tool call failing repeatedly, the same call being made
+// over and over whether or not it succeeds, or an edit to a file being
+// undone (an A-B-A oscillation). Calls are told apart by their fingerprint,
+// see toolFingerprint.
 type loopDetectorRule struct {
-	threshold  int
-	windowMins int
-	normalizer CommandNormalizer
+	threshold       int // failures of the same call
+	repeatThreshold int // calls of the same call
+	windowMins      int
+	normalizer      CommandNormalizer
 
-	// Per-session tracking: sessionID -> commandHash -> []failureTimestamp
 	mu            sync.Mutex
-	failures      map[string]map[string][]time.Time
+	sessions      map[string]*loopHistory
 	lastProcessed map[string]int // sessionID -> number of events already processed
 }
 
 func newLoopDetectorRule(cfg config.AlertsConfig, normalizer CommandNormalizer) *loopDetectorRule {
 	return &loopDetectorRule{
-		threshold:     cfg.LoopDetectorThreshold,
-		windowMins:    cfg.LoopDetectorWindowMinutes,
-		normalizer:    normalizer,
-		failures:      make(map[string]map[string][]time.Time),
-		lastProcessed: make(map[string]int),
+		threshold:       cfg.LoopDetectorThreshold,
+		repeatThreshold: cfg.LoopDetectorRepeatThreshold,
+		windowMins:      cfg.LoopDetectorWindowMinutes,
+		normalizer:      normalizer,
+		sessions:        make(map[string]*loopHistory),
+		lastProcessed:   make(map[string]int),
 	}
 }
 
@@ -113,62 +188,45 @@ func (r *loopDetectorRule) Evaluate(store state.Store, now time.Time) []Alert {
 	var alerts []Alert
 
 	for _, session := range store.ListSessions() {
+		h := r.sessions[session.SessionID]
+		if h == nil {
+			h = newLoopHistory()
+			r.sessions[session.SessionID] = h
+		}
+
 		// Only process new events since last evaluation.
 		start := r.lastProcessed[session.SessionID]
 		events := session.Events
-
 		for i := start; i < len(events); i++ {
-			evt := events[i]
-			if evt.Name != "claude_code.tool_result" {
-				continue
-			}
-			if evt.Attributes["tool_name"] != "Bash" {
-				continue
-			}
-			if evt.Attributes["success"] == "true" {
-				continue
-			}
-
-			// Extract bash_command from tool_parameters.
-			command := extractBashCommand(evt.Attributes["tool_parameters"])
-			if command == "" {
-				continue
-			}
-
-			hash := r.normalizer.Normalize(command)
-			if hash == "" {
-				continue
+			if events[i].Name == "claude_code.tool_result" {
+				h.record(events[i], r.normalizer)
 			}
-
-			// Record the failure.
-			if r.failures[session.SessionID] == nil {
-				r.failures[session.SessionID] = make(map[string][]time.Time)
-			}
-
-			r.failures[session.SessionID][hash] = append(
-				r.failures[session.SessionID][hash], evt.Timestamp)
 		}
-
 		r.lastProcessed[session.SessionID] = len(events)
 
-		// Check for threshold breaches within the window.
-		if sessionFailures, ok := r.failures[session.SessionID]; ok {
-			for hash, timestamps := range sessionFailures {
-				// Prune old timestamps.
-				pruned := pruneTimestamps(timestamps, now.Add(-window))
-				sessionFailures[hash] = pruned
-
-				if len(pruned) >= r.threshold {
-					alerts = append(alerts, Alert{
-						Rule:      RuleLoopDetector,
-						Severity:  SeverityWarning,
-						SessionID: session.SessionID,
-						Message:   fmt.Sprintf("Loop detected: same command failed %d times in %d min", len(pruned), r.windowMins),
-						FiredAt:   now,
-					})
-				}
+		h.prune(now.Add(-window))
+		alert := func(format string, args ...any) {
+			alerts = append(alerts, Alert{
+				Rule:      RuleLoopDetector,
+				Severity:  SeverityWarning,
+				SessionID: session.SessionID,
+				Message:   "Loop detected: " + fmt.Sprintf(format, args...),
+				FiredAt:   now,
+			})
+		}
+		for _, fp := range slices.Sorted(maps.Keys(h.failures)) {
+			if n := len(h.failures[fp]); n >= r.threshold {
+				alert("%s failed %d times in %d min", h.labels[fp], n, r.windowMins)
 			}
 		}
+		for _, fp := range slices.Sorted(maps.Keys(h.calls)) {
+			if n := len(h.calls[fp]); n >= r.repeatThreshold {
+				alert("%s repeated %d times in %d min", h.labels[fp], n, r.windowMins)
+			}
+		}
+		for _, path := range slices.Sorted(maps.Keys(h.oscillations)) {
+			alert("%s edited back and forth (A-B-A) in %d min", path, r.windowMins)
+		}
 	}
 
 	return alerts
@@ -442,3 +500,72 @@ func pruneTimestamps(timestamps []time.Time, cutoff time.Time) []time.Time {
 	}
 	return timestamps[:n]
 }
+
+// budgetRule fires as a budget's consumption crosses each of its alert_at
+// percentages, once per tier per period. Tiers at or above 100% are critical.
+type budgetRule struct {
+	tracker *budget.Tracker
+
+	mu    sync.Mutex
+	tiers map[string]budgetTier // budget name -> highest tier alerted
+}
+
+// budgetTier is the highest tier alerted for a budget in a period.
+type budgetTier struct {
+	periodStart time.Time
+	tier        int
+}
+
+func newBudgetRule(tracker *budget.Tracker) *budgetRule {
+	return &budgetRule{
+		tracker: tracker,
+		tiers:   make(map[string]budgetTier),
+	}
+}
+
+func (r *budgetRule) Evaluate(store state.Store, now time.Time) []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var alerts []Alert
+	for _, st := range r.tracker.Evaluate(store, now) {
+		prev := r.tiers[st.Name]
+		if !prev.periodStart.Equal(st.PeriodStart) {
+			prev = budgetTier{periodStart: st.PeriodStart}
+		}
+		tier := st.Tier()
+		if tier > prev.tier {
+			severity := SeverityWarning
+			if tier >= 100 {
+				severity = SeverityCritical
+			}
+			alerts = append(alerts, Alert{
+				Rule:     RuleBudget,
+				Severity: severity,
+				Message:  budgetMessage(st),
+				Budget:   st.Name,
+				FiredAt:  now,
+			})
+			prev.tier = tier
+		}
+		r.tiers[st.Name] = prev
+	}
+	return alerts
+}
+
+// Holds reports whether the budget is still at or above an alert tier in
+// its current period.
+func (r *budgetRule) Holds(alert Alert) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tiers[alert.Budget].tier > 0
+}
+
+// budgetMessage describes a budget's consumption and forecast.
+func budgetMessage(st budget.Status) string {
+	msg := fmt.Sprintf("Budget %s: $%.2f of $%.2f (%.0f%%) this %s", st.Name, st.Spent, st.Limit, st.Percent(), st.Period)
+	if !st.ExhaustsAt.IsZero() {
+		msg += fmt.Sprintf(", exhausted by %s at $%.2f/hr", st.ExhaustsAt.In(st.PeriodStart.Location()).Format("Mon 15:04"), st.HourlyRate)
+	}
+	return msg
+}
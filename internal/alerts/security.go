@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// maxSecurityDetail bounds the command or path quoted in a security alert.
+const maxSecurityDetail = 120
+
+// fileWriteTools are the tools that write the file named by their
+// file_path or notebook_path parameter.
+var fileWriteTools = map[string]bool{
+	"Write": true, "Edit": true, "MultiEdit": true, "NotebookEdit": true,
+}
+
+// securityRule raises the DangerousCommand, SensitivePath and
+// WriteOutsideCWD alerts from the tool parameters of tool_result events,
+// which Claude Code only reports when started with
+// OTEL_LOG_TOOL_DETAILS=1. Each offending call raises one critical alert,
+// keyed by the call so that calls close together are not deduplicated;
+// calls the user rejected are left alone.
+type securityRule struct {
+	commands      []*regexp.Regexp
+	sensitive     []string // path globs, ~ expanded
+	allowedWrites []string // path globs, ~ expanded
+
+	mu            sync.Mutex
+	lastProcessed map[string]int // sessionID -> number of events already processed
+}
+
+func newSecurityRule(cfg config.SecurityConfig) *securityRule {
+	home, _ := os.UserHomeDir()
+	r := &securityRule{
+		sensitive:     expandHome(cfg.SensitivePaths, home),
+		allowedWrites: expandHome(cfg.AllowedWritePaths, home),
+		lastProcessed: make(map[string]int),
+	}
+	for _, pattern := range cfg.DangerousCommands {
+		// Patterns are checked when the config is loaded.
+		if re, err := regexp.Compile(pattern); err == nil {
+			r.commands = append(r.commands, re)
+		}
+	}
+	return r
+}
+
+// Holds reports false: a security alert is about a single tool call, so
+// it resolves on its own and a later call raises a new one.
+func (r *securityRule) Holds(Alert) bool {
+	return false
+}
+
+func (r *securityRule) Evaluate(store state.Store, now time.Time) []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var alerts []Alert
+	for _, session := range store.ListSessions() {
+		start := min(r.lastProcessed[session.SessionID], len(session.Events))
+		for i, evt := range session.Events[start:] {
+			// The accepted tool_decision of a call is followed by its
+			// tool_result; only the result is checked, so the call is
+			// reported once.
+			if evt.Name != "claude_code.tool_result" || evt.Attributes["decision"] == "reject" {
+				continue
+			}
+			for _, a := range r.check(evt, session.CWD) {
+				a.Severity = SeverityCritical
+				a.SessionID = session.SessionID
+				a.Subject = strconv.Itoa(start + i)
+				a.FiredAt = now
+				alerts = append(alerts, a)
+			}
+		}
+		r.lastProcessed[session.SessionID] = len(session.Events)
+	}
+	return alerts
+}
+
+// check returns the security alerts raised by a tool call, without their
+// severity, session and time. A leading ~ in cwd is expanded, as tools
+// report absolute paths.
+func (r *securityRule) check(evt state.Event, cwd string) []Alert {
+	raw := evt.Attributes["tool_parameters"]
+	if raw == "" {
+		return nil
+	}
+	if cwd != "" {
+		cwd = resolvePath(cwd, "")
+	}
+	var params map[string]any
+	if err := json.Unmarshal([]byte(raw), &params); err != nil {
+		return nil
+	}
+	tool := evt.Attributes["tool_name"]
+
+	var alerts []Alert
+	var paths []string
+	if command := extractBashCommand(raw); command != "" && tool == "Bash" {
+		for _, re := range r.commands {
+			if re.MatchString(command) {
+				alerts = append(alerts, Alert{
+					Rule:    RuleDangerousCommand,
+					Message: "Dangerous command: " + truncateDetail(command),
+				})
+				break
+			}
+		}
+		paths = commandWords(command)
+	}
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if p := stringParam(params, key); p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	for _, p := range paths {
+		p = resolvePath(p, cwd)
+		if matchAnyPath(r.sensitive, p) {
+			alerts = append(alerts, Alert{
+				Rule:    RuleSensitivePath,
+				Message: fmt.Sprintf("Sensitive path accessed by %s: %s", tool, truncateDetail(p)),
+			})
+			break
+		}
+	}
+
+	if fileWriteTools[tool] && cwd != "" {
+		p := stringParam(params, "file_path")
+		if p == "" {
+			p = stringParam(params, "notebook_path")
+		}
+		if p != "" {
+			p = resolvePath(p, cwd)
+			if !withinDir(p, cwd) && !matchAnyPath(r.allowedWrites, p) {
+				alerts = append(alerts, Alert{
+					Rule:    RuleWriteOutsideCWD,
+					Message: fmt.Sprintf("%s outside the working directory: %s", tool, truncateDetail(p)),
+				})
+			}
+		}
+	}
+	return alerts
+}
+
+// commandWords splits a shell command into the words that may be paths,
+// dropping quotes, operators and redirections.
+func commandWords(command string) []string {
+	return strings.FieldsFunc(command, func(c rune) bool {
+		return strings.ContainsRune(" \t\n;|&<>()`'\"=", c)
+	})
+}
+
+// resolvePath makes p absolute against cwd, and cleans it. A leading ~
+// is expanded to the home directory.
+func resolvePath(p, cwd string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+	if !filepath.IsAbs(p) && cwd != "" {
+		p = filepath.Join(cwd, p)
+	}
+	return filepath.Clean(p)
+}
+
+// matchAnyPath reports whether one of the globs matches p. A glob with a
+// slash matches p or one of its parents; one without matches any element
+// of p.
+func matchAnyPath(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if matchCWD(pattern, p) {
+				return true
+			}
+			continue
+		}
+		for _, elem := range strings.Split(p, string(filepath.Separator)) {
+			if ok, _ := filepath.Match(pattern, elem); ok && elem != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withinDir reports whether p is dir or inside it.
+func withinDir(p, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// expandHome replaces a leading ~ in each pattern with home.
+func expandHome(patterns []string, home string) []string {
+	out := make([]string, len(patterns))
+	for i, p := range patterns {
+		if home != "" && (p == "~" || strings.HasPrefix(p, "~/")) {
+			p = home + p[1:]
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// truncateDetail shortens a command or path quoted in an alert message.
+func truncateDetail(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxSecurityDetail {
+		return s[:maxSecurityDetail-3] + "..."
+	}
+	return s
+}
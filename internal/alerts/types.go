@@ -4,14 +4,20 @@ import "time"
 
 // Alert rule name constants.
 const (
-	RuleCostSurge       = "CostSurge"
-	RuleRunawayTokens   = "RunawayTokens"
-	RuleLoopDetector    = "LoopDetector"
-	RuleErrorStorm      = "ErrorStorm"
-	RuleStaleSession    = "StaleSession"
-	RuleContextPressure = "ContextPressure"
-	RuleHighRejection   = "HighRejection"
-	RuleSessionCost     = "SessionCost"
+	RuleCostSurge        = "CostSurge"
+	RuleRunawayTokens    = "RunawayTokens"
+	RuleLoopDetector     = "LoopDetector"
+	RuleErrorStorm       = "ErrorStorm"
+	RuleStaleSession     = "StaleSession"
+	RuleContextPressure  = "ContextPressure"
+	RuleHighRejection    = "HighRejection"
+	RuleSessionCost      = "SessionCost"
+	RuleBudget           = "Budget"
+	RuleAnomaly          = "Anomaly"
+	RuleDangerousCommand = "DangerousCommand"
+	RuleSensitivePath    = "SensitivePath"
+	RuleWriteOutsideCWD  = "WriteOutsideCWD"
+	RuleUnlistedDomain   = "UnlistedDomain"
 )
 
 // Alert severity constants.
@@ -20,20 +26,49 @@ const (
 	SeverityCritical = "critical"
 )
 
+// Alert state constants.
+const (
+	StateFiring       = "firing"
+	StateAcknowledged = "acknowledged"
+	StateSnoozed      = "snoozed"
+	StateResolved     = "resolved"
+)
+
 // Alert represents a triggered alert from the alert engine.
 type Alert struct {
 	Rule      string // CostSurge, RunawayTokens, LoopDetector, etc.
 	Severity  string // warning, critical
 	Message   string
 	SessionID string // empty for global alerts
+	Budget    string // budget name for Budget alerts
+	Subject   string // what a per-occurrence alert is about, e.g. a tool call or domain
 	FiredAt   time.Time
+
+	// Lifecycle, maintained by the engine.
+	ID           uint64    // unique per engine, assigned when the alert fires
+	State        string    // firing, acknowledged, snoozed, resolved
+	LastSeen     time.Time // last evaluation at which the condition held
+	SnoozedUntil time.Time // set while snoozed
+	ResolvedAt   time.Time // set once resolved
+}
+
+// Active reports whether the alert has not been resolved.
+func (a Alert) Active() bool {
+	return a.State != StateResolved
 }
 
-// alertKey returns a deduplication key for this alert, combining the rule name
-// and session ID. Two alerts with the same key within the dedup window are
-// considered duplicates.
+// alertKey returns a deduplication key for this alert, combining the rule name,
+// session ID, budget name and subject. Two alerts with the same key within the
+// dedup window are considered duplicates.
 func (a Alert) alertKey() string {
-	return a.Rule + ":" + a.SessionID
+	key := a.Rule + ":" + a.SessionID
+	if a.Budget != "" {
+		key += ":" + a.Budget
+	}
+	if a.Subject != "" {
+		key += ":" + a.Subject
+	}
+	return key
 }
 
 // CommandNormalizer normalizes bash commands for loop detection grouping.
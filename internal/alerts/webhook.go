@@ -0,0 +1,272 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+)
+
+// webhookPresets are the payload templates for each preset.
+var webhookPresets = map[string]string{
+	"json":    `{{json .}}`,
+	"slack":   `{"text": {{json .Text}}}`,
+	"discord": `{"content": {{json .Text}}}`,
+}
+
+// maxWebhookBackoff caps the wait between delivery attempts.
+const maxWebhookBackoff = time.Minute
+
+// webhookPayload is what payload templates are executed with. The json
+// preset sends it as is.
+type webhookPayload struct {
+	Rule      string    `json:"rule"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	SessionID string    `json:"session_id,omitempty"`
+	Budget    string    `json:"budget,omitempty"`
+	State     string    `json:"state"`
+	FiredAt   time.Time `json:"fired_at"`
+	Text      string    `json:"text"` // one-line summary for chat presets
+}
+
+// WebhookNotifier posts alerts to an HTTP endpoint. Notify queues the
+// alert and returns; a background goroutine delivers queued alerts in
+// order, retrying failures with exponential backoff.
+type WebhookNotifier struct {
+	name       string
+	url        string
+	headers    map[string]string
+	tmpl       *template.Template
+	severities map[string]bool
+	rules      map[string]bool
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+
+	queue  chan Alert
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	status DeliveryStatus
+}
+
+// WebhookOption configures a WebhookNotifier.
+type WebhookOption func(*WebhookNotifier)
+
+// WithWebhookClient sets the HTTP client used for deliveries. Its timeout
+// is replaced by the configured one.
+func WithWebhookClient(c *http.Client) WebhookOption {
+	return func(n *WebhookNotifier) {
+		n.client = c
+	}
+}
+
+// WithWebhookBackoff sets the wait before the first retry. It doubles on
+// each further retry, up to a minute.
+func WithWebhookBackoff(d time.Duration) WebhookOption {
+	return func(n *WebhookNotifier) {
+		n.backoff = d
+	}
+}
+
+// NewWebhookNotifier creates a webhook notifier from its configuration and
+// starts its delivery goroutine. Close stops it.
+func NewWebhookNotifier(cfg config.WebhookConfig, opts ...WebhookOption) (*WebhookNotifier, error) {
+	src := cfg.Template
+	if src == "" {
+		src = webhookPresets[cfg.Preset]
+	}
+	tmpl, err := template.New(cfg.Name).Funcs(template.FuncMap{"json": marshalJSON}).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: template: %w", cfg.Name, err)
+	}
+
+	n := &WebhookNotifier{
+		name:       cfg.Name,
+		url:        cfg.URL,
+		headers:    cfg.Headers,
+		tmpl:       tmpl,
+		severities: stringSet(cfg.Severities),
+		rules:      stringSet(cfg.Rules),
+		maxRetries: cfg.MaxRetries,
+		backoff:    time.Second,
+		client:     &http.Client{},
+		queue:      make(chan Alert, max(cfg.QueueSize, 1)),
+		done:       make(chan struct{}),
+		status:     DeliveryStatus{Kind: "webhook", Name: cfg.Name},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	client := *n.client
+	client.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	n.client = &client
+
+	var ctx context.Context
+	ctx, n.cancel = context.WithCancel(context.Background())
+	go n.run(ctx)
+	return n, nil
+}
+
+// Notify queues an alert for delivery if it passes the severity and rule
+// filters. When the queue is full the alert is dropped.
+func (n *WebhookNotifier) Notify(alert Alert) {
+	if (n.severities != nil && !n.severities[alert.Severity]) || (n.rules != nil && !n.rules[alert.Rule]) {
+		return
+	}
+	select {
+	case n.queue <- alert:
+	default:
+		n.mu.Lock()
+		n.status.Dropped++
+		n.mu.Unlock()
+	}
+}
+
+// Close stops delivery, abandoning any attempt in progress and the alerts
+// still queued.
+func (n *WebhookNotifier) Close() {
+	n.cancel()
+	<-n.done
+}
+
+// DeliveryStatus reports the notifier's delivery counts and last outcome.
+func (n *WebhookNotifier) DeliveryStatus() []DeliveryStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	st := n.status
+	st.Pending = len(n.queue)
+	return []DeliveryStatus{st}
+}
+
+// run delivers queued alerts until ctx is cancelled.
+func (n *WebhookNotifier) run(ctx context.Context) {
+	defer close(n.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-n.queue:
+			err := n.deliver(ctx, alert)
+			if ctx.Err() != nil {
+				return
+			}
+			n.mu.Lock()
+			if err != nil {
+				n.status.Failed++
+				n.status.LastFailure = time.Now()
+				n.status.LastError = err.Error()
+			} else {
+				n.status.Sent++
+				n.status.LastSuccess = time.Now()
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+// deliver posts alert, retrying transport errors, 429s and 5xx responses.
+func (n *WebhookNotifier) deliver(ctx context.Context, alert Alert) error {
+	body, err := n.render(alert)
+	if err != nil {
+		return err
+	}
+
+	wait := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, body)
+		if err == nil || !retry || attempt >= n.maxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxWebhookBackoff)
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cc-top")
+	for k, v := range n.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook %s: %s", n.name, resp.Status)
+}
+
+// render executes the payload template for alert.
+func (n *WebhookNotifier) render(alert Alert) ([]byte, error) {
+	state := alert.State
+	if state == "" {
+		state = StateFiring
+	}
+	text := fmt.Sprintf("[%s] %s: %s", alert.Severity, alert.Rule, alert.Message)
+	if alert.SessionID != "" {
+		text += " (session " + truncateSessionID(alert.SessionID) + ")"
+	}
+
+	var buf bytes.Buffer
+	err := n.tmpl.Execute(&buf, webhookPayload{
+		Rule:      alert.Rule,
+		Severity:  alert.Severity,
+		Message:   alert.Message,
+		SessionID: alert.SessionID,
+		Budget:    alert.Budget,
+		State:     state,
+		FiredAt:   alert.FiredAt,
+		Text:      text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: template: %w", n.name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is the json template function: it encodes v as JSON, so
+// templates can embed strings safely.
+func marshalJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// stringSet returns the values as a set, or nil if there are none.
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
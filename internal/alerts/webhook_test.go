@@ -0,0 +1,215 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/config"
+)
+
+// webhookServer records the requests it receives and answers each with
+// the next status in statuses, then 200.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	statuses []int
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		s.headers = append(s.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func newTestWebhook(t *testing.T, cfg config.WebhookConfig) *WebhookNotifier {
+	t.Helper()
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+	if cfg.Preset == "" {
+		cfg.Preset = "json"
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = 5
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = 10
+	}
+	n, err := NewWebhookNotifier(cfg, WithWebhookBackoff(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier: %v", err)
+	}
+	t.Cleanup(n.Close)
+	return n
+}
+
+// waitForStatus polls the notifier until cond holds or a second passes.
+func waitForStatus(t *testing.T, n StatusReporter, cond func(DeliveryStatus) bool) DeliveryStatus {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		st := n.DeliveryStatus()[0]
+		if cond(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for delivery, status %+v", st)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var testWebhookAlert = Alert{
+	Rule:      RuleCostSurge,
+	Severity:  SeverityCritical,
+	Message:   `Cost surge: "$120.00/hr"`,
+	SessionID: "sess-1234567890abcdef",
+	FiredAt:   time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	State:     StateFiring,
+}
+
+func TestWebhookNotifier_Presets(t *testing.T) {
+	text := `[critical] CostSurge: Cost surge: "$120.00/hr" (session sess-1234567...)`
+	tests := []struct {
+		preset, template string
+		want             map[string]any
+	}{
+		{preset: "slack", want: map[string]any{"text": text}},
+		{preset: "discord", want: map[string]any{"content": text}},
+		{preset: "json", want: map[string]any{
+			"rule": "CostSurge", "severity": "critical", "message": `Cost surge: "$120.00/hr"`,
+			"session_id": "sess-1234567890abcdef", "state": "firing",
+			"fired_at": "2026-10-18T12:00:00Z", "text": text,
+		}},
+		{preset: "json", template: `{"title": {{json .Rule}}, "urgent": {{if eq .Severity "critical"}}true{{else}}false{{end}}}`,
+			want: map[string]any{"title": "CostSurge", "urgent": true}},
+	}
+	for _, tt := range tests {
+		srv := newWebhookServer(t)
+		n := newTestWebhook(t, config.WebhookConfig{
+			URL:      srv.URL,
+			Preset:   tt.preset,
+			Template: tt.template,
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		})
+		n.Notify(testWebhookAlert)
+		waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 1 })
+
+		var got map[string]any
+		if err := json.Unmarshal([]byte(srv.bodies[0]), &got); err != nil {
+			t.Fatalf("%s: body %q is not JSON: %v", tt.preset, srv.bodies[0], err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(tt.want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s payload = %s, want %s", tt.preset, gotJSON, wantJSON)
+		}
+		h := srv.headers[0]
+		if h.Get("Authorization") != "Bearer token" || h.Get("Content-Type") != "application/json" {
+			t.Errorf("%s headers = %v", tt.preset, h)
+		}
+	}
+}
+
+func TestWebhookNotifier_RetriesWithBackoff(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	n := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxRetries: 3})
+
+	n.Notify(testWebhookAlert)
+	st := waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 1 })
+	if st.Failed != 0 || srv.requests() != 3 {
+		t.Errorf("expected success on the third attempt, got %d requests and status %+v", srv.requests(), st)
+	}
+}
+
+func TestWebhookNotifier_GivesUp(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusBadRequest, http.StatusBadGateway, http.StatusBadGateway)
+	n := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, MaxRetries: 1})
+
+	// A 400 is not retried.
+	n.Notify(testWebhookAlert)
+	st := waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Failed == 1 })
+	if srv.requests() != 1 || !strings.Contains(st.LastError, "400") {
+		t.Errorf("a client error should fail without retrying, got %d requests and status %+v", srv.requests(), st)
+	}
+
+	// A 502 is retried max_retries times.
+	n.Notify(testWebhookAlert)
+	st = waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Failed == 2 })
+	if srv.requests() != 3 || st.Sent != 0 || st.LastFailure.IsZero() {
+		t.Errorf("expected one retry before giving up, got %d requests and status %+v", srv.requests(), st)
+	}
+}
+
+func TestWebhookNotifier_Filters(t *testing.T) {
+	srv := newWebhookServer(t)
+	n := newTestWebhook(t, config.WebhookConfig{
+		URL:        srv.URL,
+		Severities: []string{SeverityCritical},
+		Rules:      []string{RuleCostSurge, RuleErrorStorm},
+	})
+
+	n.Notify(Alert{Rule: RuleCostSurge, Severity: SeverityWarning})
+	n.Notify(Alert{Rule: RuleBudget, Severity: SeverityCritical})
+	n.Notify(Alert{Rule: RuleErrorStorm, Severity: SeverityCritical})
+	waitForStatus(t, n, func(st DeliveryStatus) bool { return st.Sent == 1 })
+	time.Sleep(10 * time.Millisecond)
+
+	if srv.requests() != 1 || !strings.Contains(srv.bodies[0], `"rule":"ErrorStorm"`) {
+		t.Errorf("only the critical ErrorStorm alert should be sent, got %v", srv.bodies)
+	}
+}
+
+func TestWebhookNotifier_BoundedQueue(t *testing.T) {
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	n := newTestWebhook(t, config.WebhookConfig{URL: srv.URL, QueueSize: 1})
+
+	n.Notify(testWebhookAlert)
+	<-received // the first alert is being delivered
+
+	start := time.Now()
+	n.Notify(testWebhookAlert) // queued
+	n.Notify(testWebhookAlert) // dropped
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("Notify should not block while a delivery is in progress")
+	}
+	if st := n.DeliveryStatus()[0]; st.Pending != 1 || st.Dropped != 1 {
+		t.Errorf("expected 1 pending and 1 dropped, got %+v", st)
+	}
+}
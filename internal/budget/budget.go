@@ -0,0 +1,274 @@
+// Package budget tracks spending against the named budgets in the
+// [budgets] config section: what has been spent in the current day, week
+// or month within each budget's scope, how fast that scope is spending,
+// and when the limit will be reached at that rate.
+package budget
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/burnrate"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// Status is a budget's consumption in its current period.
+type Status struct {
+	Name        string
+	Scope       string // human-readable scope; empty if the budget covers everything
+	Period      string // "day", "week" or "month"
+	Limit       float64
+	Spent       float64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	// CountedFrom is when Spent starts: PeriodStart, or when the tracker
+	// was created if that is later. Spend is only known from the state
+	// store, which starts empty, so an earlier part of the period is
+	// missing after a restart.
+	CountedFrom time.Time
+	HourlyRate  float64 // current spend rate within the scope
+	Projected   float64 // expected spend by PeriodEnd at HourlyRate
+	// ExhaustsAt is when Spent reaches Limit at HourlyRate. It is zero if
+	// the limit is already reached or will not be before PeriodEnd.
+	ExhaustsAt time.Time
+	AlertAt    []int    // percentages of Limit that raise alerts, ascending
+	Sessions   []string // sessions in scope that spent during the period
+}
+
+// Percent returns Spent as a percentage of Limit.
+func (s Status) Percent() float64 {
+	if s.Limit <= 0 {
+		return 0
+	}
+	return s.Spent / s.Limit * 100
+}
+
+// Exhausted reports whether the limit has been reached.
+func (s Status) Exhausted() bool {
+	return s.Spent >= s.Limit
+}
+
+// Tier returns the highest AlertAt percentage reached, or 0 if none is.
+func (s Status) Tier() int {
+	pct := s.Percent()
+	tier := 0
+	for _, t := range s.AlertAt {
+		if pct >= float64(t) {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// Tracker computes the status of each configured budget from the state
+// store, using a burn rate calculator's per-session rates for forecasts.
+type Tracker struct {
+	budgets []budget
+	rates   *burnrate.Calculator
+	started time.Time
+}
+
+// budget is a BudgetConfig prepared for evaluation.
+type budget struct {
+	name    string
+	cfg     config.BudgetConfig
+	loc     *time.Location
+	project string // with ~ expanded
+	alertAt []int
+}
+
+// NewTracker creates a Tracker for the given budgets. rates may be nil, in
+// which case no forecasts are made.
+func NewTracker(budgets map[string]config.BudgetConfig, rates *burnrate.Calculator) *Tracker {
+	t := &Tracker{rates: rates, started: time.Now()}
+	for name, cfg := range budgets {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			loc = time.Local
+		}
+		alertAt := append([]int(nil), cfg.AlertAt...)
+		sort.Ints(alertAt)
+		t.budgets = append(t.budgets, budget{
+			name:    name,
+			cfg:     cfg,
+			loc:     loc,
+			project: expandHome(cfg.Project),
+			alertAt: alertAt,
+		})
+	}
+	sort.Slice(t.budgets, func(i, j int) bool { return t.budgets[i].name < t.budgets[j].name })
+	return t
+}
+
+// Evaluate returns the status of every budget at now, in name order.
+func (t *Tracker) Evaluate(store state.Store, now time.Time) []Status {
+	if len(t.budgets) == 0 {
+		return nil
+	}
+	sessions := store.ListSessions()
+	var rates map[string]burnrate.BurnRate
+	if t.rates != nil {
+		rates = t.rates.Sessions()
+	}
+
+	result := make([]Status, 0, len(t.budgets))
+	for _, b := range t.budgets {
+		st := b.status(sessions, rates, now)
+		st.CountedFrom = st.PeriodStart
+		if t.started.After(st.PeriodStart) {
+			st.CountedFrom = t.started
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// status computes the budget's consumption, rate and forecast.
+func (b budget) status(sessions []state.SessionData, rates map[string]burnrate.BurnRate, now time.Time) Status {
+	start, end := periodBounds(b.cfg.Period, now, b.loc)
+	st := Status{
+		Name:        b.name,
+		Scope:       b.scope(),
+		Period:      b.cfg.Period,
+		Limit:       b.cfg.Limit,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		AlertAt:     b.alertAt,
+	}
+
+	match := b.modelMatcher()
+	for i := range sessions {
+		s := &sessions[i]
+		if !b.matchSession(s) {
+			continue
+		}
+		if cost := s.CostSince(start, match); cost > 0 {
+			st.Spent += cost
+			st.Sessions = append(st.Sessions, s.SessionID)
+		}
+
+		br, ok := rates[s.SessionID]
+		if !ok {
+			continue
+		}
+		if match == nil {
+			st.HourlyRate += br.HourlyRate
+			continue
+		}
+		for _, pm := range br.PerModel {
+			if match(pm.Model) {
+				st.HourlyRate += pm.HourlyRate
+			}
+		}
+	}
+
+	st.Projected = st.Spent + st.HourlyRate*end.Sub(now).Hours()
+	if remaining := st.Limit - st.Spent; remaining > 0 && st.HourlyRate > 0 {
+		at := now.Add(time.Duration(remaining / st.HourlyRate * float64(time.Hour)))
+		if at.Before(end) {
+			st.ExhaustsAt = at
+		}
+	}
+	return st
+}
+
+// matchSession reports whether the session is within the budget's
+// project, organization and user scope.
+func (b budget) matchSession(s *state.SessionData) bool {
+	if b.cfg.OrgID != "" && s.OrgID != b.cfg.OrgID {
+		return false
+	}
+	if b.cfg.UserID != "" && s.UserUUID != b.cfg.UserID {
+		return false
+	}
+	if b.project != "" && !matchProject(b.project, s) {
+		return false
+	}
+	return true
+}
+
+// modelMatcher returns a matcher for the budget's model pattern, or nil if
+// the budget covers every model.
+func (b budget) modelMatcher() func(string) bool {
+	if b.cfg.Model == "" {
+		return nil
+	}
+	return func(model string) bool {
+		ok, _ := path.Match(b.cfg.Model, model)
+		return ok
+	}
+}
+
+// scope describes the budget's restrictions.
+func (b budget) scope() string {
+	var parts []string
+	if b.cfg.Project != "" {
+		parts = append(parts, "project "+b.cfg.Project)
+	}
+	if b.cfg.Model != "" {
+		parts = append(parts, "model "+b.cfg.Model)
+	}
+	if b.cfg.OrgID != "" {
+		parts = append(parts, "org "+b.cfg.OrgID)
+	}
+	if b.cfg.UserID != "" {
+		parts = append(parts, "user "+b.cfg.UserID)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matchProject reports whether the session's git repository is named
+// project, or its CWD is the project directory, inside it, or matches it
+// as a glob. project has ~ expanded already; the CWD is expanded too, so
+// both sides are compared as absolute paths.
+func matchProject(project string, s *state.SessionData) bool {
+	if s.Metadata.Git.Repo == project {
+		return true
+	}
+	if s.CWD == "" {
+		return false
+	}
+	cwd := filepath.Clean(expandHome(s.CWD))
+	if ok, _ := filepath.Match(project, cwd); ok {
+		return true
+	}
+	dir := filepath.Clean(project)
+	return cwd == dir || strings.HasPrefix(cwd, dir+string(filepath.Separator))
+}
+
+// periodBounds returns the start of the period containing now and the
+// start of the next, in loc. Weeks start on Monday.
+func periodBounds(period string, now time.Time, loc *time.Location) (start, end time.Time) {
+	t := now.In(loc)
+	y, m, d := t.Date()
+	switch period {
+	case "day":
+		start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 1)
+	case "week":
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		start = time.Date(y, m, d-daysSinceMonday, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 7)
+	default:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// expandHome replaces a leading "~" or "~/" with the user's home
+// directory.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
@@ -0,0 +1,187 @@
+package budget
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/burnrate"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// addCost adds a cumulative claude_code.cost.usage data point.
+func addCost(store state.Store, sessionID, model string, value float64, ts time.Time) {
+	store.AddMetric(sessionID, state.Metric{
+		Name:       "claude_code.cost.usage",
+		Value:      value,
+		Attributes: map[string]string{"model": model},
+		Timestamp:  ts,
+	})
+}
+
+func TestPeriodBounds(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Sunday 18 Oct 2026, 23:30 UTC is already Monday 00:30 in London (BST).
+	now := time.Date(2026, 10, 18, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period     string
+		loc        *time.Location
+		start, end time.Time
+	}{
+		{"day", time.UTC, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"day", london, time.Date(2026, 10, 19, 0, 0, 0, 0, london), time.Date(2026, 10, 20, 0, 0, 0, 0, london)},
+		{"week", time.UTC, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"week", london, time.Date(2026, 10, 19, 0, 0, 0, 0, london), time.Date(2026, 10, 26, 0, 0, 0, 0, london)},
+		{"month", time.UTC, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		start, end := periodBounds(tt.period, now, tt.loc)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s in %s: got %v - %v, want %v - %v", tt.period, tt.loc, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestTracker_Scopes(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// The counter stood at $40 last month, so only $10 of Opus is this month's.
+	addCost(store, "sess-web", "claude-opus-4-6", 40, now.AddDate(0, -1, 0))
+	addCost(store, "sess-web", "claude-opus-4-6", 50, now.Add(-time.Hour))
+	addCost(store, "sess-web", "claude-haiku-4-5", 2, now.Add(-time.Hour))
+	store.UpdateCWD("sess-web", "/home/dev/work/web")
+	store.AddMetric("sess-web", state.Metric{Name: "claude_code.session.count", Value: 1,
+		Attributes: map[string]string{"organization.id": "org-1", "user.account_uuid": "u-1"}})
+
+	addCost(store, "sess-api", "claude-opus-4-6", 8, now.Add(-time.Hour))
+	store.UpdateGit("sess-api", state.GitInfo{Repo: "api"})
+
+	tracker := NewTracker(map[string]config.BudgetConfig{
+		"all":      {Limit: 100, Period: "month"},
+		"work-dir": {Limit: 100, Period: "month", Project: "/home/dev/work"},
+		"api-repo": {Limit: 100, Period: "month", Project: "api"},
+		"opus":     {Limit: 100, Period: "month", Model: "claude-opus-*"},
+		"user":     {Limit: 100, Period: "month", OrgID: "org-1", UserID: "u-1"},
+		"glob":     {Limit: 100, Period: "month", Project: "/home/*/work/*"},
+	}, nil)
+
+	want := map[string]float64{
+		"all":      20,
+		"work-dir": 12,
+		"api-repo": 8,
+		"opus":     18,
+		"user":     12,
+		"glob":     12,
+	}
+	statuses := tracker.Evaluate(store, now)
+	if len(statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(want))
+	}
+	for i, st := range statuses {
+		if i > 0 && statuses[i-1].Name > st.Name {
+			t.Errorf("statuses not in name order: %q before %q", statuses[i-1].Name, st.Name)
+		}
+		if math.Abs(st.Spent-want[st.Name]) > 1e-9 {
+			t.Errorf("%s: spent %f, want %f", st.Name, st.Spent, want[st.Name])
+		}
+	}
+	if api := statuses[1]; api.Name != "api-repo" || len(api.Sessions) != 1 || api.Sessions[0] != "sess-api" {
+		t.Errorf("api-repo sessions = %v, want [sess-api]", api.Sessions)
+	}
+}
+
+func TestMatchProject_ExpandsHome(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	tracker := NewTracker(map[string]config.BudgetConfig{
+		"work": {Limit: 100, Period: "month", Project: "~/work"},
+	}, nil)
+	project := tracker.budgets[0].project
+	for _, tc := range []struct {
+		cwd  string
+		want bool
+	}{
+		{"/home/dev/work/api", true},
+		{"~/work/api", true},
+		{"~/work", true},
+		{"~/workshop", false},
+		{"/home/other/work", false},
+	} {
+		s := &state.SessionData{CWD: tc.cwd}
+		if got := matchProject(project, s); got != tc.want {
+			t.Errorf("matchProject(%q, %q) = %v, want %v", project, tc.cwd, got, tc.want)
+		}
+	}
+}
+
+func TestTracker_CountedFrom(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(map[string]config.BudgetConfig{
+		"daily":   {Limit: 10, Period: "day", Timezone: "UTC"},
+		"monthly": {Limit: 100, Period: "month", Timezone: "UTC"},
+	}, nil)
+
+	// Started mid-month: the monthly spend before then is not known, while
+	// the whole of today is.
+	tracker.started = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	statuses := tracker.Evaluate(store, now)
+	if daily := statuses[0]; !daily.CountedFrom.Equal(daily.PeriodStart) {
+		t.Errorf("daily counted from %v, want the period start %v", daily.CountedFrom, daily.PeriodStart)
+	}
+	if monthly := statuses[1]; !monthly.CountedFrom.Equal(tracker.started) {
+		t.Errorf("monthly counted from %v, want the tracker start %v", monthly.CountedFrom, tracker.started)
+	}
+}
+
+func TestTracker_Forecast(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := burnrate.NewCalculator(burnrate.DefaultThresholds())
+
+	// 09:00 on the 18th: $60 spent this month, then $1 over 5 minutes ($12/hr),
+	// all on Opus.
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	addCost(store, "sess-1", "claude-opus-4-6", 60, base)
+	calc.ComputeWithTime(store, base)
+	now := base.Add(5 * time.Minute)
+	addCost(store, "sess-1", "claude-opus-4-6", 61, now)
+	calc.ComputeWithTime(store, now)
+
+	tracker := NewTracker(map[string]config.BudgetConfig{
+		"monthly": {Limit: 100, Period: "month", AlertAt: []int{100, 50, 80}},
+		"haiku":   {Limit: 10, Period: "day", Model: "claude-haiku-*"},
+		"small":   {Limit: 50, Period: "day"},
+	}, calc)
+	statuses := tracker.Evaluate(store, now)
+
+	haiku, monthly, small := statuses[0], statuses[1], statuses[2]
+
+	// $39 left at $12/hr runs out 3h15m from now.
+	if math.Abs(monthly.HourlyRate-12) > 0.01 {
+		t.Errorf("monthly rate = %f, want 12", monthly.HourlyRate)
+	}
+	if wantAt := now.Add(3*time.Hour + 15*time.Minute); monthly.ExhaustsAt.Sub(wantAt).Abs() > time.Second {
+		t.Errorf("monthly exhausts at %v, want %v", monthly.ExhaustsAt, wantAt)
+	}
+	if monthly.Tier() != 50 || monthly.AlertAt[0] != 50 {
+		t.Errorf("monthly tier = %d with alert_at %v, want 50 from sorted tiers", monthly.Tier(), monthly.AlertAt)
+	}
+
+	// Nothing is spent on Haiku, so there is no rate or forecast.
+	if haiku.Spent != 0 || haiku.HourlyRate != 0 || !haiku.ExhaustsAt.IsZero() {
+		t.Errorf("haiku = %+v, want no spend or forecast", haiku)
+	}
+
+	// Already over the limit: exhausted, no forecast.
+	if !small.Exhausted() || !small.ExhaustsAt.IsZero() || small.Tier() != 0 {
+		t.Errorf("small = %+v, want exhausted without tiers", small)
+	}
+	if wantProj := 61 + 12*15.0 - 5.0/60*12; math.Abs(small.Projected-wantProj) > 0.01 {
+		t.Errorf("small projected = %f, want %f by the end of the day", small.Projected, wantProj)
+	}
+}
@@ -12,114 +12,286 @@ import (
 )
 
 const (
-	// windowDuration is the rolling window used for rate calculations.
-	windowDuration = 5 * time.Minute
+	// DefaultRateWindow is the rolling window used for rate calculations.
+	DefaultRateWindow = 5 * time.Minute
+	// DefaultTrendWindow is the length of the two consecutive windows
+	// compared to determine the trend.
+	DefaultTrendWindow = 5 * time.Minute
 )
 
 // Calculator computes burn rate metrics from the state store.
-// It maintains a rolling window of cost and token samples to provide
-// smooth rate calculations. All methods are safe for concurrent use.
+// It maintains a rolling window of cost and token samples for all sessions
+// combined and one per session, each with a window per model, to provide
+// smooth rate calculations at every level. All methods are safe for
+// concurrent use.
 type Calculator struct {
-	mu          sync.Mutex
-	thresholds  Thresholds
-	costSamples []costSample
+	mu         sync.Mutex
+	thresholds Thresholds
+	spans      spans
+	global     window
+	history    history
+	models     modelWindows
+	sessions   map[string]*sessionWindows
+	rates      map[string]BurnRate // per-session results of the last Compute
+}
+
+// spans are the window lengths used for rate and trend calculations.
+type spans struct {
+	rate  time.Duration
+	trend time.Duration
+}
+
+// window is a rolling series of cumulative cost and token observations.
+type window struct {
+	spans
+	costSamples  []costSample
 	tokenSamples []tokenSample
-	prevCost    float64
-	prevTokens  int64
-	initialized bool
+}
+
+// sessionWindows holds a session's overall and per-model windows.
+type sessionWindows struct {
+	window
+	models modelWindows
+}
+
+// modelWindows holds a cost window per model name.
+type modelWindows map[string]*window
+
+// CalculatorOption configures optional Calculator parameters.
+type CalculatorOption func(*Calculator)
+
+// WithRateWindow sets the rolling window the hourly rate and token
+// velocity are measured over. Non-positive values are ignored.
+func WithRateWindow(d time.Duration) CalculatorOption {
+	return func(c *Calculator) {
+		if d > 0 {
+			c.spans.rate = d
+		}
+	}
+}
+
+// WithTrendWindow sets the length of the two consecutive windows whose
+// rates are compared to determine the trend. Non-positive values are
+// ignored.
+func WithTrendWindow(d time.Duration) CalculatorOption {
+	return func(c *Calculator) {
+		if d > 0 {
+			c.spans.trend = d
+		}
+	}
 }
 
 // NewCalculator creates a new Calculator with the given color thresholds.
-func NewCalculator(thresholds Thresholds) *Calculator {
-	return &Calculator{
+func NewCalculator(thresholds Thresholds, opts ...CalculatorOption) *Calculator {
+	c := &Calculator{
 		thresholds: thresholds,
+		spans:      spans{rate: DefaultRateWindow, trend: DefaultTrendWindow},
+		models:     make(modelWindows),
+		sessions:   make(map[string]*sessionWindows),
+		rates:      make(map[string]BurnRate),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.global.spans = c.spans
+	return c
 }
 
 // Compute calculates the current burn rate from the state store data.
 // It should be called periodically (e.g., every 500ms) to update the
 // rolling window with fresh data.
 func (c *Calculator) Compute(store state.Store) BurnRate {
+	return c.ComputeWithTime(store, time.Now())
+}
+
+// ComputeWithTime is like Compute but uses a specific timestamp instead of
+// time.Now(). This is primarily useful for testing deterministic behavior.
+//
+// Besides the global rate it returns, it samples every session in the store
+// into that session's own window; the results are available from Session
+// and Sessions until the next call. Windows of sessions no longer in the
+// store are dropped.
+//
+// Historical spend (see state.HistoricalAttr) counts towards the totals
+// but is left out of the windows and the history, so a backfill does not
+// show up as a burst of spending.
+func (c *Calculator) ComputeWithTime(store state.Store, now time.Time) BurnRate {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	now := time.Now()
 	totalCost := store.GetAggregatedCost()
 
-	// Calculate total tokens across all sessions.
+	// Calculate total tokens across all sessions, and the live spend
+	// without historical data.
 	sessions := store.ListSessions()
-	var totalTokens int64
+	var totalTokens, liveTokens int64
+	var liveCost float64
 	for _, s := range sessions {
 		totalTokens += s.TotalTokens
+		liveTokens += s.TotalTokens - s.HistoricalTokens
+		liveCost += s.TotalCost - s.HistoricalCost
 	}
 
-	// Record samples for rate calculation.
-	if !c.initialized {
-		c.prevCost = totalCost
-		c.prevTokens = totalTokens
-		c.initialized = true
-		c.costSamples = append(c.costSamples, costSample{cost: totalCost, at: now})
-		c.tokenSamples = append(c.tokenSamples, tokenSample{tokens: totalTokens, at: now})
-		return BurnRate{
-			TotalCost:     totalCost,
-			HourlyRate:    0,
-			Trend:         TrendFlat,
-			TokenVelocity: 0,
-			PerModel:      computePerModel(sessions, totalCost, 0),
+	modelCosts := make(map[string]float64)
+	historicalCosts := make(map[string]float64)
+	for _, s := range sessions {
+		for model, cost := range s.ModelCosts {
+			modelCosts[model] += cost
+		}
+		for model, cost := range s.HistoricalModelCosts {
+			historicalCosts[model] += cost
 		}
 	}
 
-	// Handle counter resets: if the total went down, treat the previous as 0.
-	costDelta := totalCost - c.prevCost
-	if costDelta < 0 {
-		costDelta = totalCost
-	}
+	prev := c.global.lastSampleAt()
+	br := c.global.observe(liveCost, liveTokens, now)
+	br.TotalCost = totalCost
+	br.PerModel = c.models.observe(modelCosts, historicalCosts, c.spans, prev, now)
+
+	c.history.observe(liveCost, liveTokens, now)
+	br.DailyProjection = c.history.projectDay(br.HourlyRate, now)
+	br.MonthlyProjection = br.DailyProjection * 30
 
-	tokenDelta := totalTokens - c.prevTokens
-	if tokenDelta < 0 {
-		tokenDelta = totalTokens
+	seen := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		seen[s.SessionID] = true
+		w, ok := c.sessions[s.SessionID]
+		if !ok {
+			w = &sessionWindows{window: window{spans: c.spans}, models: make(modelWindows)}
+			c.sessions[s.SessionID] = w
+		}
+		prev := w.lastSampleAt()
+		sbr := w.observe(s.TotalCost-s.HistoricalCost, s.TotalTokens-s.HistoricalTokens, now)
+		sbr.TotalCost = s.TotalCost
+		sbr.PerModel = w.models.observe(s.ModelCosts, s.HistoricalModelCosts, c.spans, prev, now)
+		c.rates[s.SessionID] = sbr
+	}
+	for id := range c.sessions {
+		if !seen[id] {
+			delete(c.sessions, id)
+			delete(c.rates, id)
+		}
 	}
 
-	c.prevCost = totalCost
-	c.prevTokens = totalTokens
+	return br
+}
+
+// Series returns the spend across all sessions over the given span,
+// ending now, as a downsampled series for sparklines and charts: one point
+// per minute for spans up to 24 hours, one per hour for longer spans up to
+// 30 days. Each point holds the cost and tokens spent in its bucket.
+func (c *Calculator) Series(span time.Duration) []Point {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.history.series(span)
+}
+
+// Session returns the burn rate of one session as of the last Compute.
+// The second result is false if the session has not been sampled.
+func (c *Calculator) Session(sessionID string) (BurnRate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	br, ok := c.rates[sessionID]
+	return br, ok
+}
+
+// Sessions returns the burn rate of every session as of the last Compute,
+// keyed by session ID.
+func (c *Calculator) Sessions() map[string]BurnRate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.costSamples = append(c.costSamples, costSample{cost: totalCost, at: now})
-	c.tokenSamples = append(c.tokenSamples, tokenSample{tokens: totalTokens, at: now})
+	result := make(map[string]BurnRate, len(c.rates))
+	for id, br := range c.rates {
+		result[id] = br
+	}
+	return result
+}
+
+// lastSampleAt returns the time of the window's latest sample, or the zero
+// time if it has none.
+func (w *window) lastSampleAt() time.Time {
+	if len(w.costSamples) == 0 {
+		return time.Time{}
+	}
+	return w.costSamples[len(w.costSamples)-1].at
+}
 
-	// Prune samples older than 2 * windowDuration (need two windows for trend).
-	cutoff := now.Add(-2 * windowDuration)
-	c.costSamples = pruneCostSamples(c.costSamples, cutoff)
-	c.tokenSamples = pruneTokenSamples(c.tokenSamples, cutoff)
+// observe samples each model's cumulative cost, less its historical part,
+// into its own window and returns the per-model rates, sorted by total
+// cost descending. A model
+// first seen after the previous observation at prev is given a zero-cost
+// baseline at prev, since it had not been billed then; this keeps the
+// per-model rates summing to the overall rate. Windows of models absent
+// from costs are dropped.
+func (mw modelWindows) observe(costs, historical map[string]float64, sp spans, prev, now time.Time) []ModelBurnRate {
+	result := make([]ModelBurnRate, 0, len(costs))
+	for model, cost := range costs {
+		w, ok := mw[model]
+		if !ok {
+			w = &window{spans: sp}
+			if !prev.IsZero() {
+				w.costSamples = append(w.costSamples, costSample{cost: 0, at: prev})
+			}
+			mw[model] = w
+		}
+		br := w.observe(cost-historical[model], 0, now)
+		result = append(result, ModelBurnRate{
+			Model:      model,
+			HourlyRate: br.HourlyRate,
+			TotalCost:  cost,
+		})
+	}
+	for model := range mw {
+		if _, ok := costs[model]; !ok {
+			delete(mw, model)
+		}
+	}
 
-	// Compute hourly rate from the current 5-minute window.
-	hourlyRate := c.computeHourlyRate(now)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCost != result[j].TotalCost {
+			return result[i].TotalCost > result[j].TotalCost
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
 
-	// Compute trend by comparing current vs previous window.
-	trend := c.computeTrend(now)
+// observe records a cumulative cost and token observation and returns the
+// resulting rates, projecting the current hourly rate forward. PerModel is
+// left for the caller to fill in.
+func (w *window) observe(cost float64, tokens int64, now time.Time) BurnRate {
+	w.costSamples = append(w.costSamples, costSample{cost: cost, at: now})
+	w.tokenSamples = append(w.tokenSamples, tokenSample{tokens: tokens, at: now})
 
-	// Compute token velocity (tokens/minute).
-	tokenVelocity := c.computeTokenVelocity(now)
+	// Keep enough history for the rate window and both trend windows.
+	cutoff := now.Add(-max(w.rate, 2*w.trend))
+	w.costSamples = pruneCostSamples(w.costSamples, cutoff)
+	w.tokenSamples = pruneTokenSamples(w.tokenSamples, cutoff)
 
+	// A single sample yields zero rates, a flat trend and no projection.
+	hourlyRate := w.computeHourlyRate(now)
 	return BurnRate{
-		TotalCost:         totalCost,
+		TotalCost:         cost,
 		HourlyRate:        hourlyRate,
-		Trend:             trend,
-		TokenVelocity:     tokenVelocity,
-		PerModel:          computePerModel(sessions, totalCost, hourlyRate),
+		Trend:             w.computeTrend(now),
+		TokenVelocity:     w.computeTokenVelocity(now),
 		DailyProjection:   hourlyRate * 24,
 		MonthlyProjection: hourlyRate * 720,
 	}
 }
 
 // computeHourlyRate calculates the cost rate extrapolated to an hourly rate
-// from the most recent 5-minute window.
-func (c *Calculator) computeHourlyRate(now time.Time) float64 {
-	windowStart := now.Add(-windowDuration)
+// from the most recent rate window.
+func (w *window) computeHourlyRate(now time.Time) float64 {
+	windowStart := now.Add(-w.rate)
 
 	// Find the earliest and latest samples within the current window.
 	var earliest, latest *costSample
-	for i := range c.costSamples {
-		s := &c.costSamples[i]
+	for i := range w.costSamples {
+		s := &w.costSamples[i]
 		if s.at.Before(windowStart) {
 			continue
 		}
@@ -133,8 +305,8 @@ func (c *Calculator) computeHourlyRate(now time.Time) float64 {
 
 	// Also check for the last sample before the window for a baseline.
 	var baseline *costSample
-	for i := range c.costSamples {
-		s := &c.costSamples[i]
+	for i := range w.costSamples {
+		s := &w.costSamples[i]
 		if s.at.Before(windowStart) || s.at.Equal(windowStart) {
 			if baseline == nil || s.at.After(baseline.at) {
 				baseline = s
@@ -172,15 +344,15 @@ func (c *Calculator) computeHourlyRate(now time.Time) float64 {
 	return costDiff / hoursElapsed
 }
 
-// computeTrend compares the current 5-minute window cost rate against the
-// previous 5-minute window to determine if spending is increasing, decreasing,
-// or flat.
-func (c *Calculator) computeTrend(now time.Time) TrendDirection {
-	currentWindowStart := now.Add(-windowDuration)
-	prevWindowStart := now.Add(-2 * windowDuration)
+// computeTrend compares the cost rate over the latest trend window against
+// the trend window before it to determine if spending is increasing,
+// decreasing, or flat.
+func (w *window) computeTrend(now time.Time) TrendDirection {
+	currentWindowStart := now.Add(-w.trend)
+	prevWindowStart := now.Add(-2 * w.trend)
 
-	currentRate := c.windowRate(currentWindowStart, now)
-	prevRate := c.windowRate(prevWindowStart, currentWindowStart)
+	currentRate := w.windowRate(currentWindowStart, now)
+	prevRate := w.windowRate(prevWindowStart, currentWindowStart)
 
 	// Need both windows to have data for a meaningful comparison.
 	if prevRate == 0 && currentRate == 0 {
@@ -199,10 +371,10 @@ func (c *Calculator) computeTrend(now time.Time) TrendDirection {
 }
 
 // windowRate computes the cost rate (per hour) for a specific time window.
-func (c *Calculator) windowRate(windowStart, windowEnd time.Time) float64 {
+func (w *window) windowRate(windowStart, windowEnd time.Time) float64 {
 	var first, last *costSample
-	for i := range c.costSamples {
-		s := &c.costSamples[i]
+	for i := range w.costSamples {
+		s := &w.costSamples[i]
 		if s.at.Before(windowStart) || s.at.After(windowEnd) {
 			continue
 		}
@@ -232,15 +404,15 @@ func (c *Calculator) windowRate(windowStart, windowEnd time.Time) float64 {
 }
 
 // computeTokenVelocity calculates tokens per minute from the rolling window.
-func (c *Calculator) computeTokenVelocity(now time.Time) float64 {
-	windowStart := now.Add(-windowDuration)
+func (w *window) computeTokenVelocity(now time.Time) float64 {
+	windowStart := now.Add(-w.rate)
 
 	var first, last *tokenSample
 
 	// Find the baseline (last sample before or at window start).
 	var baseline *tokenSample
-	for i := range c.tokenSamples {
-		s := &c.tokenSamples[i]
+	for i := range w.tokenSamples {
+		s := &w.tokenSamples[i]
 		if s.at.Before(windowStart) || s.at.Equal(windowStart) {
 			if baseline == nil || s.at.After(baseline.at) {
 				baseline = s
@@ -328,98 +500,3 @@ func pruneTokenSamples(samples []tokenSample, cutoff time.Time) []tokenSample {
 	}
 	return samples[:n]
 }
-
-// computePerModel aggregates cost by session model and computes proportional
-// hourly rates. Results are sorted by total cost descending.
-func computePerModel(sessions []state.SessionData, totalCost, hourlyRate float64) []ModelBurnRate {
-	modelCosts := make(map[string]float64)
-	for _, s := range sessions {
-		model := s.Model
-		if model == "" {
-			model = "unknown"
-		}
-		modelCosts[model] += s.TotalCost
-	}
-
-	result := make([]ModelBurnRate, 0, len(modelCosts))
-	for model, cost := range modelCosts {
-		var modelHourly float64
-		if totalCost > 0 {
-			modelHourly = (cost / totalCost) * hourlyRate
-		}
-		result = append(result, ModelBurnRate{
-			Model:      model,
-			HourlyRate: modelHourly,
-			TotalCost:  cost,
-		})
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].TotalCost > result[j].TotalCost
-	})
-
-	return result
-}
-
-// ComputeWithTime is like Compute but uses a specific timestamp instead of
-// time.Now(). This is primarily useful for testing deterministic behavior.
-func (c *Calculator) ComputeWithTime(store state.Store, now time.Time) BurnRate {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	totalCost := store.GetAggregatedCost()
-
-	sessions := store.ListSessions()
-	var totalTokens int64
-	for _, s := range sessions {
-		totalTokens += s.TotalTokens
-	}
-
-	if !c.initialized {
-		c.prevCost = totalCost
-		c.prevTokens = totalTokens
-		c.initialized = true
-		c.costSamples = append(c.costSamples, costSample{cost: totalCost, at: now})
-		c.tokenSamples = append(c.tokenSamples, tokenSample{tokens: totalTokens, at: now})
-		return BurnRate{
-			TotalCost:     totalCost,
-			HourlyRate:    0,
-			Trend:         TrendFlat,
-			TokenVelocity: 0,
-			PerModel:      computePerModel(sessions, totalCost, 0),
-		}
-	}
-
-	costDelta := totalCost - c.prevCost
-	if costDelta < 0 {
-		costDelta = totalCost
-	}
-	tokenDelta := totalTokens - c.prevTokens
-	if tokenDelta < 0 {
-		tokenDelta = totalTokens
-	}
-
-	c.prevCost = totalCost
-	c.prevTokens = totalTokens
-
-	c.costSamples = append(c.costSamples, costSample{cost: totalCost, at: now})
-	c.tokenSamples = append(c.tokenSamples, tokenSample{tokens: totalTokens, at: now})
-
-	cutoff := now.Add(-2 * windowDuration)
-	c.costSamples = pruneCostSamples(c.costSamples, cutoff)
-	c.tokenSamples = pruneTokenSamples(c.tokenSamples, cutoff)
-
-	hourlyRate := c.computeHourlyRate(now)
-	trend := c.computeTrend(now)
-	tokenVelocity := c.computeTokenVelocity(now)
-
-	return BurnRate{
-		TotalCost:         totalCost,
-		HourlyRate:        hourlyRate,
-		Trend:             trend,
-		TokenVelocity:     tokenVelocity,
-		PerModel:          computePerModel(sessions, totalCost, hourlyRate),
-		DailyProjection:   hourlyRate * 24,
-		MonthlyProjection: hourlyRate * 720,
-	}
-}
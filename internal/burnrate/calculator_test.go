@@ -405,3 +405,117 @@ func TestBurnRate_ProjectionsFromHourlyRate(t *testing.T) {
 		}
 	}
 }
+
+func TestBurnRate_PerSession(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := NewCalculator(DefaultThresholds())
+
+	base := time.Now().Add(-11 * time.Minute)
+
+	addCostMetric(store, "sess-busy", 0.0, base)
+	addCostMetric(store, "sess-idle", 0.0, base)
+	addTokenMetric(store, "sess-busy", 0, base)
+	_ = calc.ComputeWithTime(store, base)
+
+	// sess-busy spends $1 in the first window and $2 in the second, with
+	// 10k tokens over the last 5 minutes; sess-idle spends $0.10 total.
+	addCostMetric(store, "sess-busy", 1.00, base.Add(5*time.Minute))
+	addTokenMetric(store, "sess-busy", 0, base.Add(5*time.Minute))
+	_ = calc.ComputeWithTime(store, base.Add(5*time.Minute))
+
+	now := base.Add(10 * time.Minute)
+	addCostMetric(store, "sess-busy", 3.00, now)
+	addCostMetric(store, "sess-idle", 0.10, now)
+	addTokenMetric(store, "sess-busy", 10000, now)
+	global := calc.ComputeWithTime(store, now)
+
+	busy, ok := calc.Session("sess-busy")
+	if !ok {
+		t.Fatal("sess-busy should have a burn rate")
+	}
+	idle, _ := calc.Session("sess-idle")
+
+	if math.Abs(busy.TotalCost-3.00) > 0.01 || math.Abs(idle.TotalCost-0.10) > 0.01 {
+		t.Errorf("session totals = %f, %f, want 3.00, 0.10", busy.TotalCost, idle.TotalCost)
+	}
+	// $2 over the last 5 minutes => $24/hr.
+	if math.Abs(busy.HourlyRate-24.0) > 0.5 {
+		t.Errorf("sess-busy HourlyRate = %f, want ~24", busy.HourlyRate)
+	}
+	if busy.Trend != TrendUp {
+		t.Errorf("sess-busy Trend = %s, want up", busy.Trend)
+	}
+	if math.Abs(busy.TokenVelocity-2000) > 1 {
+		t.Errorf("sess-busy TokenVelocity = %f, want 2000", busy.TokenVelocity)
+	}
+	if math.Abs(busy.DailyProjection-busy.HourlyRate*24) > 0.01 {
+		t.Errorf("sess-busy DailyProjection = %f, want %f", busy.DailyProjection, busy.HourlyRate*24)
+	}
+	if len(busy.PerModel) != 1 || busy.PerModel[0].TotalCost != busy.TotalCost {
+		t.Errorf("sess-busy PerModel = %+v, want only its own model", busy.PerModel)
+	}
+	if idle.HourlyRate >= busy.HourlyRate {
+		t.Errorf("sess-idle rate %f should be below sess-busy %f", idle.HourlyRate, busy.HourlyRate)
+	}
+	if global.HourlyRate <= busy.HourlyRate {
+		t.Errorf("global rate %f should include both sessions (sess-busy %f)", global.HourlyRate, busy.HourlyRate)
+	}
+
+	if _, ok := calc.Session("sess-unknown"); ok {
+		t.Error("an unsampled session should report ok = false")
+	}
+	if n := len(calc.Sessions()); n != 2 {
+		t.Errorf("Sessions() has %d entries, want 2", n)
+	}
+}
+
+func TestBurnRate_PerModelMixedSession(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := NewCalculator(DefaultThresholds())
+
+	cost := func(model string, value float64, ts time.Time) {
+		store.AddMetric("sess-1", state.Metric{
+			Name:       "claude_code.cost.usage",
+			Value:      value,
+			Attributes: map[string]string{"model": model},
+			Timestamp:  ts,
+		})
+	}
+
+	base := time.Now().Add(-6 * time.Minute)
+	cost("claude-opus-4-6", 1.00, base)
+	_ = calc.ComputeWithTime(store, base)
+
+	// Opus spends $0.50 and a background Haiku model, first seen now,
+	// spends $0.05, with Haiku reported last.
+	now := base.Add(5 * time.Minute)
+	cost("claude-opus-4-6", 1.50, now)
+	cost("claude-haiku-4-5", 0.05, now)
+	br := calc.ComputeWithTime(store, now)
+
+	if len(br.PerModel) != 2 {
+		t.Fatalf("expected 2 models, got %+v", br.PerModel)
+	}
+	opus, haiku := br.PerModel[0], br.PerModel[1]
+	if opus.Model != "claude-opus-4-6" || math.Abs(opus.TotalCost-1.50) > 0.001 {
+		t.Errorf("opus = %+v, want TotalCost 1.50", opus)
+	}
+	if haiku.Model != "claude-haiku-4-5" || math.Abs(haiku.TotalCost-0.05) > 0.001 {
+		t.Errorf("haiku = %+v, want TotalCost 0.05", haiku)
+	}
+	// $0.50 and $0.05 over 5 minutes: $6.00/hr and $0.60/hr.
+	if math.Abs(opus.HourlyRate-6.00) > 0.01 {
+		t.Errorf("opus HourlyRate = %f, want 6.00", opus.HourlyRate)
+	}
+	if math.Abs(haiku.HourlyRate-0.60) > 0.01 {
+		t.Errorf("haiku HourlyRate = %f, want 0.60", haiku.HourlyRate)
+	}
+	if math.Abs(opus.HourlyRate+haiku.HourlyRate-br.HourlyRate) > 0.01 {
+		t.Errorf("per-model rates %f + %f should sum to %f", opus.HourlyRate, haiku.HourlyRate, br.HourlyRate)
+	}
+
+	sess, _ := calc.Session("sess-1")
+	if len(sess.PerModel) != 2 || math.Abs(sess.PerModel[1].HourlyRate-0.60) > 0.01 {
+		t.Errorf("session PerModel = %+v, want both models with exact rates", sess.PerModel)
+	}
+}
@@ -0,0 +1,166 @@
+package burnrate
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// minuteRetention is how long per-minute buckets are kept.
+	minuteRetention = 24 * time.Hour
+	// hourRetention is how long per-hour buckets are kept.
+	hourRetention = 30 * 24 * time.Hour
+	// minHourCoverage is how much of an hour must have been observed for
+	// its bucket to count as history in projections.
+	minHourCoverage = 50 * time.Minute
+)
+
+// Point is one bucket of a downsampled series: the cost and tokens spent
+// across all sessions in the bucket starting at At.
+type Point struct {
+	At     time.Time
+	Cost   float64
+	Tokens int64
+}
+
+// bucket is a Point plus the span of the observations that fell in it.
+type bucket struct {
+	Point
+	first, last time.Time
+}
+
+// history accumulates global spend into per-minute buckets for the last
+// 24 hours and per-hour buckets for the last 30 days.
+type history struct {
+	minutes []bucket
+	hours   []bucket
+
+	observed   bool
+	lastCost   float64
+	lastTokens int64
+	latest     time.Time
+}
+
+// observe adds the spend since the previous observation to the buckets
+// containing now. The first observation only sets the baseline.
+func (h *history) observe(cost float64, tokens int64, now time.Time) {
+	var dc float64
+	var dt int64
+	if h.observed {
+		// Handle counter resets: if the total went down, treat the previous as 0.
+		if dc = cost - h.lastCost; dc < 0 {
+			dc = cost
+		}
+		if dt = tokens - h.lastTokens; dt < 0 {
+			dt = tokens
+		}
+	}
+	h.observed = true
+	h.lastCost, h.lastTokens = cost, tokens
+	if now.After(h.latest) {
+		h.latest = now
+	}
+
+	h.minutes = addToBucket(h.minutes, now.Truncate(time.Minute), now, dc, dt)
+	h.hours = addToBucket(h.hours, now.Truncate(time.Hour), now, dc, dt)
+	h.minutes = pruneBuckets(h.minutes, h.latest.Add(-minuteRetention))
+	h.hours = pruneBuckets(h.hours, h.latest.Add(-hourRetention))
+}
+
+// series returns the buckets covering the span ending at the latest
+// observation, oldest first, with unobserved buckets as zero points. Spans
+// up to 24 hours use per-minute buckets; longer ones use per-hour buckets
+// and are capped at 30 days.
+func (h *history) series(span time.Duration) []Point {
+	if span <= 0 || !h.observed {
+		return nil
+	}
+	buckets, step := h.minutes, time.Minute
+	if span > minuteRetention {
+		buckets, step = h.hours, time.Hour
+		span = min(span, hourRetention)
+	}
+
+	byStart := make(map[int64]Point, len(buckets))
+	for _, b := range buckets {
+		byStart[b.At.UnixNano()] = b.Point
+	}
+
+	n := int((span + step - 1) / step)
+	end := h.latest.Truncate(step)
+	points := make([]Point, n)
+	for i := range points {
+		at := end.Add(-time.Duration(n-1-i) * step)
+		p, ok := byStart[at.UnixNano()]
+		if !ok {
+			p = Point{At: at}
+		}
+		points[i] = p
+	}
+	return points
+}
+
+// projectDay estimates the spend over the 24 hours from now. The rest of
+// the current hour is projected at the current hourly rate. Each following
+// hour uses the mean spend of past, mostly observed hours at the same time
+// of day in now's location, or the current rate if there are none.
+func (h *history) projectDay(hourlyRate float64, now time.Time) float64 {
+	var sum [24]float64
+	var count [24]int
+	current := now.Truncate(time.Hour)
+	for _, b := range h.hours {
+		if !b.At.Before(current) || b.last.Sub(b.first) < minHourCoverage {
+			continue
+		}
+		hod := b.At.In(now.Location()).Hour()
+		sum[hod] += b.Cost
+		count[hod]++
+	}
+	expected := func(at time.Time) float64 {
+		hod := at.In(now.Location()).Hour()
+		if count[hod] == 0 {
+			return hourlyRate
+		}
+		return sum[hod] / float64(count[hod])
+	}
+
+	elapsed := float64(now.Sub(current)) / float64(time.Hour)
+	total := (1 - elapsed) * hourlyRate
+	for k := 1; k < 24; k++ {
+		total += expected(current.Add(time.Duration(k) * time.Hour))
+	}
+	// The part of this hour of day that falls 24 hours from now.
+	total += elapsed * expected(current)
+	return total
+}
+
+// addToBucket adds spend observed at now to the bucket starting at start,
+// creating it in order if needed.
+func addToBucket(buckets []bucket, start, now time.Time, cost float64, tokens int64) []bucket {
+	i := sort.Search(len(buckets), func(i int) bool {
+		return !buckets[i].At.Before(start)
+	})
+	if i == len(buckets) || !buckets[i].At.Equal(start) {
+		buckets = append(buckets, bucket{})
+		copy(buckets[i+1:], buckets[i:])
+		buckets[i] = bucket{Point: Point{At: start}, first: now, last: now}
+	}
+	b := &buckets[i]
+	b.Cost += cost
+	b.Tokens += tokens
+	if now.Before(b.first) {
+		b.first = now
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	return buckets
+}
+
+// pruneBuckets removes buckets that start before the cutoff.
+func pruneBuckets(buckets []bucket, cutoff time.Time) []bucket {
+	i := sort.Search(len(buckets), func(i int) bool {
+		return !buckets[i].At.Before(cutoff)
+	})
+	return append(buckets[:0], buckets[i:]...)
+}
@@ -0,0 +1,128 @@
+package burnrate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func TestBurnRate_ConfigurableWindows(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := NewCalculator(DefaultThresholds(), WithRateWindow(time.Minute), WithTrendWindow(2*time.Minute))
+
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addCostMetric(store, "sess-1", 0.0, base)
+	_ = calc.ComputeWithTime(store, base)
+
+	// $0.10 in the first two minutes, then $0.20 per minute: the 1-minute
+	// rate window sees only the recent spend, and the trend compares the
+	// last two minutes with the two before.
+	steps := []float64{0.05, 0.10, 0.30, 0.50}
+	var br BurnRate
+	for i, cost := range steps {
+		now := base.Add(time.Duration(i+1) * time.Minute)
+		addCostMetric(store, "sess-1", cost, now)
+		br = calc.ComputeWithTime(store, now)
+	}
+
+	if math.Abs(br.HourlyRate-12.0) > 0.01 {
+		t.Errorf("HourlyRate = %f, want 12.00 over the 1-minute window", br.HourlyRate)
+	}
+	if br.Trend != TrendUp {
+		t.Errorf("Trend = %s, want up", br.Trend)
+	}
+
+	// Non-positive windows keep the defaults.
+	def := NewCalculator(DefaultThresholds(), WithRateWindow(0), WithTrendWindow(-time.Minute))
+	if def.spans.rate != DefaultRateWindow || def.spans.trend != DefaultTrendWindow {
+		t.Errorf("spans = %+v, want the defaults", def.spans)
+	}
+}
+
+func TestBurnRate_Series(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := NewCalculator(DefaultThresholds())
+
+	if got := calc.Series(time.Hour); got != nil {
+		t.Errorf("Series before any observation = %v, want nil", got)
+	}
+
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addCostMetric(store, "sess-1", 0.0, base)
+	addTokenMetric(store, "sess-1", 0, base)
+	_ = calc.ComputeWithTime(store, base)
+
+	// Two observations in the first minute, none in the second, one in the third.
+	for _, obs := range []struct {
+		at     time.Duration
+		cost   float64
+		tokens float64
+	}{
+		{20 * time.Second, 0.10, 1000},
+		{40 * time.Second, 0.25, 2500},
+		{2*time.Minute + 10*time.Second, 0.40, 4000},
+	} {
+		addCostMetric(store, "sess-1", obs.cost, base.Add(obs.at))
+		addTokenMetric(store, "sess-1", obs.tokens, base.Add(obs.at))
+		_ = calc.ComputeWithTime(store, base.Add(obs.at))
+	}
+
+	series := calc.Series(5 * time.Minute)
+	if len(series) != 5 {
+		t.Fatalf("got %d points, want 5", len(series))
+	}
+	if !series[4].At.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("last point at %v, want the current minute %v", series[4].At, base.Add(2*time.Minute))
+	}
+	first, gap, last := series[2], series[3], series[4]
+	if math.Abs(first.Cost-0.25) > 1e-9 || first.Tokens != 2500 {
+		t.Errorf("first minute = %+v, want $0.25 and 2500 tokens", first)
+	}
+	if gap.Cost != 0 || gap.Tokens != 0 {
+		t.Errorf("unobserved minute = %+v, want zero", gap)
+	}
+	if math.Abs(last.Cost-0.15) > 1e-9 || last.Tokens != 1500 {
+		t.Errorf("third minute = %+v, want $0.15 and 1500 tokens", last)
+	}
+
+	// Spans beyond 24 hours are per hour.
+	hourly := calc.Series(48 * time.Hour)
+	if len(hourly) != 48 {
+		t.Fatalf("got %d hourly points, want 48", len(hourly))
+	}
+	if p := hourly[47]; !p.At.Equal(base) || math.Abs(p.Cost-0.40) > 1e-9 {
+		t.Errorf("current hour = %+v, want $0.40 at %v", p, base)
+	}
+}
+
+func TestBurnRate_TimeOfDayProjection(t *testing.T) {
+	store := state.NewMemoryStore()
+	calc := NewCalculator(DefaultThresholds())
+
+	// Two days of history sampled every 5 minutes: $1 per hour from 09:00
+	// to 17:00, nothing otherwise.
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	cost := 0.0
+	var br BurnRate
+	for now := start; now.Before(start.Add(48 * time.Hour)); now = now.Add(5 * time.Minute) {
+		if h := now.Hour(); h >= 9 && h < 17 && now != start {
+			cost += 1.0 / 12
+		}
+		addCostMetric(store, "sess-1", cost, now)
+		br = calc.ComputeWithTime(store, now)
+	}
+
+	// At 23:55 with nothing being spent, the next 24 hours should look
+	// like the past days: $8, not the current rate times 24.
+	if br.HourlyRate != 0 {
+		t.Fatalf("HourlyRate = %f, want 0 outside working hours", br.HourlyRate)
+	}
+	if math.Abs(br.DailyProjection-8.0) > 0.2 {
+		t.Errorf("DailyProjection = %f, want ~8.00 from history", br.DailyProjection)
+	}
+	if math.Abs(br.MonthlyProjection-br.DailyProjection*30) > 0.01 {
+		t.Errorf("MonthlyProjection = %f, want DailyProjection * 30", br.MonthlyProjection)
+	}
+}
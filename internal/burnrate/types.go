@@ -16,8 +16,8 @@ type BurnRate struct {
 	Trend             TrendDirection
 	TokenVelocity     float64 // tokens per minute
 	PerModel          []ModelBurnRate
-	DailyProjection   float64 // HourlyRate * 24
-	MonthlyProjection float64 // HourlyRate * 720
+	DailyProjection   float64 // expected spend over the next 24 hours
+	MonthlyProjection float64 // DailyProjection * 30
 }
 
 // TrendDirection indicates rate change direction.
@@ -9,21 +9,34 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/nixlim/cc-top/internal/alerts/expr"
 )
 
 // Config holds all cc-top configuration loaded from TOML.
 type Config struct {
-	Receiver ReceiverConfig
-	Scanner  ScannerConfig
-	Alerts   AlertsConfig
-	Display  DisplayConfig
-	Models   map[string]int        // model name -> context token limit
-	Pricing  map[string][4]float64 // model name -> [input, output, cache_read, cache_creation] per million
+	Receiver    ReceiverConfig
+	Scanner     ScannerConfig
+	Alerts      AlertsConfig
+	Display     DisplayConfig
+	BurnRate    BurnRateConfig
+	Transcripts TranscriptsConfig
+	Enforcement EnforcementConfig
+	Budgets     map[string]BudgetConfig // budget name -> definition
+	Models      map[string]int          // model name -> context token limit
+	Pricing     map[string][4]float64   // model name -> [input, output, cache_read, cache_creation] per million
 }
 
 // ReceiverConfig configures the OTLP receiver endpoints.
@@ -36,6 +49,13 @@ type ReceiverConfig struct {
 // ScannerConfig configures the process scanner.
 type ScannerConfig struct {
 	IntervalSeconds int `toml:"interval_seconds"`
+	// Discovery selects how new and exited processes are detected:
+	// "auto" uses OS process notifications where available (falling back
+	// to polling), "poll" always scans every interval_seconds.
+	Discovery string `toml:"discovery"`
+	// FallbackIntervalSeconds is the full-scan interval while event-driven
+	// discovery is active, to catch anything the notifications missed.
+	FallbackIntervalSeconds int `toml:"fallback_interval_seconds"`
 }
 
 // AlertsConfig configures alert thresholds and notification behaviour.
@@ -46,19 +66,203 @@ type AlertsConfig struct {
 	RunawayTokenSustainedMinutes int                `toml:"runaway_token_sustained_minutes"`
 	LoopDetectorThreshold        int                `toml:"loop_detector_threshold"`
 	LoopDetectorWindowMinutes    int                `toml:"loop_detector_window_minutes"`
+	LoopDetectorRepeatThreshold  int                `toml:"loop_detector_repeat_threshold"`
 	ErrorStormCount              int                `toml:"error_storm_count"`
 	StaleSessionHours            int                `toml:"stale_session_hours"`
 	ContextPressurePercent       int                `toml:"context_pressure_percent"`
 	HighRejectionPercent         int                `toml:"high_rejection_percent"`
 	HighRejectionWindowMinutes   int                `toml:"high_rejection_window_minutes"`
 	Notifications                NotificationConfig `toml:"notifications"`
+	// Modes limits a rule to sessions started in the listed invocation
+	// modes, keyed by rule name. Rules without an entry apply to every mode.
+	Modes map[string][]string `toml:"modes"`
+	// SuppressModes silences a rule for sessions started in the listed
+	// invocation modes, keyed by rule name.
+	SuppressModes map[string][]string `toml:"suppress_modes"`
+	// Custom rules are defined by expressions over windowed aggregates.
+	Custom []CustomRuleConfig `toml:"custom"`
+	// ResolveAfterSeconds is how long a condition must stay clear before
+	// its alert is resolved, so alerts do not flap around a threshold.
+	ResolveAfterSeconds int `toml:"resolve_after_seconds"`
+	// HistorySize bounds the number of alerts kept; the oldest resolved
+	// alerts are dropped first.
+	HistorySize int `toml:"history_size"`
+	// Rules overrides how individual rules are treated, keyed by rule name,
+	// from [alerts.rules.<Name>].
+	Rules map[string]RuleConfig `toml:"rules"`
+	// Anomaly configures the Anomaly rule, from [alerts.anomaly].
+	Anomaly AnomalyConfig `toml:"anomaly"`
+	// Security configures the security rules, from [alerts.security].
+	Security SecurityConfig `toml:"security"`
+	// Egress configures the UnlistedDomain rule, from [alerts.egress].
+	Egress EgressConfig `toml:"egress"`
+}
+
+// EgressConfig configures the UnlistedDomain rule, which alerts when a
+// session contacts an external domain that is not allowlisted.
+type EgressConfig struct {
+	// Allowlist holds the domains sessions may contact. An entry covers
+	// the domain and its subdomains, and may be a glob. When empty, the
+	// rule is off.
+	Allowlist []string `toml:"allowlist"`
+}
+
+// SecurityConfig configures the DangerousCommand, SensitivePath and
+// WriteOutsideCWD rules, which inspect the tool parameters Claude Code
+// reports when started with OTEL_LOG_TOOL_DETAILS=1. Each list replaces
+// the default one.
+type SecurityConfig struct {
+	// DangerousCommands are regular expressions matched against Bash
+	// commands.
+	DangerousCommands []string `toml:"dangerous_commands"`
+	// SensitivePaths are globs for files tools should not touch. A glob
+	// with a slash matches a path or one of its parents, so "~/.ssh"
+	// covers everything in it; one without matches any path element, so
+	// ".env" covers every .env file.
+	SensitivePaths []string `toml:"sensitive_paths"`
+	// AllowedWritePaths are globs, matched like SensitivePaths, outside a
+	// session's working directory that files may still be written to.
+	AllowedWritePaths []string `toml:"allowed_write_paths"`
+}
+
+// AnomalyConfig configures the Anomaly rule, which learns a baseline of
+// each project's cost per minute, tokens per turn and tool-call rate and
+// alerts when a minute of activity is far above it.
+type AnomalyConfig struct {
+	// Sensitivity is the number of standard deviations above the baseline
+	// mean at which a sample is anomalous. Lower is more sensitive.
+	Sensitivity float64 `toml:"sensitivity"`
+	// WarmupSamples is the number of active minutes a project's baseline
+	// learns from before it can alert.
+	WarmupSamples int `toml:"warmup_samples"`
+	// HalfLifeMinutes is how many active minutes it takes for a sample's
+	// weight in the baseline to halve.
+	HalfLifeMinutes int `toml:"half_life_minutes"`
+}
+
+// RuleConfig overrides the treatment of one rule's alerts. Fields left
+// unset keep the rule's own behavior.
+type RuleConfig struct {
+	// Enabled turns the rule off when false. It defaults to true.
+	Enabled bool `toml:"enabled"`
+	// Severity replaces the severity of the rule's alerts.
+	Severity string `toml:"severity"`
+	// DedupSeconds replaces the window in which the rule cannot fire again
+	// for the same session. 0 keeps the default.
+	DedupSeconds int `toml:"dedup_seconds"`
+	// Notify names the notifiers the rule's alerts are sent to: "desktop"
+	// or the name of a webhook or exec notifier. Unset sends to all of
+	// them; an empty list to none, leaving the alert in cc-top only.
+	Notify []string `toml:"notify"`
+	// IncludeCWD and ExcludeCWD are globs matched against a session's
+	// working directory and its parents, so "/work/*" covers every
+	// project under /work. A leading ~ is the home directory.
+	IncludeCWD []string `toml:"include_cwd"`
+	ExcludeCWD []string `toml:"exclude_cwd"`
+	// IncludeModels and ExcludeModels are model names or globs.
+	IncludeModels []string `toml:"include_models"`
+	ExcludeModels []string `toml:"exclude_models"`
+	// IncludeModes and ExcludeModes are invocation modes, as in
+	// [alerts.modes] and [alerts.suppress_modes].
+	IncludeModes []string `toml:"include_modes"`
+	ExcludeModes []string `toml:"exclude_modes"`
+}
+
+// CustomRuleConfig defines an alert rule from an expression in
+// [[alerts.custom]]. See package alerts/expr for the expression language.
+type CustomRuleConfig struct {
+	Name string `toml:"name"` // rule name shown on alerts; must be unique
+	Expr string `toml:"expr"`
+	// WindowMinutes is the time window the expression's aggregates cover.
+	WindowMinutes int `toml:"window_minutes"`
+	// Scope is "session" to evaluate each session separately or "global"
+	// to aggregate across all sessions.
+	Scope    string `toml:"scope"`
+	Severity string `toml:"severity"` // "warning" or "critical"
+	// Message is the alert text. {rule}, {value}, {window} and {session}
+	// are replaced; {value} is the left side of the first comparison.
+	Message string `toml:"message"`
+}
+
+// BuiltinAlertRules are the names of the built-in alert rules.
+var BuiltinAlertRules = []string{
+	"CostSurge", "RunawayTokens", "LoopDetector", "ErrorStorm", "StaleSession",
+	"ContextPressure", "HighRejection", "SessionCost", "Budget", "Anomaly",
+	"DangerousCommand", "SensitivePath", "WriteOutsideCWD", "UnlistedDomain",
 }
 
-// NotificationConfig controls system notification behaviour.
+// CustomRuleScopes are the values accepted for a custom rule's scope.
+var CustomRuleScopes = []string{"session", "global"}
+
+// AlertSeverities are the values accepted for alert severities.
+var AlertSeverities = []string{"warning", "critical"}
+
+// InvocationModes are the values accepted in [alerts.modes] and
+// [alerts.suppress_modes].
+var InvocationModes = []string{"interactive", "headless", "sdk", "ide"}
+
+// NotificationConfig controls how alerts are delivered.
 type NotificationConfig struct {
 	SystemNotify bool `toml:"system_notify"`
+	// Webhooks post alerts to HTTP endpoints, from
+	// [[alerts.notifications.webhooks]].
+	Webhooks []WebhookConfig `toml:"webhooks"`
+	// Exec runs commands for alerts, from [[alerts.notifications.exec]].
+	Exec []ExecNotifierConfig `toml:"exec"`
 }
 
+// WebhookConfig configures a webhook notifier.
+type WebhookConfig struct {
+	// Name identifies the webhook in delivery status; it defaults to the
+	// URL's host.
+	Name    string            `toml:"name"`
+	URL     string            `toml:"url"`
+	Headers map[string]string `toml:"headers"`
+	// Preset is the payload format: "json" (the alert's fields), "slack"
+	// or "discord". Template, when set, is used instead.
+	Preset string `toml:"preset"`
+	// Template is a Go text/template for the request body. It sees the
+	// alert's fields, .Text (a one-line summary) and a json function for
+	// quoting values.
+	Template string `toml:"template"`
+	// Severities and Rules limit which alerts are sent; empty sends all.
+	Severities []string `toml:"severities"`
+	Rules      []string `toml:"rules"`
+	// TimeoutSeconds bounds each delivery attempt.
+	TimeoutSeconds int `toml:"timeout_seconds"`
+	// MaxRetries is how many times a failed delivery is retried, with
+	// exponential backoff.
+	MaxRetries int `toml:"max_retries"`
+	// QueueSize bounds the alerts waiting for delivery. Alerts arriving
+	// while it is full are dropped.
+	QueueSize int `toml:"queue_size"`
+}
+
+// ExecNotifierConfig configures a notifier that runs a command per alert.
+// The command gets the alert as JSON on stdin and in CC_TOP_*
+// environment variables.
+type ExecNotifierConfig struct {
+	// Name identifies the notifier in delivery status; it defaults to the
+	// command's first word.
+	Name string `toml:"name"`
+	// Command is run with /bin/sh -c.
+	Command string `toml:"command"`
+	// Severities and Rules limit which alerts run the command; empty runs
+	// it for all.
+	Severities []string `toml:"severities"`
+	Rules      []string `toml:"rules"`
+	// TimeoutSeconds bounds each run; the command is killed after it.
+	TimeoutSeconds int `toml:"timeout_seconds"`
+	// MaxConcurrent limits how many runs happen at once.
+	MaxConcurrent int `toml:"max_concurrent"`
+	// QueueSize bounds the alerts waiting for a run. Alerts arriving while
+	// it is full are dropped.
+	QueueSize int `toml:"queue_size"`
+}
+
+// WebhookPresets are the values accepted for a webhook's preset.
+var WebhookPresets = []string{"json", "slack", "discord"}
+
 // DisplayConfig configures TUI display parameters.
 type DisplayConfig struct {
 	EventBufferSize      int     `toml:"event_buffer_size"`
@@ -67,6 +271,94 @@ type DisplayConfig struct {
 	CostColorYellowBelow float64 `toml:"cost_color_yellow_below"`
 }
 
+// BurnRateConfig configures the windows the burn rate is measured over.
+type BurnRateConfig struct {
+	// RateWindowMinutes is the rolling window for the hourly rate and
+	// token velocity.
+	RateWindowMinutes int `toml:"rate_window_minutes"`
+	// TrendWindowMinutes is the length of the two consecutive windows
+	// compared to determine the trend.
+	TrendWindowMinutes int `toml:"trend_window_minutes"`
+}
+
+// BudgetConfig defines a spending limit over a recurring period. The
+// scope fields restrict which spend counts towards it; empty fields match
+// everything. Spend is not persisted: only what cc-top has received since
+// it started counts.
+type BudgetConfig struct {
+	Limit float64 `toml:"limit"` // USD per period
+	// Period is "day", "week" (starting Monday) or "month".
+	Period string `toml:"period"`
+	// Timezone is the IANA zone period boundaries are computed in; empty
+	// means local time.
+	Timezone string `toml:"timezone"`
+	// Project matches sessions whose git repository has this name, or
+	// whose CWD is this directory, is inside it, or matches it as a glob.
+	Project string `toml:"project"`
+	// Model is a model name or glob; only spend on matching models counts.
+	Model  string `toml:"model"`
+	OrgID  string `toml:"org_id"`  // organization.id
+	UserID string `toml:"user_id"` // user.account_uuid
+	// AlertAt lists the percentages of Limit that raise an alert.
+	AlertAt []int `toml:"alert_at"`
+}
+
+// BudgetPeriods are the values accepted for a budget's period.
+var BudgetPeriods = []string{"day", "week", "month"}
+
+// DefaultBudgetAlertAt is used for budgets without alert_at.
+var DefaultBudgetAlertAt = []int{50, 80, 100}
+
+// EnforcementConfig configures opt-in actions against sessions that breach
+// a budget or the session cost threshold. Nothing is enforced unless
+// policies are configured.
+type EnforcementConfig struct {
+	// AuditLog is the file every enforcement action is appended to; empty
+	// means ~/.local/state/cc-top/enforcement.log.
+	AuditLog string                       `toml:"audit_log"`
+	Policies map[string]EnforcementPolicy `toml:"policies"` // policy name -> definition
+}
+
+// EnforcementPolicy acts on each session that breaches its trigger: either
+// an exhausted budget or an alert rule's threshold.
+type EnforcementPolicy struct {
+	// Budget is the name of the budget whose exhaustion triggers the
+	// policy for the sessions that spent from it, or "*" for any budget.
+	Budget string `toml:"budget"`
+	// Rule is the alert rule whose threshold triggers the policy.
+	Rule string `toml:"rule"`
+	// Action is "pause" (SIGSTOP until resumed from the TUI), "interrupt"
+	// (SIGINT, stopping the current turn) or "terminate" (SIGTERM).
+	Action string `toml:"action"`
+	// GraceSeconds is how long a breach must last before the action is taken.
+	GraceSeconds int `toml:"grace_seconds"`
+	// CooldownSeconds is how long after the action, or after a paused
+	// session is resumed, a breach that persists is acted on again.
+	CooldownSeconds int `toml:"cooldown_seconds"`
+}
+
+// EnforcementActions are the values accepted for a policy's action.
+var EnforcementActions = []string{"pause", "interrupt", "terminate"}
+
+// EnforcementRules are the alert rules a policy can be triggered by.
+var EnforcementRules = []string{"SessionCost"}
+
+// DefaultEnforcementGraceSeconds is used for policies without grace_seconds.
+const DefaultEnforcementGraceSeconds = 30
+
+// DefaultEnforcementCooldownSeconds is used for policies without
+// cooldown_seconds.
+const DefaultEnforcementCooldownSeconds = 600
+
+// TranscriptsConfig configures backfilling sessions from Claude Code
+// transcript files for processes that aren't sending OTLP telemetry.
+type TranscriptsConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Dir is the transcript root; empty means ~/.claude/projects.
+	Dir                 string `toml:"dir"`
+	PollIntervalSeconds int    `toml:"poll_interval_seconds"`
+}
+
 // LoadResult contains the loaded configuration and any warnings encountered during parsing.
 type LoadResult struct {
 	Config   Config
@@ -113,11 +405,15 @@ func LoadFrom(path string) (*LoadResult, error) {
 
 	// Detect unknown top-level keys.
 	knownTopLevel := map[string]bool{
-		"receiver": true,
-		"scanner":  true,
-		"alerts":   true,
-		"display":  true,
-		"models":   true,
+		"receiver":    true,
+		"scanner":     true,
+		"alerts":      true,
+		"display":     true,
+		"burnrate":    true,
+		"transcripts": true,
+		"budgets":     true,
+		"enforcement": true,
+		"models":      true,
 	}
 	for key := range raw {
 		if !knownTopLevel[key] {
@@ -134,6 +430,8 @@ func LoadFrom(path string) (*LoadResult, error) {
 
 	// Apply parsed values over defaults.
 	mergeFromRaw(&result.Config, &tf, raw)
+	mergeBudgets(&result.Config, &tf, raw)
+	mergeEnforcement(&result.Config, &tf, raw)
 	mergeModelsFromRaw(&result.Config, raw)
 
 	// Validate the final config.
@@ -147,11 +445,15 @@ func LoadFrom(path string) (*LoadResult, error) {
 // tomlFile mirrors the TOML structure for decoding purposes.
 // The [models] table has both context limits (bare keys) and a [models.pricing] sub-table.
 type tomlFile struct {
-	Receiver *ReceiverConfig `toml:"receiver"`
-	Scanner  *ScannerConfig  `toml:"scanner"`
-	Alerts   *AlertsConfig   `toml:"alerts"`
-	Display  *DisplayConfig  `toml:"display"`
-	Models   *tomlModels     `toml:"models"`
+	Receiver    *ReceiverConfig         `toml:"receiver"`
+	Scanner     *ScannerConfig          `toml:"scanner"`
+	Alerts      *AlertsConfig           `toml:"alerts"`
+	Display     *DisplayConfig          `toml:"display"`
+	BurnRate    *BurnRateConfig         `toml:"burnrate"`
+	Transcripts *TranscriptsConfig      `toml:"transcripts"`
+	Budgets     map[string]BudgetConfig `toml:"budgets"`
+	Enforcement *EnforcementConfig      `toml:"enforcement"`
+	Models      *tomlModels             `toml:"models"`
 }
 
 // tomlModels handles the [models] table which contains both context limits
@@ -185,6 +487,12 @@ func mergeFromRaw(cfg *Config, tf *tomlFile, raw map[string]any) {
 			if _, exists := section["interval_seconds"]; exists {
 				cfg.Scanner.IntervalSeconds = tf.Scanner.IntervalSeconds
 			}
+			if _, exists := section["discovery"]; exists {
+				cfg.Scanner.Discovery = tf.Scanner.Discovery
+			}
+			if _, exists := section["fallback_interval_seconds"]; exists {
+				cfg.Scanner.FallbackIntervalSeconds = tf.Scanner.FallbackIntervalSeconds
+			}
 		}
 	}
 	if tf.Alerts != nil {
@@ -204,6 +512,9 @@ func mergeFromRaw(cfg *Config, tf *tomlFile, raw map[string]any) {
 			if _, exists := section["loop_detector_window_minutes"]; exists {
 				cfg.Alerts.LoopDetectorWindowMinutes = tf.Alerts.LoopDetectorWindowMinutes
 			}
+			if _, exists := section["loop_detector_repeat_threshold"]; exists {
+				cfg.Alerts.LoopDetectorRepeatThreshold = tf.Alerts.LoopDetectorRepeatThreshold
+			}
 			if _, exists := section["error_storm_count"]; exists {
 				cfg.Alerts.ErrorStormCount = tf.Alerts.ErrorStormCount
 			}
@@ -222,8 +533,62 @@ func mergeFromRaw(cfg *Config, tf *tomlFile, raw map[string]any) {
 			if _, exists := section["high_rejection_window_minutes"]; exists {
 				cfg.Alerts.HighRejectionWindowMinutes = tf.Alerts.HighRejectionWindowMinutes
 			}
-			if _, exists := section["notifications"]; exists {
-				cfg.Alerts.Notifications = tf.Alerts.Notifications
+			if _, exists := section["resolve_after_seconds"]; exists {
+				cfg.Alerts.ResolveAfterSeconds = tf.Alerts.ResolveAfterSeconds
+			}
+			if _, exists := section["history_size"]; exists {
+				cfg.Alerts.HistorySize = tf.Alerts.HistorySize
+			}
+			if notifications, ok := rawSection(section, "notifications"); ok {
+				if _, exists := notifications["system_notify"]; exists {
+					cfg.Alerts.Notifications.SystemNotify = tf.Alerts.Notifications.SystemNotify
+				}
+				if webhooks, exists := notifications["webhooks"]; exists {
+					cfg.Alerts.Notifications.Webhooks = mergeWebhooks(tf.Alerts.Notifications.Webhooks, webhooks)
+				}
+				if execs, exists := notifications["exec"]; exists {
+					cfg.Alerts.Notifications.Exec = mergeExecNotifiers(tf.Alerts.Notifications.Exec, execs)
+				}
+			}
+			// Per-rule entries replace the default for that rule only.
+			if _, exists := section["modes"]; exists {
+				cfg.Alerts.Modes = mergeRuleModes(cfg.Alerts.Modes, tf.Alerts.Modes)
+			}
+			if _, exists := section["suppress_modes"]; exists {
+				cfg.Alerts.SuppressModes = mergeRuleModes(cfg.Alerts.SuppressModes, tf.Alerts.SuppressModes)
+			}
+			if custom, exists := section["custom"]; exists {
+				cfg.Alerts.Custom = mergeCustomRules(tf.Alerts.Custom, custom)
+			}
+			if anomaly, ok := rawSection(section, "anomaly"); ok {
+				if _, exists := anomaly["sensitivity"]; exists {
+					cfg.Alerts.Anomaly.Sensitivity = tf.Alerts.Anomaly.Sensitivity
+				}
+				if _, exists := anomaly["warmup_samples"]; exists {
+					cfg.Alerts.Anomaly.WarmupSamples = tf.Alerts.Anomaly.WarmupSamples
+				}
+				if _, exists := anomaly["half_life_minutes"]; exists {
+					cfg.Alerts.Anomaly.HalfLifeMinutes = tf.Alerts.Anomaly.HalfLifeMinutes
+				}
+			}
+			if security, ok := rawSection(section, "security"); ok {
+				if _, exists := security["dangerous_commands"]; exists {
+					cfg.Alerts.Security.DangerousCommands = tf.Alerts.Security.DangerousCommands
+				}
+				if _, exists := security["sensitive_paths"]; exists {
+					cfg.Alerts.Security.SensitivePaths = tf.Alerts.Security.SensitivePaths
+				}
+				if _, exists := security["allowed_write_paths"]; exists {
+					cfg.Alerts.Security.AllowedWritePaths = tf.Alerts.Security.AllowedWritePaths
+				}
+			}
+			if egress, ok := rawSection(section, "egress"); ok {
+				if _, exists := egress["allowlist"]; exists {
+					cfg.Alerts.Egress.Allowlist = tf.Alerts.Egress.Allowlist
+				}
+			}
+			if rules, ok := rawSection(section, "rules"); ok {
+				cfg.Alerts.Rules = mergeRuleConfigs(cfg.Alerts.Rules, tf.Alerts.Rules, rules)
 			}
 		}
 	}
@@ -243,6 +608,200 @@ func mergeFromRaw(cfg *Config, tf *tomlFile, raw map[string]any) {
 			}
 		}
 	}
+	if tf.BurnRate != nil {
+		if section, ok := rawSection(raw, "burnrate"); ok {
+			if _, exists := section["rate_window_minutes"]; exists {
+				cfg.BurnRate.RateWindowMinutes = tf.BurnRate.RateWindowMinutes
+			}
+			if _, exists := section["trend_window_minutes"]; exists {
+				cfg.BurnRate.TrendWindowMinutes = tf.BurnRate.TrendWindowMinutes
+			}
+		}
+	}
+	if tf.Transcripts != nil {
+		if section, ok := rawSection(raw, "transcripts"); ok {
+			if _, exists := section["enabled"]; exists {
+				cfg.Transcripts.Enabled = tf.Transcripts.Enabled
+			}
+			if _, exists := section["dir"]; exists {
+				cfg.Transcripts.Dir = tf.Transcripts.Dir
+			}
+			if _, exists := section["poll_interval_seconds"]; exists {
+				cfg.Transcripts.PollIntervalSeconds = tf.Transcripts.PollIntervalSeconds
+			}
+		}
+	}
+}
+
+// mergeCustomRules applies defaults to the [[alerts.custom]] rules, using
+// raw to detect which keys each rule sets.
+func mergeCustomRules(rules []CustomRuleConfig, raw any) []CustomRuleConfig {
+	tables, _ := raw.([]map[string]any)
+	merged := make([]CustomRuleConfig, len(rules))
+	for i, r := range rules {
+		var keys map[string]any
+		if i < len(tables) {
+			keys = tables[i]
+		}
+		if _, exists := keys["window_minutes"]; !exists {
+			r.WindowMinutes = 5
+		}
+		if _, exists := keys["scope"]; !exists {
+			r.Scope = "session"
+		}
+		if _, exists := keys["severity"]; !exists {
+			r.Severity = "warning"
+		}
+		if _, exists := keys["message"]; !exists {
+			r.Message = "{rule}: " + r.Expr + " (value {value})"
+		}
+		merged[i] = r
+	}
+	return merged
+}
+
+// mergeWebhooks applies defaults to the [[alerts.notifications.webhooks]]
+// entries, using raw to detect which keys each one sets.
+func mergeWebhooks(webhooks []WebhookConfig, raw any) []WebhookConfig {
+	tables, _ := raw.([]map[string]any)
+	merged := make([]WebhookConfig, len(webhooks))
+	for i, w := range webhooks {
+		var keys map[string]any
+		if i < len(tables) {
+			keys = tables[i]
+		}
+		if _, exists := keys["name"]; !exists {
+			if u, err := url.Parse(w.URL); err == nil {
+				w.Name = u.Host
+			}
+		}
+		if _, exists := keys["preset"]; !exists {
+			w.Preset = "json"
+		}
+		if _, exists := keys["timeout_seconds"]; !exists {
+			w.TimeoutSeconds = 10
+		}
+		if _, exists := keys["max_retries"]; !exists {
+			w.MaxRetries = 3
+		}
+		if _, exists := keys["queue_size"]; !exists {
+			w.QueueSize = 100
+		}
+		merged[i] = w
+	}
+	return merged
+}
+
+// mergeExecNotifiers applies defaults to the [[alerts.notifications.exec]]
+// entries, using raw to detect which keys each one sets.
+func mergeExecNotifiers(execs []ExecNotifierConfig, raw any) []ExecNotifierConfig {
+	tables, _ := raw.([]map[string]any)
+	merged := make([]ExecNotifierConfig, len(execs))
+	for i, e := range execs {
+		var keys map[string]any
+		if i < len(tables) {
+			keys = tables[i]
+		}
+		if _, exists := keys["name"]; !exists {
+			if fields := strings.Fields(e.Command); len(fields) > 0 {
+				e.Name = path.Base(fields[0])
+			}
+		}
+		if _, exists := keys["timeout_seconds"]; !exists {
+			e.TimeoutSeconds = 10
+		}
+		if _, exists := keys["max_concurrent"]; !exists {
+			e.MaxConcurrent = 2
+		}
+		if _, exists := keys["queue_size"]; !exists {
+			e.QueueSize = 100
+		}
+		merged[i] = e
+	}
+	return merged
+}
+
+// mergeBudgets applies defaults to the budgets declared in the file.
+func mergeBudgets(cfg *Config, tf *tomlFile, raw map[string]any) {
+	if len(tf.Budgets) == 0 {
+		return
+	}
+	section, _ := rawSection(raw, "budgets")
+	cfg.Budgets = make(map[string]BudgetConfig, len(tf.Budgets))
+	for name, b := range tf.Budgets {
+		keys, _ := section[name].(map[string]any)
+		if _, exists := keys["period"]; !exists {
+			b.Period = "month"
+		}
+		if _, exists := keys["alert_at"]; !exists {
+			b.AlertAt = slices.Clone(DefaultBudgetAlertAt)
+		}
+		cfg.Budgets[name] = b
+	}
+}
+
+// mergeEnforcement applies the [enforcement] section and defaults the
+// grace period and cooldown of its policies.
+func mergeEnforcement(cfg *Config, tf *tomlFile, raw map[string]any) {
+	if tf.Enforcement == nil {
+		return
+	}
+	section, _ := rawSection(raw, "enforcement")
+	if _, exists := section["audit_log"]; exists {
+		cfg.Enforcement.AuditLog = tf.Enforcement.AuditLog
+	}
+	if len(tf.Enforcement.Policies) == 0 {
+		return
+	}
+	policies, _ := section["policies"].(map[string]any)
+	cfg.Enforcement.Policies = make(map[string]EnforcementPolicy, len(tf.Enforcement.Policies))
+	for name, p := range tf.Enforcement.Policies {
+		keys, _ := policies[name].(map[string]any)
+		if _, exists := keys["grace_seconds"]; !exists {
+			p.GraceSeconds = DefaultEnforcementGraceSeconds
+		}
+		if _, exists := keys["cooldown_seconds"]; !exists {
+			p.CooldownSeconds = DefaultEnforcementCooldownSeconds
+		}
+		cfg.Enforcement.Policies[name] = p
+	}
+}
+
+// validateBudgets checks each budget's limit, period, timezone, patterns
+// and alert percentages, in name order.
+func validateBudgets(budgets map[string]BudgetConfig) []string {
+	names := make([]string, 0, len(budgets))
+	for name := range budgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		b := budgets[name]
+		if b.Limit <= 0 {
+			errs = append(errs, fmt.Sprintf("budgets.%s: limit must be positive, got %f", name, b.Limit))
+		}
+		if !slices.Contains(BudgetPeriods, b.Period) {
+			errs = append(errs, fmt.Sprintf("budgets.%s: unknown period %q (want one of %s)",
+				name, b.Period, strings.Join(BudgetPeriods, ", ")))
+		}
+		if _, err := time.LoadLocation(b.Timezone); err != nil {
+			errs = append(errs, fmt.Sprintf("budgets.%s: timezone: %v", name, err))
+		}
+		if _, err := filepath.Match(b.Project, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("budgets.%s: project: %v", name, err))
+		}
+		if _, err := path.Match(b.Model, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("budgets.%s: model: %v", name, err))
+		}
+		for _, pct := range b.AlertAt {
+			if pct < 1 {
+				errs = append(errs, fmt.Sprintf("budgets.%s: alert_at percentages must be positive, got %d", name, pct))
+			}
+		}
+	}
+	return errs
 }
 
 // rawSection returns the sub-map for a given top-level TOML section.
@@ -328,11 +887,15 @@ func LoadFromString(data string) (*LoadResult, error) {
 
 	// Detect unknown top-level keys.
 	knownTopLevel := map[string]bool{
-		"receiver": true,
-		"scanner":  true,
-		"alerts":   true,
-		"display":  true,
-		"models":   true,
+		"receiver":    true,
+		"scanner":     true,
+		"alerts":      true,
+		"display":     true,
+		"burnrate":    true,
+		"transcripts": true,
+		"budgets":     true,
+		"enforcement": true,
+		"models":      true,
 	}
 	for key := range raw {
 		if !knownTopLevel[key] {
@@ -347,6 +910,8 @@ func LoadFromString(data string) (*LoadResult, error) {
 	}
 
 	mergeFromRaw(&result.Config, &tf, raw)
+	mergeBudgets(&result.Config, &tf, raw)
+	mergeEnforcement(&result.Config, &tf, raw)
 	mergeModelsFromRaw(&result.Config, raw)
 
 	if err := validate(&result.Config); err != nil {
@@ -372,6 +937,21 @@ func validate(cfg *Config) error {
 	if cfg.Scanner.IntervalSeconds < 1 {
 		errs = append(errs, fmt.Sprintf("scanner interval_seconds must be positive, got %d", cfg.Scanner.IntervalSeconds))
 	}
+	if cfg.Scanner.Discovery != "auto" && cfg.Scanner.Discovery != "poll" {
+		errs = append(errs, fmt.Sprintf("scanner discovery must be \"auto\" or \"poll\", got %q", cfg.Scanner.Discovery))
+	}
+	if cfg.Scanner.FallbackIntervalSeconds < 1 {
+		errs = append(errs, fmt.Sprintf("scanner fallback_interval_seconds must be positive, got %d", cfg.Scanner.FallbackIntervalSeconds))
+	}
+	if cfg.BurnRate.RateWindowMinutes < 1 {
+		errs = append(errs, fmt.Sprintf("burnrate rate_window_minutes must be positive, got %d", cfg.BurnRate.RateWindowMinutes))
+	}
+	if cfg.BurnRate.TrendWindowMinutes < 1 {
+		errs = append(errs, fmt.Sprintf("burnrate trend_window_minutes must be positive, got %d", cfg.BurnRate.TrendWindowMinutes))
+	}
+	if cfg.Transcripts.PollIntervalSeconds < 1 {
+		errs = append(errs, fmt.Sprintf("transcripts poll_interval_seconds must be positive, got %d", cfg.Transcripts.PollIntervalSeconds))
+	}
 	if cfg.Alerts.CostSurgeThresholdPerHour <= 0 {
 		errs = append(errs, fmt.Sprintf("cost_surge_threshold_per_hour must be positive, got %f", cfg.Alerts.CostSurgeThresholdPerHour))
 	}
@@ -387,6 +967,9 @@ func validate(cfg *Config) error {
 	if cfg.Alerts.LoopDetectorWindowMinutes < 1 {
 		errs = append(errs, fmt.Sprintf("loop_detector_window_minutes must be positive, got %d", cfg.Alerts.LoopDetectorWindowMinutes))
 	}
+	if cfg.Alerts.LoopDetectorRepeatThreshold < 1 {
+		errs = append(errs, fmt.Sprintf("loop_detector_repeat_threshold must be positive, got %d", cfg.Alerts.LoopDetectorRepeatThreshold))
+	}
 	if cfg.Alerts.ErrorStormCount < 1 {
 		errs = append(errs, fmt.Sprintf("error_storm_count must be positive, got %d", cfg.Alerts.ErrorStormCount))
 	}
@@ -405,6 +988,29 @@ func validate(cfg *Config) error {
 	if cfg.Alerts.HighRejectionWindowMinutes < 1 {
 		errs = append(errs, fmt.Sprintf("high_rejection_window_minutes must be positive, got %d", cfg.Alerts.HighRejectionWindowMinutes))
 	}
+	if cfg.Alerts.ResolveAfterSeconds < 0 {
+		errs = append(errs, fmt.Sprintf("resolve_after_seconds must not be negative, got %d", cfg.Alerts.ResolveAfterSeconds))
+	}
+	if cfg.Alerts.HistorySize < 1 {
+		errs = append(errs, fmt.Sprintf("history_size must be positive, got %d", cfg.Alerts.HistorySize))
+	}
+	if cfg.Alerts.Anomaly.Sensitivity <= 0 {
+		errs = append(errs, fmt.Sprintf("anomaly.sensitivity must be positive, got %f", cfg.Alerts.Anomaly.Sensitivity))
+	}
+	if cfg.Alerts.Anomaly.WarmupSamples < 1 {
+		errs = append(errs, fmt.Sprintf("anomaly.warmup_samples must be positive, got %d", cfg.Alerts.Anomaly.WarmupSamples))
+	}
+	if cfg.Alerts.Anomaly.HalfLifeMinutes < 1 {
+		errs = append(errs, fmt.Sprintf("anomaly.half_life_minutes must be positive, got %d", cfg.Alerts.Anomaly.HalfLifeMinutes))
+	}
+	errs = append(errs, validateSecurity(cfg.Alerts.Security)...)
+	errs = append(errs, validateEgress(cfg.Alerts.Egress)...)
+	errs = append(errs, validateRuleModes("modes", cfg.Alerts.Modes)...)
+	errs = append(errs, validateRuleModes("suppress_modes", cfg.Alerts.SuppressModes)...)
+	errs = append(errs, validateCustomRules(cfg.Alerts.Custom)...)
+	errs = append(errs, validateWebhooks(cfg.Alerts.Notifications.Webhooks, alertRuleNames(cfg.Alerts))...)
+	errs = append(errs, validateExecNotifiers(cfg.Alerts.Notifications.Exec, alertRuleNames(cfg.Alerts))...)
+	errs = append(errs, validateRuleConfigs(cfg.Alerts)...)
 
 	// Positive buffer size.
 	if cfg.Display.EventBufferSize < 1 {
@@ -422,6 +1028,9 @@ func validate(cfg *Config) error {
 		errs = append(errs, fmt.Sprintf("cost_color_yellow_below must be positive, got %f", cfg.Display.CostColorYellowBelow))
 	}
 
+	errs = append(errs, validateBudgets(cfg.Budgets)...)
+	errs = append(errs, validateEnforcement(cfg.Enforcement, cfg.Budgets)...)
+
 	// Model context limits must be positive.
 	for model, limit := range cfg.Models {
 		if limit < 1 {
@@ -434,3 +1043,332 @@ func validate(cfg *Config) error {
 	}
 	return nil
 }
+
+// mergeRuleModes returns base with each rule in override replacing base's
+// entry for that rule.
+func mergeRuleModes(base, override map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(base)+len(override))
+	for rule, modes := range base {
+		merged[rule] = modes
+	}
+	for rule, modes := range override {
+		merged[rule] = modes
+	}
+	return merged
+}
+
+// mergeRuleConfigs returns base with each rule in override replacing
+// base's entry for that rule. raw is the [alerts.rules] table, used to
+// detect which keys each rule sets.
+func mergeRuleConfigs(base, override map[string]RuleConfig, raw map[string]any) map[string]RuleConfig {
+	merged := make(map[string]RuleConfig, len(base)+len(override))
+	for rule, rc := range base {
+		merged[rule] = rc
+	}
+	for rule, rc := range override {
+		keys, _ := raw[rule].(map[string]any)
+		if _, exists := keys["enabled"]; !exists {
+			rc.Enabled = true
+		}
+		if _, exists := keys["notify"]; exists && rc.Notify == nil {
+			rc.Notify = []string{}
+		}
+		merged[rule] = rc
+	}
+	return merged
+}
+
+// validateEnforcement checks that each policy has exactly one known
+// trigger, a known action and a non-negative grace period, in name order.
+func validateEnforcement(e EnforcementConfig, budgets map[string]BudgetConfig) []string {
+	names := make([]string, 0, len(e.Policies))
+	for name := range e.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		p := e.Policies[name]
+		switch {
+		case p.Budget == "" && p.Rule == "":
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: one of budget or rule is required", name))
+		case p.Budget != "" && p.Rule != "":
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: budget and rule are mutually exclusive", name))
+		case p.Budget != "" && p.Budget != "*":
+			if _, ok := budgets[p.Budget]; !ok {
+				errs = append(errs, fmt.Sprintf("enforcement.policies.%s: unknown budget %q", name, p.Budget))
+			}
+		case p.Rule != "" && !slices.Contains(EnforcementRules, p.Rule):
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: unsupported rule %q (want one of %s)",
+				name, p.Rule, strings.Join(EnforcementRules, ", ")))
+		}
+		if !slices.Contains(EnforcementActions, p.Action) {
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: unknown action %q (want one of %s)",
+				name, p.Action, strings.Join(EnforcementActions, ", ")))
+		}
+		if p.GraceSeconds < 0 {
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: grace_seconds must not be negative, got %d", name, p.GraceSeconds))
+		}
+		if p.CooldownSeconds < 0 {
+			errs = append(errs, fmt.Sprintf("enforcement.policies.%s: cooldown_seconds must not be negative, got %d", name, p.CooldownSeconds))
+		}
+	}
+	return errs
+}
+
+// validateCustomRules checks each custom rule's name, expression, window,
+// scope and severity, in order.
+func validateCustomRules(rules []CustomRuleConfig) []string {
+	var errs []string
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		where := fmt.Sprintf("alerts.custom[%d]", i)
+		if r.Name != "" {
+			where += " (" + r.Name + ")"
+		}
+		switch {
+		case r.Name == "":
+			errs = append(errs, where+": name is required")
+		case slices.Contains(BuiltinAlertRules, r.Name):
+			errs = append(errs, fmt.Sprintf("%s: name %q is a built-in rule", where, r.Name))
+		case seen[r.Name]:
+			errs = append(errs, fmt.Sprintf("%s: duplicate rule name %q", where, r.Name))
+		}
+		seen[r.Name] = true
+		if r.Expr == "" {
+			errs = append(errs, where+": expr is required")
+		} else if _, err := expr.Compile(r.Expr); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: expr: %v", where, err))
+		}
+		if r.WindowMinutes < 1 {
+			errs = append(errs, fmt.Sprintf("%s: window_minutes must be positive, got %d", where, r.WindowMinutes))
+		}
+		if !slices.Contains(CustomRuleScopes, r.Scope) {
+			errs = append(errs, fmt.Sprintf("%s: unknown scope %q (want one of %s)",
+				where, r.Scope, strings.Join(CustomRuleScopes, ", ")))
+		}
+		if !slices.Contains(AlertSeverities, r.Severity) {
+			errs = append(errs, fmt.Sprintf("%s: unknown severity %q (want one of %s)",
+				where, r.Severity, strings.Join(AlertSeverities, ", ")))
+		}
+	}
+	return errs
+}
+
+// alertRuleNames returns the names of the built-in and custom alert rules.
+func alertRuleNames(a AlertsConfig) []string {
+	names := slices.Clone(BuiltinAlertRules)
+	for _, r := range a.Custom {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// validateWebhooks reports problems with the configured webhooks. rules
+// are the alert rule names the webhooks may filter on.
+func validateWebhooks(webhooks []WebhookConfig, rules []string) []string {
+	var errs []string
+	seen := make(map[string]bool, len(webhooks))
+	for i, w := range webhooks {
+		where := fmt.Sprintf("alerts.notifications.webhooks[%d]", i)
+		if w.Name != "" {
+			where += " (" + w.Name + ")"
+		}
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("%s: url must be an http or https URL, got %q", where, w.URL))
+		}
+		switch {
+		case w.Name == "":
+			errs = append(errs, where+": name is required")
+		case seen[w.Name]:
+			errs = append(errs, fmt.Sprintf("%s: duplicate webhook name %q", where, w.Name))
+		}
+		seen[w.Name] = true
+		if !slices.Contains(WebhookPresets, w.Preset) {
+			errs = append(errs, fmt.Sprintf("%s: unknown preset %q (want one of %s)",
+				where, w.Preset, strings.Join(WebhookPresets, ", ")))
+		}
+		if w.Template != "" {
+			// The notifier provides json; a stand-in is enough to parse.
+			funcs := template.FuncMap{"json": func(any) string { return "" }}
+			if _, err := template.New("").Funcs(funcs).Parse(w.Template); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: template: %v", where, err))
+			}
+		}
+		errs = append(errs, validateAlertFilter(where, w.Severities, w.Rules, rules)...)
+		if w.TimeoutSeconds < 1 {
+			errs = append(errs, fmt.Sprintf("%s: timeout_seconds must be positive, got %d", where, w.TimeoutSeconds))
+		}
+		if w.MaxRetries < 0 {
+			errs = append(errs, fmt.Sprintf("%s: max_retries must not be negative, got %d", where, w.MaxRetries))
+		}
+		if w.QueueSize < 1 {
+			errs = append(errs, fmt.Sprintf("%s: queue_size must be positive, got %d", where, w.QueueSize))
+		}
+	}
+	return errs
+}
+
+// validateExecNotifiers reports problems with the configured exec
+// notifiers. rules are the alert rule names they may filter on.
+func validateExecNotifiers(execs []ExecNotifierConfig, rules []string) []string {
+	var errs []string
+	seen := make(map[string]bool, len(execs))
+	for i, e := range execs {
+		where := fmt.Sprintf("alerts.notifications.exec[%d]", i)
+		if e.Name != "" {
+			where += " (" + e.Name + ")"
+		}
+		if strings.TrimSpace(e.Command) == "" {
+			errs = append(errs, where+": command is required")
+		}
+		if e.Name != "" && seen[e.Name] {
+			errs = append(errs, fmt.Sprintf("%s: duplicate notifier name %q", where, e.Name))
+		}
+		seen[e.Name] = true
+		errs = append(errs, validateAlertFilter(where, e.Severities, e.Rules, rules)...)
+		if e.TimeoutSeconds < 1 {
+			errs = append(errs, fmt.Sprintf("%s: timeout_seconds must be positive, got %d", where, e.TimeoutSeconds))
+		}
+		if e.MaxConcurrent < 1 {
+			errs = append(errs, fmt.Sprintf("%s: max_concurrent must be positive, got %d", where, e.MaxConcurrent))
+		}
+		if e.QueueSize < 1 {
+			errs = append(errs, fmt.Sprintf("%s: queue_size must be positive, got %d", where, e.QueueSize))
+		}
+	}
+	return errs
+}
+
+// validateAlertFilter reports severities and rule names in a notifier's
+// filter that do not exist. known are the alert rule names.
+func validateAlertFilter(where string, severities, rules, known []string) []string {
+	var errs []string
+	for _, sev := range severities {
+		if !slices.Contains(AlertSeverities, sev) {
+			errs = append(errs, fmt.Sprintf("%s: unknown severity %q", where, sev))
+		}
+	}
+	for _, rule := range rules {
+		if !slices.Contains(known, rule) {
+			errs = append(errs, fmt.Sprintf("%s: unknown rule %q", where, rule))
+		}
+	}
+	return errs
+}
+
+// validateRuleConfigs checks that each [alerts.rules] entry names a known
+// rule and has valid overrides, in rule order.
+func validateRuleConfigs(a AlertsConfig) []string {
+	known := alertRuleNames(a)
+	notifiers := []string{"desktop"}
+	for _, w := range a.Notifications.Webhooks {
+		notifiers = append(notifiers, w.Name)
+	}
+	for _, e := range a.Notifications.Exec {
+		notifiers = append(notifiers, e.Name)
+	}
+
+	rules := make([]string, 0, len(a.Rules))
+	for rule := range a.Rules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	var errs []string
+	for _, rule := range rules {
+		rc := a.Rules[rule]
+		where := "alerts.rules." + rule
+		if !slices.Contains(known, rule) {
+			errs = append(errs, fmt.Sprintf("%s: unknown rule", where))
+		}
+		if rc.Severity != "" && !slices.Contains(AlertSeverities, rc.Severity) {
+			errs = append(errs, fmt.Sprintf("%s: unknown severity %q", where, rc.Severity))
+		}
+		if rc.DedupSeconds < 0 {
+			errs = append(errs, fmt.Sprintf("%s: dedup_seconds must not be negative, got %d", where, rc.DedupSeconds))
+		}
+		for _, n := range rc.Notify {
+			if !slices.Contains(notifiers, n) {
+				errs = append(errs, fmt.Sprintf("%s: unknown notifier %q (want desktop or a webhook or exec name)", where, n))
+			}
+		}
+		for _, pattern := range slices.Concat(rc.IncludeCWD, rc.ExcludeCWD) {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: cwd pattern %q: %v", where, pattern, err))
+			}
+		}
+		for _, pattern := range slices.Concat(rc.IncludeModels, rc.ExcludeModels) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: model pattern %q: %v", where, pattern, err))
+			}
+		}
+		for _, mode := range slices.Concat(rc.IncludeModes, rc.ExcludeModes) {
+			if !slices.Contains(InvocationModes, mode) {
+				errs = append(errs, fmt.Sprintf("%s: unknown mode %q (want one of %s)",
+					where, mode, strings.Join(InvocationModes, ", ")))
+			}
+		}
+	}
+	return errs
+}
+
+// validateSecurity reports patterns in [alerts.security] that do not
+// compile.
+func validateSecurity(sc SecurityConfig) []string {
+	var errs []string
+	for _, pattern := range sc.DangerousCommands {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("alerts.security.dangerous_commands: pattern %q: %v", pattern, err))
+		}
+	}
+	globs := []struct {
+		key      string
+		patterns []string
+	}{
+		{"sensitive_paths", sc.SensitivePaths},
+		{"allowed_write_paths", sc.AllowedWritePaths},
+	}
+	for _, g := range globs {
+		for _, pattern := range g.patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Sprintf("alerts.security.%s: pattern %q: %v", g.key, pattern, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateEgress reports allowlist entries in [alerts.egress] that are not
+// domains or domain globs.
+func validateEgress(ec EgressConfig) []string {
+	var errs []string
+	for _, entry := range ec.Allowlist {
+		if _, err := path.Match(entry, ""); err != nil || entry == "" || strings.ContainsAny(entry, "/: ") {
+			errs = append(errs, fmt.Sprintf("alerts.egress.allowlist: %q is not a domain", entry))
+		}
+	}
+	return errs
+}
+
+// validateRuleModes reports mode names in an [alerts.<key>] table that are
+// not invocation modes, in rule order.
+func validateRuleModes(key string, m map[string][]string) []string {
+	rules := make([]string, 0, len(m))
+	for rule := range m {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	var errs []string
+	for _, rule := range rules {
+		for _, mode := range m[rule] {
+			if !slices.Contains(InvocationModes, mode) {
+				errs = append(errs, fmt.Sprintf("alerts %s.%s: unknown mode %q (want one of %s)",
+					key, rule, mode, strings.Join(InvocationModes, ", ")))
+			}
+		}
+	}
+	return errs
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +44,9 @@ func TestConfigParser_Defaults(t *testing.T) {
 	if cfg.Alerts.LoopDetectorWindowMinutes != 5 {
 		t.Errorf("default loop_detector_window_minutes: want 5, got %d", cfg.Alerts.LoopDetectorWindowMinutes)
 	}
+	if cfg.Alerts.LoopDetectorRepeatThreshold != 8 {
+		t.Errorf("default loop_detector_repeat_threshold: want 8, got %d", cfg.Alerts.LoopDetectorRepeatThreshold)
+	}
 	if cfg.Alerts.ErrorStormCount != 10 {
 		t.Errorf("default error_storm_count: want 10, got %d", cfg.Alerts.ErrorStormCount)
 	}
@@ -128,6 +133,7 @@ func TestConfigParser_PartialConfig(t *testing.T) {
 	tomlData := `
 [scanner]
 interval_seconds = 10
+discovery = "poll"
 
 [alerts]
 cost_surge_threshold_per_hour = 5.00
@@ -144,6 +150,12 @@ error_storm_count = 20
 	if cfg.Scanner.IntervalSeconds != 10 {
 		t.Errorf("interval_seconds: want 10, got %d", cfg.Scanner.IntervalSeconds)
 	}
+	if cfg.Scanner.Discovery != "poll" {
+		t.Errorf("discovery: want poll, got %q", cfg.Scanner.Discovery)
+	}
+	if cfg.Scanner.FallbackIntervalSeconds != 30 {
+		t.Errorf("fallback_interval_seconds default: want 30, got %d", cfg.Scanner.FallbackIntervalSeconds)
+	}
 	if cfg.Alerts.CostSurgeThresholdPerHour != 5.00 {
 		t.Errorf("cost_surge_threshold_per_hour: want 5.00, got %f", cfg.Alerts.CostSurgeThresholdPerHour)
 	}
@@ -164,6 +176,280 @@ error_storm_count = 20
 	if cfg.Display.EventBufferSize != 1000 {
 		t.Errorf("event_buffer_size default: want 1000, got %d", cfg.Display.EventBufferSize)
 	}
+	if !cfg.Transcripts.Enabled || cfg.Transcripts.PollIntervalSeconds != 5 {
+		t.Errorf("transcripts defaults: want enabled every 5s, got %+v", cfg.Transcripts)
+	}
+}
+
+func TestConfigParser_Transcripts(t *testing.T) {
+	result, err := LoadFromString(`
+[transcripts]
+enabled = false
+dir = "/srv/claude/projects"
+poll_interval_seconds = 15
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+	tc := result.Config.Transcripts
+	if tc.Enabled || tc.Dir != "/srv/claude/projects" || tc.PollIntervalSeconds != 15 {
+		t.Errorf("transcripts = %+v", tc)
+	}
+}
+
+func TestConfigParser_BurnRate(t *testing.T) {
+	def := DefaultConfig().BurnRate
+	if def.RateWindowMinutes != 5 || def.TrendWindowMinutes != 5 {
+		t.Errorf("default burnrate = %+v, want 5-minute windows", def)
+	}
+
+	result, err := LoadFromString(`
+[burnrate]
+rate_window_minutes = 15
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+	br := result.Config.BurnRate
+	if br.RateWindowMinutes != 15 || br.TrendWindowMinutes != 5 {
+		t.Errorf("burnrate = %+v, want rate 15 and default trend 5", br)
+	}
+
+	if _, err := LoadFromString("[burnrate]\ntrend_window_minutes = 0\n"); err == nil ||
+		!strings.Contains(err.Error(), "trend_window_minutes") {
+		t.Errorf("zero trend window should be rejected, got %v", err)
+	}
+}
+
+func TestConfigParser_Budgets(t *testing.T) {
+	result, err := LoadFromString(`
+[budgets.team]
+limit = 500.0
+
+[budgets.opus-daily]
+limit = 20
+period = "day"
+timezone = "Europe/London"
+project = "~/work/*"
+model = "claude-opus-*"
+user_id = "u-123"
+alert_at = [90, 100]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	team := result.Config.Budgets["team"]
+	if team.Limit != 500 || team.Period != "month" || !slices.Equal(team.AlertAt, DefaultBudgetAlertAt) {
+		t.Errorf("team = %+v, want monthly with default alert_at", team)
+	}
+	daily := result.Config.Budgets["opus-daily"]
+	if daily.Period != "day" || daily.Timezone != "Europe/London" || daily.Model != "claude-opus-*" ||
+		daily.UserID != "u-123" || !slices.Equal(daily.AlertAt, []int{90, 100}) {
+		t.Errorf("opus-daily = %+v", daily)
+	}
+	if len(DefaultConfig().Budgets) != 0 {
+		t.Error("no budgets should be configured by default")
+	}
+}
+
+func TestConfigParser_BudgetsInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[budgets.bad]
+limit = 0
+period = "year"
+timezone = "Mars/Olympus"
+model = "claude-["
+alert_at = [0]
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		"budgets.bad: limit must be positive",
+		`budgets.bad: unknown period "year"`,
+		"budgets.bad: timezone",
+		"budgets.bad: model",
+		"budgets.bad: alert_at percentages must be positive",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_Enforcement(t *testing.T) {
+	result, err := LoadFromString(`
+[budgets.team]
+limit = 500.0
+
+[enforcement]
+audit_log = "/var/log/cc-top-enforcement.log"
+
+[enforcement.policies.pause-over-budget]
+budget = "team"
+action = "pause"
+
+[enforcement.policies.stop-expensive]
+rule = "SessionCost"
+action = "terminate"
+grace_seconds = 0
+cooldown_seconds = 0
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	e := result.Config.Enforcement
+	if e.AuditLog != "/var/log/cc-top-enforcement.log" {
+		t.Errorf("audit_log = %q", e.AuditLog)
+	}
+	pause := e.Policies["pause-over-budget"]
+	if pause.Budget != "team" || pause.Action != "pause" || pause.GraceSeconds != DefaultEnforcementGraceSeconds ||
+		pause.CooldownSeconds != DefaultEnforcementCooldownSeconds {
+		t.Errorf("pause-over-budget = %+v, want the default grace period and cooldown", pause)
+	}
+	stop := e.Policies["stop-expensive"]
+	if stop.Rule != "SessionCost" || stop.Action != "terminate" || stop.GraceSeconds != 0 || stop.CooldownSeconds != 0 {
+		t.Errorf("stop-expensive = %+v, want an explicit zero grace period and cooldown", stop)
+	}
+	if len(DefaultConfig().Enforcement.Policies) != 0 {
+		t.Error("enforcement should be opt-in")
+	}
+}
+
+func TestConfigParser_EnforcementInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[enforcement.policies.none]
+action = "pause"
+
+[enforcement.policies.both]
+budget = "*"
+rule = "SessionCost"
+action = "interrupt"
+
+[enforcement.policies.missing]
+budget = "team"
+action = "suspend"
+grace_seconds = -1
+cooldown_seconds = -5
+
+[enforcement.policies.rule]
+rule = "LoopDetector"
+action = "terminate"
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		"enforcement.policies.none: one of budget or rule is required",
+		"enforcement.policies.both: budget and rule are mutually exclusive",
+		`enforcement.policies.missing: unknown budget "team"`,
+		`enforcement.policies.missing: unknown action "suspend"`,
+		"enforcement.policies.missing: grace_seconds must not be negative",
+		"enforcement.policies.missing: cooldown_seconds must not be negative",
+		`enforcement.policies.rule: unsupported rule "LoopDetector"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_CustomRules(t *testing.T) {
+	result, err := LoadFromString(`
+[[alerts.custom]]
+name = "WebFetchStorm"
+expr = 'count(event == "tool_result" and tool_name == "WebFetch") > 20'
+window_minutes = 10
+severity = "critical"
+message = "{value} WebFetch calls in {session}"
+
+[[alerts.custom]]
+name = "FleetErrors"
+expr = 'count(event == "api_error") > 5'
+scope = "global"
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules := result.Config.Alerts.Custom
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 custom rules, got %d", len(rules))
+	}
+	want := []CustomRuleConfig{
+		{
+			Name:          "WebFetchStorm",
+			Expr:          `count(event == "tool_result" and tool_name == "WebFetch") > 20`,
+			WindowMinutes: 10,
+			Scope:         "session",
+			Severity:      "critical",
+			Message:       "{value} WebFetch calls in {session}",
+		},
+		{
+			Name:          "FleetErrors",
+			Expr:          `count(event == "api_error") > 5`,
+			WindowMinutes: 5,
+			Scope:         "global",
+			Severity:      "warning",
+			Message:       `{rule}: count(event == "api_error") > 5 (value {value})`,
+		},
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+}
+
+func TestConfigParser_CustomRulesInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[[alerts.custom]]
+expr = 'count(event == "x") > 1'
+
+[[alerts.custom]]
+name = "LoopDetector"
+expr = 'count(event == "x") > 1'
+
+[[alerts.custom]]
+name = "Twice"
+expr = 'count(event == "x") > 1'
+
+[[alerts.custom]]
+name = "Twice"
+expr = 'count(event = "x") > 1'
+window_minutes = 0
+scope = "project"
+severity = "info"
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		"alerts.custom[0]: name is required",
+		`alerts.custom[1] (LoopDetector): name "LoopDetector" is a built-in rule`,
+		`alerts.custom[3] (Twice): duplicate rule name "Twice"`,
+		`alerts.custom[3] (Twice): expr: column 13: unexpected character '='`,
+		"alerts.custom[3] (Twice): window_minutes must be positive",
+		`alerts.custom[3] (Twice): unknown scope "project"`,
+		`alerts.custom[3] (Twice): unknown severity "info"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
 }
 
 func TestConfigParser_InvalidValue(t *testing.T) {
@@ -190,6 +476,21 @@ http_port = 0`,
 			name: "negative scanner interval",
 			toml: `[scanner]
 interval_seconds = -5`,
+		},
+		{
+			name: "unknown scanner discovery mode",
+			toml: `[scanner]
+discovery = "inotify"`,
+		},
+		{
+			name: "zero scanner fallback interval",
+			toml: `[scanner]
+fallback_interval_seconds = 0`,
+		},
+		{
+			name: "zero transcripts poll interval",
+			toml: `[transcripts]
+poll_interval_seconds = 0`,
 		},
 		{
 			name: "zero event_buffer_size",
@@ -452,3 +753,377 @@ high_rejection_window_minutes = 0`,
 		})
 	}
 }
+
+func TestConfigParser_AlertLifecycle(t *testing.T) {
+	result, err := LoadFromString(`[alerts]
+resolve_after_seconds = 0
+history_size = 50`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := result.Config.Alerts; a.ResolveAfterSeconds != 0 || a.HistorySize != 50 {
+		t.Errorf("resolve_after_seconds = %d, history_size = %d, want 0 and 50", a.ResolveAfterSeconds, a.HistorySize)
+	}
+
+	defaults := DefaultConfig().Alerts
+	if defaults.ResolveAfterSeconds != 60 || defaults.HistorySize != 500 {
+		t.Errorf("defaults = %d, %d, want 60 and 500", defaults.ResolveAfterSeconds, defaults.HistorySize)
+	}
+
+	for _, toml := range []string{"[alerts]\nresolve_after_seconds = -1", "[alerts]\nhistory_size = 0"} {
+		if _, err := LoadFromString(toml); err == nil {
+			t.Errorf("%q: expected validation error", toml)
+		}
+	}
+}
+
+func TestConfigParser_Anomaly(t *testing.T) {
+	result, err := LoadFromString(`[alerts.anomaly]
+sensitivity = 2.5
+warmup_samples = 10`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := result.Config.Alerts.Anomaly; a.Sensitivity != 2.5 || a.WarmupSamples != 10 || a.HalfLifeMinutes != 60 {
+		t.Errorf("anomaly = %+v, want sensitivity 2.5, warmup 10 and the default half-life", a)
+	}
+
+	for _, toml := range []string{
+		"[alerts.anomaly]\nsensitivity = 0",
+		"[alerts.anomaly]\nwarmup_samples = 0",
+		"[alerts.anomaly]\nhalf_life_minutes = -5",
+	} {
+		if _, err := LoadFromString(toml); err == nil {
+			t.Errorf("%q: expected validation error", toml)
+		}
+	}
+}
+
+func TestConfigParser_Security(t *testing.T) {
+	defaults := DefaultConfig().Alerts.Security
+	if len(defaults.DangerousCommands) == 0 || !slices.Contains(defaults.SensitivePaths, "~/.ssh") {
+		t.Errorf("security defaults = %+v", defaults)
+	}
+
+	result, err := LoadFromString(`[alerts.security]
+dangerous_commands = ['\bterraform\s+destroy\b']
+sensitive_paths = []`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sc := result.Config.Alerts.Security
+	if !slices.Equal(sc.DangerousCommands, []string{`\bterraform\s+destroy\b`}) {
+		t.Errorf("dangerous_commands = %q", sc.DangerousCommands)
+	}
+	if len(sc.SensitivePaths) != 0 {
+		t.Errorf("sensitive_paths = %q, want none", sc.SensitivePaths)
+	}
+	if !slices.Equal(sc.AllowedWritePaths, defaults.AllowedWritePaths) {
+		t.Errorf("allowed_write_paths = %q, want the defaults", sc.AllowedWritePaths)
+	}
+
+	_, err = LoadFromString(`[alerts.security]
+dangerous_commands = ['rm (-rf']
+allowed_write_paths = ['/srv/[']`)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"alerts.security.dangerous_commands: pattern \"rm (-rf\"",
+		"alerts.security.allowed_write_paths: pattern \"/srv/[\"",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_Egress(t *testing.T) {
+	if a := DefaultConfig().Alerts.Egress.Allowlist; len(a) != 0 {
+		t.Errorf("default allowlist = %q, want none", a)
+	}
+
+	result, err := LoadFromString(`[alerts.egress]
+allowlist = ["github.com", "*.anthropic.com"]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := result.Config.Alerts.Egress.Allowlist; !slices.Equal(a, []string{"github.com", "*.anthropic.com"}) {
+		t.Errorf("allowlist = %q", a)
+	}
+
+	_, err = LoadFromString(`[alerts.egress]
+allowlist = ["https://github.com/org", "", "[bad"]`)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`alerts.egress.allowlist: "https://github.com/org" is not a domain`,
+		`alerts.egress.allowlist: "" is not a domain`,
+		`alerts.egress.allowlist: "[bad" is not a domain`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_Webhooks(t *testing.T) {
+	result, err := LoadFromString(`
+[alerts.notifications]
+
+[[alerts.notifications.webhooks]]
+url = "https://hooks.slack.com/services/T000/B000/XXX"
+preset = "slack"
+severities = ["critical"]
+
+[[alerts.notifications.webhooks]]
+name = "ops"
+url = "http://localhost:8080/alerts"
+template = '{"title": {{json .Rule}}}'
+headers = { Authorization = "Bearer secret" }
+rules = ["Budget", "FleetErrors"]
+timeout_seconds = 3
+max_retries = 0
+queue_size = 5
+
+[[alerts.custom]]
+name = "FleetErrors"
+expr = 'count(event == "api_error") > 5'
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	n := result.Config.Alerts.Notifications
+	if !n.SystemNotify {
+		t.Error("system_notify should keep its default when not set")
+	}
+	if len(n.Webhooks) != 2 {
+		t.Fatalf("expected 2 webhooks, got %d", len(n.Webhooks))
+	}
+	slack := n.Webhooks[0]
+	if slack.Name != "hooks.slack.com" || slack.Preset != "slack" || slack.TimeoutSeconds != 10 ||
+		slack.MaxRetries != 3 || slack.QueueSize != 100 {
+		t.Errorf("slack webhook defaults = %+v", slack)
+	}
+	ops := n.Webhooks[1]
+	if ops.Name != "ops" || ops.Preset != "json" || ops.Headers["Authorization"] != "Bearer secret" ||
+		ops.TimeoutSeconds != 3 || ops.MaxRetries != 0 || ops.QueueSize != 5 {
+		t.Errorf("ops webhook = %+v", ops)
+	}
+}
+
+func TestConfigParser_WebhooksInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[[alerts.notifications.webhooks]]
+url = "ftp://example.com"
+preset = "teams"
+template = '{{json .Rule'
+severities = ["info"]
+rules = ["NoSuchRule"]
+timeout_seconds = 0
+max_retries = -1
+queue_size = 0
+
+[[alerts.notifications.webhooks]]
+name = "example.com"
+url = "https://example.com/hook"
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		`alerts.notifications.webhooks[0] (example.com): url must be an http or https URL, got "ftp://example.com"`,
+		`alerts.notifications.webhooks[0] (example.com): unknown preset "teams"`,
+		"alerts.notifications.webhooks[0] (example.com): template: ",
+		`alerts.notifications.webhooks[0] (example.com): unknown severity "info"`,
+		`alerts.notifications.webhooks[0] (example.com): unknown rule "NoSuchRule"`,
+		"alerts.notifications.webhooks[0] (example.com): timeout_seconds must be positive, got 0",
+		"alerts.notifications.webhooks[0] (example.com): max_retries must not be negative, got -1",
+		"alerts.notifications.webhooks[0] (example.com): queue_size must be positive, got 0",
+		`alerts.notifications.webhooks[1] (example.com): duplicate webhook name "example.com"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_ExecNotifiers(t *testing.T) {
+	result, err := LoadFromString(`
+[[alerts.notifications.exec]]
+command = "/usr/local/bin/ntfy publish alerts"
+
+[[alerts.notifications.exec]]
+name = "tmux"
+command = "tmux set -g status-right \"$CC_TOP_RULE\""
+rules = ["CostSurge"]
+severities = ["critical"]
+timeout_seconds = 2
+max_concurrent = 1
+queue_size = 10
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	execs := result.Config.Alerts.Notifications.Exec
+	if len(execs) != 2 {
+		t.Fatalf("expected 2 exec notifiers, got %d", len(execs))
+	}
+	if e := execs[0]; e.Name != "ntfy" || e.TimeoutSeconds != 10 || e.MaxConcurrent != 2 || e.QueueSize != 100 {
+		t.Errorf("ntfy defaults = %+v", e)
+	}
+	if e := execs[1]; e.Name != "tmux" || e.TimeoutSeconds != 2 || e.MaxConcurrent != 1 || e.QueueSize != 10 ||
+		len(e.Rules) != 1 || len(e.Severities) != 1 {
+		t.Errorf("tmux = %+v", e)
+	}
+}
+
+func TestConfigParser_ExecNotifiersInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[[alerts.notifications.exec]]
+name = "empty"
+command = " "
+rules = ["Nope"]
+max_concurrent = 0
+
+[[alerts.notifications.exec]]
+command = "notify.sh"
+timeout_seconds = 0
+queue_size = 0
+
+[[alerts.notifications.exec]]
+command = "/opt/bin/notify.sh --loud"
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		"alerts.notifications.exec[0] (empty): command is required",
+		`alerts.notifications.exec[0] (empty): unknown rule "Nope"`,
+		"alerts.notifications.exec[0] (empty): max_concurrent must be positive, got 0",
+		"alerts.notifications.exec[1] (notify.sh): timeout_seconds must be positive, got 0",
+		"alerts.notifications.exec[1] (notify.sh): queue_size must be positive, got 0",
+		`alerts.notifications.exec[2] (notify.sh): duplicate notifier name "notify.sh"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_RuleOverrides(t *testing.T) {
+	result, err := LoadFromString(`
+[[alerts.notifications.exec]]
+name = "ntfy"
+command = "ntfy publish cc-top"
+
+[alerts.rules.StaleSession]
+enabled = false
+
+[alerts.rules.ErrorStorm]
+severity = "critical"
+dedup_seconds = 600
+notify = ["desktop", "ntfy"]
+include_cwd = ["/work/*"]
+exclude_models = ["*haiku*"]
+exclude_modes = ["headless"]
+
+[alerts.rules.CostSurge]
+notify = []
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules := result.Config.Alerts.Rules
+	if rules["StaleSession"].Enabled {
+		t.Error("StaleSession should be disabled")
+	}
+	storm := rules["ErrorStorm"]
+	if !storm.Enabled || storm.Severity != "critical" || storm.DedupSeconds != 600 ||
+		strings.Join(storm.Notify, ",") != "desktop,ntfy" || storm.IncludeCWD[0] != "/work/*" ||
+		storm.ExcludeModels[0] != "*haiku*" || storm.ExcludeModes[0] != "headless" {
+		t.Errorf("ErrorStorm = %+v", storm)
+	}
+	if surge := rules["CostSurge"]; !surge.Enabled || surge.Notify == nil || len(surge.Notify) != 0 {
+		t.Errorf("CostSurge notify = %#v, want an empty, non-nil list", surge.Notify)
+	}
+	if _, ok := rules["LoopDetector"]; ok {
+		t.Error("rules without a block should have no entry")
+	}
+}
+
+func TestConfigParser_RuleOverridesInvalid(t *testing.T) {
+	_, err := LoadFromString(`
+[alerts.rules.Nope]
+enabled = true
+
+[alerts.rules.ErrorStorm]
+severity = "fatal"
+dedup_seconds = -1
+notify = ["pager"]
+include_cwd = ["[/work"]
+include_models = ["[opus"]
+exclude_modes = ["batch"]
+`)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{
+		"alerts.rules.Nope: unknown rule",
+		`alerts.rules.ErrorStorm: unknown severity "fatal"`,
+		"alerts.rules.ErrorStorm: dedup_seconds must not be negative, got -1",
+		`alerts.rules.ErrorStorm: unknown notifier "pager"`,
+		`alerts.rules.ErrorStorm: cwd pattern "[/work"`,
+		`alerts.rules.ErrorStorm: model pattern "[opus"`,
+		`alerts.rules.ErrorStorm: unknown mode "batch"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestConfigParser_AlertModes(t *testing.T) {
+	result, err := LoadFromString("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.Config.Alerts.SuppressModes["StaleSession"]; len(got) != 2 {
+		t.Errorf("default suppress_modes.StaleSession: want [headless sdk], got %v", got)
+	}
+
+	tomlData := `
+[alerts.modes]
+HighRejection = ["interactive", "ide"]
+
+[alerts.suppress_modes]
+ErrorStorm = ["sdk"]
+`
+	result, err = LoadFromString(tomlData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alerts := result.Config.Alerts
+	if got := alerts.Modes["HighRejection"]; len(got) != 2 || got[0] != "interactive" || got[1] != "ide" {
+		t.Errorf("modes.HighRejection: want [interactive ide], got %v", got)
+	}
+	if got := alerts.SuppressModes["ErrorStorm"]; len(got) != 1 || got[0] != "sdk" {
+		t.Errorf("suppress_modes.ErrorStorm: want [sdk], got %v", got)
+	}
+	// Rules not mentioned keep their defaults.
+	if got := alerts.SuppressModes["StaleSession"]; len(got) != 2 {
+		t.Errorf("suppress_modes.StaleSession should keep its default, got %v", got)
+	}
+}
+
+func TestConfigParser_AlertModesInvalid(t *testing.T) {
+	tomlData := `
+[alerts.suppress_modes]
+StaleSession = ["batch"]
+`
+	_, err := LoadFromString(tomlData)
+	if err == nil || !strings.Contains(err.Error(), `unknown mode "batch"`) {
+		t.Errorf("expected unknown mode error, got %v", err)
+	}
+}
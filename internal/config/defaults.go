@@ -10,7 +10,9 @@ func DefaultConfig() Config {
 			Bind:     "127.0.0.1",
 		},
 		Scanner: ScannerConfig{
-			IntervalSeconds: 5,
+			IntervalSeconds:         5,
+			Discovery:               "auto",
+			FallbackIntervalSeconds: 30,
 		},
 		Alerts: AlertsConfig{
 			CostSurgeThresholdPerHour:    2.00,
@@ -19,14 +21,44 @@ func DefaultConfig() Config {
 			RunawayTokenSustainedMinutes: 2,
 			LoopDetectorThreshold:        3,
 			LoopDetectorWindowMinutes:    5,
+			LoopDetectorRepeatThreshold:  8,
 			ErrorStormCount:              10,
 			StaleSessionHours:            2,
 			ContextPressurePercent:       80,
 			HighRejectionPercent:         50,
 			HighRejectionWindowMinutes:   5,
+			ResolveAfterSeconds:          60,
+			HistorySize:                  500,
 			Notifications: NotificationConfig{
 				SystemNotify: true,
 			},
+			Anomaly: AnomalyConfig{
+				Sensitivity:     3,
+				WarmupSamples:   30,
+				HalfLifeMinutes: 60,
+			},
+			Security: SecurityConfig{
+				DangerousCommands: []string{
+					`\brm\s+(-\S+\s+)+(/\*?|~/?\*?|\$HOME/?)(\s|[;&|]|$)`,
+					`\bgit\s+push\b.*\s(--force\b|-f\b)`,
+					`\bgit\s+reset\b.*\s--hard\b`,
+					`(?i)\bdrop\s+(table|database|schema)\b`,
+					`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|z|da)?sh\b`,
+					`\bmkfs(\.\w+)?\s`,
+					`\bdd\b.*\bof=/dev/`,
+				},
+				SensitivePaths: []string{
+					"~/.ssh", "~/.aws/credentials", "~/.gnupg", "~/.netrc",
+					"~/.kube/config", ".env", "*.pem",
+				},
+				// Temporary files and Claude Code's own state.
+				AllowedWritePaths: []string{"/tmp", "/private/tmp", "/var/folders", "~/.claude"},
+			},
+			// Scripted and SDK runs are expected to sit idle between
+			// invocations, so a stale session is not worth an alert.
+			SuppressModes: map[string][]string{
+				"StaleSession": {"headless", "sdk"},
+			},
 		},
 		Display: DisplayConfig{
 			EventBufferSize:      1000,
@@ -34,6 +66,14 @@ func DefaultConfig() Config {
 			CostColorGreenBelow:  0.50,
 			CostColorYellowBelow: 2.00,
 		},
+		BurnRate: BurnRateConfig{
+			RateWindowMinutes:  5,
+			TrendWindowMinutes: 5,
+		},
+		Transcripts: TranscriptsConfig{
+			Enabled:             true,
+			PollIntervalSeconds: 5,
+		},
 		Models: defaultModelContextLimits(),
 		Pricing: map[string][4]float64{
 			"claude-sonnet-4-5-20250929": {3.00, 15.00, 0.30, 3.75},
@@ -46,9 +46,14 @@ type Correlator struct {
 	// pidToSession is the final correlation result.
 	pidToSession map[int]string
 
-	// sessionToPID is the reverse index.
+	// sessionToPID is the reverse index. Entries outlive a recycled PID so
+	// the earlier session is never matched again.
 	sessionToPID map[string]int
 
+	// pidStart records the start time of the process currently holding each
+	// PID, so a recycled PID is recognised as a different process.
+	pidStart map[int]time.Time
+
 	// newPIDs tracks recently discovered PIDs with their first-seen timestamp,
 	// used for the timing heuristic fallback.
 	newPIDs map[int]time.Time
@@ -67,6 +72,7 @@ func NewCorrelator(portMapper PortMapper, receiverPort int) *Correlator {
 		portToSession: make(map[int]string),
 		pidToSession:  make(map[int]string),
 		sessionToPID:  make(map[string]int),
+		pidStart:      make(map[int]time.Time),
 		newPIDs:       make(map[int]time.Time),
 		newSessions:   make(map[string]time.Time),
 	}
@@ -89,12 +95,19 @@ func (c *Correlator) RecordConnection(sourcePort int, sessionID string) {
 	}
 }
 
-// RecordPID records that a new Claude Code PID was discovered by the
-// process scanner. This is called when the scanner finds a new PID.
-func (c *Correlator) RecordPID(pid int) {
+// RecordPID records that a new Claude Code process, identified by PID and
+// start time, was discovered by the process scanner. If the PID previously
+// belonged to a different process, the stale correlation is dropped so the
+// new process is matched afresh. A zero startTime means it is unknown.
+func (c *Correlator) RecordPID(pid int, startTime time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if prev, ok := c.pidStart[pid]; ok && !sameStart(prev, startTime) {
+		delete(c.pidToSession, pid)
+	}
+	c.pidStart[pid] = startTime
+
 	if _, exists := c.pidToSession[pid]; !exists {
 		c.newPIDs[pid] = time.Now()
 	}
@@ -140,6 +153,11 @@ func (c *Correlator) Correlate(activePIDs []int) {
 			// port matches our receiver port.
 			if remotePort == c.receiverPort {
 				if sessionID, ok := c.portToSession[localPort]; ok {
+					if _, taken := c.sessionToPID[sessionID]; taken {
+						// A stale port entry from a session that already
+						// belongs to another (possibly exited) process.
+						continue
+					}
 					c.pidToSession[pid] = sessionID
 					c.sessionToPID[sessionID] = pid
 					delete(c.newPIDs, pid)
@@ -191,6 +209,12 @@ func (c *Correlator) Correlate(activePIDs []int) {
 	}
 }
 
+// sameStart reports whether two start times may belong to the same process.
+// An unknown (zero) start time matches anything.
+func sameStart(a, b time.Time) bool {
+	return a.IsZero() || b.IsZero() || a.Equal(b)
+}
+
 // GetCorrelation returns the current PID-to-session ID mapping.
 // The returned map is a snapshot (safe to read concurrently).
 func (c *Correlator) GetCorrelation() map[int]string {
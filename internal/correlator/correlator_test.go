@@ -77,7 +77,7 @@ func TestCorrelator_TimingHeuristic(t *testing.T) {
 	})
 
 	// Record the new PID.
-	c.RecordPID(6200)
+	c.RecordPID(6200, time.Time{})
 
 	// Within 10 seconds, a new session arrives (no matching port).
 	c.RecordConnection(99999, "sess-xyz")
@@ -218,7 +218,7 @@ func TestCorrelator_ConcurrentAccess(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		for i := 0; i < 100; i++ {
-			c.RecordPID(1000 + i)
+			c.RecordPID(1000+i, time.Time{})
 		}
 	}()
 
@@ -232,3 +232,57 @@ func TestCorrelator_ConcurrentAccess(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestCorrelator_PIDReuse(t *testing.T) {
+	pm := newMockPortMapper()
+	c := NewCorrelator(pm, 4317)
+
+	oldStart := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	newStart := oldStart.Add(3 * time.Hour)
+
+	// The original process correlates via its OTLP socket.
+	c.RecordPID(4821, oldStart)
+	pm.SetPorts(4821, [][2]int{{52345, 4317}})
+	c.RecordConnection(52345, "sess-old")
+	c.Correlate([]int{4821})
+	if sid := c.GetSessionForPID(4821); sid != "sess-old" {
+		t.Fatalf("GetSessionForPID(4821) = %q, want sess-old", sid)
+	}
+
+	// The process exits and an unrelated Claude Code process is later
+	// assigned the same PID. It must not inherit the old session, even if
+	// its socket happens to reuse the old ephemeral port.
+	c.RemovePID(4821)
+	c.RecordPID(4821, newStart)
+	c.Correlate([]int{4821})
+	if sid := c.GetSessionForPID(4821); sid != "" {
+		t.Fatalf("recycled PID inherited session %q", sid)
+	}
+
+	// Once the new process sends telemetry, it correlates to its own session.
+	pm.SetPorts(4821, [][2]int{{52345, 4317}, {53000, 4317}})
+	c.RecordConnection(53000, "sess-new")
+	c.Correlate([]int{4821})
+	if sid := c.GetSessionForPID(4821); sid != "sess-new" {
+		t.Errorf("GetSessionForPID(4821) = %q, want sess-new", sid)
+	}
+}
+
+func TestCorrelator_RecordPIDSameProcessKeepsCorrelation(t *testing.T) {
+	pm := newMockPortMapper()
+	c := NewCorrelator(pm, 4317)
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	c.RecordPID(4821, start)
+	pm.SetPorts(4821, [][2]int{{52345, 4317}})
+	c.RecordConnection(52345, "sess-abc")
+	c.Correlate([]int{4821})
+
+	// A repeat notification for the same process (same start time, or an
+	// unknown one) leaves the correlation intact.
+	c.RecordPID(4821, start)
+	c.RecordPID(4821, time.Time{})
+	if sid := c.GetSessionForPID(4821); sid != "sess-abc" {
+		t.Errorf("GetSessionForPID(4821) = %q, want sess-abc", sid)
+	}
+}
@@ -0,0 +1,86 @@
+package enforce
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit events recorded in addition to the actions themselves.
+const (
+	EventScheduled = "scheduled" // a breach started its policy's grace period
+	EventCancelled = "cancelled" // the breach ended before the grace period did
+	EventResumed   = "resume"    // a paused session was resumed with approval
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Policy    string    `json:"policy,omitempty"`
+	Event     string    `json:"event"` // an action, or one of the Event constants
+	SessionID string    `json:"session_id"`
+	PID       int       `json:"pid,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditLog appends entries as JSON lines to a writer.
+type AuditLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewAuditLog creates an AuditLog that writes to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+// OpenAuditLog opens the audit log file at path for appending, creating it
+// and its directory if needed. A leading "~/" is expanded, and an empty
+// path means the default location, ~/.local/state/cc-top/enforcement.log.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if path == "" {
+		path = "~/.local/state/cc-top/enforcement.log"
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("locating audit log: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &AuditLog{w: f, closer: f}, nil
+}
+
+// Record appends an entry to the log.
+func (l *AuditLog) Record(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying file, if the log owns one.
+func (l *AuditLog) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,466 @@
+// Package enforce implements opt-in enforcement policies from the
+// [enforcement] config section. When a session breaches a policy's trigger
+// (an exhausted budget or the SessionCost threshold) for longer than the
+// policy's grace period, the enforcer pauses it with SIGSTOP, interrupts
+// its current turn with SIGINT or terminates it with SIGTERM. Paused
+// sessions wait for approval to resume. A breach that persists is acted on
+// again once the policy's cooldown has passed since the action, or since
+// the session was resumed. Every step is written to an audit log.
+//
+// Signals are sent to the session's process group, so the tool commands
+// and MCP servers it started are paused and resumed with it. Processes
+// that left the group, e.g. with setsid, are not signalled, nor are a
+// session's children when its PID does not lead a process group; only the
+// session process itself is then signalled.
+package enforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/alerts"
+	"github.com/nixlim/cc-top/internal/budget"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/process"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// Actions a policy can take.
+const (
+	ActionPause     = "pause"
+	ActionInterrupt = "interrupt"
+	ActionTerminate = "terminate"
+)
+
+// PolicyManual is the policy recorded for sessions paused with Pause
+// rather than by a policy.
+const PolicyManual = "manual"
+
+// ErrNotPaused is returned by Resume for a session the enforcer has not
+// paused.
+var ErrNotPaused = errors.New("session is not paused by enforcement")
+
+// Paused describes a session paused by a policy and awaiting approval to
+// resume.
+type Paused struct {
+	SessionID string
+	PID       int
+	CWD       string
+	Policy    string
+	Reason    string
+	At        time.Time
+}
+
+// policy is an EnforcementPolicy prepared for evaluation.
+type policy struct {
+	name     string
+	budget   string // budget name or "*"; empty for rule policies
+	rule     string
+	action   string
+	grace    time.Duration
+	cooldown time.Duration
+}
+
+// breach is a session currently in breach of a policy's trigger.
+type breach struct {
+	policy    policy
+	sessionID string
+	reason    string
+}
+
+// pendingAction is a breach waiting out its policy's grace period.
+type pendingAction struct {
+	breach
+	due time.Time
+}
+
+// actedBreach is a breach that has been acted on, and is not acted on
+// again before rearm.
+type actedBreach struct {
+	breach
+	rearm time.Time
+}
+
+// Enforcer evaluates enforcement policies periodically against the state
+// store and signals the offending sessions.
+type Enforcer struct {
+	store         state.Store
+	tracker       *budget.Tracker
+	costThreshold float64
+	scope         alerts.RuleScope
+	policies      []policy
+	audit         *AuditLog
+	signal        func(pid int, sig process.SignalType) error
+	startTime     func(pid int) (time.Time, error)
+	interval      time.Duration
+	now           func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*pendingAction // breach key -> action awaiting its grace period
+	acted   map[string]*actedBreach   // breach key -> action awaiting its cooldown
+	paused  map[string]Paused         // session ID -> pause awaiting approval
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// EnforcerOption configures the enforcer.
+type EnforcerOption func(*Enforcer)
+
+// WithAuditLog sets the log enforcement actions are recorded in.
+func WithAuditLog(l *AuditLog) EnforcerOption {
+	return func(e *Enforcer) {
+		e.audit = l
+	}
+}
+
+// WithSignalSender replaces process.SendSignal, e.g. for testing.
+func WithSignalSender(fn func(pid int, sig process.SignalType) error) EnforcerOption {
+	return func(e *Enforcer) {
+		e.signal = fn
+	}
+}
+
+// WithStartTimeLookup sets how the start time of a running process is
+// found. Before a session is signalled it is compared with the start time
+// recorded for the session's PID, so that a PID reused by another process
+// is never signalled. Without it the PID is trusted.
+func WithStartTimeLookup(fn func(pid int) (time.Time, error)) EnforcerOption {
+	return func(e *Enforcer) {
+		e.startTime = fn
+	}
+}
+
+// WithClock overrides the time source of Pause and Resume, for tests.
+func WithClock(now func() time.Time) EnforcerOption {
+	return func(e *Enforcer) {
+		e.now = now
+	}
+}
+
+// WithInterval sets the evaluation interval.
+func WithInterval(d time.Duration) EnforcerOption {
+	return func(e *Enforcer) {
+		e.interval = d
+	}
+}
+
+// NewEnforcer creates an enforcer for the policies in cfg.Enforcement.
+// tracker supplies budget statuses for budget policies.
+func NewEnforcer(store state.Store, cfg config.Config, tracker *budget.Tracker, opts ...EnforcerOption) *Enforcer {
+	e := &Enforcer{
+		store:         store,
+		tracker:       tracker,
+		costThreshold: cfg.Alerts.SessionCostThreshold,
+		scope:         alerts.NewRuleScope(cfg.Alerts),
+		signal:        process.SendSignal,
+		interval:      1 * time.Second,
+		now:           time.Now,
+		pending:       make(map[string]*pendingAction),
+		acted:         make(map[string]*actedBreach),
+		paused:        make(map[string]Paused),
+		done:          make(chan struct{}),
+	}
+	for name, p := range cfg.Enforcement.Policies {
+		e.policies = append(e.policies, policy{
+			name:     name,
+			budget:   p.Budget,
+			rule:     p.Rule,
+			action:   p.Action,
+			grace:    time.Duration(p.GraceSeconds) * time.Second,
+			cooldown: time.Duration(p.CooldownSeconds) * time.Second,
+		})
+	}
+	sort.Slice(e.policies, func(i, j int) bool { return e.policies[i].name < e.policies[j].name })
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Start begins periodic evaluation. It runs until Stop is called or the
+// context is cancelled.
+func (e *Enforcer) Start(ctx context.Context) {
+	ctx, e.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.EvaluateAt(time.Now())
+			}
+		}
+	}()
+}
+
+// Stop halts periodic evaluation.
+func (e *Enforcer) Stop() {
+	if e.cancel != nil {
+		e.cancel()
+		<-e.done
+	}
+}
+
+// EvaluateAt runs a single evaluation at now: breaches that are new start
+// their grace period, breaches whose grace period has elapsed are acted
+// on, and pending actions whose breach has ended are cancelled. A breach
+// that has been acted on starts its grace period again once its cooldown
+// has passed, unless the session is still paused.
+func (e *Enforcer) EvaluateAt(now time.Time) {
+	if len(e.policies) == 0 {
+		return
+	}
+	breaches := e.breaches(now)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, key := range sortedKeys(e.pending) {
+		if _, ok := breaches[key]; !ok {
+			p := e.pending[key]
+			delete(e.pending, key)
+			e.record(Entry{Time: now, Policy: p.policy.name, Event: EventCancelled,
+				SessionID: p.sessionID, Reason: "no longer in breach"})
+		}
+	}
+	for key := range e.acted {
+		if _, ok := breaches[key]; !ok {
+			delete(e.acted, key)
+		}
+	}
+	for id := range e.paused {
+		if s := e.store.GetSession(id); s == nil || s.Exited {
+			delete(e.paused, id)
+		}
+	}
+
+	for _, key := range sortedKeys(breaches) {
+		b := breaches[key]
+		if a, ok := e.acted[key]; ok {
+			if _, paused := e.paused[b.sessionID]; paused || now.Before(a.rearm) {
+				continue
+			}
+			delete(e.acted, key)
+		}
+		p, ok := e.pending[key]
+		if !ok {
+			p = &pendingAction{breach: b, due: now.Add(b.policy.grace)}
+			e.pending[key] = p
+			if b.policy.grace > 0 {
+				e.record(Entry{Time: now, Policy: b.policy.name, Event: EventScheduled, SessionID: b.sessionID,
+					Reason: fmt.Sprintf("%s; %s in %s", b.reason, b.policy.action, b.policy.grace)})
+			}
+		}
+		if now.Before(p.due) {
+			continue
+		}
+		delete(e.pending, key)
+		e.acted[key] = &actedBreach{breach: b, rearm: now.Add(b.policy.cooldown)}
+		_ = e.act(b, now)
+	}
+}
+
+// breaches returns every session currently in breach of a policy, keyed
+// by policy, trigger and session. Exited sessions are never in breach,
+// nor are sessions a rule trigger does not apply to, as the alert engine
+// decides from [alerts.rules] and mode scoping.
+func (e *Enforcer) breaches(now time.Time) map[string]breach {
+	result := make(map[string]breach)
+
+	var statuses []budget.Status
+	for _, p := range e.policies {
+		if p.budget != "" && e.tracker != nil {
+			statuses = e.tracker.Evaluate(e.store, now)
+			break
+		}
+	}
+
+	for _, p := range e.policies {
+		switch {
+		case p.budget != "":
+			for _, st := range statuses {
+				if !st.Exhausted() || (p.budget != "*" && p.budget != st.Name) {
+					continue
+				}
+				reason := fmt.Sprintf("budget %s exhausted: $%.2f of $%.2f this %s", st.Name, st.Spent, st.Limit, st.Period)
+				for _, id := range st.Sessions {
+					if s := e.store.GetSession(id); s == nil || s.Exited {
+						continue
+					}
+					// Keyed by period so the next period's breach acts again.
+					key := fmt.Sprintf("%s\x00budget:%s@%d\x00%s", p.name, st.Name, st.PeriodStart.Unix(), id)
+					result[key] = breach{policy: p, sessionID: id, reason: reason}
+				}
+			}
+		case p.rule == "SessionCost":
+			for _, s := range e.store.ListSessions() {
+				if s.Exited || s.TotalCost <= e.costThreshold || !e.scope.Applies(alerts.RuleSessionCost, &s) {
+					continue
+				}
+				key := fmt.Sprintf("%s\x00rule:%s\x00%s", p.name, p.rule, s.SessionID)
+				result[key] = breach{policy: p, sessionID: s.SessionID,
+					reason: fmt.Sprintf("session cost $%.2f exceeds threshold $%.2f", s.TotalCost, e.costThreshold)}
+			}
+		}
+	}
+	return result
+}
+
+// act signals the breaching session according to its policy and records
+// the outcome. Must be called with e.mu held.
+func (e *Enforcer) act(b breach, now time.Time) error {
+	entry := Entry{Time: now, Policy: b.policy.name, Event: b.policy.action, SessionID: b.sessionID, Reason: b.reason}
+
+	s := e.store.GetSession(b.sessionID)
+	if s == nil || s.PID <= 0 {
+		entry.Error = "no PID available for this session"
+		e.record(entry)
+		return errors.New(entry.Error)
+	}
+	entry.PID = s.PID
+
+	if e.startTime != nil && !s.PIDStartTime.IsZero() {
+		started, err := e.startTime(s.PID)
+		switch {
+		case err != nil:
+			entry.Error = "cannot verify process: " + err.Error()
+		case !started.IsZero() && !started.Equal(s.PIDStartTime):
+			entry.Error = "PID now belongs to another process"
+		}
+		if entry.Error != "" {
+			e.record(entry)
+			return errors.New(entry.Error)
+		}
+	}
+
+	if err := e.signal(s.PID, signalFor(b.policy.action)); err != nil {
+		if process.IsNoSuchProcess(err) {
+			entry.Error = "process already exited"
+		} else {
+			entry.Error = err.Error()
+		}
+		e.record(entry)
+		return errors.New(entry.Error)
+	}
+	e.record(entry)
+
+	if b.policy.action == ActionPause {
+		e.paused[b.sessionID] = Paused{
+			SessionID: b.sessionID,
+			PID:       s.PID,
+			CWD:       s.CWD,
+			Policy:    b.policy.name,
+			Reason:    b.reason,
+			At:        now,
+		}
+	}
+	return nil
+}
+
+// Pause pauses a session on request, such as from a desktop notification,
+// whether or not any policy is configured. Like a policy pause it is
+// audited and waits for approval to resume. Pausing a session that is
+// already paused does nothing.
+func (e *Enforcer) Pause(sessionID, reason string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.paused[sessionID]; ok {
+		return nil
+	}
+	if s := e.store.GetSession(sessionID); s == nil || s.Exited {
+		return fmt.Errorf("session %s is not running", sessionID)
+	}
+	b := breach{policy: policy{name: PolicyManual, action: ActionPause}, sessionID: sessionID, reason: reason}
+	return e.act(b, e.now())
+}
+
+// Paused returns the sessions paused by enforcement that have not been
+// resumed, oldest first.
+func (e *Enforcer) Paused() []Paused {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]Paused, 0, len(e.paused))
+	for _, p := range e.paused {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].At.Equal(result[j].At) {
+			return result[i].At.Before(result[j].At)
+		}
+		return result[i].SessionID < result[j].SessionID
+	})
+	return result
+}
+
+// Resume continues a session paused by enforcement, recording the
+// approval. A breach of the session that persists is acted on again once
+// its policy's cooldown has passed since the approval.
+func (e *Enforcer) Resume(sessionID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p, ok := e.paused[sessionID]
+	if !ok {
+		return ErrNotPaused
+	}
+
+	now := e.now()
+	entry := Entry{Time: now, Policy: p.Policy, Event: EventResumed, SessionID: sessionID,
+		PID: p.PID, Reason: "approved"}
+	err := e.signal(p.PID, process.SignalContinue)
+	if err != nil && !process.IsNoSuchProcess(err) {
+		entry.Error = err.Error()
+		e.record(entry)
+		return err
+	}
+	delete(e.paused, sessionID)
+	for _, a := range e.acted {
+		if a.sessionID == sessionID {
+			a.rearm = now.Add(a.policy.cooldown)
+		}
+	}
+	e.record(entry)
+	return nil
+}
+
+// record writes an entry to the audit log, if one is configured.
+func (e *Enforcer) record(entry Entry) {
+	if e.audit != nil {
+		_ = e.audit.Record(entry)
+	}
+}
+
+// signalFor maps a policy action to the signal that carries it out.
+func signalFor(action string) process.SignalType {
+	switch action {
+	case ActionPause:
+		return process.SignalStop
+	case ActionInterrupt:
+		return process.SignalInterrupt
+	default:
+		return process.SignalTerminate
+	}
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
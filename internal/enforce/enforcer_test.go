@@ -0,0 +1,372 @@
+package enforce
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/budget"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/process"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// sentSignal is a signal recorded by fakeSignals.
+type sentSignal struct {
+	pid int
+	sig process.SignalType
+}
+
+type fakeSignals struct {
+	sent []sentSignal
+}
+
+func (f *fakeSignals) send(pid int, sig process.SignalType) error {
+	f.sent = append(f.sent, sentSignal{pid, sig})
+	return nil
+}
+
+// addSession adds a live session with the given PID and cumulative cost.
+func addSession(store *state.MemoryStore, id string, pid int, cost float64, at time.Time) {
+	store.AddMetric(id, state.Metric{
+		Name:       "claude_code.cost.usage",
+		Value:      cost,
+		Attributes: map[string]string{"model": "claude-opus-4-6"},
+		Timestamp:  at,
+	})
+	if pid > 0 {
+		store.UpdatePID(id, pid, time.Time{})
+	}
+}
+
+// auditEvents parses the audit log into "event session" strings.
+func auditEvents(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad audit line %q: %v", line, err)
+		}
+		s := e.Event + " " + e.SessionID
+		if e.Error != "" {
+			s += " error"
+		}
+		events = append(events, s)
+	}
+	return events
+}
+
+func newTestEnforcer(store state.Store, cfg config.Config, buf *bytes.Buffer, sig *fakeSignals, opts ...EnforcerOption) *Enforcer {
+	opts = append([]EnforcerOption{WithAuditLog(NewAuditLog(buf)), WithSignalSender(sig.send)}, opts...)
+	return NewEnforcer(store, cfg, budget.NewTracker(cfg.Budgets, nil), opts...)
+}
+
+func TestEnforcer_SessionCostGracePeriod(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addSession(store, "sess-cheap", 100, 1, now)
+	addSession(store, "sess-dear", 200, 8, now)
+
+	cfg := config.DefaultConfig()
+	cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+		"stop": {Rule: "SessionCost", Action: ActionTerminate, GraceSeconds: 30, CooldownSeconds: 600},
+	}
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	e := newTestEnforcer(store, cfg, &buf, sig)
+
+	e.EvaluateAt(now)
+	e.EvaluateAt(now.Add(29 * time.Second))
+	if len(sig.sent) != 0 {
+		t.Fatalf("no signal should be sent during the grace period, got %v", sig.sent)
+	}
+
+	e.EvaluateAt(now.Add(30 * time.Second))
+	e.EvaluateAt(now.Add(time.Minute))
+	if len(sig.sent) != 1 || sig.sent[0] != (sentSignal{200, process.SignalTerminate}) {
+		t.Fatalf("expected one SIGTERM to PID 200, got %v", sig.sent)
+	}
+
+	got := strings.Join(auditEvents(t, &buf), ", ")
+	if want := "scheduled sess-dear, terminate sess-dear"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+}
+
+func TestEnforcer_ActsAgainAfterCooldown(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addSession(store, "sess-1", 100, 8, now)
+
+	cfg := config.DefaultConfig()
+	cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+		"interrupt": {Rule: "SessionCost", Action: ActionInterrupt, GraceSeconds: 30, CooldownSeconds: 300},
+	}
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	e := newTestEnforcer(store, cfg, &buf, sig)
+
+	for _, at := range []time.Duration{0, 30 * time.Second, time.Minute, 5 * time.Minute} {
+		e.EvaluateAt(now.Add(at))
+	}
+	if len(sig.sent) != 1 {
+		t.Fatalf("expected one SIGINT within the cooldown, got %v", sig.sent)
+	}
+
+	// The cooldown has passed: the grace period starts again.
+	e.EvaluateAt(now.Add(5*time.Minute + 30*time.Second))
+	e.EvaluateAt(now.Add(6 * time.Minute))
+	if len(sig.sent) != 2 || sig.sent[1] != (sentSignal{100, process.SignalInterrupt}) {
+		t.Fatalf("expected a second SIGINT after the cooldown and grace period, got %v", sig.sent)
+	}
+
+	got := strings.Join(auditEvents(t, &buf), ", ")
+	if want := "scheduled sess-1, interrupt sess-1, scheduled sess-1, interrupt sess-1"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+}
+
+func TestEnforcer_CancelledWhenBreachEnds(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addSession(store, "sess-1", 100, 8, now)
+
+	cfg := config.DefaultConfig()
+	cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+		"interrupt": {Rule: "SessionCost", Action: ActionInterrupt, GraceSeconds: 60},
+	}
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	e := newTestEnforcer(store, cfg, &buf, sig)
+
+	e.EvaluateAt(now)
+	store.MarkExited(100, time.Time{})
+	e.EvaluateAt(now.Add(time.Minute))
+
+	if len(sig.sent) != 0 {
+		t.Errorf("an exited session should not be signalled, got %v", sig.sent)
+	}
+	got := strings.Join(auditEvents(t, &buf), ", ")
+	if want := "scheduled sess-1, cancelled sess-1"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+}
+
+func TestEnforcer_BudgetPauseAndResume(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	addSession(store, "sess-1", 100, 6, now)
+	addSession(store, "sess-2", 0, 5, now) // no PID known
+	addSession(store, "sess-old", 300, 9, now.AddDate(0, -1, 0))
+
+	cfg := config.DefaultConfig()
+	cfg.Alerts.SessionCostThreshold = 1000
+	cfg.Budgets = map[string]config.BudgetConfig{"team": {Limit: 10, Period: "month"}}
+	cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+		"pause": {Budget: "*", Action: ActionPause, CooldownSeconds: 600},
+	}
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	clock := now
+	e := newTestEnforcer(store, cfg, &buf, sig, WithClock(func() time.Time { return clock }))
+
+	e.EvaluateAt(now)
+	if len(sig.sent) != 1 || sig.sent[0] != (sentSignal{100, process.SignalStop}) {
+		t.Fatalf("expected SIGSTOP to PID 100 only, got %v", sig.sent)
+	}
+	paused := e.Paused()
+	if len(paused) != 1 || paused[0].SessionID != "sess-1" || paused[0].Policy != "pause" ||
+		!strings.Contains(paused[0].Reason, "budget team exhausted") {
+		t.Fatalf("Paused() = %+v", paused)
+	}
+
+	// A paused session is not paused again, however long it waits.
+	e.EvaluateAt(now.Add(9 * time.Minute))
+	if len(sig.sent) != 1 {
+		t.Fatalf("a paused session should not be signalled again, got %v", sig.sent)
+	}
+
+	clock = now.Add(9 * time.Minute)
+	if err := e.Resume("sess-1"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := e.Resume("sess-1"); err != ErrNotPaused {
+		t.Errorf("second Resume = %v, want ErrNotPaused", err)
+	}
+	if last := sig.sent[len(sig.sent)-1]; last != (sentSignal{100, process.SignalContinue}) {
+		t.Errorf("Resume should send SIGCONT, got %v", last)
+	}
+
+	// Approval holds for the cooldown, counted from the approval.
+	e.EvaluateAt(now.Add(18 * time.Minute))
+	if len(sig.sent) != 2 || len(e.Paused()) != 0 {
+		t.Errorf("resumed session should not be paused again within the cooldown, signals %v", sig.sent)
+	}
+	e.EvaluateAt(now.Add(19 * time.Minute))
+	if len(sig.sent) != 3 || sig.sent[2] != (sentSignal{100, process.SignalStop}) || len(e.Paused()) != 1 {
+		t.Errorf("a breach that persists should pause the session again after the cooldown, signals %v", sig.sent)
+	}
+
+	got := strings.Join(auditEvents(t, &buf), ", ")
+	if want := "pause sess-1, pause sess-2 error, resume sess-1, pause sess-2 error, pause sess-1"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+}
+
+func TestEnforcer_NoPolicies(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Now()
+	addSession(store, "sess-1", 100, 1000, now)
+
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	e := newTestEnforcer(store, config.DefaultConfig(), &buf, sig)
+	e.EvaluateAt(now)
+
+	if len(sig.sent) != 0 || buf.Len() != 0 {
+		t.Errorf("enforcement must be opt-in, got signals %v and audit %q", sig.sent, buf.String())
+	}
+}
+
+func TestEnforcer_ManualPause(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Now()
+	addSession(store, "sess-1", 100, 1, now)
+	addSession(store, "sess-2", 0, 1, now)
+
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	e := newTestEnforcer(store, config.DefaultConfig(), &buf, sig)
+
+	if err := e.Pause("sess-1", "paused from notification"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if err := e.Pause("sess-1", "again"); err != nil || len(sig.sent) != 1 {
+		t.Errorf("pausing a paused session should do nothing, got %v and signals %v", err, sig.sent)
+	}
+	paused := e.Paused()
+	if len(paused) != 1 || paused[0].Policy != PolicyManual || paused[0].Reason != "paused from notification" {
+		t.Fatalf("Paused() = %+v", paused)
+	}
+
+	if err := e.Pause("sess-2", "no pid"); err == nil {
+		t.Error("expected an error pausing a session without a PID")
+	}
+	if err := e.Pause("sess-unknown", "gone"); err == nil {
+		t.Error("expected an error pausing an unknown session")
+	}
+
+	if err := e.Resume("sess-1"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	got := strings.Join(auditEvents(t, &buf), ", ")
+	if want := "pause sess-1, pause sess-2 error, resume sess-1"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+}
+
+func TestEnforcer_SessionCostHonorsRuleScope(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		rule config.RuleConfig
+		want int
+	}{
+		{name: "no override", rule: config.RuleConfig{Enabled: true}, want: 2},
+		{name: "disabled", rule: config.RuleConfig{Enabled: false}, want: 0},
+		{name: "include cwd", rule: config.RuleConfig{Enabled: true, IncludeCWD: []string{"/work/*"}}, want: 1},
+		{name: "exclude model", rule: config.RuleConfig{Enabled: true, ExcludeModels: []string{"*opus*"}}, want: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := state.NewMemoryStore()
+			addSession(store, "sess-work", 100, 8, now)
+			store.UpdateCWD("sess-work", "/work/api")
+			addSession(store, "sess-home", 200, 8, now)
+			store.UpdateCWD("sess-home", "/home/me/notes")
+
+			cfg := config.DefaultConfig()
+			cfg.Alerts.Rules = map[string]config.RuleConfig{"SessionCost": tc.rule}
+			cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+				"stop": {Rule: "SessionCost", Action: ActionTerminate},
+			}
+			var buf bytes.Buffer
+			sig := &fakeSignals{}
+			e := newTestEnforcer(store, cfg, &buf, sig)
+
+			e.EvaluateAt(now)
+			if len(sig.sent) != tc.want {
+				t.Errorf("sent %v, want %d signals", sig.sent, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnforcer_PIDReuseNotSignalled(t *testing.T) {
+	store := state.NewMemoryStore()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Hour)
+	addSession(store, "sess-1", 100, 8, now)
+	store.UpdatePID("sess-1", 100, started)
+
+	cfg := config.DefaultConfig()
+	cfg.Enforcement.Policies = map[string]config.EnforcementPolicy{
+		"stop": {Rule: "SessionCost", Action: ActionTerminate},
+	}
+	var buf bytes.Buffer
+	sig := &fakeSignals{}
+	// PID 100 has since exited and been reused by another process.
+	e := NewEnforcer(store, cfg, nil, WithAuditLog(NewAuditLog(&buf)), WithSignalSender(sig.send),
+		WithStartTimeLookup(func(pid int) (time.Time, error) { return now, nil }))
+
+	e.EvaluateAt(now)
+	if len(sig.sent) != 0 {
+		t.Fatalf("reused PID should not be signalled, got %v", sig.sent)
+	}
+	if got, want := strings.Join(auditEvents(t, &buf), ", "), "terminate sess-1 error"; got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+
+	// The session's own process is signalled.
+	buf.Reset()
+	e = NewEnforcer(store, cfg, nil, WithAuditLog(NewAuditLog(&buf)), WithSignalSender(sig.send),
+		WithStartTimeLookup(func(pid int) (time.Time, error) { return started, nil }))
+	e.EvaluateAt(now)
+	if len(sig.sent) != 1 || sig.sent[0] != (sentSignal{100, process.SignalTerminate}) {
+		t.Fatalf("expected one SIGTERM to PID 100, got %v", sig.sent)
+	}
+}
+
+func TestOpenAuditLog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	l, err := OpenAuditLog("~/logs/enforcement.log")
+	if err != nil {
+		t.Fatalf("OpenAuditLog: %v", err)
+	}
+	if err := l.Record(Entry{Event: ActionPause, SessionID: "sess-1", PID: 42}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	path := filepath.Join(os.Getenv("HOME"), "logs", "enforcement.log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"event":"pause","session_id":"sess-1","pid":42`) {
+		t.Errorf("audit log = %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
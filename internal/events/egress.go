@@ -0,0 +1,116 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// mcpURLParams are the tool parameters that may carry the address of a
+// remote MCP server, in the order they are tried.
+var mcpURLParams = []string{"mcp_server_url", "server_url", "url"}
+
+// EgressDomains returns the external domains a tool_result event shows a
+// tool contacting, as reported in its tool_parameters when Claude Code is
+// started with OTEL_LOG_TOOL_DETAILS=1:
+//   - WebFetch: the host of its url.
+//   - WebSearch: its allowed_domains and the site: terms of its query. The
+//     search itself is run by the API and contacts no domain of its own.
+//   - MCP calls: the host of the remote server's URL, when reported.
+//
+// Domains are lowercased, without port or trailing dot, in order of
+// appearance without repeats. Other events yield none.
+func EgressDomains(e state.Event) []string {
+	if e.Name != "claude_code.tool_result" || e.Attributes["tool_parameters"] == "" {
+		return nil
+	}
+	var params map[string]any
+	if err := json.Unmarshal([]byte(e.Attributes["tool_parameters"]), &params); err != nil {
+		return nil
+	}
+	str := func(key string) string {
+		s, _ := params[key].(string)
+		return strings.TrimSpace(s)
+	}
+
+	var domains []string
+	add := func(host string) {
+		if d := normalizeDomain(host); d != "" && !slices.Contains(domains, d) {
+			domains = append(domains, d)
+		}
+	}
+	switch {
+	case str("mcp_server_name") != "":
+		for _, key := range mcpURLParams {
+			if host := urlHost(str(key)); host != "" {
+				add(host)
+				break
+			}
+		}
+	case e.Attributes["tool_name"] == "WebFetch":
+		add(urlHost(str("url")))
+	case e.Attributes["tool_name"] == "WebSearch":
+		if allowed, ok := params["allowed_domains"].([]any); ok {
+			for _, d := range allowed {
+				if s, ok := d.(string); ok {
+					add(s)
+				}
+			}
+		}
+		for _, term := range strings.Fields(str("query")) {
+			if site, ok := strings.CutPrefix(term, "site:"); ok {
+				add(site)
+			}
+		}
+	}
+	return domains
+}
+
+// DomainAllowed reports whether domain is covered by the allowlist. An
+// entry covers the domain itself and its subdomains, so "github.com"
+// covers "api.github.com"; entries may also be globs like "*.example.*".
+func DomainAllowed(domain string, allowlist []string) bool {
+	for _, entry := range allowlist {
+		entry = strings.ToLower(entry)
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+		if ok, _ := path.Match(entry, domain); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the host of an http(s) URL, or "" if raw is not one.
+// URLs without a scheme, as WebFetch accepts, are read as https.
+func urlHost(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Host
+}
+
+// normalizeDomain lowercases a host and strips its port and trailing dot.
+func normalizeDomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if strings.ContainsAny(host, "/ ") {
+		return ""
+	}
+	return host
+}
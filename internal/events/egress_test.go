@@ -0,0 +1,64 @@
+package events
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func TestEgressDomains(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		params string
+		want   []string
+	}{
+		{"webfetch", "WebFetch", `{"url": "https://Docs.Example.com:443/guide#intro", "prompt": "summarize"}`, []string{"docs.example.com"}},
+		{"webfetch without scheme", "WebFetch", `{"url": "pkg.go.dev/net/url"}`, []string{"pkg.go.dev"}},
+		{"websearch", "WebSearch", `{"query": "go generics site:go.dev site:GitHub.com", "allowed_domains": ["go.dev", "stackoverflow.com"]}`,
+			[]string{"go.dev", "stackoverflow.com", "github.com"}},
+		{"websearch without domains", "WebSearch", `{"query": "golang release notes"}`, nil},
+		{"remote mcp", "mcp_tool", `{"mcp_server_name": "linear", "mcp_tool_name": "list_issues", "mcp_server_url": "https://mcp.linear.app/sse"}`,
+			[]string{"mcp.linear.app"}},
+		{"local mcp", "mcp_tool", `{"mcp_server_name": "fs", "mcp_tool_name": "read"}`, nil},
+		{"other tool", "Bash", `{"bash_command": "curl https://example.com"}`, nil},
+		{"ftp url", "WebFetch", `{"url": "ftp://files.example.com/x"}`, nil},
+		{"malformed", "WebFetch", `{"url": `, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := state.Event{Name: "claude_code.tool_result", Attributes: map[string]string{
+				"tool_name": tc.tool, "tool_parameters": tc.params,
+			}}
+			if got := EgressDomains(e); !slices.Equal(got, tc.want) {
+				t.Errorf("EgressDomains = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	decision := state.Event{Name: "claude_code.tool_decision", Attributes: map[string]string{
+		"tool_name": "WebFetch", "tool_parameters": `{"url": "https://example.com"}`,
+	}}
+	if got := EgressDomains(decision); got != nil {
+		t.Errorf("tool_decision events should yield no domains, got %q", got)
+	}
+}
+
+func TestDomainAllowed(t *testing.T) {
+	allowlist := []string{"github.com", "*.anthropic.com", "Go.dev"}
+	for domain, want := range map[string]bool{
+		"github.com":          true,
+		"api.github.com":      true,
+		"notgithub.com":       false,
+		"docs.anthropic.com":  true,
+		"anthropic.com":       false,
+		"go.dev":              true,
+		"example.com":         false,
+		"github.com.evil.net": false,
+	} {
+		if got := DomainAllowed(domain, allowlist); got != want {
+			t.Errorf("DomainAllowed(%q) = %v, want %v", domain, got, want)
+		}
+	}
+}
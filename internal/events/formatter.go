@@ -58,6 +58,10 @@ func FormatEvent(sessionID string, e state.Event) FormattedEvent {
 		fe.Formatted = fmt.Sprintf("[%s] %s", shortSession, e.Name)
 	}
 
+	if e.Attributes[state.SourceAttr] == state.SourceTranscript {
+		fe.Formatted += " (estimated from transcript)"
+	}
+
 	return fe
 }
 
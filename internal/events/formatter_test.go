@@ -361,3 +361,21 @@ func TestFormatCost(t *testing.T) {
 		})
 	}
 }
+
+func TestEventFormat_EstimatedFromTranscript(t *testing.T) {
+	e := state.Event{
+		Name: "claude_code.user_prompt",
+		Attributes: map[string]string{
+			"prompt_length":  "12",
+			state.SourceAttr: state.SourceTranscript,
+		},
+		Timestamp: time.Now(),
+	}
+
+	fe := FormatEvent("sess-abc", e)
+
+	expected := "[sess-abc] Prompt (12 chars) (estimated from transcript)"
+	if fe.Formatted != expected {
+		t.Errorf("expected %q, got %q", expected, fe.Formatted)
+	}
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// Turn is one user prompt and the activity it caused: the API requests,
+// tool decisions, tool results and errors that followed it.
+type Turn struct {
+	Number   int          // 1-based position in the session; 0 for activity before the first prompt
+	PromptID string       // prompt.id shared by the turn's events; "" if not reported
+	Prompt   *state.Event // the user_prompt event; nil if it was not received
+	Events   []state.Event
+
+	Start time.Time // timestamp of the first event
+	End   time.Time // timestamp of the last event
+
+	APIRequests   int
+	ToolDecisions int
+	ToolResults   int
+	Errors        int // api_error events and failed tool results
+
+	InputTokens         int64
+	OutputTokens        int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	CostUSD             float64
+}
+
+// Tokens returns the turn's total token count across all token types.
+func (t Turn) Tokens() int64 {
+	return t.InputTokens + t.OutputTokens + t.CacheReadTokens + t.CacheCreationTokens
+}
+
+// WallTime returns the time between the turn's first and last events.
+func (t Turn) WallTime() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// PromptLabel describes the turn's prompt: its text when Claude Code logs
+// prompts (OTEL_LOG_USER_PROMPTS=1), otherwise its length.
+func (t Turn) PromptLabel() string {
+	switch {
+	case t.Prompt == nil:
+		return "(prompt not received)"
+	case t.Prompt.Attributes["prompt"] != "":
+		return strings.Join(strings.Fields(t.Prompt.Attributes["prompt"]), " ")
+	case t.Prompt.Attributes["prompt_length"] != "":
+		return "(" + t.Prompt.Attributes["prompt_length"] + " chars)"
+	}
+	return "(prompt)"
+}
+
+// GroupTurns splits a session's events, already ordered by event.sequence
+// and timestamp as the state store keeps them, into turns. Events carrying
+// a prompt.id join the turn for that ID. Events without one join the most
+// recent turn, and a user_prompt without one starts a new turn. Activity
+// before the first prompt is collected in a turn with Number 0.
+func GroupTurns(evts []state.Event) []Turn {
+	var turns []Turn
+	byID := make(map[string]int)
+	current := -1
+	number := 0
+
+	for _, e := range evts {
+		id := e.Attributes["prompt.id"]
+		idx, known := byID[id]
+		if id == "" || !known {
+			idx = current
+			isPrompt := e.Name == "claude_code.user_prompt"
+			if id != "" || isPrompt || current < 0 {
+				t := Turn{PromptID: id}
+				if id != "" || isPrompt {
+					number++
+					t.Number = number
+				}
+				turns = append(turns, t)
+				idx = len(turns) - 1
+				if id != "" {
+					byID[id] = idx
+				}
+			}
+		}
+		current = idx
+		turns[idx].add(e)
+	}
+	return turns
+}
+
+// add appends e to the turn and updates its totals.
+func (t *Turn) add(e state.Event) {
+	t.Events = append(t.Events, e)
+	if t.Start.IsZero() || e.Timestamp.Before(t.Start) {
+		t.Start = e.Timestamp
+	}
+	if e.Timestamp.After(t.End) {
+		t.End = e.Timestamp
+	}
+
+	switch e.Name {
+	case "claude_code.user_prompt":
+		if t.Prompt == nil {
+			p := e
+			t.Prompt = &p
+		}
+	case "claude_code.api_request":
+		t.APIRequests++
+		t.InputTokens += attrInt(e, "input_tokens")
+		t.OutputTokens += attrInt(e, "output_tokens")
+		t.CacheReadTokens += attrInt(e, "cache_read_tokens")
+		t.CacheCreationTokens += attrInt(e, "cache_creation_tokens")
+		if cost, err := strconv.ParseFloat(attrStr(e, "cost_usd"), 64); err == nil {
+			t.CostUSD += cost
+		}
+	case "claude_code.api_error":
+		t.Errors++
+	case "claude_code.tool_decision":
+		t.ToolDecisions++
+	case "claude_code.tool_result":
+		t.ToolResults++
+		if attrStr(e, "success") != "true" && attrStr(e, "decision") != "reject" {
+			t.Errors++
+		}
+	}
+}
+
+// attrInt parses an integer attribute, returning 0 if absent or invalid.
+func attrInt(e state.Event, key string) int64 {
+	n, _ := strconv.ParseInt(attrStr(e, key), 10, 64)
+	return n
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func TestGroupTurns_ByPromptID(t *testing.T) {
+	base := time.Date(2026, 10, 18, 14, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+
+	evts := []state.Event{
+		{Name: "claude_code.user_prompt", Timestamp: at(0), Attributes: map[string]string{"prompt.id": "p1", "prompt_length": "12"}},
+		{Name: "claude_code.api_request", Timestamp: at(2), Attributes: map[string]string{
+			"prompt.id": "p1", "input_tokens": "1000", "output_tokens": "200", "cache_read_tokens": "5000", "cost_usd": "0.05",
+		}},
+		{Name: "claude_code.tool_decision", Timestamp: at(3), Attributes: map[string]string{"prompt.id": "p1", "decision": "accept"}},
+		{Name: "claude_code.tool_result", Timestamp: at(5), Attributes: map[string]string{"prompt.id": "p1", "success": "false"}},
+		{Name: "claude_code.user_prompt", Timestamp: at(60), Attributes: map[string]string{"prompt.id": "p2"}},
+		{Name: "claude_code.api_error", Timestamp: at(61), Attributes: map[string]string{"prompt.id": "p2"}},
+		// A late event for the first prompt still belongs to it.
+		{Name: "claude_code.api_request", Timestamp: at(62), Attributes: map[string]string{"prompt.id": "p1", "cost_usd": "0.01"}},
+	}
+
+	turns := GroupTurns(evts)
+	if len(turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(turns))
+	}
+
+	first := turns[0]
+	if first.Number != 1 || first.PromptID != "p1" || first.Prompt == nil {
+		t.Errorf("first turn = %+v", first)
+	}
+	if first.APIRequests != 2 || first.ToolDecisions != 1 || first.ToolResults != 1 || first.Errors != 1 {
+		t.Errorf("first turn counts: req %d dec %d res %d err %d",
+			first.APIRequests, first.ToolDecisions, first.ToolResults, first.Errors)
+	}
+	if first.Tokens() != 6200 {
+		t.Errorf("first turn tokens = %d, want 6200", first.Tokens())
+	}
+	if first.CostUSD < 0.0599 || first.CostUSD > 0.0601 {
+		t.Errorf("first turn cost = %f, want 0.06", first.CostUSD)
+	}
+	if first.WallTime() != 62*time.Second {
+		t.Errorf("first turn wall time = %v, want 62s", first.WallTime())
+	}
+
+	if second := turns[1]; second.Number != 2 || second.Errors != 1 || len(second.Events) != 2 {
+		t.Errorf("second turn = %+v", second)
+	}
+}
+
+func TestGroupTurns_WithoutPromptIDs(t *testing.T) {
+	now := time.Now()
+	evts := []state.Event{
+		{Name: "claude_code.api_request", Timestamp: now},
+		{Name: "claude_code.user_prompt", Timestamp: now.Add(time.Second)},
+		{Name: "claude_code.tool_result", Timestamp: now.Add(2 * time.Second), Attributes: map[string]string{"success": "true"}},
+		{Name: "claude_code.user_prompt", Timestamp: now.Add(3 * time.Second)},
+		{Name: "claude_code.tool_result", Timestamp: now.Add(4 * time.Second), Attributes: map[string]string{"success": "false", "decision": "reject"}},
+	}
+
+	turns := GroupTurns(evts)
+	if len(turns) != 3 {
+		t.Fatalf("got %d turns, want 3", len(turns))
+	}
+	if turns[0].Number != 0 || turns[0].Prompt != nil || turns[0].APIRequests != 1 {
+		t.Errorf("activity before the first prompt = %+v", turns[0])
+	}
+	if turns[1].Number != 1 || len(turns[1].Events) != 2 {
+		t.Errorf("turn 1 = %+v", turns[1])
+	}
+	// A rejection is a decision, not an error.
+	if turns[2].Number != 2 || turns[2].Errors != 0 {
+		t.Errorf("turn 2 = %+v", turns[2])
+	}
+}
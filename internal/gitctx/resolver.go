@@ -0,0 +1,363 @@
+// Package gitctx resolves the git context (repository, branch, HEAD commit
+// and dirty state) of a working directory. The repository, branch and HEAD
+// are read from the .git directory directly, which is cheap enough to do on
+// every refresh; the dirty state comes from running git status, with a
+// timeout, at most once per TTL. Results are cached per directory.
+package gitctx
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotRepository is returned when a directory is not inside a git
+// working tree.
+var ErrNotRepository = errors.New("not a git repository")
+
+// Info describes the git working tree containing a directory.
+type Info struct {
+	// Root is the absolute path of the working tree root.
+	Root string
+	// Repo is the repository name. Linked worktrees report the name of
+	// the main repository so they group with it.
+	Repo string
+	// CommonDir is the absolute path of the git directory holding the
+	// repository's refs and objects, shared by all its linked worktrees.
+	CommonDir string
+	// Branch is the checked-out branch, or empty when HEAD is detached.
+	Branch string
+	// Head is the full commit hash HEAD points to, or empty on an unborn
+	// branch.
+	Head string
+	// Dirty reports whether there are staged changes, tracked files in
+	// the working tree that differ from the index, or unresolved
+	// conflicts, as listed by git status. Untracked files do not count.
+	// It is false if git is not installed.
+	Dirty bool
+}
+
+// ShortHead returns the abbreviated HEAD commit hash.
+func (i Info) ShortHead() string {
+	if len(i.Head) > 7 {
+		return i.Head[:7]
+	}
+	return i.Head
+}
+
+// DefaultTTL is how long a resolved context is reused before the .git
+// directory and working tree are read again.
+const DefaultTTL = 10 * time.Second
+
+// A working tree whose git status takes at least slowStatus, or times
+// out, has its dirty state reused for slowRepoTTL unless the index or
+// HEAD changes.
+const (
+	slowStatus  = time.Second
+	slowRepoTTL = time.Minute
+)
+
+// Resolver resolves and caches git context per directory.
+// It is safe for concurrent use.
+type Resolver struct {
+	ttl         time.Duration
+	now         func() time.Time
+	slowStatus  time.Duration
+	slowRepoTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	dirty map[string]dirtyEntry // by working tree root
+}
+
+type cacheEntry struct {
+	info     Info
+	err      error
+	resolved time.Time
+}
+
+// dirtyEntry is the dirty state of a working tree, valid while its index
+// and HEAD are unchanged.
+type dirtyEntry struct {
+	head    string
+	index   time.Time // index modification time
+	dirty   bool
+	checked time.Time
+	slow    bool
+}
+
+// ResolverOption configures a Resolver.
+type ResolverOption func(*Resolver)
+
+// WithTTL sets how long resolved results are cached.
+func WithTTL(ttl time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.ttl = ttl
+	}
+}
+
+// WithClock overrides the time source, for tests.
+func WithClock(now func() time.Time) ResolverOption {
+	return func(r *Resolver) {
+		r.now = now
+	}
+}
+
+// NewResolver creates a Resolver with the given options.
+func NewResolver(opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		ttl:         DefaultTTL,
+		now:         time.Now,
+		slowStatus:  slowStatus,
+		slowRepoTTL: slowRepoTTL,
+		cache:       make(map[string]cacheEntry),
+		dirty:       make(map[string]dirtyEntry),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns the git context of dir. A leading "~" is expanded to
+// the user's home directory. Results, including ErrNotRepository, are
+// cached for the resolver's TTL.
+func (r *Resolver) Resolve(dir string) (Info, error) {
+	dir = expandHome(dir)
+
+	r.mu.Lock()
+	e, ok := r.cache[dir]
+	r.mu.Unlock()
+	now := r.now()
+	if ok && now.Sub(e.resolved) < r.ttl {
+		return e.info, e.err
+	}
+
+	info, loc, err := resolveHead(dir)
+	if err == nil {
+		info.Dirty = r.isDirty(loc, info.Head, now)
+	}
+
+	r.mu.Lock()
+	r.cache[dir] = cacheEntry{info: info, err: err, resolved: now}
+	r.mu.Unlock()
+	return info, err
+}
+
+// isDirty checks the dirty state of a working tree. The last result is
+// reused while the index and HEAD are unchanged, for the TTL, or for
+// slowRepoTTL if git status was slow. Directories in the same working
+// tree share the result, and a failed check keeps the last one.
+func (r *Resolver) isDirty(loc location, head string, now time.Time) bool {
+	var indexTime time.Time
+	if fi, err := os.Stat(filepath.Join(loc.gitDir, "index")); err == nil {
+		indexTime = fi.ModTime()
+	}
+	r.mu.Lock()
+	d, ok := r.dirty[loc.root]
+	r.mu.Unlock()
+	ttl := r.ttl
+	if d.slow {
+		ttl = r.slowRepoTTL
+	}
+	if ok && d.head == head && d.index.Equal(indexTime) && now.Sub(d.checked) < ttl {
+		return d.dirty
+	}
+
+	start := time.Now()
+	dirty, checked := gitStatus(loc.root, statusTimeout)
+	if !checked {
+		dirty = d.dirty
+	}
+
+	r.mu.Lock()
+	r.dirty[loc.root] = dirtyEntry{
+		head:    head,
+		index:   indexTime,
+		dirty:   dirty,
+		checked: now,
+		slow:    time.Since(start) >= r.slowStatus,
+	}
+	r.mu.Unlock()
+	return dirty
+}
+
+// Resolve reads the git context of dir without caching.
+func Resolve(dir string) (Info, error) {
+	info, loc, err := resolveHead(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	info.Dirty, _ = gitStatus(loc.root, statusTimeout)
+	return info, nil
+}
+
+// location is where a working tree and its git directory are.
+type location struct {
+	root, gitDir string
+}
+
+// resolveHead reads the git context of dir apart from its dirty state.
+func resolveHead(dir string) (Info, location, error) {
+	if dir == "" {
+		return Info{}, location{}, ErrNotRepository
+	}
+	root, gitDir, err := findGitDir(dir)
+	if err != nil {
+		return Info{}, location{}, err
+	}
+	commonDir := readCommonDir(gitDir)
+
+	info := Info{Root: root, Repo: repoName(root, commonDir), CommonDir: commonDir}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return Info{}, location{}, err
+	}
+	ref := strings.TrimSpace(string(head))
+	if target, ok := strings.CutPrefix(ref, "ref: "); ok {
+		info.Branch = strings.TrimPrefix(target, "refs/heads/")
+		info.Head = resolveRef(gitDir, commonDir, target)
+	} else {
+		info.Head = ref
+	}
+	return info, location{root: root, gitDir: gitDir}, nil
+}
+
+// findGitDir walks up from dir to the nearest directory containing .git
+// and returns the working tree root and the git directory. A .git file
+// ("gitdir: <path>") is followed, as used by linked worktrees and
+// submodules.
+func findGitDir(dir string) (root, gitDir string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		candidate := filepath.Join(dir, ".git")
+		fi, statErr := os.Stat(candidate)
+		if statErr == nil {
+			if fi.IsDir() {
+				return dir, candidate, nil
+			}
+			if gd, ok := readGitFile(candidate); ok {
+				return dir, gd, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// readGitFile parses a .git file of the form "gitdir: <path>".
+func readGitFile(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	gd, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", false
+	}
+	if !filepath.IsAbs(gd) {
+		gd = filepath.Join(filepath.Dir(path), gd)
+	}
+	return filepath.Clean(gd), true
+}
+
+// readCommonDir returns the directory holding shared refs and objects.
+// For a linked worktree this is named by the "commondir" file; otherwise
+// it is the git directory itself.
+func readCommonDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	cd := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(cd) {
+		cd = filepath.Join(gitDir, cd)
+	}
+	return filepath.Clean(cd)
+}
+
+// repoName names the repository after the directory containing its common
+// .git directory, so linked worktrees share the main checkout's name.
+func repoName(root, commonDir string) string {
+	if filepath.Base(commonDir) == ".git" {
+		return filepath.Base(filepath.Dir(commonDir))
+	}
+	return filepath.Base(root)
+}
+
+// maxSymrefDepth bounds how many symbolic refs are followed.
+const maxSymrefDepth = 5
+
+// resolveRef returns the commit hash a ref points to, following symbolic
+// refs, or "" if the ref does not exist (e.g. an unborn branch).
+func resolveRef(gitDir, commonDir, ref string) string {
+	for range maxSymrefDepth {
+		val, ok := readLooseRef(gitDir, commonDir, ref)
+		if !ok {
+			return readPackedRef(commonDir, ref)
+		}
+		target, sym := strings.CutPrefix(val, "ref: ")
+		if !sym {
+			return val
+		}
+		ref = target
+	}
+	return ""
+}
+
+// readLooseRef reads refs/... from the worktree's git directory first,
+// then from the common directory.
+func readLooseRef(gitDir, commonDir, ref string) (string, bool) {
+	for _, dir := range []string{gitDir, commonDir} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(data)), true
+		}
+	}
+	return "", false
+}
+
+// readPackedRef looks ref up in the packed-refs file.
+func readPackedRef(commonDir, ref string) string {
+	f, err := os.Open(filepath.Join(commonDir, "packed-refs"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if ok && name == ref {
+			return hash
+		}
+	}
+	return ""
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,327 @@
+package gitctx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile creates path (and its parents) with the given content.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testHash = "3f786850e387550fdab836ed7e6dc881de23001b"
+
+func TestResolve_LooseRefFromSubdirectory(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "myrepo")
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/feature/x\n")
+	writeFile(t, filepath.Join(root, ".git", "refs", "heads", "feature", "x"), testHash+"\n")
+	sub := filepath.Join(root, "internal", "pkg")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Resolve(sub)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if info.Root != root || info.Repo != "myrepo" {
+		t.Errorf("Root/Repo = %q/%q, want %q/myrepo", info.Root, info.Repo, root)
+	}
+	if info.Branch != "feature/x" || info.Head != testHash {
+		t.Errorf("Branch/Head = %q/%q", info.Branch, info.Head)
+	}
+	if info.ShortHead() != "3f78685" {
+		t.Errorf("ShortHead() = %q", info.ShortHead())
+	}
+	if info.Dirty {
+		t.Error("repo without an index should be clean")
+	}
+}
+
+func TestResolve_PackedRefAndDetached(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(root, ".git", "packed-refs"),
+		"# pack-refs with: peeled fully-peeled sorted\n"+
+			testHash+" refs/heads/main\n"+
+			"^0000000000000000000000000000000000000000\n")
+
+	info, err := Resolve(root)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if info.Branch != "main" || info.Head != testHash {
+		t.Errorf("Branch/Head = %q/%q, want main/%s", info.Branch, info.Head, testHash)
+	}
+
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), testHash+"\n")
+	info, _ = Resolve(root)
+	if info.Branch != "" || info.Head != testHash {
+		t.Errorf("detached Branch/Head = %q/%q", info.Branch, info.Head)
+	}
+}
+
+func TestResolve_UnbornBranch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	info, err := Resolve(root)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if info.Branch != "main" || info.Head != "" {
+		t.Errorf("Branch/Head = %q/%q, want main/empty", info.Branch, info.Head)
+	}
+}
+
+func TestResolve_LinkedWorktree(t *testing.T) {
+	base := t.TempDir()
+	main := filepath.Join(base, "cc-top")
+	wtGitDir := filepath.Join(main, ".git", "worktrees", "wt")
+	writeFile(t, filepath.Join(main, ".git", "refs", "heads", "topic"), testHash+"\n")
+	writeFile(t, filepath.Join(wtGitDir, "HEAD"), "ref: refs/heads/topic\n")
+	writeFile(t, filepath.Join(wtGitDir, "commondir"), "../..\n")
+
+	wt := filepath.Join(base, "cc-top-topic")
+	writeFile(t, filepath.Join(wt, ".git"), "gitdir: "+wtGitDir+"\n")
+
+	info, err := Resolve(wt)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if info.Root != wt {
+		t.Errorf("Root = %q, want %q", info.Root, wt)
+	}
+	if info.Repo != "cc-top" || info.CommonDir != filepath.Join(main, ".git") {
+		t.Errorf("Repo/CommonDir = %q/%q, want the main repository's", info.Repo, info.CommonDir)
+	}
+	if info.Branch != "topic" || info.Head != testHash {
+		t.Errorf("Branch/Head = %q/%q", info.Branch, info.Head)
+	}
+}
+
+func TestResolve_NotRepository(t *testing.T) {
+	if _, err := Resolve(t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Resolve() error = %v, want ErrNotRepository", err)
+	}
+	if _, err := Resolve(""); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Resolve(\"\") error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestResolver_CachesUntilTTL(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	now := time.Unix(1000, 0)
+	r := NewResolver(WithTTL(10*time.Second), WithClock(func() time.Time { return now }))
+
+	info, _ := r.Resolve(root)
+	if info.Branch != "main" {
+		t.Fatalf("Branch = %q, want main", info.Branch)
+	}
+
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/dev\n")
+	now = now.Add(5 * time.Second)
+	if info, _ = r.Resolve(root); info.Branch != "main" {
+		t.Errorf("within TTL Branch = %q, want cached main", info.Branch)
+	}
+
+	now = now.Add(10 * time.Second)
+	if info, _ = r.Resolve(root); info.Branch != "dev" {
+		t.Errorf("after TTL Branch = %q, want dev", info.Branch)
+	}
+}
+
+// gitRepo creates a real repository with one committed file using the git
+// binary, skipping the test if git is unavailable.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	gitRun(t, root, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(root, "README.md"), "hello\n")
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n")
+	gitRun(t, root, "add", ".")
+	gitRun(t, root, "commit", "-q", "-m", "init")
+	return root
+}
+
+// gitRun runs git in root with a fixed identity and no user config.
+func gitRun(t *testing.T, root string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestResolve_DirtyState(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		v4   bool
+	}{
+		{name: "index v2"},
+		{name: "index v4", v4: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := gitRepo(t)
+			if tc.v4 {
+				cmd := exec.Command("git", "update-index", "--index-version", "4")
+				cmd.Dir = root
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("update-index: %v\n%s", err, out)
+				}
+			}
+
+			info, err := Resolve(root)
+			if err != nil {
+				t.Fatalf("Resolve() error: %v", err)
+			}
+			if info.Branch != "main" || len(info.Head) != 40 {
+				t.Errorf("Branch/Head = %q/%q", info.Branch, info.Head)
+			}
+			if info.Dirty {
+				t.Error("fresh commit should be clean")
+			}
+
+			// Touching a file without changing it keeps the tree clean.
+			later := time.Now().Add(time.Hour)
+			if err := os.Chtimes(filepath.Join(root, "README.md"), later, later); err != nil {
+				t.Fatal(err)
+			}
+			if info, _ = Resolve(root); info.Dirty {
+				t.Error("touched but unchanged file should not be dirty")
+			}
+
+			writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n\nfunc main() {}\n")
+			if info, _ = Resolve(root); !info.Dirty {
+				t.Error("modified tracked file should be dirty")
+			}
+
+			writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n")
+			if err := os.Remove(filepath.Join(root, "README.md")); err != nil {
+				t.Fatal(err)
+			}
+			if info, _ = Resolve(root); !info.Dirty {
+				t.Error("deleted tracked file should be dirty")
+			}
+		})
+	}
+}
+
+func TestResolve_StagedChanges(t *testing.T) {
+	root := gitRepo(t)
+	dirty := func() bool {
+		t.Helper()
+		info, err := Resolve(root)
+		if err != nil {
+			t.Fatalf("Resolve() error: %v", err)
+		}
+		return info.Dirty
+	}
+	if dirty() {
+		t.Fatal("fresh commit should be clean")
+	}
+
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n\nfunc main() {}\n")
+	gitRun(t, root, "add", "src/main.go")
+	if !dirty() {
+		t.Error("staged modification should be dirty")
+	}
+
+	// Staging the original content again leaves nothing to commit.
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main\n")
+	gitRun(t, root, "add", "src/main.go")
+	if dirty() {
+		t.Error("index matching HEAD again should be clean")
+	}
+
+	writeFile(t, filepath.Join(root, "docs", "new.md"), "new\n")
+	gitRun(t, root, "add", "docs/new.md")
+	if !dirty() {
+		t.Error("staged new file should be dirty")
+	}
+	gitRun(t, root, "rm", "-q", "--cached", "docs/new.md")
+	if dirty() {
+		t.Error("untracked file should not be dirty")
+	}
+
+	gitRun(t, root, "rm", "-q", "--cached", "README.md")
+	if !dirty() {
+		t.Error("staged deletion should be dirty")
+	}
+	gitRun(t, root, "commit", "-q", "-m", "remove readme")
+	if dirty() {
+		t.Error("committed changes should be clean")
+	}
+}
+
+func TestResolver_ReusesSlowStatus(t *testing.T) {
+	root := gitRepo(t)
+	now := time.Unix(1000, 0)
+	r := NewResolver(WithTTL(time.Second), WithClock(func() time.Time { return now }))
+	r.slowStatus = 0 // every git status counts as slow
+
+	if info, _ := r.Resolve(root); info.Dirty {
+		t.Fatal("fresh commit should be clean")
+	}
+
+	// A slow working tree is not checked again until slowRepoTTL has
+	// passed.
+	writeFile(t, filepath.Join(root, "README.md"), "changed\n")
+	now = now.Add(5 * time.Second)
+	if info, _ := r.Resolve(root); info.Dirty {
+		t.Error("slow repo checked again within slowRepoTTL")
+	}
+	now = now.Add(slowRepoTTL)
+	if info, _ := r.Resolve(root); !info.Dirty {
+		t.Error("slow repo not checked again after slowRepoTTL")
+	}
+
+	// A change to the index is picked up at once.
+	writeFile(t, filepath.Join(root, "README.md"), "hello\n")
+	now = now.Add(5 * time.Second)
+	gitRun(t, root, "add", "README.md")
+	if info, _ := r.Resolve(root); info.Dirty {
+		t.Error("index change should trigger a new check")
+	}
+}
+
+func TestResolver_SharesStatusWithinWorkingTree(t *testing.T) {
+	root := gitRepo(t)
+	now := time.Unix(1000, 0)
+	r := NewResolver(WithTTL(10*time.Second), WithClock(func() time.Time { return now }))
+
+	if info, _ := r.Resolve(root); info.Dirty {
+		t.Fatal("fresh commit should be clean")
+	}
+	// Another directory of the same working tree reuses the status for
+	// the TTL.
+	writeFile(t, filepath.Join(root, "README.md"), "changed\n")
+	if info, _ := r.Resolve(filepath.Join(root, "src")); info.Dirty {
+		t.Error("status should be shared within the TTL")
+	}
+	now = now.Add(10 * time.Second)
+	if info, _ := r.Resolve(filepath.Join(root, "src")); !info.Dirty {
+		t.Error("status should be checked again after the TTL")
+	}
+}
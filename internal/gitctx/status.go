@@ -0,0 +1,32 @@
+package gitctx
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+)
+
+// statusTimeout bounds a single git status run.
+const statusTimeout = 5 * time.Second
+
+// gitStatus runs git status in the working tree at root and reports
+// whether it lists changes to tracked files: staged changes, unstaged
+// modifications or unresolved conflicts. Leaving the comparison to git
+// honours everything it does, such as alternates, split and sparse
+// indexes, and clean filters. ok is false if git is not installed, fails,
+// or does not finish within timeout.
+func gitStatus(root string, timeout time.Duration) (dirty, ok bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// --no-optional-locks keeps git from refreshing the index, which
+	// would contend with the user's own git commands.
+	cmd := exec.CommandContext(ctx, "git", "--no-optional-locks", "status", "--porcelain", "--untracked-files=no")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return false, false
+	}
+	return len(bytes.TrimSpace(out)) > 0, true
+}
@@ -1,4 +1,5 @@
-// Package process provides signal-sending utilities for the kill switch.
+// Package process provides signal-sending utilities for the kill switch
+// and budget enforcement.
 // On macOS/Linux, it sends POSIX signals to process groups.
 package process
 
@@ -22,6 +23,9 @@ const (
 	SignalContinue
 	// SignalTerminate sends SIGTERM for graceful termination.
 	SignalTerminate
+	// SignalInterrupt sends SIGINT to interrupt the current operation,
+	// as Ctrl+C would.
+	SignalInterrupt
 )
 
 // errNoSuchProcess is returned when the target process does not exist.
@@ -82,6 +86,8 @@ func toOSSignal(sig SignalType) os.Signal {
 		return syscall.SIGCONT
 	case SignalTerminate:
 		return syscall.SIGTERM
+	case SignalInterrupt:
+		return syscall.SIGINT
 	default:
 		return nil
 	}
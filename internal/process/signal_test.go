@@ -1,6 +1,7 @@
 package process
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"syscall"
@@ -54,6 +55,7 @@ func TestToOSSignal(t *testing.T) {
 		{SignalKill, syscall.SIGKILL},
 		{SignalContinue, syscall.SIGCONT},
 		{SignalTerminate, syscall.SIGTERM},
+		{SignalInterrupt, syscall.SIGINT},
 	}
 
 	for _, tt := range tests {
@@ -163,3 +165,30 @@ func TestSendSignal_RealProcess(t *testing.T) {
 		_ = SendSignal(pid, SignalKill)
 	}
 }
+
+func TestSendSignal_Interrupt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping real process test in short mode")
+	}
+
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep process: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+	}()
+
+	if err := SendSignal(cmd.Process.Pid, SignalInterrupt); err != nil {
+		t.Fatalf("SendSignal(SIGINT) failed: %v", err)
+	}
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("sleep should have been interrupted, got: %v", err)
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); !ok || ws.Signal() != syscall.SIGINT {
+		t.Errorf("sleep exited with %v, want SIGINT", exitErr)
+	}
+}
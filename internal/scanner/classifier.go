@@ -1,7 +1,6 @@
 package scanner
 
 import (
-	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
@@ -9,16 +8,22 @@ import (
 
 // ClassifyTelemetry is a pure function that determines the telemetry status
 // of a Claude Code process based on its environment variables and the
-// configured OTLP receiver port.
+// ports cc-top's OTLP receivers listen on.
 //
 // Classification logic:
 //   - hasReceivedData=true => Connected (ground truth overrides env vars)
 //   - EnvReadable=false => Unknown
 //   - CLAUDE_CODE_ENABLE_TELEMETRY absent or "0" => Off
-//   - Telemetry=1, no OTEL_EXPORTER_OTLP_ENDPOINT => ConsoleOnly
-//   - Telemetry=1, endpoint port != configuredPort => WrongPort
-//   - Telemetry=1, endpoint port == configuredPort, no data yet => Waiting
-func ClassifyTelemetry(proc ProcessInfo, configuredPort int, hasReceivedData bool) StatusInfo {
+//   - Telemetry=1, in a container, endpoint on its own loopback => Unreachable
+//   - Telemetry=1, any OTLP signal aimed at the wrong receiver => WrongPort
+//   - Telemetry=1, no signal exported over OTLP => ConsoleOnly
+//   - Telemetry=1, only some signals exported over OTLP => ConsoleOnly (partial)
+//   - Telemetry=1, every signal reaches cc-top, no data yet => Waiting
+//
+// Each signal is resolved with ResolveSignal, so gRPC must target
+// ports.GRPC and http/protobuf or http/json must target ports.HTTP. The
+// per-signal results, with fix suggestions, are returned in Signals.
+func ClassifyTelemetry(proc ProcessInfo, ports ReceiverPorts, hasReceivedData bool) StatusInfo {
 	// If we've actually received telemetry data from this process, it's
 	// connected regardless of what the env vars say. This handles the case
 	// where telemetry is configured via settings file rather than env vars.
@@ -46,47 +51,62 @@ func ClassifyTelemetry(proc ProcessInfo, configuredPort int, hasReceivedData boo
 			Status: TelemetryOff,
 			Icon:   "\u274c", // red X
 			Label:  "No telemetry",
+			Fix:    withSource("set CLAUDE_CODE_ENABLE_TELEMETRY=1", "CLAUDE_CODE_ENABLE_TELEMETRY", proc.EnvSources),
 		}
 	}
 
-	// Telemetry is enabled. Check exporter configuration.
-	endpoint, hasEndpoint := proc.EnvVars["OTEL_EXPORTER_OTLP_ENDPOINT"]
-	metricsExporter := proc.EnvVars["OTEL_METRICS_EXPORTER"]
-	logsExporter := proc.EnvVars["OTEL_LOGS_EXPORTER"]
-
-	// If no OTLP endpoint set and exporters aren't set to "otlp", it's console only.
-	if !hasEndpoint || endpoint == "" {
-		if metricsExporter != "otlp" && logsExporter != "otlp" {
-			return StatusInfo{
-				Status: TelemetryConsoleOnly,
-				Icon:   "\u26a0\ufe0f", // warning
-				Label:  "Console only",
+	// Telemetry is enabled. Check where each signal is exported.
+	var otlp, misdirected, unreachable []string
+	signals := make([]SignalDiagnosis, 0, len(Signals))
+	for _, sig := range Signals {
+		d := DiagnoseSignal(ResolveSignal(proc.EnvVars, sig), ports, proc.EnvSources)
+		d = DiagnoseContainerReach(d, proc.Container, ports, proc.EnvSources)
+		signals = append(signals, d)
+		if d.OTLP {
+			otlp = append(otlp, sig)
+			switch {
+			case d.Unreachable:
+				unreachable = append(unreachable, sig)
+			case !d.OK:
+				misdirected = append(misdirected, sig)
 			}
 		}
-		// Exporters set to otlp but no endpoint means they'll try default (localhost:4317).
-		// Check if our port matches the default.
-		if configuredPort == 4317 {
-			return connectedOrWaiting(hasReceivedData)
+	}
+
+	switch {
+	case len(unreachable) > 0:
+		return StatusInfo{
+			Status:  TelemetryUnreachable,
+			Icon:    "\u26a0\ufe0f", // warning
+			Label:   "Unreachable",
+			Signals: signals,
 		}
+	case len(misdirected) > 0:
 		return StatusInfo{
-			Status: TelemetryWrongPort,
-			Icon:   "\u26a0\ufe0f", // warning
-			Label:  "Wrong port",
+			Status:  TelemetryWrongPort,
+			Icon:    "\u26a0\ufe0f", // warning
+			Label:   "Wrong port",
+			Signals: signals,
+		}
+	case len(otlp) == 0:
+		return StatusInfo{
+			Status:  TelemetryConsoleOnly,
+			Icon:    "\u26a0\ufe0f", // warning
+			Label:   "Console only",
+			Signals: signals,
+		}
+	case len(otlp) < len(Signals):
+		return StatusInfo{
+			Status:  TelemetryConsoleOnly,
+			Icon:    "\u26a0\ufe0f", // warning
+			Label:   "Partial (" + strings.Join(otlp, ", ") + " only)",
+			Signals: signals,
 		}
 	}
 
-	// Parse the endpoint to extract the port.
-	endpointPort := extractPort(endpoint, configuredPort)
-
-	if endpointPort == configuredPort {
-		return connectedOrWaiting(hasReceivedData)
-	}
-
-	return StatusInfo{
-		Status: TelemetryWrongPort,
-		Icon:   "\u26a0\ufe0f", // warning
-		Label:  fmt.Sprintf("Wrong port"),
-	}
+	status := connectedOrWaiting(hasReceivedData)
+	status.Signals = signals
+	return status
 }
 
 // connectedOrWaiting returns Connected or Waiting status based on whether
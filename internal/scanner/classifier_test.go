@@ -16,7 +16,7 @@ func TestTelemetryClassifier_Connected(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 4317, true)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, true)
 
 	if result.Status != TelemetryConnected {
 		t.Errorf("Status = %v, want TelemetryConnected", result.Status)
@@ -38,7 +38,7 @@ func TestTelemetryClassifier_Waiting(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 4317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryWaiting {
 		t.Errorf("Status = %v, want TelemetryWaiting", result.Status)
@@ -60,7 +60,7 @@ func TestTelemetryClassifier_WrongPort(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 4317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryWrongPort {
 		t.Errorf("Status = %v, want TelemetryWrongPort", result.Status)
@@ -81,7 +81,7 @@ func TestTelemetryClassifier_WrongPort_CustomConfiguredPort(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 5317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 5317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryWrongPort {
 		t.Errorf("Status = %v, want TelemetryWrongPort (process points to 4317 but cc-top is on 5317)", result.Status)
@@ -99,7 +99,7 @@ func TestTelemetryClassifier_ConsoleOnly(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 4317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryConsoleOnly {
 		t.Errorf("Status = %v, want TelemetryConsoleOnly", result.Status)
@@ -120,7 +120,7 @@ func TestTelemetryClassifier_ConsoleOnly_ExplicitConsoleExporter(t *testing.T) {
 		},
 	}
 
-	result := ClassifyTelemetry(proc, 4317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryConsoleOnly {
 		t.Errorf("Status = %v, want TelemetryConsoleOnly", result.Status)
@@ -158,7 +158,7 @@ func TestTelemetryClassifier_NoTelemetry(t *testing.T) {
 				EnvVars:     tt.envVars,
 			}
 
-			result := ClassifyTelemetry(proc, 4317, false)
+			result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 			if result.Status != TelemetryOff {
 				t.Errorf("Status = %v, want TelemetryOff", result.Status)
@@ -177,7 +177,7 @@ func TestTelemetryClassifier_Unknown(t *testing.T) {
 		EnvVars:     nil,
 	}
 
-	result := ClassifyTelemetry(proc, 4317, false)
+	result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 
 	if result.Status != TelemetryUnknown {
 		t.Errorf("Status = %v, want TelemetryUnknown", result.Status)
@@ -237,7 +237,7 @@ func TestTelemetryClassifier_EndpointParsing(t *testing.T) {
 				},
 			}
 
-			result := ClassifyTelemetry(proc, tt.configuredPort, false)
+			result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: tt.configuredPort, HTTP: 4318}, false)
 
 			if result.Status != tt.wantStatus {
 				t.Errorf("Status = %v, want %v for endpoint %q with configured port %d",
@@ -296,7 +296,7 @@ func TestTelemetryClassifier_HasReceivedData_OverridesEnvVars(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ClassifyTelemetry(tt.proc, 4317, true)
+			result := ClassifyTelemetry(tt.proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, true)
 
 			if result.Status != TelemetryConnected {
 				t.Errorf("Status = %v, want TelemetryConnected (hasReceivedData should override)", result.Status)
@@ -323,14 +323,14 @@ func TestTelemetryClassifier_OtlpExporterWithoutEndpoint(t *testing.T) {
 	}
 
 	t.Run("default port matches", func(t *testing.T) {
-		result := ClassifyTelemetry(proc, 4317, false)
+		result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
 		if result.Status != TelemetryWaiting {
 			t.Errorf("Status = %v, want TelemetryWaiting (otlp defaults to 4317)", result.Status)
 		}
 	})
 
 	t.Run("custom port does not match default", func(t *testing.T) {
-		result := ClassifyTelemetry(proc, 5317, false)
+		result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 5317, HTTP: 4318}, false)
 		if result.Status != TelemetryWrongPort {
 			t.Errorf("Status = %v, want TelemetryWrongPort (otlp defaults to 4317, not 5317)", result.Status)
 		}
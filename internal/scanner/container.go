@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"encoding/hex"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ContainerInspector is implemented by ProcessAPIs that can tell whether a
+// process runs in a container (another PID, mount or network namespace).
+// The scanner uses it when available.
+type ContainerInspector interface {
+	// GetContainerInfo describes the container pid runs in, given its CWD
+	// as read from the process. Returns nil if the process shares cc-top's
+	// namespaces and is not in a container cgroup.
+	GetContainerInfo(pid int, cwd string) (*ContainerInfo, error)
+}
+
+// ContainerInfo describes the container a process runs in.
+type ContainerInfo struct {
+	Runtime      string // "docker", "podman", "containerd", "cri-o", "kubernetes", or "" if unknown
+	ID           string // full container ID; "" if unknown
+	Name         string // container name; "" if unknown
+	Devcontainer bool   // started by a Dev Containers tool
+
+	// Namespaces the process does not share with cc-top.
+	PIDNamespace   bool
+	MountNamespace bool
+	NetNamespace   bool
+
+	CWD         string // working directory as seen inside the container
+	HostCWD     string // CWD translated to a host path; "" if not bind-mounted from the host
+	HostGateway string // address of the host from inside the container; "" if unknown
+}
+
+// Label returns the container name, else the short container ID, else the
+// runtime.
+func (c *ContainerInfo) Label() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case len(c.ID) > 12:
+		return c.ID[:12]
+	case c.ID != "":
+		return c.ID
+	case c.Runtime != "":
+		return c.Runtime
+	}
+	return "container"
+}
+
+// hostGatewayHost returns the host to use in an OTLP endpoint from inside
+// the container: the default gateway when known, otherwise the runtime's
+// well-known host alias.
+func (c *ContainerInfo) hostGatewayHost() string {
+	if c.HostGateway != "" {
+		return c.HostGateway
+	}
+	if c.Runtime == "podman" {
+		return "host.containers.internal"
+	}
+	return "host.docker.internal"
+}
+
+// applyContainerEnv fills in details only the process environment carries.
+func applyContainerEnv(c *ContainerInfo, env map[string]string) {
+	// distrobox exports the container name as CONTAINER_ID.
+	if c.Name == "" && env["CONTAINER_ID"] != "" {
+		c.Name = env["CONTAINER_ID"]
+	}
+	// podman and systemd-nspawn set container=<runtime>.
+	if c.Runtime == "" && env["container"] != "" {
+		c.Runtime = env["container"]
+	}
+	if env["REMOTE_CONTAINERS"] == "true" || env["DEVCONTAINER"] == "true" || env["CODESPACES"] == "true" {
+		c.Devcontainer = true
+	}
+}
+
+// cgroupContainerRe matches the container ID in cgroup paths written by
+// docker ("docker-<id>.scope", "/docker/<id>"), podman ("libpod-<id>"),
+// containerd ("cri-containerd-<id>") and CRI-O ("crio-<id>").
+var cgroupContainerRe = regexp.MustCompile(`(docker|libpod|cri-containerd|crio)[-/]([0-9a-f]{64})`)
+
+// kubepodsRe matches a container ID at the end of a kubepods cgroup path.
+var kubepodsRe = regexp.MustCompile(`kubepods.*/([0-9a-f]{64})(?:\.scope)?$`)
+
+// parseCgroupContainer extracts the runtime and container ID from the
+// contents of /proc/[pid]/cgroup. Returns empty strings if none is found.
+func parseCgroupContainer(data string) (runtime, id string) {
+	for _, line := range strings.Split(data, "\n") {
+		// hierarchy-ID:controllers:path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		p := parts[2]
+		if m := cgroupContainerRe.FindStringSubmatch(p); m != nil {
+			switch m[1] {
+			case "libpod":
+				return "podman", m[2]
+			case "cri-containerd":
+				return "containerd", m[2]
+			case "crio":
+				return "cri-o", m[2]
+			}
+			return m[1], m[2]
+		}
+		if m := kubepodsRe.FindStringSubmatch(p); m != nil {
+			return "kubernetes", m[1]
+		}
+	}
+	return "", ""
+}
+
+// parseContainerEnvFile parses podman's /run/.containerenv, which holds
+// key="value" lines such as name, id and engine.
+func parseContainerEnvFile(data string) map[string]string {
+	result := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		if unq, err := strconv.Unquote(v); err == nil {
+			v = unq
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// mountEntry is one line of /proc/[pid]/mountinfo.
+type mountEntry struct {
+	dev   string // major:minor of the filesystem
+	root  string // path within the filesystem that is mounted
+	point string // mount point, relative to the process's root
+}
+
+// parseMountInfo parses the contents of /proc/[pid]/mountinfo.
+// Format: id parent major:minor root mount-point options ...
+func parseMountInfo(data string) []mountEntry {
+	var mounts []mountEntry
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, mountEntry{
+			dev:   fields[2],
+			root:  unescapeMountPath(fields[3]),
+			point: unescapeMountPath(fields[4]),
+		})
+	}
+	return mounts
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space, etc.)
+// mountinfo uses for whitespace and backslashes.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// translatePath maps p, a path inside a container, to the host path it is
+// bind-mounted from, using the container's and the host's mount tables.
+// Returns "" when p lives on the container's own root filesystem or its
+// source is not mounted on the host.
+func translatePath(p string, inner, host []mountEntry) string {
+	in, ok := longestMount(inner, func(m mountEntry) string { return m.point }, p)
+	if !ok || in.point == "/" {
+		return ""
+	}
+	src := path.Join(in.root, strings.TrimPrefix(p, in.point))
+
+	var candidates []mountEntry
+	for _, m := range host {
+		if m.dev == in.dev {
+			candidates = append(candidates, m)
+		}
+	}
+	out, ok := longestMount(candidates, func(m mountEntry) string { return m.root }, src)
+	if !ok {
+		return ""
+	}
+	return path.Join(out.point, strings.TrimPrefix(src, out.root))
+}
+
+// longestMount returns the mount whose key (mount point or root) is the
+// longest path prefix of p.
+func longestMount(mounts []mountEntry, key func(mountEntry) string, p string) (mountEntry, bool) {
+	var best mountEntry
+	found := false
+	for _, m := range mounts {
+		k := key(m)
+		if k != "/" && p != k && !strings.HasPrefix(p, k+"/") {
+			continue
+		}
+		if !found || len(k) > len(key(best)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// parseDefaultGateway returns the IPv4 default gateway from the contents
+// of /proc/[pid]/net/route, or "" if there is none. Addresses in that file
+// are little-endian hex.
+func parseDefaultGateway(data string) string {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+		if b[0]|b[1]|b[2]|b[3] == 0 {
+			continue
+		}
+		return net.IPv4(b[3], b[2], b[1], b[0]).String()
+	}
+	return ""
+}
+
+// isLoopbackHost reports whether host only reaches the local network
+// namespace: "localhost", a loopback address, or the unspecified address.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package scanner
+
+import (
+	"fmt"
+	"os"
+)
+
+// GetContainerInfo compares pid's PID, mount and network namespaces with
+// cc-top's own and reads its cgroup for a container ID. For processes in
+// another mount namespace it also reads podman's /run/.containerenv and
+// translates cwd to a host path; for another network namespace it reads
+// the default gateway.
+func (l *linuxProcessAPI) GetContainerInfo(pid int, cwd string) (*ContainerInfo, error) {
+	c := &ContainerInfo{
+		PIDNamespace:   otherNamespace(pid, "pid"),
+		MountNamespace: otherNamespace(pid, "mnt"),
+		NetNamespace:   otherNamespace(pid, "net"),
+	}
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid)); err == nil {
+		c.Runtime, c.ID = parseCgroupContainer(string(data))
+	}
+	if !c.PIDNamespace && !c.MountNamespace && !c.NetNamespace && c.ID == "" {
+		return nil, nil
+	}
+
+	if c.MountNamespace {
+		c.CWD = cwd
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/root/run/.containerenv", pid)); err == nil {
+			env := parseContainerEnvFile(string(data))
+			c.Name = env["name"]
+			if c.ID == "" {
+				c.ID = env["id"]
+			}
+			if c.Runtime == "" {
+				c.Runtime = "podman"
+			}
+		}
+		inner, err := os.ReadFile(fmt.Sprintf("/proc/%d/mountinfo", pid))
+		host, herr := os.ReadFile("/proc/self/mountinfo")
+		if err == nil && herr == nil {
+			c.HostCWD = translatePath(cwd, parseMountInfo(string(inner)), parseMountInfo(string(host)))
+		}
+	}
+
+	if c.NetNamespace {
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/net/route", pid)); err == nil {
+			c.HostGateway = parseDefaultGateway(string(data))
+		}
+	}
+	return c, nil
+}
+
+// otherNamespace reports whether pid is in a different namespace of the
+// given kind than cc-top. Unreadable namespaces count as shared.
+func otherNamespace(pid int, kind string) bool {
+	self, err := os.Readlink("/proc/self/ns/" + kind)
+	if err != nil {
+		return false
+	}
+	theirs, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/%s", pid, kind))
+	if err != nil {
+		return false
+	}
+	return self != theirs
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"testing"
+)
+
+const testContainerID = "4f1e3c2b9a8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f"
+
+func TestParseCgroupContainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantRuntime string
+		wantID      string
+	}{
+		{
+			name:        "docker systemd driver",
+			data:        "0::/system.slice/docker-" + testContainerID + ".scope\n",
+			wantRuntime: "docker",
+			wantID:      testContainerID,
+		},
+		{
+			name:        "docker cgroupfs driver v1",
+			data:        "12:pids:/docker/" + testContainerID + "\n11:memory:/docker/" + testContainerID + "\n",
+			wantRuntime: "docker",
+			wantID:      testContainerID,
+		},
+		{
+			name:        "rootless podman",
+			data:        "0::/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + testContainerID + ".scope/container\n",
+			wantRuntime: "podman",
+			wantID:      testContainerID,
+		},
+		{
+			name:        "kubernetes",
+			data:        "0::/kubepods/burstable/pod1234/" + testContainerID + "\n",
+			wantRuntime: "kubernetes",
+			wantID:      testContainerID,
+		},
+		{
+			name: "host session",
+			data: "0::/user.slice/user-1000.slice/session-2.scope\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime, id := parseCgroupContainer(tt.data)
+			if runtime != tt.wantRuntime || id != tt.wantID {
+				t.Errorf("parseCgroupContainer() = %q, %q; want %q, %q", runtime, id, tt.wantRuntime, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestParseContainerEnvFile(t *testing.T) {
+	env := parseContainerEnvFile("engine=\"podman-4.9.3\"\nname=\"devbox\"\nid=\"" + testContainerID + "\"\nrootless=1\n")
+	if env["name"] != "devbox" || env["id"] != testContainerID || env["rootless"] != "1" {
+		t.Errorf("parseContainerEnvFile() = %v", env)
+	}
+}
+
+func TestTranslatePath(t *testing.T) {
+	host := parseMountInfo(`22 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw
+45 22 259:3 / /home rw,relatime shared:30 - ext4 /dev/nvme0n1p3 rw
+`)
+	inner := parseMountInfo(`612 540 0:58 / / rw,relatime - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/l/X
+640 612 259:3 /alice/src/my\040app /workspaces/app rw,relatime - ext4 /dev/nvme0n1p3 rw
+641 612 259:2 /etc/hosts /etc/hosts rw,relatime - ext4 /dev/nvme0n1p2 rw
+`)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/workspaces/app", "/home/alice/src/my app"},
+		{"/workspaces/app/internal/tui", "/home/alice/src/my app/internal/tui"},
+		{"/workspaces/application", ""}, // not under the bind mount
+		{"/root", ""},                   // container root filesystem
+	}
+	for _, tt := range tests {
+		if got := translatePath(tt.path, inner, host); got != tt.want {
+			t.Errorf("translatePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseDefaultGateway(t *testing.T) {
+	route := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	010011AC	0003	0	0	0	00000000	0	0	0
+eth0	000011AC	00000000	0001	0	0	0	0000FFFF	0	0	0
+`
+	if got := parseDefaultGateway(route); got != "172.17.0.1" {
+		t.Errorf("parseDefaultGateway() = %q, want 172.17.0.1", got)
+	}
+	if got := parseDefaultGateway("Iface\tDestination\tGateway\n"); got != "" {
+		t.Errorf("parseDefaultGateway(no default route) = %q, want empty", got)
+	}
+}
+
+func TestContainerInfo_Label(t *testing.T) {
+	tests := []struct {
+		c    ContainerInfo
+		want string
+	}{
+		{ContainerInfo{Name: "devbox", ID: testContainerID}, "devbox"},
+		{ContainerInfo{ID: testContainerID, Runtime: "docker"}, "4f1e3c2b9a8d"},
+		{ContainerInfo{Runtime: "podman"}, "podman"},
+		{ContainerInfo{}, "container"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestClassifyTelemetry_ContainerEndpoint(t *testing.T) {
+	env := map[string]string{
+		"CLAUDE_CODE_ENABLE_TELEMETRY": "1",
+		"OTEL_METRICS_EXPORTER":        "otlp",
+		"OTEL_LOGS_EXPORTER":           "otlp",
+		"OTEL_EXPORTER_OTLP_ENDPOINT":  "http://localhost:4317",
+	}
+	ports := ReceiverPorts{GRPC: 4317, HTTP: 4318, Bind: "127.0.0.1"}
+	bridged := &ContainerInfo{Runtime: "docker", ID: testContainerID, NetNamespace: true, HostGateway: "172.17.0.1"}
+
+	t.Run("loopback endpoint in own network namespace", func(t *testing.T) {
+		proc := ProcessInfo{EnvReadable: true, EnvVars: env, Container: bridged}
+		result := ClassifyTelemetry(proc, ports, false)
+		if result.Status != TelemetryUnreachable {
+			t.Fatalf("Status = %v (%s), want TelemetryUnreachable", result.Status, result.Label)
+		}
+		want := `set OTEL_EXPORTER_OTLP_ENDPOINT=http://172.17.0.1:4317; set [receiver] bind = "172.17.0.1" in cc-top's config`
+		if got := result.Signals[0].Fix; got != want {
+			t.Errorf("Fix = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("gateway endpoint with a reachable receiver", func(t *testing.T) {
+		gwEnv := map[string]string{}
+		for k, v := range env {
+			gwEnv[k] = v
+		}
+		gwEnv["OTEL_EXPORTER_OTLP_ENDPOINT"] = "http://172.17.0.1:4317"
+		proc := ProcessInfo{EnvReadable: true, EnvVars: gwEnv, Container: bridged}
+		open := ReceiverPorts{GRPC: 4317, HTTP: 4318, Bind: "0.0.0.0"}
+		if result := ClassifyTelemetry(proc, open, false); result.Status != TelemetryWaiting {
+			t.Errorf("Status = %v (%s), want TelemetryWaiting", result.Status, result.Label)
+		}
+	})
+
+	t.Run("host network", func(t *testing.T) {
+		hostNet := &ContainerInfo{Runtime: "podman", Name: "devbox", MountNamespace: true}
+		proc := ProcessInfo{EnvReadable: true, EnvVars: env, Container: hostNet}
+		if result := ClassifyTelemetry(proc, ports, false); result.Status != TelemetryWaiting {
+			t.Errorf("Status = %v (%s), want TelemetryWaiting", result.Status, result.Label)
+		}
+	})
+
+	t.Run("podman without a known gateway", func(t *testing.T) {
+		c := &ContainerInfo{Runtime: "podman", NetNamespace: true}
+		httpEnv := map[string]string{
+			"CLAUDE_CODE_ENABLE_TELEMETRY":        "1",
+			"OTEL_METRICS_EXPORTER":               "otlp",
+			"OTEL_LOGS_EXPORTER":                  "otlp",
+			"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/protobuf",
+			"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "http://127.0.0.1:4318/v1/metrics",
+		}
+		proc := ProcessInfo{EnvReadable: true, EnvVars: httpEnv, Container: c}
+		result := ClassifyTelemetry(proc, ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
+		want := "set OTEL_EXPORTER_OTLP_METRICS_ENDPOINT=http://host.containers.internal:4318/v1/metrics"
+		if got := result.Signals[0].Fix; got != want {
+			t.Errorf("Fix = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// InvocationMode describes how a Claude Code process was started.
+type InvocationMode string
+
+const (
+	ModeInteractive InvocationMode = "interactive" // a user at a terminal
+	ModeHeadless    InvocationMode = "headless"    // claude -p / --print
+	ModeSDK         InvocationMode = "sdk"         // driven by an Agent SDK host over stream-json
+	ModeIDE         InvocationMode = "ide"         // launched by an IDE extension
+)
+
+// InvocationModes lists every mode, in display order.
+var InvocationModes = []InvocationMode{ModeInteractive, ModeHeadless, ModeSDK, ModeIDE}
+
+// ClassifyInvocation determines the invocation mode from a process's argv
+// and environment. CLAUDE_CODE_ENTRYPOINT, which the SDKs and IDE
+// extensions set when they spawn the CLI, takes precedence; otherwise a
+// stream-json input format means an SDK host and -p/--print a headless
+// run.
+func ClassifyInvocation(args []string, env map[string]string) InvocationMode {
+	entrypoint := strings.ToLower(env["CLAUDE_CODE_ENTRYPOINT"])
+	switch {
+	case strings.HasPrefix(entrypoint, "sdk"):
+		return ModeSDK
+	case strings.Contains(entrypoint, "vscode"), strings.Contains(entrypoint, "jetbrains"), strings.Contains(entrypoint, "ide"):
+		return ModeIDE
+	}
+
+	print, streamInput := false, false
+flags:
+	for i, a := range args {
+		switch {
+		case a == "--":
+			break flags
+		case a == "-p" || a == "--print":
+			print = true
+		case a == "--input-format=stream-json",
+			a == "--input-format" && i+1 < len(args) && args[i+1] == "stream-json":
+			streamInput = true
+		}
+	}
+	switch {
+	case streamInput:
+		return ModeSDK
+	case print:
+		return ModeHeadless
+	}
+	return ModeInteractive
+}
+
+// Parent applications reported by detectParentApp.
+const (
+	ParentVSCode      = "VS Code"
+	ParentCursor      = "Cursor"
+	ParentJetBrains   = "JetBrains"
+	ParentCron        = "cron"
+	ParentShellScript = "shell script"
+	ParentCI          = "CI"
+)
+
+// maxAncestors bounds how far up the process tree detectParentApp looks.
+const maxAncestors = 10
+
+// ancestor is a process above a Claude Code instance in the process tree.
+type ancestor struct {
+	name string
+	args []string
+}
+
+// detectParentApp names the application that launched a Claude Code
+// process. The nearest recognised ancestor wins: an editor, a cron daemon,
+// or a shell running a script file. Without one, CI and editor-terminal
+// environment variables are consulted, and SDK hosts are named after
+// their immediate parent process. Returns "" if nothing is recognised.
+func detectParentApp(ancestors []ancestor, env map[string]string, mode InvocationMode) string {
+	for _, a := range ancestors {
+		if app := recogniseApp(a); app != "" {
+			return app
+		}
+	}
+
+	switch {
+	case env["GITHUB_ACTIONS"] == "true", env["CI"] == "true", env["CI"] == "1":
+		return ParentCI
+	case env["TERMINAL_EMULATOR"] == "JetBrains-JediTerm":
+		return ParentJetBrains
+	case env["CURSOR_TRACE_ID"] != "":
+		return ParentCursor
+	case env["TERM_PROGRAM"] == "vscode", env["VSCODE_PID"] != "":
+		return ParentVSCode
+	}
+
+	if mode == ModeSDK && len(ancestors) > 0 {
+		return ancestors[0].name
+	}
+	return ""
+}
+
+// jetbrainsIDEs are the launcher names of JetBrains IDEs.
+var jetbrainsIDEs = map[string]bool{
+	"idea": true, "pycharm": true, "goland": true, "webstorm": true, "clion": true,
+	"rider": true, "phpstorm": true, "rubymine": true, "datagrip": true, "studio": true,
+}
+
+// scriptShells are shells that may run Claude Code from a script file.
+var scriptShells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true,
+}
+
+// recogniseApp returns the parent application a single ancestor process
+// identifies, or "".
+func recogniseApp(a ancestor) string {
+	name := strings.ToLower(strings.TrimSuffix(a.name, ".sh"))
+	switch {
+	case name == "cron" || name == "crond" || name == "anacron":
+		return ParentCron
+	case name == "code" || name == "code-insiders" || strings.HasPrefix(name, "code helper"):
+		return ParentVSCode
+	case name == "cursor" || strings.HasPrefix(name, "cursor helper"):
+		return ParentCursor
+	case jetbrainsIDEs[name] || (name == "java" && strings.Contains(strings.Join(a.args, " "), "JetBrains")):
+		return ParentJetBrains
+	case scriptShells[name] && runsScriptFile(a.args):
+		return ParentShellScript
+	}
+	return ""
+}
+
+// runsScriptFile reports whether a shell's argv names a script file to run,
+// as opposed to an interactive shell or "sh -c <command>".
+func runsScriptFile(args []string) bool {
+	for _, a := range args[min(1, len(args)):] {
+		switch {
+		case a == "-c":
+			return false
+		case strings.HasPrefix(a, "-"):
+			continue
+		}
+		return filepath.Ext(a) == ".sh" || strings.Contains(a, "/")
+	}
+	return false
+}
+
+// ancestors walks up the process tree from pid using the ProcessAPI,
+// nearest first. It stops at init or after maxAncestors levels.
+func (s *Scanner) ancestors(pid int) []ancestor {
+	var chain []ancestor
+	for i := 0; i < maxAncestors; i++ {
+		stat, err := s.api.GetProcessStat(pid)
+		if err != nil || stat.PPID <= 1 {
+			break
+		}
+		pid = stat.PPID
+		raw, err := s.api.GetProcessInfo(pid)
+		if err != nil {
+			break
+		}
+		args, _, _ := s.api.GetProcessArgs(pid)
+		chain = append(chain, ancestor{name: raw.BinaryName, args: args})
+	}
+	return chain
+}
+
+// classifyInvocation records the invocation mode and parent application
+// of a Claude Code process.
+func (s *Scanner) classifyInvocation(info *ProcessInfo, envVars map[string]string) {
+	info.Mode = ClassifyInvocation(info.Args, envVars)
+	info.ParentApp = detectParentApp(s.ancestors(info.PID), envVars, info.Mode)
+}
@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyInvocation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want InvocationMode
+	}{
+		{"bare claude", []string{"claude"}, nil, ModeInteractive},
+		{"print short flag", []string{"claude", "-p", "fix the tests"}, nil, ModeHeadless},
+		{"print long flag", []string{"node", "/usr/lib/node_modules/@anthropic-ai/claude-code/cli.js", "--print", "hi"}, nil, ModeHeadless},
+		{"stream-json input", []string{"claude", "-p", "--input-format", "stream-json", "--output-format", "stream-json"}, nil, ModeSDK},
+		{"stream-json input with equals", []string{"claude", "--input-format=stream-json"}, nil, ModeSDK},
+		{"sdk entrypoint", []string{"claude", "-p"}, map[string]string{"CLAUDE_CODE_ENTRYPOINT": "sdk-py"}, ModeSDK},
+		{"vscode entrypoint", []string{"claude"}, map[string]string{"CLAUDE_CODE_ENTRYPOINT": "claude-vscode"}, ModeIDE},
+		{"cli entrypoint", []string{"claude"}, map[string]string{"CLAUDE_CODE_ENTRYPOINT": "cli"}, ModeInteractive},
+		{"-p after --", []string{"claude", "--", "-p"}, nil, ModeInteractive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyInvocation(tt.args, tt.env); got != tt.want {
+				t.Errorf("ClassifyInvocation(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectParentApp(t *testing.T) {
+	tests := []struct {
+		name      string
+		ancestors []ancestor
+		env       map[string]string
+		mode      InvocationMode
+		want      string
+	}{
+		{
+			name:      "interactive shell in a terminal",
+			ancestors: []ancestor{{name: "zsh", args: []string{"-zsh"}}, {name: "login"}},
+			want:      "",
+		},
+		{
+			name:      "shell script",
+			ancestors: []ancestor{{name: "bash", args: []string{"/bin/bash", "./nightly-review.sh"}}, {name: "zsh"}},
+			mode:      ModeHeadless,
+			want:      ParentShellScript,
+		},
+		{
+			name:      "sh -c is not a script",
+			ancestors: []ancestor{{name: "sh", args: []string{"sh", "-c", "claude -p hi"}}, {name: "cron"}},
+			mode:      ModeHeadless,
+			want:      ParentCron,
+		},
+		{
+			name:      "VS Code integrated terminal",
+			ancestors: []ancestor{{name: "bash", args: []string{"/bin/bash"}}, {name: "code"}},
+			want:      ParentVSCode,
+		},
+		{
+			name:      "JetBrains via java launcher",
+			ancestors: []ancestor{{name: "java", args: []string{"java", "-Didea.paths.selector=GoLand2026.1", "com.intellij.idea.Main", "JetBrains"}}},
+			want:      ParentJetBrains,
+		},
+		{
+			name: "JetBrains terminal env fallback",
+			env:  map[string]string{"TERMINAL_EMULATOR": "JetBrains-JediTerm"},
+			want: ParentJetBrains,
+		},
+		{
+			name: "CI env",
+			env:  map[string]string{"GITHUB_ACTIONS": "true"},
+			mode: ModeHeadless,
+			want: ParentCI,
+		},
+		{
+			name:      "SDK host named after its parent",
+			ancestors: []ancestor{{name: "python3"}, {name: "zsh"}},
+			mode:      ModeSDK,
+			want:      "python3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectParentApp(tt.ancestors, tt.env, tt.mode); got != tt.want {
+				t.Errorf("detectParentApp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessScanner_Invocation(t *testing.T) {
+	api := newMockAPI()
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 300, BinaryName: "cron"},
+		args: []string{"/usr/sbin/cron", "-f"},
+		stat: &ProcessStat{PID: 300, PPID: 1},
+	})
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 310, BinaryName: "sh"},
+		args: []string{"/bin/sh", "-c", "claude -p 'triage issues'"},
+		stat: &ProcessStat{PID: 310, PPID: 300},
+	})
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 320, BinaryName: "claude"},
+		args: []string{"claude", "-p", "triage issues"},
+		env:  map[string]string{},
+		cwd:  "/srv/repo",
+		stat: &ProcessStat{PID: 320, PPID: 310, StartTime: time.Unix(1700000000, 0)},
+	})
+
+	s := NewScanner(api, 5*time.Second)
+	results := s.Scan()
+	if len(results) != 1 {
+		t.Fatalf("got %d processes, want 1", len(results))
+	}
+	if p := results[0]; p.Mode != ModeHeadless || p.ParentApp != ParentCron {
+		t.Errorf("Mode, ParentApp = %q, %q; want %q, %q", p.Mode, p.ParentApp, ModeHeadless, ParentCron)
+	}
+}
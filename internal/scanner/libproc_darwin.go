@@ -4,7 +4,9 @@ package scanner
 
 /*
 #include <libproc.h>
+#include <mach/mach_time.h>
 #include <sys/sysctl.h>
+#include <sys/proc.h>
 #include <sys/proc_info.h>
 #include <sys/socket.h>
 #include <arpa/inet.h>
@@ -32,6 +34,8 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -203,6 +207,54 @@ func (d *darwinProcessAPI) PgrepClaude() []int {
 	return pgrepClaude()
 }
 
+// GetProcessStat returns parent PID, start time and CPU time for a PID
+// using PROC_PIDTASKALLINFO, which carries both the BSD info (ppid, start
+// time) and the Mach task info (user/system time) in a single call.
+func (d *darwinProcessAPI) GetProcessStat(pid int) (*ProcessStat, error) {
+	var info C.struct_proc_taskallinfo
+	ret := C.proc_pidinfo(C.int(pid), C.PROC_PIDTASKALLINFO, 0,
+		unsafe.Pointer(&info), C.int(C.sizeof_struct_proc_taskallinfo))
+	if ret <= 0 {
+		return nil, fmt.Errorf("proc_pidinfo PROC_PIDTASKALLINFO failed for pid %d", pid)
+	}
+
+	state := "R"
+	switch info.pbsd.pbi_status {
+	case C.SSTOP:
+		state = "T"
+	case C.SZOMB:
+		state = "Z"
+	case C.SSLEEP:
+		state = "S"
+	}
+
+	return &ProcessStat{
+		PID:       pid,
+		PPID:      int(info.pbsd.pbi_ppid),
+		State:     state,
+		StartTime: time.Unix(int64(info.pbsd.pbi_start_tvsec), int64(info.pbsd.pbi_start_tvusec)*1000),
+		CPUTime:   machToDuration(uint64(info.ptinfo.pti_total_user) + uint64(info.ptinfo.pti_total_system)),
+	}, nil
+}
+
+// machTimebase returns the ratio of nanoseconds to mach absolute time
+// units, in which pti_total_* are reported: 1/1 on Intel, 125/3 on Apple
+// Silicon.
+var machTimebase = sync.OnceValues(func() (numer, denom uint64) {
+	var tb C.mach_timebase_info_data_t
+	if C.mach_timebase_info(&tb) != 0 || tb.denom == 0 {
+		return 1, 1
+	}
+	return uint64(tb.numer), uint64(tb.denom)
+})
+
+// machToDuration converts mach absolute time units to a duration.
+func machToDuration(t uint64) time.Duration {
+	numer, denom := machTimebase()
+	// Divide first so large values do not overflow.
+	return time.Duration(t/denom*numer + t%denom*numer/denom)
+}
+
 // GetOpenPorts returns local and remote port pairs for TCP sockets owned by pid.
 // Each entry is [localPort, remotePort].
 func (d *darwinProcessAPI) GetOpenPorts(pid int) ([][2]int, error) {
@@ -0,0 +1,259 @@
+//go:build linux
+
+package scanner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Netlink process connector constants (linux/connector.h, linux/cn_proc.h).
+const (
+	netlinkConnector = 11 // NETLINK_CONNECTOR
+	cnIdxProc        = 1  // CN_IDX_PROC
+	cnValProc        = 1  // CN_VAL_PROC
+
+	procCnMcastListen = 1 // PROC_CN_MCAST_LISTEN
+	procCnMcastIgnore = 2 // PROC_CN_MCAST_IGNORE
+
+	procEventNone = 0x00000000 // PROC_EVENT_NONE, used for acks
+	procEventExec = 0x00000002 // PROC_EVENT_EXEC
+	procEventExit = 0x80000000 // PROC_EVENT_EXIT
+
+	cnMsgLen        = 20 // struct cn_msg without payload
+	procEventHdrLen = 16 // what, cpu, timestamp_ns
+)
+
+// netlinkAckTimeout bounds how long to wait for the kernel to confirm the
+// subscription. Unprivileged or namespaced callers get an error ack or no
+// ack at all.
+const netlinkAckTimeout = time.Second
+
+// netlinkPollTimeout is the receive timeout used so the read loop can
+// notice Close without relying on the socket being shut down under it.
+const netlinkPollTimeout = 500 * time.Millisecond
+
+// netlinkWatcher receives exec/exit notifications from the kernel's
+// process connector. Subscribing requires CAP_NET_ADMIN in the initial
+// user namespace, so it is usually only available when running as root.
+type netlinkWatcher struct {
+	fd    int
+	uid   int
+	queue *procEventQueue
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// procConnectorEvent is the subset of struct proc_event that cc-top uses.
+type procConnectorEvent struct {
+	what uint32
+	pid  int    // process_pid (thread ID)
+	tgid int    // process_tgid (process ID)
+	err  uint32 // ack.err for PROC_EVENT_NONE
+}
+
+// newNetlinkWatcher subscribes to the process connector and starts
+// delivering events. Returns an error if the subscription is not permitted.
+func newNetlinkWatcher() (*netlinkWatcher, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, netlinkConnector)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: cnIdxProc,
+	}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink bind: %w", err)
+	}
+
+	tv := syscall.NsecToTimeval(netlinkPollTimeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink set timeout: %w", err)
+	}
+
+	if err := sendProcConnectorOp(fd, procCnMcastListen); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink subscribe: %w", err)
+	}
+
+	w := &netlinkWatcher{
+		fd:    fd,
+		uid:   os.Getuid(),
+		queue: newProcEventQueue(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if err := w.awaitAck(); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Events returns the notification channel.
+func (w *netlinkWatcher) Events() <-chan ProcEvent {
+	return w.queue.ch
+}
+
+// Name identifies the backend.
+func (w *netlinkWatcher) Name() string {
+	return "netlink"
+}
+
+// Close unsubscribes, stops the read loop and closes the socket.
+func (w *netlinkWatcher) Close() error {
+	select {
+	case <-w.stop:
+		return nil
+	default:
+		close(w.stop)
+	}
+	<-w.done
+	_ = sendProcConnectorOp(w.fd, procCnMcastIgnore)
+	return syscall.Close(w.fd)
+}
+
+// awaitAck waits for the kernel's reply to PROC_CN_MCAST_LISTEN. Events
+// that arrive before the ack are discarded; the initial full scan covers
+// them.
+func (w *netlinkWatcher) awaitAck() error {
+	buf := make([]byte, os.Getpagesize())
+	deadline := time.Now().Add(netlinkAckTimeout)
+	for time.Now().Before(deadline) {
+		n, _, err := syscall.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			return fmt.Errorf("netlink receive: %w", err)
+		}
+		for _, ev := range parseProcConnectorMessages(buf[:n]) {
+			if ev.what != procEventNone {
+				continue
+			}
+			if ev.err != 0 {
+				return fmt.Errorf("netlink subscribe: %w", syscall.Errno(ev.err))
+			}
+			return nil
+		}
+	}
+	return errors.New("netlink subscribe: no acknowledgement from kernel")
+}
+
+func (w *netlinkWatcher) run() {
+	defer close(w.done)
+	defer close(w.queue.ch)
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		n, _, err := syscall.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			switch {
+			case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+				continue
+			case errors.Is(err, syscall.ENOBUFS):
+				// The kernel dropped notifications; rebuild from a full scan.
+				w.queue.push(ProcEvent{Kind: ProcResync})
+				continue
+			default:
+				return
+			}
+		}
+
+		for _, ev := range parseProcConnectorMessages(buf[:n]) {
+			switch ev.what {
+			case procEventExec:
+				if !ownedBy(ev.tgid, w.uid) {
+					continue
+				}
+				w.queue.push(ProcEvent{Kind: ProcExec, PID: ev.tgid})
+			case procEventExit:
+				// Exit is reported for every thread; only the thread
+				// group leader's exit means the process is gone.
+				if ev.pid != ev.tgid {
+					continue
+				}
+				w.queue.push(ProcEvent{Kind: ProcExit, PID: ev.tgid})
+			}
+		}
+	}
+}
+
+// sendProcConnectorOp sends a PROC_CN_MCAST_* control message to the kernel.
+func sendProcConnectorOp(fd int, op uint32) error {
+	const total = syscall.NLMSG_HDRLEN + cnMsgLen + 4
+	msg := make([]byte, total)
+	ne := binary.NativeEndian
+
+	// struct nlmsghdr
+	ne.PutUint32(msg[0:], total)
+	ne.PutUint16(msg[4:], syscall.NLMSG_DONE)
+	ne.PutUint32(msg[12:], uint32(os.Getpid()))
+
+	// struct cn_msg
+	cn := msg[syscall.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4) // payload length
+
+	// enum proc_cn_mcast_op
+	ne.PutUint32(cn[cnMsgLen:], op)
+
+	return syscall.Sendto(fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// parseProcConnectorMessages decodes the proc_event payloads in a netlink
+// datagram. Malformed or truncated messages are skipped.
+func parseProcConnectorMessages(buf []byte) []procConnectorEvent {
+	msgs, err := syscall.ParseNetlinkMessage(buf)
+	if err != nil {
+		return nil
+	}
+
+	ne := binary.NativeEndian
+	var result []procConnectorEvent
+	for _, m := range msgs {
+		data := m.Data
+		if len(data) < cnMsgLen+procEventHdrLen {
+			continue
+		}
+		if ne.Uint32(data[0:]) != cnIdxProc || ne.Uint32(data[4:]) != cnValProc {
+			continue
+		}
+
+		ev := data[cnMsgLen:]
+		body := ev[procEventHdrLen:]
+		pe := procConnectorEvent{what: ne.Uint32(ev[0:])}
+		switch pe.what {
+		case procEventNone:
+			if len(body) < 4 {
+				continue
+			}
+			pe.err = ne.Uint32(body[0:])
+		case procEventExec, procEventExit:
+			if len(body) < 8 {
+				continue
+			}
+			pe.pid = int(ne.Uint32(body[0:]))
+			pe.tgid = int(ne.Uint32(body[4:]))
+		}
+		result = append(result, pe)
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+package scanner
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// procConnectorMsg builds a netlink datagram carrying one proc_event.
+func procConnectorMsg(what uint32, body ...uint32) []byte {
+	ne := binary.NativeEndian
+	payload := procEventHdrLen + 4*len(body)
+	total := syscall.NLMSG_HDRLEN + cnMsgLen + payload
+	msg := make([]byte, total)
+
+	ne.PutUint32(msg[0:], uint32(total))
+	ne.PutUint16(msg[4:], syscall.NLMSG_DONE)
+
+	cn := msg[syscall.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], uint16(payload))
+
+	ev := cn[cnMsgLen:]
+	ne.PutUint32(ev[0:], what)
+	for i, v := range body {
+		ne.PutUint32(ev[procEventHdrLen+4*i:], v)
+	}
+	return msg
+}
+
+func TestParseProcConnectorMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+		want []procConnectorEvent
+	}{
+		{
+			name: "exec",
+			msg:  procConnectorMsg(procEventExec, 4821, 4821),
+			want: []procConnectorEvent{{what: procEventExec, pid: 4821, tgid: 4821}},
+		},
+		{
+			name: "thread exit",
+			msg:  procConnectorMsg(procEventExit, 4830, 4821, 0, 17),
+			want: []procConnectorEvent{{what: procEventExit, pid: 4830, tgid: 4821}},
+		},
+		{
+			name: "subscription ack with EPERM",
+			msg:  procConnectorMsg(procEventNone, uint32(syscall.EPERM)),
+			want: []procConnectorEvent{{what: procEventNone, err: uint32(syscall.EPERM)}},
+		},
+		{
+			name: "truncated exec body",
+			msg:  procConnectorMsg(procEventExec, 4821),
+			want: nil,
+		},
+		{
+			name: "garbage",
+			msg:  []byte{1, 2, 3},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseProcConnectorMessages(tt.msg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseProcConnectorMessages_Multiple(t *testing.T) {
+	buf := append(procConnectorMsg(procEventExec, 10, 10), procConnectorMsg(procEventExit, 11, 11, 0, 17)...)
+	got := parseProcConnectorMessages(buf)
+	if len(got) != 2 || got[0].pid != 10 || got[1].what != procEventExit {
+		t.Errorf("got %+v, want exec 10 then exit 11", got)
+	}
+}
+
+func TestListProcDirPIDs_IncludesSelf(t *testing.T) {
+	pids, err := listProcDirPIDs()
+	if err != nil {
+		t.Fatalf("listProcDirPIDs() error: %v", err)
+	}
+	found := false
+	for _, pid := range pids {
+		if pid == os.Getpid() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("listProcDirPIDs() did not include own PID %d", os.Getpid())
+	}
+	if !ownedBy(os.Getpid(), os.Getuid()) {
+		t.Error("ownedBy() should report own process as owned")
+	}
+}
+
+func TestNewPlatformWatcher_Linux(t *testing.T) {
+	w, err := newPlatformWatcher()
+	if err != nil {
+		t.Fatalf("newPlatformWatcher() error: %v", err)
+	}
+	defer w.Close()
+
+	// netlink needs CAP_NET_ADMIN; without it the /proc diff is used.
+	if name := w.Name(); name != "netlink" && name != "procdiff" {
+		t.Errorf("Name() = %q, want netlink or procdiff", name)
+	}
+}
@@ -0,0 +1,324 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Telemetry signals Claude Code exports over OTLP.
+const (
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+)
+
+// Signals lists the signals Claude Code exports, in display order.
+var Signals = []string{SignalMetrics, SignalLogs}
+
+// OTLP exporter protocols.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+	ProtocolHTTPJSON     = "http/json"
+)
+
+// Default OTLP ports, per the OpenTelemetry specification.
+const (
+	DefaultGRPCPort = 4317
+	DefaultHTTPPort = 4318
+)
+
+// EnvSource records where a telemetry environment variable was set.
+type EnvSource string
+
+const (
+	EnvSourceProcess EnvSource = "process environment"
+	EnvSourceUser    EnvSource = "user settings"
+	EnvSourceManaged EnvSource = "managed settings"
+)
+
+// ReceiverPorts are the ports cc-top's OTLP receivers listen on.
+type ReceiverPorts struct {
+	GRPC int
+	HTTP int
+	Bind string // listen address; "" if unknown
+}
+
+// forProtocol returns the receiver port that accepts protocol.
+func (p ReceiverPorts) forProtocol(protocol string) int {
+	if isHTTPProtocol(protocol) {
+		return p.HTTP
+	}
+	return p.GRPC
+}
+
+// SignalConfig is the exporter configuration a process will use for one
+// signal, after applying the OpenTelemetry SDK precedence rules.
+type SignalConfig struct {
+	Signal      string
+	OTLP        bool   // the signal is exported over OTLP
+	Exporter    string // raw OTEL_<SIGNAL>_EXPORTER value; "" when unset
+	Protocol    string
+	ProtocolVar string // variable that set Protocol; "" for the default
+	Endpoint    string // effective endpoint URL, including any /v1/<signal> path
+	EndpointVar string // variable that set Endpoint; "" for the default
+	Port        int    // -1 when Endpoint cannot be parsed
+	HeaderNames []string
+}
+
+// ResolveSignal returns the OTLP configuration env yields for signal:
+//   - OTEL_EXPORTER_OTLP_<SIGNAL>_* variables take precedence over the
+//     generic OTEL_EXPORTER_OTLP_* ones.
+//   - The protocol defaults to grpc, matching Claude Code.
+//   - A signal-specific endpoint is used as-is; the generic endpoint gets
+//     /v1/<signal> appended for HTTP protocols.
+//   - Without an endpoint, the default is localhost on 4317 for grpc and
+//     4318 for HTTP.
+//
+// A signal whose exporter is unset counts as OTLP when an endpoint is
+// configured for it, since that is almost always the intent.
+func ResolveSignal(env map[string]string, signal string) SignalConfig {
+	sig := strings.ToUpper(signal)
+	cfg := SignalConfig{Signal: signal}
+
+	cfg.Protocol, cfg.ProtocolVar = lookupFirst(env,
+		"OTEL_EXPORTER_OTLP_"+sig+"_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL")
+	if cfg.Protocol == "" {
+		cfg.Protocol = ProtocolGRPC
+	}
+
+	switch endpoint, v := lookupFirst(env, "OTEL_EXPORTER_OTLP_"+sig+"_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"); {
+	case v == "OTEL_EXPORTER_OTLP_ENDPOINT" && isHTTPProtocol(cfg.Protocol):
+		cfg.Endpoint, cfg.EndpointVar = strings.TrimRight(endpoint, "/")+"/v1/"+signal, v
+	case v != "":
+		cfg.Endpoint, cfg.EndpointVar = endpoint, v
+	case isHTTPProtocol(cfg.Protocol):
+		cfg.Endpoint = fmt.Sprintf("http://localhost:%d/v1/%s", DefaultHTTPPort, signal)
+	default:
+		cfg.Endpoint = fmt.Sprintf("http://localhost:%d", DefaultGRPCPort)
+	}
+	cfg.Port = extractPort(cfg.Endpoint, 0)
+
+	if headers, _ := lookupFirst(env, "OTEL_EXPORTER_OTLP_"+sig+"_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		cfg.HeaderNames = headerNames(headers)
+	}
+
+	cfg.Exporter = strings.TrimSpace(env["OTEL_"+sig+"_EXPORTER"])
+	if cfg.Exporter == "" {
+		cfg.OTLP = cfg.EndpointVar != ""
+	} else {
+		for _, e := range strings.Split(cfg.Exporter, ",") {
+			if strings.TrimSpace(e) == "otlp" {
+				cfg.OTLP = true
+			}
+		}
+	}
+	return cfg
+}
+
+// SignalDiagnosis reports whether one signal reaches cc-top and, if not,
+// what to change.
+type SignalDiagnosis struct {
+	SignalConfig
+	OK          bool
+	Unreachable bool // the endpoint cannot reach cc-top from the process's network namespace
+	Problem     string
+	Fix         string // exact variable assignment that would fix it
+}
+
+// DiagnoseSignal checks one resolved signal against cc-top's receivers.
+// sources, which may be nil, names where each variable was set so the fix
+// can point at the right place.
+func DiagnoseSignal(cfg SignalConfig, ports ReceiverPorts, sources map[string]EnvSource) SignalDiagnosis {
+	d := SignalDiagnosis{SignalConfig: cfg}
+	exporterVar := "OTEL_" + strings.ToUpper(cfg.Signal) + "_EXPORTER"
+
+	if !cfg.OTLP {
+		if cfg.Exporter == "" {
+			d.Problem = "not exported (" + exporterVar + " unset)"
+		} else {
+			d.Problem = "exported to " + cfg.Exporter + ", not OTLP"
+		}
+		d.Fix = withSource("set "+exporterVar+"=otlp", exporterVar, sources)
+		return d
+	}
+
+	want := ports.forProtocol(cfg.Protocol)
+	if cfg.Port == want {
+		d.OK = true
+		return d
+	}
+
+	other, otherName := ports.GRPC, "gRPC"
+	matching := ProtocolGRPC
+	if !isHTTPProtocol(cfg.Protocol) {
+		other, otherName, matching = ports.HTTP, "HTTP", ProtocolHTTPProtobuf
+	}
+	if cfg.Port == other && cfg.EndpointVar != "" {
+		// Right receiver, wrong wire format: switching the protocol is the
+		// smallest change.
+		d.Problem = fmt.Sprintf("%s sent to cc-top's %s port %d", cfg.Protocol, otherName, other)
+		protocolVar := "OTEL_EXPORTER_OTLP_PROTOCOL"
+		if isSignalVar(cfg.EndpointVar) || isSignalVar(cfg.ProtocolVar) {
+			// Only this signal is affected, and the generic protocol may
+			// still be right for the others.
+			protocolVar = "OTEL_EXPORTER_OTLP_" + strings.ToUpper(cfg.Signal) + "_PROTOCOL"
+		}
+		d.Fix = withSource("set "+protocolVar+"="+matching, protocolVar, sources)
+		return d
+	}
+
+	if cfg.Port < 0 {
+		d.Problem = fmt.Sprintf("endpoint %q is not a valid URL", cfg.Endpoint)
+	} else {
+		d.Problem = fmt.Sprintf("%s sent to port %d, cc-top listens on %d", cfg.Protocol, cfg.Port, want)
+	}
+	endpointVar := endpointVarFor(cfg)
+	d.Fix = withSource("set "+endpointVar+"="+fixedEndpoint(cfg, endpointVar, "", want), endpointVar, sources)
+	return d
+}
+
+// DiagnoseContainerReach re-checks a signal diagnosis for a process in its
+// own network namespace, where localhost is the container's loopback
+// rather than the host running cc-top. The fix points the endpoint at the
+// host gateway and, if cc-top only listens on loopback, moves its bind
+// address to the gateway too.
+func DiagnoseContainerReach(d SignalDiagnosis, c *ContainerInfo, ports ReceiverPorts, sources map[string]EnvSource) SignalDiagnosis {
+	if c == nil || !c.NetNamespace || !d.OTLP {
+		return d
+	}
+	rebind := ""
+	if ports.Bind == "localhost" || net.ParseIP(ports.Bind).IsLoopback() {
+		bind := c.HostGateway
+		if bind == "" {
+			bind = "0.0.0.0"
+		}
+		rebind = fmt.Sprintf(`set [receiver] bind = %q in cc-top's config`, bind)
+	}
+
+	host := endpointHost(d.Endpoint)
+	switch {
+	case isLoopbackHost(host):
+		want := ports.forProtocol(d.Protocol)
+		endpointVar := endpointVarFor(d.SignalConfig)
+		d.OK, d.Unreachable = false, true
+		d.Problem = fmt.Sprintf("endpoint unreachable from container %s: %s is the container's own loopback", c.Label(), host)
+		d.Fix = withSource("set "+endpointVar+"="+fixedEndpoint(d.SignalConfig, endpointVar, c.hostGatewayHost(), want), endpointVar, sources)
+		if rebind != "" {
+			d.Fix += "; " + rebind
+		}
+	case d.OK && rebind != "":
+		d.OK, d.Unreachable = false, true
+		d.Problem = fmt.Sprintf("endpoint unreachable from container %s: cc-top listens on %s only", c.Label(), ports.Bind)
+		d.Fix = rebind
+	}
+	return d
+}
+
+// endpointVarFor returns the variable to change to move cfg's endpoint.
+func endpointVarFor(cfg SignalConfig) string {
+	switch {
+	case isSignalVar(cfg.ProtocolVar):
+		// A signal-specific protocol needs its own port, which a shared
+		// generic endpoint cannot provide.
+		return "OTEL_EXPORTER_OTLP_" + strings.ToUpper(cfg.Signal) + "_ENDPOINT"
+	case cfg.EndpointVar == "":
+		return "OTEL_EXPORTER_OTLP_ENDPOINT"
+	}
+	return cfg.EndpointVar
+}
+
+// endpointHost returns the host of an endpoint URL, or "" if it cannot be
+// parsed.
+func endpointHost(endpoint string) string {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// fixedEndpoint returns the value endpointVar needs for cfg to reach host
+// (or, if empty, the host already configured) on port. Signal-specific
+// endpoints are used as-is by the SDK, so HTTP ones must include the
+// /v1/<signal> path; the generic endpoint must not.
+func fixedEndpoint(cfg SignalConfig, endpointVar, host string, port int) string {
+	scheme := "http"
+	raw := cfg.Endpoint
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	if u, err := url.Parse(raw); err == nil && cfg.EndpointVar != "" && u.Hostname() != "" {
+		scheme = u.Scheme
+		if host == "" {
+			host = u.Hostname()
+		}
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	base := scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+	if endpointVar != "OTEL_EXPORTER_OTLP_ENDPOINT" && isHTTPProtocol(cfg.Protocol) {
+		return base + "/v1/" + cfg.Signal
+	}
+	return base
+}
+
+// withSource annotates fix with where name is currently set, when that is
+// not the process's own environment.
+func withSource(fix, name string, sources map[string]EnvSource) string {
+	switch sources[name] {
+	case EnvSourceUser:
+		return fix + " in ~/.claude/settings.json"
+	case EnvSourceManaged:
+		return fix + " in managed settings (overrides the environment)"
+	}
+	return fix
+}
+
+// lookupFirst returns the first non-empty value among names, and the name
+// it came from.
+func lookupFirst(env map[string]string, names ...string) (string, string) {
+	for _, n := range names {
+		if v := strings.TrimSpace(env[n]); v != "" {
+			return v, n
+		}
+	}
+	return "", ""
+}
+
+// isSignalVar reports whether name is a signal-specific
+// OTEL_EXPORTER_OTLP_<SIGNAL>_* variable rather than a generic one.
+func isSignalVar(name string) bool {
+	for _, sig := range Signals {
+		if strings.HasPrefix(name, "OTEL_EXPORTER_OTLP_"+strings.ToUpper(sig)+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTTPProtocol reports whether protocol is one of the OTLP/HTTP variants.
+func isHTTPProtocol(protocol string) bool {
+	return strings.HasPrefix(protocol, "http/")
+}
+
+// headerNames returns the sorted keys of an OTEL_EXPORTER_OTLP_HEADERS
+// value ("k1=v1,k2=v2").
+func headerNames(headers string) []string {
+	var names []string
+	for _, kv := range strings.Split(headers, ",") {
+		k, _, _ := strings.Cut(kv, "=")
+		if k = strings.TrimSpace(k); k != "" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,233 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var defaultPorts = ReceiverPorts{GRPC: 4317, HTTP: 4318}
+
+func TestResolveSignal_Precedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		signal       string
+		wantProtocol string
+		wantEndpoint string
+		wantPort     int
+		wantOTLP     bool
+	}{
+		{
+			name:         "defaults to grpc on 4317",
+			env:          map[string]string{"OTEL_METRICS_EXPORTER": "otlp"},
+			signal:       SignalMetrics,
+			wantProtocol: ProtocolGRPC,
+			wantEndpoint: "http://localhost:4317",
+			wantPort:     4317,
+			wantOTLP:     true,
+		},
+		{
+			name:         "http default port is 4318",
+			env:          map[string]string{"OTEL_LOGS_EXPORTER": "otlp", "OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf"},
+			signal:       SignalLogs,
+			wantProtocol: ProtocolHTTPProtobuf,
+			wantEndpoint: "http://localhost:4318/v1/logs",
+			wantPort:     4318,
+			wantOTLP:     true,
+		},
+		{
+			name: "generic endpoint gets signal path for http",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318/",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "http/json",
+			},
+			signal:       SignalMetrics,
+			wantProtocol: ProtocolHTTPJSON,
+			wantEndpoint: "http://localhost:4318/v1/metrics",
+			wantPort:     4318,
+			wantOTLP:     true,
+		},
+		{
+			name: "signal-specific settings override generic ones",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER":               "console,otlp",
+				"OTEL_EXPORTER_OTLP_ENDPOINT":         "http://localhost:4317",
+				"OTEL_EXPORTER_OTLP_PROTOCOL":         "grpc",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "http://localhost:4318/custom",
+				"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/protobuf",
+			},
+			signal:       SignalMetrics,
+			wantProtocol: ProtocolHTTPProtobuf,
+			wantEndpoint: "http://localhost:4318/custom",
+			wantPort:     4318,
+			wantOTLP:     true,
+		},
+		{
+			name:         "no exporter and no endpoint is not OTLP",
+			env:          map[string]string{},
+			signal:       SignalLogs,
+			wantProtocol: ProtocolGRPC,
+			wantEndpoint: "http://localhost:4317",
+			wantPort:     4317,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveSignal(tt.env, tt.signal)
+			if got.Protocol != tt.wantProtocol || got.Endpoint != tt.wantEndpoint ||
+				got.Port != tt.wantPort || got.OTLP != tt.wantOTLP {
+				t.Errorf("ResolveSignal() = %+v, want protocol %s endpoint %s port %d otlp %v",
+					got, tt.wantProtocol, tt.wantEndpoint, tt.wantPort, tt.wantOTLP)
+			}
+		})
+	}
+}
+
+func TestResolveSignal_HeaderNames(t *testing.T) {
+	got := ResolveSignal(map[string]string{
+		"OTEL_EXPORTER_OTLP_HEADERS":      "x-b=1, x-a=2",
+		"OTEL_EXPORTER_OTLP_LOGS_HEADERS": "Authorization=Bearer t",
+	}, SignalMetrics)
+	if want := []string{"x-a", "x-b"}; !reflect.DeepEqual(got.HeaderNames, want) {
+		t.Errorf("HeaderNames = %v, want %v", got.HeaderNames, want)
+	}
+}
+
+func TestClassifyTelemetry_MixedProtocols(t *testing.T) {
+	proc := ProcessInfo{
+		EnvReadable: true,
+		EnvVars: map[string]string{
+			"CLAUDE_CODE_ENABLE_TELEMETRY":     "1",
+			"OTEL_METRICS_EXPORTER":            "otlp",
+			"OTEL_LOGS_EXPORTER":               "otlp",
+			"OTEL_EXPORTER_OTLP_PROTOCOL":      "http/protobuf",
+			"OTEL_EXPORTER_OTLP_ENDPOINT":      "http://localhost:4318",
+			"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL": "grpc",
+			"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT": "http://localhost:4317",
+		},
+	}
+
+	result := ClassifyTelemetry(proc, defaultPorts, false)
+	if result.Status != TelemetryWaiting {
+		t.Fatalf("Status = %v (%s), want TelemetryWaiting; signals %+v", result.Status, result.Label, result.Signals)
+	}
+	if len(result.Signals) != 2 {
+		t.Fatalf("got %d signal diagnoses, want 2", len(result.Signals))
+	}
+	for _, d := range result.Signals {
+		if !d.OK {
+			t.Errorf("%s: unexpected problem %q", d.Signal, d.Problem)
+		}
+	}
+}
+
+func TestClassifyTelemetry_SignalFixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		sources map[string]EnvSource
+		status  TelemetryStatus
+		label   string
+		signal  string
+		fix     string
+	}{
+		{
+			name: "http sent to the gRPC port",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER":       "otlp",
+				"OTEL_LOGS_EXPORTER":          "otlp",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317",
+			},
+			status: TelemetryWrongPort,
+			label:  "Wrong port",
+			signal: SignalMetrics,
+			fix:    "set OTEL_EXPORTER_OTLP_PROTOCOL=grpc",
+		},
+		{
+			name: "signal endpoint on an unrelated port",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER":               "otlp",
+				"OTEL_LOGS_EXPORTER":                  "otlp",
+				"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/json",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "http://127.0.0.1:9000/v1/metrics",
+			},
+			status: TelemetryWrongPort,
+			label:  "Wrong port",
+			signal: SignalMetrics,
+			fix:    "set OTEL_EXPORTER_OTLP_METRICS_ENDPOINT=http://127.0.0.1:4318/v1/metrics",
+		},
+		{
+			name: "endpoint from managed settings",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER":       "otlp",
+				"OTEL_LOGS_EXPORTER":          "otlp",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:9999",
+			},
+			sources: map[string]EnvSource{"OTEL_EXPORTER_OTLP_ENDPOINT": EnvSourceManaged},
+			status:  TelemetryWrongPort,
+			label:   "Wrong port",
+			signal:  SignalLogs,
+			fix:     "set OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317 in managed settings (overrides the environment)",
+		},
+		{
+			name: "logs not exported",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER": "otlp",
+				"OTEL_LOGS_EXPORTER":    "console",
+			},
+			sources: map[string]EnvSource{"OTEL_LOGS_EXPORTER": EnvSourceUser},
+			status:  TelemetryConsoleOnly,
+			label:   "Partial (metrics only)",
+			signal:  SignalLogs,
+			fix:     "set OTEL_LOGS_EXPORTER=otlp in ~/.claude/settings.json",
+		},
+		{
+			name: "signal protocol with the shared endpoint",
+			env: map[string]string{
+				"OTEL_METRICS_EXPORTER":            "otlp",
+				"OTEL_LOGS_EXPORTER":               "otlp",
+				"OTEL_EXPORTER_OTLP_ENDPOINT":      "http://localhost:4317",
+				"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL": "http/json",
+			},
+			status: TelemetryWrongPort,
+			label:  "Wrong port",
+			signal: SignalLogs,
+			fix:    "set OTEL_EXPORTER_OTLP_LOGS_PROTOCOL=grpc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.env["CLAUDE_CODE_ENABLE_TELEMETRY"] = "1"
+			proc := ProcessInfo{EnvReadable: true, EnvVars: tt.env, EnvSources: tt.sources}
+
+			result := ClassifyTelemetry(proc, defaultPorts, false)
+			if result.Status != tt.status || result.Label != tt.label {
+				t.Errorf("status = %v %q, want %v %q", result.Status, result.Label, tt.status, tt.label)
+			}
+			for _, d := range result.Signals {
+				if d.Signal != tt.signal {
+					continue
+				}
+				if d.OK {
+					t.Fatalf("%s should not be OK", d.Signal)
+				}
+				if d.Fix != tt.fix {
+					t.Errorf("Fix = %q, want %q", d.Fix, tt.fix)
+				}
+				return
+			}
+			t.Errorf("no diagnosis for %s", tt.signal)
+		})
+	}
+}
+
+func TestClassifyTelemetry_OffSuggestsEnabling(t *testing.T) {
+	result := ClassifyTelemetry(ProcessInfo{EnvReadable: true, EnvVars: map[string]string{}}, defaultPorts, false)
+	if !strings.Contains(result.Fix, "CLAUDE_CODE_ENABLE_TELEMETRY=1") {
+		t.Errorf("Fix = %q, want a suggestion to enable telemetry", result.Fix)
+	}
+}
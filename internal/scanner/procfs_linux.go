@@ -11,11 +11,21 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// clockTicksPerSecond is USER_HZ, the unit of the time fields in
+// /proc/[pid]/stat. It is 100 on every mainstream Linux architecture and
+// cannot be queried without CGO (sysconf(_SC_CLK_TCK)).
+const clockTicksPerSecond = 100
+
 // linuxProcessAPI implements ProcessAPI using the Linux /proc filesystem.
 // No CGO is required.
-type linuxProcessAPI struct{}
+type linuxProcessAPI struct {
+	bootOnce sync.Once
+	bootTime time.Time // from the btime line of /proc/stat
+}
 
 // newLinuxProcessAPI returns a ProcessAPI backed by procfs.
 func newLinuxProcessAPI() ProcessAPI {
@@ -152,6 +162,98 @@ func (l *linuxProcessAPI) PgrepClaude() []int {
 	return pgrepClaude()
 }
 
+// GetProcessStat reads /proc/[pid]/stat for the parent PID, state,
+// start time and cumulative CPU time of a process.
+func (l *linuxProcessAPI) GetProcessStat(pid int) (*ProcessStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, fmt.Errorf("read stat for pid %d: %w", pid, err)
+	}
+
+	l.bootOnce.Do(func() {
+		l.bootTime, _ = readBootTime("/proc/stat")
+	})
+
+	return parseProcStat(pid, string(data), l.bootTime)
+}
+
+// parseProcStat parses the contents of /proc/[pid]/stat. The comm field
+// (field 2) is wrapped in parentheses and may itself contain spaces or
+// parentheses, so parsing starts after the last ')'.
+//
+// Fields used (1-indexed, see proc(5)): 3 state, 4 ppid, 14 utime,
+// 15 stime, 22 starttime. Time fields are in clock ticks; starttime is
+// relative to boot.
+func parseProcStat(pid int, data string, bootTime time.Time) (*ProcessStat, error) {
+	end := strings.LastIndexByte(data, ')')
+	if end < 0 || end+2 > len(data) {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	// fields[0] is field 3 (state).
+	fields := strings.Fields(data[end+2:])
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("short stat for pid %d: %d fields", pid, len(fields))
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("parse ppid for pid %d: %w", pid, err)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse utime for pid %d: %w", pid, err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse stime for pid %d: %w", pid, err)
+	}
+	startTicks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse starttime for pid %d: %w", pid, err)
+	}
+
+	st := &ProcessStat{
+		PID:     pid,
+		PPID:    ppid,
+		State:   fields[0],
+		CPUTime: ticksToDuration(utime + stime),
+	}
+	if !bootTime.IsZero() {
+		st.StartTime = bootTime.Add(ticksToDuration(startTicks))
+	}
+	return st, nil
+}
+
+// ticksToDuration converts USER_HZ clock ticks to a time.Duration.
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond
+}
+
+// readBootTime returns the system boot time from the "btime" line of
+// /proc/stat (seconds since the epoch).
+func readBootTime(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "btime ") {
+			continue
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(line[len("btime "):]), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse btime: %w", err)
+		}
+		return time.Unix(secs, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("btime not found in %s", path)
+}
+
 // readProcUID reads the real UID from /proc/[pid]/status.
 func readProcUID(pid int) (int, error) {
 	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
@@ -270,3 +372,35 @@ func parseHexPort(addrPort string) (int, error) {
 	}
 	return int(portBytes[0])<<8 | int(portBytes[1]), nil
 }
+
+// ownedBy reports whether pid belongs to the given UID, matching the
+// ownership filter applied by ListAllPIDs.
+func ownedBy(pid, uid int) bool {
+	owner, err := readProcUID(pid)
+	return err == nil && owner == uid
+}
+
+// listProcDirPIDs returns every numeric entry in /proc without reading
+// per-process files, for the incremental diff watcher.
+func listProcDirPIDs() ([]int, error) {
+	f, err := os.Open("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("open /proc: %w", err)
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("read /proc: %w", err)
+	}
+
+	pids := make([]int, 0, len(names))
+	for _, name := range names {
+		pid, err := strconv.Atoi(name)
+		if err != nil || pid <= 0 {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestLinuxProcessAPI_ListAllPIDs_IncludesSelf(t *testing.T) {
@@ -198,3 +199,58 @@ func TestNewDefaultScanner_Linux(t *testing.T) {
 	_ = fmt.Sprintf("scanner interval: %v", s.interval)
 	_ = strconv.Itoa(len(s.globalConfigPaths))
 }
+
+func TestParseProcStat(t *testing.T) {
+	boot := time.Unix(1700000000, 0)
+	// comm contains a space and a parenthesis to exercise last-')' parsing.
+	data := "4900 (npm test) (x) S 4821 4900 4821 0 -1 4194560 1000 0 0 0 250 50 0 0 20 0 1 0 12345 1000000 100 0 0\n"
+
+	st, err := parseProcStat(4900, data, boot)
+	if err != nil {
+		t.Fatalf("parseProcStat() error: %v", err)
+	}
+	if st.PPID != 4821 {
+		t.Errorf("PPID = %d, want 4821", st.PPID)
+	}
+	if st.State != "S" {
+		t.Errorf("State = %q, want S", st.State)
+	}
+	if st.CPUTime != 3*time.Second {
+		t.Errorf("CPUTime = %v, want 3s", st.CPUTime)
+	}
+	wantStart := boot.Add(123450 * time.Millisecond)
+	if !st.StartTime.Equal(wantStart) {
+		t.Errorf("StartTime = %v, want %v", st.StartTime, wantStart)
+	}
+
+	if _, err := parseProcStat(1, "garbage", boot); err == nil {
+		t.Error("expected error for malformed stat")
+	}
+}
+
+func TestLinuxProcessAPI_GetProcessStat_Self(t *testing.T) {
+	api := newLinuxProcessAPI()
+	st, err := api.GetProcessStat(os.Getpid())
+	if err != nil {
+		t.Fatalf("GetProcessStat() error: %v", err)
+	}
+	if st.PPID != os.Getppid() {
+		t.Errorf("PPID = %d, want %d", st.PPID, os.Getppid())
+	}
+	if st.StartTime.IsZero() || st.StartTime.After(time.Now()) {
+		t.Errorf("StartTime = %v, want a time in the past", st.StartTime)
+	}
+}
+
+func TestLinuxProcessAPI_GetContainerInfo_Self(t *testing.T) {
+	api := newLinuxProcessAPI().(*linuxProcessAPI)
+	c, err := api.GetContainerInfo(os.Getpid(), "/tmp")
+	if err != nil {
+		t.Fatalf("GetContainerInfo() error: %v", err)
+	}
+	// cc-top may itself run in a container, but it always shares its own
+	// namespaces.
+	if c != nil && (c.PIDNamespace || c.MountNamespace || c.NetNamespace) {
+		t.Errorf("own process reported in other namespaces: %+v", *c)
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// procEntry is what a scan cycle learned about a single PID. Entries for
+// every visible PID are kept for the duration of the scan so the process
+// tree beneath each Claude Code instance can be rebuilt from parent links.
+type procEntry struct {
+	stat *ProcessStat
+	name string
+	args []string
+}
+
+// cpuSample records a child's cumulative CPU time at a scan, used to turn
+// the next scan's reading into a CPU percentage.
+type cpuSample struct {
+	cpu     time.Duration
+	at      time.Time
+	started time.Time // guards against PID reuse between scans
+}
+
+// shellNames lists shells the Bash tool uses to run commands.
+var shellNames = map[string]bool{
+	"sh":   true,
+	"bash": true,
+	"zsh":  true,
+	"dash": true,
+	"fish": true,
+}
+
+// mcpTokenRe matches "mcp" as a standalone token within an argument,
+// e.g. "mcp-server-git", "github-mcp-server", "@playwright/mcp@latest".
+var mcpTokenRe = regexp.MustCompile(`(^|[^a-z])mcp([^a-z]|$)`)
+
+// indexChildren maps each parent PID to its child PIDs (sorted ascending)
+// using the PPID recorded in each entry's stat.
+func indexChildren(entries map[int]*procEntry) map[int][]int {
+	children := make(map[int][]int)
+	for pid, e := range entries {
+		if e.stat == nil || e.stat.PPID == pid {
+			continue
+		}
+		children[e.stat.PPID] = append(children[e.stat.PPID], pid)
+	}
+	for _, kids := range children {
+		sort.Ints(kids)
+	}
+	return children
+}
+
+// collectDescendants walks the tree below rootPID and returns every
+// descendant in depth-first order. Children inherit the kind of a
+// classified ancestor: everything beneath a Bash tool shell is a tool
+// process, and everything beneath an MCP server belongs to that server.
+func collectDescendants(rootPID int, children map[int][]int, entries map[int]*procEntry) []ChildProcess {
+	var result []ChildProcess
+	visited := map[int]bool{rootPID: true}
+
+	var walk func(parent, depth int, inherited ChildKind)
+	walk = func(parent, depth int, inherited ChildKind) {
+		for _, pid := range children[parent] {
+			if visited[pid] {
+				continue
+			}
+			visited[pid] = true
+
+			e := entries[pid]
+			kind := inherited
+			if kind == ChildOther {
+				kind = classifyChild(e.name, e.args)
+			}
+
+			child := ChildProcess{
+				PID:        pid,
+				PPID:       parent,
+				Depth:      depth,
+				BinaryName: e.name,
+				Args:       e.args,
+				Kind:       kind,
+			}
+			if e.stat != nil {
+				child.State = e.stat.State
+				child.StartedAt = e.stat.StartTime
+				child.CPUTime = e.stat.CPUTime
+			}
+			result = append(result, child)
+
+			walk(pid, depth+1, kind)
+		}
+	}
+	walk(rootPID, 1, ChildOther)
+
+	return result
+}
+
+// classifyChild decides whether a process is a Bash tool command, an MCP
+// server, or something else, from its binary name and argv.
+func classifyChild(binaryName string, args []string) ChildKind {
+	if isShellToolCommand(binaryName, args) {
+		return ChildShellTool
+	}
+	if isMCPServer(args) {
+		return ChildMCPServer
+	}
+	return ChildOther
+}
+
+// isShellToolCommand reports whether the process is a shell running an
+// inline command (sh -c, bash -lc, zsh -c -l, ...), which is how Claude
+// Code's Bash tool executes commands.
+func isShellToolCommand(binaryName string, args []string) bool {
+	name := strings.ToLower(binaryName)
+	if len(args) > 0 {
+		name = strings.ToLower(filepath.Base(args[0]))
+	}
+	name = strings.TrimPrefix(name, "-") // login shells
+	if !shellNames[name] {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune(arg, 'c') {
+			return true
+		}
+	}
+	return false
+}
+
+// isMCPServer reports whether argv looks like a Model Context Protocol server.
+func isMCPServer(args []string) bool {
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		if strings.Contains(lower, "modelcontextprotocol") {
+			return true
+		}
+		if mcpTokenRe.MatchString(lower) {
+			return true
+		}
+	}
+	return false
+}
+
+// childCPUPercent returns a child's CPU usage since the previous sample,
+// or its lifetime average when there is no usable previous sample.
+func childCPUPercent(c ChildProcess, prev cpuSample, hasPrev bool, now time.Time) float64 {
+	if hasPrev && prev.started.Equal(c.StartedAt) {
+		elapsed := now.Sub(prev.at)
+		delta := c.CPUTime - prev.cpu
+		if elapsed > 0 && delta >= 0 {
+			return float64(delta) / float64(elapsed) * 100
+		}
+	}
+	age := c.Age(now)
+	if age <= 0 {
+		return 0
+	}
+	return float64(c.CPUTime) / float64(age) * 100
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClassifyChild(t *testing.T) {
+	tests := []struct {
+		name       string
+		binaryName string
+		args       []string
+		want       ChildKind
+	}{
+		{"bash -c tool command", "bash", []string{"/bin/bash", "-c", "npm test"}, ChildShellTool},
+		{"zsh -c -l tool command", "zsh", []string{"/bin/zsh", "-c", "-l", "source snap && eval 'ls'"}, ChildShellTool},
+		{"combined -lc flags", "bash", []string{"bash", "-lc", "make"}, ChildShellTool},
+		{"login shell argv0", "bash", []string{"-bash", "-c", "ls"}, ChildShellTool},
+		{"interactive shell", "bash", []string{"/bin/bash"}, ChildOther},
+		{"shell running script", "bash", []string{"/bin/bash", "script.sh"}, ChildOther},
+		{"npx MCP server", "node", []string{"npx", "-y", "@modelcontextprotocol/server-filesystem", "/tmp"}, ChildMCPServer},
+		{"uvx MCP server", "uvx", []string{"uvx", "mcp-server-git"}, ChildMCPServer},
+		{"suffix mcp binary", "github-mcp-server", []string{"/usr/local/bin/github-mcp-server", "stdio"}, ChildMCPServer},
+		{"scoped mcp package", "node", []string{"npx", "@playwright/mcp@latest"}, ChildMCPServer},
+		{"mcp substring in word", "tmcpd", []string{"/usr/bin/tmcpd"}, ChildOther},
+		{"language server", "gopls", []string{"gopls", "serve"}, ChildOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyChild(tt.binaryName, tt.args)
+			if got != tt.want {
+				t.Errorf("classifyChild(%q, %v) = %v, want %v", tt.binaryName, tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectDescendants_DepthFirstWithInheritedKind(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := map[int]*procEntry{
+		100: {name: "claude", args: []string{"claude"}, stat: &ProcessStat{PID: 100, PPID: 1}},
+		200: {name: "bash", args: []string{"/bin/bash", "-c", "npm test"}, stat: &ProcessStat{PID: 200, PPID: 100, StartTime: start}},
+		201: {name: "node", args: []string{"node", "jest"}, stat: &ProcessStat{PID: 201, PPID: 200, State: "R", CPUTime: 3 * time.Second}},
+		300: {name: "node", args: []string{"npx", "mcp-server-git"}, stat: &ProcessStat{PID: 300, PPID: 100}},
+		301: {name: "python3", args: []string{"python3", "server.py"}, stat: &ProcessStat{PID: 301, PPID: 300}},
+		400: {name: "gopls", args: []string{"gopls"}, stat: &ProcessStat{PID: 400, PPID: 100}},
+		500: {name: "vim", args: []string{"vim"}, stat: &ProcessStat{PID: 500, PPID: 1}},
+		600: {name: "orphan", args: nil, stat: nil},
+	}
+
+	children := collectDescendants(100, indexChildren(entries), entries)
+
+	wantPIDs := []int{200, 201, 300, 301, 400}
+	if len(children) != len(wantPIDs) {
+		t.Fatalf("got %d children, want %d: %+v", len(children), len(wantPIDs), children)
+	}
+	for i, pid := range wantPIDs {
+		if children[i].PID != pid {
+			t.Errorf("children[%d].PID = %d, want %d", i, children[i].PID, pid)
+		}
+	}
+
+	byPID := make(map[int]ChildProcess)
+	for _, c := range children {
+		byPID[c.PID] = c
+	}
+
+	if byPID[200].Kind != ChildShellTool || byPID[200].Depth != 1 {
+		t.Errorf("bash: kind=%v depth=%d, want tool/1", byPID[200].Kind, byPID[200].Depth)
+	}
+	if byPID[201].Kind != ChildShellTool || byPID[201].Depth != 2 || byPID[201].PPID != 200 {
+		t.Errorf("jest: kind=%v depth=%d ppid=%d, want tool/2/200", byPID[201].Kind, byPID[201].Depth, byPID[201].PPID)
+	}
+	if byPID[201].State != "R" || byPID[201].CPUTime != 3*time.Second {
+		t.Errorf("jest: state=%q cpu=%v, want R/3s", byPID[201].State, byPID[201].CPUTime)
+	}
+	if byPID[300].Kind != ChildMCPServer || byPID[301].Kind != ChildMCPServer {
+		t.Errorf("MCP server subtree kinds = %v/%v, want mcp/mcp", byPID[300].Kind, byPID[301].Kind)
+	}
+	if byPID[400].Kind != ChildOther {
+		t.Errorf("gopls kind = %v, want other", byPID[400].Kind)
+	}
+	if !byPID[200].StartedAt.Equal(start) {
+		t.Errorf("bash StartedAt = %v, want %v", byPID[200].StartedAt, start)
+	}
+}
+
+func TestCollectDescendants_CycleSafe(t *testing.T) {
+	// Snapshot races can produce inconsistent parent links; never loop.
+	entries := map[int]*procEntry{
+		10: {name: "a", stat: &ProcessStat{PID: 10, PPID: 11}},
+		11: {name: "b", stat: &ProcessStat{PID: 11, PPID: 10}},
+	}
+	children := collectDescendants(10, indexChildren(entries), entries)
+	if len(children) != 1 || children[0].PID != 11 {
+		t.Errorf("got %+v, want only PID 11", children)
+	}
+}
+
+func TestChildCPUPercent(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 10, 0, time.UTC)
+	start := now.Add(-100 * time.Second)
+	c := ChildProcess{PID: 1, StartedAt: start, CPUTime: 20 * time.Second}
+
+	// No previous sample: lifetime average (20s CPU over 100s).
+	if got := childCPUPercent(c, cpuSample{}, false, now); math.Abs(got-20) > 0.01 {
+		t.Errorf("lifetime CPU%% = %.2f, want 20", got)
+	}
+
+	// Previous sample 10s ago at 15s CPU: 5s over 10s = 50%.
+	prev := cpuSample{cpu: 15 * time.Second, at: now.Add(-10 * time.Second), started: start}
+	if got := childCPUPercent(c, prev, true, now); math.Abs(got-50) > 0.01 {
+		t.Errorf("interval CPU%% = %.2f, want 50", got)
+	}
+
+	// Previous sample from a different process that reused the PID is ignored.
+	reused := cpuSample{cpu: 15 * time.Second, at: now.Add(-10 * time.Second), started: start.Add(-time.Hour)}
+	if got := childCPUPercent(c, reused, true, now); math.Abs(got-20) > 0.01 {
+		t.Errorf("CPU%% with reused PID sample = %.2f, want 20", got)
+	}
+}
+
+func TestProcessScanner_Children(t *testing.T) {
+	api := newMockAPI()
+	started := time.Now().Add(-time.Minute)
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+		args: []string{"/usr/local/bin/claude"},
+		env:  map[string]string{},
+		cwd:  "/tmp",
+		stat: &ProcessStat{PID: 4821, PPID: 1},
+	})
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4900, BinaryName: "bash"},
+		args: []string{"/bin/bash", "-c", "npm test"},
+		env:  map[string]string{},
+		stat: &ProcessStat{PID: 4900, PPID: 4821, StartTime: started, CPUTime: 6 * time.Second},
+	})
+
+	s := NewScanner(api, 5*time.Second)
+	results := s.Scan()
+
+	p := findPID(results, 4821)
+	if p == nil {
+		t.Fatal("PID 4821 not found")
+	}
+	if len(p.Children) != 1 {
+		t.Fatalf("got %d children, want 1", len(p.Children))
+	}
+	c := p.Children[0]
+	if c.PID != 4900 || c.Kind != ChildShellTool {
+		t.Errorf("child = PID %d kind %v, want 4900/tool", c.PID, c.Kind)
+	}
+	if c.CommandLine() != "/bin/bash -c npm test" {
+		t.Errorf("CommandLine() = %q", c.CommandLine())
+	}
+	if c.CPUPercent <= 0 {
+		t.Errorf("CPUPercent = %.2f, want > 0", c.CPUPercent)
+	}
+
+	// Once the Claude process exits, its stale tree is dropped.
+	api.removeProcess(4821)
+	api.removeProcess(4900)
+	results = s.Scan()
+	p = findPID(results, 4821)
+	if p == nil || !p.Exited {
+		t.Fatal("PID 4821 should be reported as exited")
+	}
+	if len(p.Children) != 0 {
+		t.Errorf("exited process should have no children, got %d", len(p.Children))
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,6 +42,10 @@ type ProcessAPI interface {
 	// GetOpenPorts returns local/remote port pairs for TCP sockets owned by pid.
 	GetOpenPorts(pid int) ([][2]int, error)
 
+	// GetProcessStat returns the parent PID, start time and CPU usage for a PID.
+	// Used to build the process tree beneath each Claude Code instance.
+	GetProcessStat(pid int) (*ProcessStat, error)
+
 	// PgrepClaude uses pgrep as a fallback to find Claude Code PIDs when
 	// libproc-based detection fails (e.g. macOS privacy restrictions).
 	PgrepClaude() []int
@@ -52,42 +57,83 @@ type Scanner struct {
 	interval time.Duration
 
 	mu      sync.RWMutex
-	current map[int]*ProcessInfo // currently known live processes
-	seen    map[int]bool         // PIDs seen in any previous scan (for IsNew tracking)
-	exited  map[int]*ProcessInfo // exited processes preserved for display
+	current map[int]*ProcessInfo        // currently known live processes
+	seen    map[processKey]bool         // processes seen in any previous scan (for IsNew tracking)
+	exited  map[processKey]*ProcessInfo // exited processes preserved for display
+
+	childCPU map[int]cpuSample // last CPU reading per child PID, for CPU %
+
+	listeners []ProcessListener // guarded by mu
+
+	// scanMu serialises full scans with watcher-driven updates so a scan
+	// that started before an exec notification cannot report that process
+	// as exited.
+	scanMu sync.Mutex
+
+	watcher          ProcessWatcher // nil = interval polling only
+	fallbackInterval time.Duration  // full-scan interval while a watcher is active
 
-	globalEnv         map[string]string // telemetry env from global config files
-	globalConfigPaths []string          // settings files to check; later overrides earlier
+	globalEnv         map[string]settingsValue // telemetry env from global config files
+	globalConfigPaths []string                 // settings files to check; later overrides earlier
 
 	stopCh chan struct{}
 	done   chan struct{}
 }
 
+// processKey identifies a process by PID and start time, so a PID recycled
+// by the OS for an unrelated process is treated as a different process.
+type processKey struct {
+	pid   int
+	start int64 // start time in Unix nanoseconds; 0 if unknown
+}
+
+// keyOf returns the identity of p.
+func keyOf(p *ProcessInfo) processKey {
+	k := processKey{pid: p.PID}
+	if !p.StartTime.IsZero() {
+		k.start = p.StartTime.UnixNano()
+	}
+	return k
+}
+
+// sameProcess reports whether a and b (which share a PID) are the same
+// process. An unknown start time on either side is assumed to match.
+func sameProcess(a, b *ProcessInfo) bool {
+	return a.StartTime.IsZero() || b.StartTime.IsZero() || a.StartTime.Equal(b.StartTime)
+}
+
 // NewScanner creates a Scanner with the given ProcessAPI and scan interval.
 func NewScanner(api ProcessAPI, interval time.Duration) *Scanner {
 	return &Scanner{
 		api:      api,
 		interval: interval,
 		current:  make(map[int]*ProcessInfo),
-		seen:     make(map[int]bool),
-		exited:   make(map[int]*ProcessInfo),
+		seen:     make(map[processKey]bool),
+		exited:   make(map[processKey]*ProcessInfo),
+		childCPU: make(map[int]cpuSample),
 		stopCh:   make(chan struct{}),
 		done:     make(chan struct{}),
 	}
 }
 
 // Scan performs a single scan cycle: discovers Claude Code processes,
-// enriches them with argv/env/CWD, and tracks new/exited state.
+// enriches them with argv/env/CWD and their process tree, and tracks
+// new/exited state.
 // Uses libproc as the primary method and pgrep as a fallback to ensure
 // detection on macOS Sequoia where libproc may have restricted access.
 func (s *Scanner) Scan() []ProcessInfo {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
 	pids, err := s.api.ListAllPIDs()
 	if err != nil {
 		// If we can't list PIDs at all, return whatever we have.
 		return s.listAll()
 	}
 
+	now := time.Now()
 	discovered := make(map[int]*ProcessInfo)
+	entries := make(map[int]*procEntry, len(pids))
 
 	for _, pid := range pids {
 		raw, err := s.api.GetProcessInfo(pid)
@@ -98,6 +144,9 @@ func (s *Scanner) Scan() []ProcessInfo {
 		args, envVars, envErr := s.api.GetProcessArgs(pid)
 		envReadable := envErr == nil
 
+		stat, _ := s.api.GetProcessStat(pid)
+		entries[pid] = &procEntry{stat: stat, name: raw.BinaryName, args: args}
+
 		if !isClaude(raw.BinaryName, args) {
 			continue
 		}
@@ -108,11 +157,16 @@ func (s *Scanner) Scan() []ProcessInfo {
 			PID:         pid,
 			BinaryName:  raw.BinaryName,
 			Args:        args,
-			CWD:         shortenHome(cwd),
+			CWD:         cwd,
 			Terminal:    detectTerminal(envVars),
 			EnvVars:     filterTelemetryEnvVars(envVars),
 			EnvReadable: envReadable,
 		}
+		if stat != nil {
+			info.StartTime = stat.StartTime
+		}
+		s.inspectContainer(info, cwd, envVars)
+		s.classifyInvocation(info, envVars)
 
 		discovered[pid] = info
 	}
@@ -141,70 +195,193 @@ func (s *Scanner) Scan() []ProcessInfo {
 				PID:         pid,
 				BinaryName:  "claude",
 				Args:        args,
-				CWD:         shortenHome(cwd),
+				CWD:         cwd,
 				Terminal:    detectTerminal(envVars),
 				EnvVars:     filterTelemetryEnvVars(envVars),
 				EnvReadable: envReadable,
 			}
+			if stat, err := s.api.GetProcessStat(pid); err == nil {
+				info.StartTime = stat.StartTime
+			}
+			s.classifyInvocation(info, envVars)
 			discovered[pid] = info
 		}
 	}
 
-	// Merge global config env vars into discovered processes.
-	// Process env vars take precedence over global config.
+	// Attach the process tree beneath each Claude Code instance.
+	childIndex := indexChildren(entries)
+	for pid, info := range discovered {
+		info.Children = collectDescendants(pid, childIndex, entries)
+	}
+
+	// Merge global config env vars into discovered processes. Process
+	// env vars take precedence over user settings; managed settings win.
 	s.globalEnv = s.readGlobalTelemetryConfig()
 	for _, info := range discovered {
-		for k, v := range s.globalEnv {
-			if _, has := info.EnvVars[k]; !has {
-				info.EnvVars[k] = v
-			}
-		}
+		applyGlobalEnv(info, s.globalEnv)
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
-	// Mark new PIDs: a PID is new if it has never been seen before.
+	var evts []ProcessEvent
+
+	// Detect exited processes: gone from the scan, or their PID now belongs
+	// to a process with a different start time.
+	for pid, prev := range s.current {
+		if info, alive := discovered[pid]; !alive || !sameProcess(prev, info) {
+			exited := s.markExitedLocked(prev)
+			evts = append(evts, ProcessEvent{Type: ProcessExited, Process: *exited})
+		}
+	}
+
+	// A process that is not currently tracked has just appeared, whether or
+	// not it was seen before; IsNew marks processes never seen in any
+	// earlier scan.
 	for pid, info := range discovered {
-		if !s.seen[pid] {
+		if !s.seen[keyOf(info)] {
 			info.IsNew = true
 		}
+		if prev, tracked := s.current[pid]; !tracked || !sameProcess(prev, info) {
+			evts = append(evts, ProcessEvent{Type: ProcessStarted, Process: *info})
+		}
 	}
 
-	// Detect exited PIDs (present in current but not in discovered).
-	for pid, prev := range s.current {
-		if _, stillAlive := discovered[pid]; !stillAlive {
-			exited := *prev
-			exited.Exited = true
-			exited.IsNew = false
-			s.exited[pid] = &exited
+	s.updateChildCPULocked(discovered, now)
+
+	// Record all discovered processes as seen.
+	for _, info := range discovered {
+		s.seen[keyOf(info)] = true
+	}
+
+	s.current = discovered
+
+	result := s.listAllLocked()
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(listeners, evts)
+	return result
+}
+
+// markExitedLocked records prev as exited and returns the exited copy.
+// Caller must hold s.mu and remove prev from s.current itself.
+func (s *Scanner) markExitedLocked(prev *ProcessInfo) *ProcessInfo {
+	exited := *prev
+	exited.Exited = true
+	exited.IsNew = false
+	exited.Children = nil
+	s.exited[keyOf(prev)] = &exited
+	return &exited
+}
+
+// notify delivers events to listeners. Called outside s.mu so listeners
+// may query the scanner.
+func notify(listeners []ProcessListener, evts []ProcessEvent) {
+	for _, ev := range evts {
+		for _, fn := range listeners {
+			fn(ev)
 		}
 	}
+}
+
+// OnProcessEvent registers a listener that is called whenever a Claude Code
+// process appears or exits. Listeners are invoked synchronously outside the
+// scanner lock, from whichever goroutine observed the change.
+func (s *Scanner) OnProcessEvent(fn ProcessListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
 
-	// Record all discovered PIDs as seen.
-	for pid := range discovered {
-		s.seen[pid] = true
+// updateChildCPULocked fills in CPUPercent for every child of the
+// discovered processes and records fresh CPU samples for the next scan.
+// Caller must hold s.mu.
+func (s *Scanner) updateChildCPULocked(discovered map[int]*ProcessInfo, now time.Time) {
+	samples := make(map[int]cpuSample)
+	for _, info := range discovered {
+		for i := range info.Children {
+			c := &info.Children[i]
+			prev, ok := s.childCPU[c.PID]
+			c.CPUPercent = childCPUPercent(*c, prev, ok, now)
+			samples[c.PID] = cpuSample{cpu: c.CPUTime, at: now, started: c.StartedAt}
+		}
 	}
+	s.childCPU = samples
+}
 
-	s.current = discovered
+// SetWatcher enables event-driven discovery: exec/exit notifications from w
+// are applied as they arrive, and full scans only run every
+// fallbackInterval to catch anything the watcher missed. The process trees
+// of tracked processes are still refreshed at the normal interval. If the
+// watcher's channel closes, scanning reverts to the normal interval. Must
+// be called before StartPeriodicScan; the scanner closes w on Stop.
+func (s *Scanner) SetWatcher(w ProcessWatcher, fallbackInterval time.Duration) {
+	s.watcher = w
+	s.fallbackInterval = fallbackInterval
+}
 
-	return s.listAllLocked()
+// EnableEventDiscovery installs the platform's process watcher via
+// SetWatcher and returns its name. On error the scanner keeps polling.
+func (s *Scanner) EnableEventDiscovery(fallbackInterval time.Duration) (string, error) {
+	w, err := newPlatformWatcher()
+	if err != nil {
+		return "", err
+	}
+	s.SetWatcher(w, fallbackInterval)
+	return w.Name(), nil
+}
+
+// DiscoveryMode names the active discovery backend: the watcher's name when
+// event-driven discovery is enabled, otherwise "poll".
+func (s *Scanner) DiscoveryMode() string {
+	if s.watcher == nil {
+		return "poll"
+	}
+	return s.watcher.Name()
 }
 
 // StartPeriodicScan starts background periodic scanning at the configured
 // interval. Call Stop() to halt. The initial scan runs immediately.
+// When a watcher is set, its notifications are handled on the same
+// goroutine and full scans run at the fallback interval instead, with the
+// process trees refreshed at the configured interval in between.
 func (s *Scanner) StartPeriodicScan() {
 	go func() {
 		defer close(s.done)
 		s.Scan()
 
-		ticker := time.NewTicker(s.interval)
+		interval := s.interval
+		var watchCh <-chan ProcEvent
+		var treeC <-chan time.Time
+		if s.watcher != nil {
+			watchCh = s.watcher.Events()
+			if s.fallbackInterval > interval {
+				interval = s.fallbackInterval
+			}
+			treeTicker := time.NewTicker(s.interval)
+			defer treeTicker.Stop()
+			treeC = treeTicker.C
+		}
+
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
 				s.Scan()
+			case <-treeC:
+				s.refreshTrees()
+			case ev, ok := <-watchCh:
+				if !ok {
+					// Watcher failed: fall back to interval polling, which
+					// refreshes the trees itself.
+					watchCh, treeC = nil, nil
+					ticker.Reset(s.interval)
+					s.Scan()
+					continue
+				}
+				s.handleProcEvent(ev)
 			case <-s.stopCh:
 				return
 			}
@@ -212,10 +389,206 @@ func (s *Scanner) StartPeriodicScan() {
 	}()
 }
 
-// Stop halts periodic scanning and waits for the goroutine to exit.
+// Stop halts periodic scanning, closes the watcher if one is set, and
+// waits for the goroutine to exit.
 func (s *Scanner) Stop() {
 	close(s.stopCh)
 	<-s.done
+	if s.watcher != nil {
+		_ = s.watcher.Close()
+	}
+}
+
+// refreshTrees rebuilds the process tree beneath each tracked process and
+// the children's CPU usage, between the full scans of event-driven
+// discovery. Only stats are read for every PID; names and argv are read
+// for the processes in the trees.
+func (s *Scanner) refreshTrees() {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	s.mu.RLock()
+	roots := make(map[int]time.Time, len(s.current))
+	for pid, info := range s.current {
+		roots[pid] = info.StartTime
+	}
+	s.mu.RUnlock()
+	if len(roots) == 0 {
+		return
+	}
+
+	pids, err := s.api.ListAllPIDs()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	entries := make(map[int]*procEntry, len(pids))
+	for _, pid := range pids {
+		if stat, err := s.api.GetProcessStat(pid); err == nil {
+			entries[pid] = &procEntry{stat: stat}
+		}
+	}
+	childIndex := indexChildren(entries)
+
+	trees := make(map[int][]ChildProcess, len(roots))
+	for root := range roots {
+		// Fill in names and argv below the root before collecting it.
+		visited := map[int]bool{root: true}
+		queue := append([]int(nil), childIndex[root]...)
+		for len(queue) > 0 {
+			pid := queue[0]
+			queue = queue[1:]
+			if visited[pid] {
+				continue
+			}
+			visited[pid] = true
+			e := entries[pid]
+			if raw, err := s.api.GetProcessInfo(pid); err == nil {
+				e.name = raw.BinaryName
+			}
+			e.args, _, _ = s.api.GetProcessArgs(pid)
+			queue = append(queue, childIndex[pid]...)
+		}
+		trees[root] = collectDescendants(root, childIndex, entries)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for root, children := range trees {
+		info, ok := s.current[root]
+		if !ok || !info.StartTime.Equal(roots[root]) {
+			continue // exited or replaced since the snapshot
+		}
+		info.Children = children
+	}
+	s.updateChildCPULocked(s.current, now)
+}
+
+// handleProcEvent applies a single watcher notification without a full scan.
+func (s *Scanner) handleProcEvent(ev ProcEvent) {
+	switch ev.Kind {
+	case ProcResync:
+		s.Scan()
+	case ProcExec:
+		s.handleExec(ev.PID)
+	case ProcExit:
+		s.handleExit(ev.PID)
+	}
+}
+
+// handleExec inspects a PID that has just exec'd. A Claude Code process is
+// added to the tracked set immediately; a tracked PID that exec'd into
+// something else is treated as having exited.
+func (s *Scanner) handleExec(pid int) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	info := s.inspect(pid)
+
+	s.mu.Lock()
+	var evts []ProcessEvent
+	prev, tracked := s.current[pid]
+	if tracked && info != nil && !sameProcess(prev, info) {
+		// The PID was recycled without an exit notification reaching us.
+		exited := s.markExitedLocked(prev)
+		delete(s.current, pid)
+		evts = append(evts, ProcessEvent{Type: ProcessExited, Process: *exited})
+		tracked = false
+	}
+	switch {
+	case info != nil && tracked:
+		// Re-exec of a tracked process (or a repeat notification): refresh
+		// its details but keep the tree from the last full scan.
+		applyGlobalEnv(info, s.globalEnv)
+		info.IsNew = prev.IsNew
+		info.Children = prev.Children
+		s.current[pid] = info
+	case info != nil:
+		applyGlobalEnv(info, s.globalEnv)
+		key := keyOf(info)
+		info.IsNew = !s.seen[key]
+		s.seen[key] = true
+		s.current[pid] = info
+		delete(s.exited, key)
+		evts = append(evts, ProcessEvent{Type: ProcessStarted, Process: *info})
+	case tracked:
+		exited := s.markExitedLocked(prev)
+		delete(s.current, pid)
+		evts = append(evts, ProcessEvent{Type: ProcessExited, Process: *exited})
+	}
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(listeners, evts)
+}
+
+// handleExit records a tracked PID as exited. Unknown PIDs are ignored.
+func (s *Scanner) handleExit(pid int) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	s.mu.Lock()
+	var evts []ProcessEvent
+	if prev, tracked := s.current[pid]; tracked {
+		exited := s.markExitedLocked(prev)
+		delete(s.current, pid)
+		evts = append(evts, ProcessEvent{Type: ProcessExited, Process: *exited})
+	}
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(listeners, evts)
+}
+
+// inspect returns enriched info for pid if it is a Claude Code process,
+// or nil otherwise. The process tree is left empty.
+func (s *Scanner) inspect(pid int) *ProcessInfo {
+	raw, err := s.api.GetProcessInfo(pid)
+	if err != nil {
+		return nil
+	}
+
+	args, envVars, envErr := s.api.GetProcessArgs(pid)
+	if !isClaude(raw.BinaryName, args) {
+		return nil
+	}
+
+	cwd, _ := s.api.GetProcessCWD(pid)
+
+	info := &ProcessInfo{
+		PID:         pid,
+		BinaryName:  raw.BinaryName,
+		Args:        args,
+		CWD:         cwd,
+		Terminal:    detectTerminal(envVars),
+		EnvVars:     filterTelemetryEnvVars(envVars),
+		EnvReadable: envErr == nil,
+	}
+	if stat, err := s.api.GetProcessStat(pid); err == nil {
+		info.StartTime = stat.StartTime
+	}
+	s.inspectContainer(info, cwd, envVars)
+	s.classifyInvocation(info, envVars)
+	return info
+}
+
+// inspectContainer attaches container details to info when the ProcessAPI
+// can provide them. A CWD bind-mounted from the host is replaced with the
+// host path, so it matches transcripts and git repositories on the host.
+func (s *Scanner) inspectContainer(info *ProcessInfo, cwd string, envVars map[string]string) {
+	ci, ok := s.api.(ContainerInspector)
+	if !ok {
+		return
+	}
+	c, err := ci.GetContainerInfo(info.PID, cwd)
+	if err != nil || c == nil {
+		return
+	}
+	applyContainerEnv(c, envVars)
+	info.Container = c
+	if c.HostCWD != "" {
+		info.CWD = c.HostCWD
+	}
 }
 
 // API returns the underlying ProcessAPI, used by the correlator for port mapping.
@@ -243,9 +616,11 @@ func (s *Scanner) listAllLocked() []ProcessInfo {
 	for _, info := range s.current {
 		result = append(result, *info)
 	}
-	for _, info := range s.exited {
-		// Don't include exited PIDs that reappeared (shouldn't happen, but be safe).
-		if _, alive := s.current[info.PID]; !alive {
+	for key, info := range s.exited {
+		// Don't include an exited process that is alive again (only possible
+		// when its start time is unknown). A recycled PID is a different
+		// process, so its exited predecessor is still listed.
+		if alive, ok := s.current[info.PID]; !ok || keyOf(alive) != key {
 			result = append(result, *info)
 		}
 	}
@@ -323,55 +698,95 @@ func detectTerminal(envVars map[string]string) string {
 // filterTelemetryEnvVars extracts only the telemetry-related env vars
 // we care about for classification.
 func filterTelemetryEnvVars(envVars map[string]string) map[string]string {
-	if envVars == nil {
-		return make(map[string]string)
-	}
-
-	keys := []string{
-		"CLAUDE_CODE_ENABLE_TELEMETRY",
-		"OTEL_METRICS_EXPORTER",
-		"OTEL_LOGS_EXPORTER",
-		"OTEL_EXPORTER_OTLP_ENDPOINT",
-		"OTEL_EXPORTER_OTLP_PROTOCOL",
-	}
-
 	result := make(map[string]string)
-	for _, k := range keys {
-		if v, ok := envVars[k]; ok {
-			result[k] = v
+	for k, v := range envVars {
+		if isTelemetryEnvVar(k) {
+			result[k] = redactHeaders(k, v)
 		}
 	}
 	return result
 }
 
-// shortenHome replaces the user's home directory prefix with ~.
-func shortenHome(path string) string {
-	if path == "" {
-		return ""
+// isTelemetryEnvVar reports whether key affects where Claude Code sends
+// telemetry: the enable flag, the per-signal exporters, and every generic
+// or per-signal OTEL_EXPORTER_OTLP_* setting.
+func isTelemetryEnvVar(key string) bool {
+	switch key {
+	case "CLAUDE_CODE_ENABLE_TELEMETRY", "OTEL_METRICS_EXPORTER", "OTEL_LOGS_EXPORTER":
+		return true
 	}
-	home, err := os.UserHomeDir()
-	if err != nil || home == "" {
-		return path
+	return strings.HasPrefix(key, "OTEL_EXPORTER_OTLP_")
+}
+
+// redactHeaders drops the values from OTEL_EXPORTER_OTLP_*HEADERS, which
+// usually carry credentials; only the header names are kept.
+func redactHeaders(key, value string) string {
+	if !strings.HasSuffix(key, "HEADERS") {
+		return value
 	}
-	if strings.HasPrefix(path, home) {
-		return "~" + path[len(home):]
+	names := headerNames(value)
+	for i, n := range names {
+		names[i] = n + "=<redacted>"
 	}
-	return path
+	return strings.Join(names, ",")
 }
 
 // readGlobalTelemetryConfig reads telemetry-related env vars from global
 // Claude Code config files (user settings + managed settings).
 // Files are read in order from s.globalConfigPaths; later files override earlier.
-func (s *Scanner) readGlobalTelemetryConfig() map[string]string {
-	merged := make(map[string]string)
+// Each variable is returned with the kind of file that set it.
+func (s *Scanner) readGlobalTelemetryConfig() map[string]settingsValue {
+	merged := make(map[string]settingsValue)
 	for _, path := range s.globalConfigPaths {
+		source := EnvSourceUser
+		if isManagedSettings(path) {
+			source = EnvSourceManaged
+		}
 		for k, v := range readSettingsEnv(path) {
-			merged[k] = v
+			merged[k] = settingsValue{value: v, source: source}
 		}
 	}
 	return merged
 }
 
+// settingsValue is an env var read from a settings file.
+type settingsValue struct {
+	value  string
+	source EnvSource
+}
+
+// managedSettingsFile is the name Claude Code gives its administrator-
+// managed settings file on every platform.
+const managedSettingsFile = "managed-settings.json"
+
+// isManagedSettings reports whether path is a managed settings file.
+func isManagedSettings(path string) bool {
+	return filepath.Base(path) == managedSettingsFile
+}
+
+// applyGlobalEnv merges global settings into info's env vars and records
+// where each one came from. User settings only fill gaps left by the
+// process environment; managed settings override it, as Claude Code
+// applies them last.
+func applyGlobalEnv(info *ProcessInfo, global map[string]settingsValue) {
+	if len(global) == 0 {
+		return
+	}
+	if info.EnvSources == nil {
+		info.EnvSources = make(map[string]EnvSource, len(info.EnvVars)+len(global))
+	}
+	for k := range info.EnvVars {
+		info.EnvSources[k] = EnvSourceProcess
+	}
+	for k, sv := range global {
+		if _, has := info.EnvVars[k]; has && sv.source != EnvSourceManaged {
+			continue
+		}
+		info.EnvVars[k] = redactHeaders(k, sv.value)
+		info.EnvSources[k] = sv.source
+	}
+}
+
 // readSettingsEnv reads a Claude Code settings JSON file and extracts
 // telemetry-related environment variables from its "env" block.
 // Returns an empty map if the file is missing, unreadable, or malformed.
@@ -390,16 +805,8 @@ func readSettingsEnv(path string) map[string]string {
 		return result
 	}
 
-	telemetryKeys := map[string]bool{
-		"CLAUDE_CODE_ENABLE_TELEMETRY": true,
-		"OTEL_METRICS_EXPORTER":        true,
-		"OTEL_LOGS_EXPORTER":           true,
-		"OTEL_EXPORTER_OTLP_ENDPOINT":  true,
-		"OTEL_EXPORTER_OTLP_PROTOCOL":  true,
-	}
-
 	for k, v := range settings.Env {
-		if telemetryKeys[k] {
+		if isTelemetryEnvVar(k) {
 			result[k] = v
 		}
 	}
@@ -3,6 +3,7 @@
 package scanner
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"time"
@@ -23,3 +24,9 @@ func NewDefaultScanner(intervalSeconds int) *Scanner {
 	)
 	return s
 }
+
+// newPlatformWatcher reports that event-driven discovery is unavailable on
+// macOS; the scanner keeps polling at its configured interval.
+func newPlatformWatcher() (ProcessWatcher, error) {
+	return nil, errors.New("event-driven process discovery is not supported on darwin")
+}
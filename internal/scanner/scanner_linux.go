@@ -23,3 +23,18 @@ func NewDefaultScanner(intervalSeconds int) *Scanner {
 	)
 	return s
 }
+
+// procDiffInterval is how often the /proc diff watcher lists PIDs.
+const procDiffInterval = time.Second
+
+// newPlatformWatcher returns the best available process watcher: the
+// netlink process connector when permitted (typically root), otherwise an
+// incremental /proc diff.
+func newPlatformWatcher() (ProcessWatcher, error) {
+	if w, err := newNetlinkWatcher(); err == nil {
+		return w, nil
+	}
+	uid := os.Getuid()
+	owned := func(pid int) bool { return ownedBy(pid, uid) }
+	return newProcDiffWatcher(listProcDirPIDs, owned, procDiffInterval), nil
+}
@@ -3,6 +3,7 @@ package scanner
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -23,6 +24,7 @@ type mockProcess struct {
 	envErr  error
 	infoErr error
 	ports   [][2]int
+	stat    *ProcessStat
 }
 
 func newMockAPI() *mockProcessAPI {
@@ -99,6 +101,19 @@ func (m *mockProcessAPI) GetOpenPorts(pid int) ([][2]int, error) {
 	return p.ports, nil
 }
 
+func (m *mockProcessAPI) GetProcessStat(pid int) (*ProcessStat, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.processes[pid]
+	if !ok {
+		return nil, fmt.Errorf("no such process: %d", pid)
+	}
+	if p.stat == nil {
+		return nil, fmt.Errorf("no stat for process: %d", pid)
+	}
+	return p.stat, nil
+}
+
 func (m *mockProcessAPI) PgrepClaude() []int {
 	return nil
 }
@@ -631,6 +646,56 @@ func TestGlobalConfigMerge_ManagedOverridesUser(t *testing.T) {
 	}
 }
 
+func TestGlobalConfigMerge_ManagedOverridesProcess(t *testing.T) {
+	userSettings := writeTempSettings(t, `{
+		"env": {
+			"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+			"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL": "http/protobuf"
+		}
+	}`)
+	managedSettings := filepath.Join(t.TempDir(), "managed-settings.json")
+	if err := os.WriteFile(managedSettings, []byte(`{
+		"env": {"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317"}
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := newMockAPI()
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+		args: []string{"/usr/local/bin/claude"},
+		env: map[string]string{
+			"OTEL_EXPORTER_OTLP_ENDPOINT":         "http://localhost:4317",
+			"OTEL_EXPORTER_OTLP_PROTOCOL":         "http/json",
+			"OTEL_EXPORTER_OTLP_HEADERS":          "Authorization=Bearer secret,x-team=a",
+			"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": "http/protobuf",
+		},
+		cwd: "/tmp",
+	})
+
+	s := NewScanner(api, 5*time.Second)
+	s.globalConfigPaths = []string{userSettings, managedSettings}
+
+	p := s.Scan()[0]
+	for key, want := range map[string]struct {
+		value  string
+		source EnvSource
+	}{
+		"OTEL_EXPORTER_OTLP_ENDPOINT":         {"http://collector:4317", EnvSourceManaged},
+		"OTEL_EXPORTER_OTLP_PROTOCOL":         {"http/json", EnvSourceProcess},
+		"OTEL_EXPORTER_OTLP_LOGS_PROTOCOL":    {"http/protobuf", EnvSourceUser},
+		"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL": {"http/protobuf", EnvSourceProcess},
+		"OTEL_EXPORTER_OTLP_HEADERS":          {"Authorization=<redacted>,x-team=<redacted>", EnvSourceProcess},
+	} {
+		if got := p.EnvVars[key]; got != want.value {
+			t.Errorf("%s = %q, want %q", key, got, want.value)
+		}
+		if got := p.EnvSources[key]; got != want.source {
+			t.Errorf("%s source = %q, want %q", key, got, want.source)
+		}
+	}
+}
+
 func TestGlobalConfigMerge_MissingFilesGraceful(t *testing.T) {
 	api := newMockAPI()
 	api.addProcess(&mockProcess{
@@ -704,3 +769,160 @@ func TestGlobalConfigMerge_NoConfigPaths(t *testing.T) {
 		t.Error("scanner should work without global config paths")
 	}
 }
+
+func TestProcessScanner_PIDReuse(t *testing.T) {
+	api := newMockAPI()
+	oldStart := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	newStart := oldStart.Add(3 * time.Hour)
+
+	proc := func(start time.Time, cwd string) *mockProcess {
+		return &mockProcess{
+			info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+			args: []string{"claude"},
+			env:  map[string]string{},
+			cwd:  cwd,
+			stat: &ProcessStat{PID: 4821, PPID: 1, StartTime: start},
+		}
+	}
+
+	api.addProcess(proc(oldStart, "/old"))
+	s := NewScanner(api, 5*time.Second)
+	s.Scan()
+	s.Scan() // IsNew cleared
+
+	var evts []ProcessEvent
+	s.OnProcessEvent(func(ev ProcessEvent) { evts = append(evts, ev) })
+
+	// Between scans the process exits and the OS hands PID 4821 to an
+	// unrelated Claude Code process.
+	api.addProcess(proc(newStart, "/new"))
+	results := s.Scan()
+
+	if len(evts) != 2 {
+		t.Fatalf("got %d events, want exit + start: %+v", len(evts), evts)
+	}
+	if evts[0].Type != ProcessExited || !evts[0].Process.StartTime.Equal(oldStart) {
+		t.Errorf("first event = %+v, want exit of the old process", evts[0])
+	}
+	if evts[1].Type != ProcessStarted || !evts[1].Process.StartTime.Equal(newStart) {
+		t.Errorf("second event = %+v, want start of the new process", evts[1])
+	}
+
+	var live, exited *ProcessInfo
+	for i := range results {
+		if results[i].PID != 4821 {
+			continue
+		}
+		if results[i].Exited {
+			exited = &results[i]
+		} else {
+			live = &results[i]
+		}
+	}
+	if live == nil || exited == nil {
+		t.Fatalf("want both the live and the exited process listed, got %+v", results)
+	}
+	if !live.IsNew {
+		t.Error("recycled PID should be marked IsNew")
+	}
+	if live.CWD != "/new" || exited.CWD != "/old" {
+		t.Errorf("CWDs = live %q / exited %q, want /new and /old", live.CWD, exited.CWD)
+	}
+
+	// The next scan sees the same new process: no events, IsNew cleared.
+	evts = nil
+	results = s.Scan()
+	if len(evts) != 0 {
+		t.Errorf("unchanged scan emitted %+v", evts)
+	}
+	for _, p := range results {
+		if p.PID == 4821 && !p.Exited && p.IsNew {
+			t.Error("IsNew should clear on the second scan of the new process")
+		}
+	}
+}
+
+func TestProcessScanner_PIDReuseViaWatcherExec(t *testing.T) {
+	api := newMockAPI()
+	oldStart := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+		args: []string{"claude"},
+		env:  map[string]string{},
+		stat: &ProcessStat{PID: 4821, StartTime: oldStart},
+	})
+	s := NewScanner(api, time.Hour)
+	s.Scan()
+
+	var evts []ProcessEvent
+	s.OnProcessEvent(func(ev ProcessEvent) { evts = append(evts, ev) })
+
+	// The exit notification was lost; the exec of the recycled PID arrives.
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+		args: []string{"claude", "-p", "hi"},
+		env:  map[string]string{},
+		stat: &ProcessStat{PID: 4821, StartTime: oldStart.Add(time.Hour)},
+	})
+	s.handleProcEvent(ProcEvent{Kind: ProcExec, PID: 4821})
+
+	if len(evts) != 2 || evts[0].Type != ProcessExited || evts[1].Type != ProcessStarted {
+		t.Fatalf("got %+v, want exit then start", evts)
+	}
+	if !evts[1].Process.IsNew {
+		t.Error("recycled PID should be marked IsNew")
+	}
+}
+
+// containerMockAPI adds ContainerInspector to mockProcessAPI.
+type containerMockAPI struct {
+	*mockProcessAPI
+	containers map[int]ContainerInfo
+}
+
+func (m *containerMockAPI) GetContainerInfo(pid int, cwd string) (*ContainerInfo, error) {
+	c, ok := m.containers[pid]
+	if !ok {
+		return nil, nil
+	}
+	c.CWD = cwd
+	return &c, nil
+}
+
+func TestProcessScanner_Container(t *testing.T) {
+	api := &containerMockAPI{mockProcessAPI: newMockAPI(), containers: map[int]ContainerInfo{
+		4821: {Runtime: "podman", MountNamespace: true, HostCWD: "/srv/src/app"},
+	}}
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 4821, BinaryName: "claude"},
+		args: []string{"claude"},
+		env:  map[string]string{"CONTAINER_ID": "devbox", "REMOTE_CONTAINERS": "true"},
+		cwd:  "/workspaces/app",
+	})
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 5102, BinaryName: "claude"},
+		args: []string{"claude"},
+		cwd:  "/srv/src/other",
+	})
+
+	s := NewScanner(api, 5*time.Second)
+	byPID := make(map[int]ProcessInfo)
+	for _, p := range s.Scan() {
+		byPID[p.PID] = p
+	}
+
+	p := byPID[4821]
+	if p.Container == nil {
+		t.Fatal("containerised process has no Container info")
+	}
+	if p.CWD != "/srv/src/app" || p.Container.CWD != "/workspaces/app" {
+		t.Errorf("CWD = %q (container %q), want host path /srv/src/app (container /workspaces/app)", p.CWD, p.Container.CWD)
+	}
+	if p.Container.Label() != "devbox" || !p.Container.Devcontainer {
+		t.Errorf("Container = %+v, want name devbox from the environment, devcontainer", *p.Container)
+	}
+	if byPID[5102].Container != nil {
+		t.Error("host process should have no Container info")
+	}
+}
@@ -1,33 +1,128 @@
 package scanner
 
+import (
+	"strings"
+	"time"
+)
+
 // ProcessInfo holds information about a discovered Claude Code process.
 type ProcessInfo struct {
-	PID          int
-	BinaryName   string
-	Args         []string
-	CWD          string
-	Terminal     string
-	EnvVars      map[string]string
-	EnvReadable  bool
-	IsNew        bool   // first scan cycle where this PID appeared
-	Exited       bool
+	PID         int
+	BinaryName  string
+	Args        []string
+	CWD         string
+	Terminal    string
+	EnvVars     map[string]string
+	EnvSources  map[string]EnvSource // where each EnvVars entry was set; nil means the process environment
+	EnvReadable bool
+	StartTime   time.Time // with PID, identifies the process across PID reuse; zero if unknown
+	IsNew       bool      // first scan cycle where this process appeared
+	Exited      bool
+	Children    []ChildProcess // descendant processes in depth-first order
+	Container   *ContainerInfo // nil when the process runs on the host
+	Mode        InvocationMode // how the process was started
+	ParentApp   string         // application that launched it, e.g. "VS Code" or "cron"; "" if unknown
+}
+
+// ProcessEventType identifies a Claude Code process lifecycle change.
+type ProcessEventType int
+
+const (
+	ProcessStarted ProcessEventType = iota // a new Claude Code process appeared
+	ProcessExited                          // a tracked Claude Code process exited
+)
+
+// ProcessEvent reports a Claude Code process appearing or exiting, whether
+// it was observed by a full scan or by a ProcessWatcher notification.
+type ProcessEvent struct {
+	Type    ProcessEventType
+	Process ProcessInfo
+}
+
+// ProcessListener is called for every ProcessEvent.
+type ProcessListener func(ProcessEvent)
+
+// ProcessStat holds lineage and scheduling data for a single PID, as read
+// from /proc/[pid]/stat on Linux or proc_pidinfo on macOS.
+type ProcessStat struct {
+	PID       int
+	PPID      int
+	State     string        // single-letter scheduler state (R, S, D, Z, T, ...)
+	StartTime time.Time     // wall-clock time the process started
+	CPUTime   time.Duration // cumulative user + system CPU time
+}
+
+// ChildKind classifies a descendant process of a Claude Code instance.
+type ChildKind int
+
+const (
+	ChildOther     ChildKind = iota // language servers, helpers, anything unrecognised
+	ChildMCPServer                  // stdio MCP server launched from the MCP config
+	ChildShellTool                  // Bash tool command or one of its descendants
+)
+
+// String returns a short label for the child kind.
+func (k ChildKind) String() string {
+	switch k {
+	case ChildMCPServer:
+		return "mcp"
+	case ChildShellTool:
+		return "tool"
+	default:
+		return "other"
+	}
+}
+
+// ChildProcess describes a process spawned (directly or indirectly) by a
+// Claude Code instance.
+type ChildProcess struct {
+	PID        int
+	PPID       int
+	Depth      int // 1 for direct children of the Claude process
+	BinaryName string
+	Args       []string
+	Kind       ChildKind
+	State      string
+	StartedAt  time.Time
+	CPUTime    time.Duration
+	CPUPercent float64 // CPU usage since the previous scan (lifetime average on first sight)
+}
+
+// CommandLine returns the space-joined argv, falling back to the binary name.
+func (c ChildProcess) CommandLine() string {
+	if len(c.Args) == 0 {
+		return c.BinaryName
+	}
+	return strings.Join(c.Args, " ")
+}
+
+// Age returns how long the child has been running as of now.
+// Returns 0 if the start time is unknown.
+func (c ChildProcess) Age(now time.Time) time.Duration {
+	if c.StartedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(c.StartedAt)
 }
 
 // TelemetryStatus classifies a process's telemetry configuration.
 type TelemetryStatus int
 
 const (
-	TelemetryConnected  TelemetryStatus = iota // ✅ ON, correct endpoint, data received
+	TelemetryConnected   TelemetryStatus = iota // ✅ ON, correct endpoint, data received
 	TelemetryWaiting                            // ✅ ON, correct endpoint, no data yet
 	TelemetryWrongPort                          // ⚠️ ON, wrong endpoint
 	TelemetryConsoleOnly                        // ⚠️ ON, no OTLP endpoint
 	TelemetryOff                                // ❌ not enabled
 	TelemetryUnknown                            // ❓ env unreadable
+	TelemetryUnreachable                        // ⚠️ ON, endpoint unreachable from the process's container
 )
 
 // StatusInfo holds display information for a telemetry status.
 type StatusInfo struct {
-	Status TelemetryStatus
-	Icon   string
-	Label  string
+	Status  TelemetryStatus
+	Icon    string
+	Label   string
+	Fix     string            // suggested change when telemetry is off
+	Signals []SignalDiagnosis // per-signal diagnosis; nil when not evaluated
 }
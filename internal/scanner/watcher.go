@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"time"
+)
+
+// ProcEventKind identifies a raw process notification from a ProcessWatcher.
+type ProcEventKind int
+
+const (
+	// ProcExec reports that a PID has started running a new program.
+	ProcExec ProcEventKind = iota
+	// ProcExit reports that a PID has exited.
+	ProcExit
+	// ProcResync reports that notifications were lost and the caller
+	// should fall back to a full scan to rebuild its view.
+	ProcResync
+)
+
+// ProcEvent is a single raw notification delivered by a ProcessWatcher.
+type ProcEvent struct {
+	Kind ProcEventKind
+	PID  int
+}
+
+// ProcessWatcher abstracts OS notifications of process exec and exit.
+// Production code uses platform-specific implementations (the Linux
+// netlink process connector or an incremental /proc diff); tests use mocks.
+type ProcessWatcher interface {
+	// Events returns the channel notifications are delivered on. The
+	// channel is closed when the watcher fails or is closed, after which
+	// the scanner reverts to interval polling.
+	Events() <-chan ProcEvent
+
+	// Name identifies the backend (e.g. "netlink", "procdiff").
+	Name() string
+
+	// Close stops the watcher and releases its resources.
+	Close() error
+}
+
+// watcherQueueSize bounds the number of undelivered notifications.
+const watcherQueueSize = 1024
+
+// procEventQueue delivers notifications without ever blocking the producer.
+// When the consumer falls behind, the backlog is collapsed into a single
+// ProcResync so the scanner does one full scan instead. Not safe for use
+// by more than one producer.
+type procEventQueue struct {
+	ch         chan ProcEvent
+	needResync bool
+}
+
+func newProcEventQueue() *procEventQueue {
+	return &procEventQueue{ch: make(chan ProcEvent, watcherQueueSize)}
+}
+
+// push enqueues ev, or records that a resync is needed if the queue is full.
+func (q *procEventQueue) push(ev ProcEvent) {
+	if q.needResync {
+		select {
+		case q.ch <- ProcEvent{Kind: ProcResync}:
+			q.needResync = false
+		default:
+			return
+		}
+	}
+	select {
+	case q.ch <- ev:
+	default:
+		q.needResync = true
+	}
+}
+
+// procDiffWatcher approximates exec/exit notifications by diffing the set
+// of PIDs between short ticks. Listing PIDs is far cheaper than a full scan,
+// which reads argv, environment and CWD for every process; only PIDs that
+// appear are inspected further by the scanner.
+type procDiffWatcher struct {
+	listPIDs func() ([]int, error)
+	owned    func(pid int) bool // filters out other users' processes; nil = all
+	interval time.Duration
+
+	queue *procEventQueue
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// newProcDiffWatcher starts a watcher that lists PIDs every interval.
+// The initial listing is taken as the baseline and produces no events.
+func newProcDiffWatcher(listPIDs func() ([]int, error), owned func(int) bool, interval time.Duration) *procDiffWatcher {
+	w := &procDiffWatcher{
+		listPIDs: listPIDs,
+		owned:    owned,
+		interval: interval,
+		queue:    newProcEventQueue(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run(w.snapshot())
+	return w
+}
+
+// Events returns the notification channel.
+func (w *procDiffWatcher) Events() <-chan ProcEvent {
+	return w.queue.ch
+}
+
+// Name identifies the backend.
+func (w *procDiffWatcher) Name() string {
+	return "procdiff"
+}
+
+// Close stops the watcher and waits for its goroutine to exit.
+func (w *procDiffWatcher) Close() error {
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+	<-w.done
+	return nil
+}
+
+// run diffs each tick's PID set against prev, the baseline taken by the
+// constructor.
+func (w *procDiffWatcher) run(prev map[int]bool) {
+	defer close(w.done)
+	defer close(w.queue.ch)
+
+	if prev == nil {
+		return
+	}
+	// PIDs that appeared on the previous tick. A fork is often seen before
+	// its exec, so new PIDs are reported a second time to catch the program
+	// they settle on.
+	var recent []int
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur := w.snapshot()
+		if cur == nil {
+			w.queue.push(ProcEvent{Kind: ProcResync})
+			continue
+		}
+
+		for _, pid := range recent {
+			if cur[pid] {
+				w.queue.push(ProcEvent{Kind: ProcExec, PID: pid})
+			}
+		}
+		recent = recent[:0]
+
+		for pid := range cur {
+			if prev[pid] {
+				continue
+			}
+			if w.owned != nil && !w.owned(pid) {
+				continue
+			}
+			w.queue.push(ProcEvent{Kind: ProcExec, PID: pid})
+			recent = append(recent, pid)
+		}
+		for pid := range prev {
+			if !cur[pid] {
+				w.queue.push(ProcEvent{Kind: ProcExit, PID: pid})
+			}
+		}
+		prev = cur
+	}
+}
+
+// snapshot returns the current PID set, or nil if listing failed.
+func (w *procDiffWatcher) snapshot() map[int]bool {
+	pids, err := w.listPIDs()
+	if err != nil {
+		return nil
+	}
+	set := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		set[pid] = true
+	}
+	return set
+}
@@ -0,0 +1,392 @@
+package scanner
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWatcher is a ProcessWatcher driven directly by tests.
+type fakeWatcher struct {
+	ch     chan ProcEvent
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{ch: make(chan ProcEvent, 16), closed: make(chan struct{})}
+}
+
+func (f *fakeWatcher) Events() <-chan ProcEvent { return f.ch }
+func (f *fakeWatcher) Name() string             { return "fake" }
+func (f *fakeWatcher) Close() error {
+	f.once.Do(func() { close(f.closed) })
+	return nil
+}
+
+// pidLister is a mutable PID set for driving procDiffWatcher.
+type pidLister struct {
+	mu   sync.Mutex
+	pids map[int]bool
+}
+
+func (l *pidLister) set(pids ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pids = make(map[int]bool)
+	for _, p := range pids {
+		l.pids[p] = true
+	}
+}
+
+func (l *pidLister) list() ([]int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]int, 0, len(l.pids))
+	for p := range l.pids {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func recvEvent(t *testing.T, ch <-chan ProcEvent) ProcEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("watcher channel closed unexpectedly")
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for watcher event")
+	}
+	return ProcEvent{}
+}
+
+func TestProcDiffWatcher_ExecAndExit(t *testing.T) {
+	lister := &pidLister{}
+	lister.set(1, 2)
+
+	owned := func(pid int) bool { return pid != 99 }
+	w := newProcDiffWatcher(lister.list, owned, 10*time.Millisecond)
+	defer w.Close()
+
+	if w.Name() != "procdiff" {
+		t.Errorf("Name() = %q, want procdiff", w.Name())
+	}
+
+	// PID 99 belongs to another user and must be ignored.
+	lister.set(1, 2, 3, 99)
+	ev := recvEvent(t, w.Events())
+	if ev.Kind != ProcExec || ev.PID != 3 {
+		t.Fatalf("got %+v, want exec of PID 3", ev)
+	}
+
+	// New PIDs are reported once more on the following tick, to catch a
+	// fork observed before its exec.
+	ev = recvEvent(t, w.Events())
+	if ev.Kind != ProcExec || ev.PID != 3 {
+		t.Fatalf("got %+v, want repeated exec of PID 3", ev)
+	}
+
+	lister.set(1, 99)
+	seen := map[int]bool{}
+	for len(seen) < 2 {
+		ev = recvEvent(t, w.Events())
+		if ev.Kind != ProcExit {
+			t.Fatalf("got %+v, want exit", ev)
+		}
+		seen[ev.PID] = true
+	}
+	if !seen[2] || !seen[3] {
+		t.Errorf("exits = %v, want PIDs 2 and 3", seen)
+	}
+}
+
+func TestProcDiffWatcher_CloseClosesChannel(t *testing.T) {
+	lister := &pidLister{}
+	lister.set(1)
+	w := newProcDiffWatcher(lister.list, nil, 10*time.Millisecond)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Error("Events() should be closed after Close()")
+	}
+	// A second Close must not panic.
+	_ = w.Close()
+}
+
+func TestProcEventQueue_OverflowCollapsesToResync(t *testing.T) {
+	q := &procEventQueue{ch: make(chan ProcEvent, 2)}
+	q.push(ProcEvent{Kind: ProcExec, PID: 1})
+	q.push(ProcEvent{Kind: ProcExec, PID: 2})
+	q.push(ProcEvent{Kind: ProcExec, PID: 3}) // dropped
+	q.push(ProcEvent{Kind: ProcExec, PID: 4}) // dropped
+
+	<-q.ch
+	<-q.ch
+
+	q.push(ProcEvent{Kind: ProcExit, PID: 5})
+	if ev := <-q.ch; ev.Kind != ProcResync {
+		t.Errorf("first event after overflow = %+v, want resync", ev)
+	}
+	if ev := <-q.ch; ev.Kind != ProcExit || ev.PID != 5 {
+		t.Errorf("second event after overflow = %+v, want exit of PID 5", ev)
+	}
+}
+
+func claudeProc(pid int) *mockProcess {
+	return &mockProcess{
+		info: &RawProcessInfo{PID: pid, BinaryName: "claude"},
+		args: []string{"claude", "-p", "summarise"},
+		env:  map[string]string{"CLAUDE_CODE_ENABLE_TELEMETRY": "1"},
+		cwd:  "/tmp",
+	}
+}
+
+// eventRecorder collects ProcessEvents delivered to a listener.
+type eventRecorder struct {
+	mu  sync.Mutex
+	evs []ProcessEvent
+}
+
+func (r *eventRecorder) record(ev ProcessEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evs = append(r.evs, ev)
+}
+
+func (r *eventRecorder) snapshot() []ProcessEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ProcessEvent(nil), r.evs...)
+}
+
+func (r *eventRecorder) waitFor(t *testing.T, n int) []ProcessEvent {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if evs := r.snapshot(); len(evs) >= n {
+			return evs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d process events, got %+v", n, r.snapshot())
+	return nil
+}
+
+func TestScanner_ScanEmitsProcessEvents(t *testing.T) {
+	api := newMockAPI()
+	api.addProcess(claudeProc(100))
+
+	s := NewScanner(api, time.Hour)
+	rec := &eventRecorder{}
+	s.OnProcessEvent(rec.record)
+
+	s.Scan()
+	evs := rec.snapshot()
+	if len(evs) != 1 || evs[0].Type != ProcessStarted || evs[0].Process.PID != 100 {
+		t.Fatalf("first scan events = %+v, want started 100", evs)
+	}
+
+	// Unchanged scan emits nothing.
+	s.Scan()
+	if got := len(rec.snapshot()); got != 1 {
+		t.Fatalf("unchanged scan emitted %d events", got-1)
+	}
+
+	api.removeProcess(100)
+	s.Scan()
+	evs = rec.snapshot()
+	if len(evs) != 2 || evs[1].Type != ProcessExited || !evs[1].Process.Exited {
+		t.Fatalf("events after exit = %+v, want exited 100", evs)
+	}
+}
+
+func TestScanner_WatcherDrivesDiscovery(t *testing.T) {
+	api := newMockAPI()
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 1, BinaryName: "init"},
+		args: []string{"/sbin/init"},
+	})
+
+	s := NewScanner(api, time.Hour)
+	w := newFakeWatcher()
+	s.SetWatcher(w, time.Hour)
+	if s.DiscoveryMode() != "fake" {
+		t.Errorf("DiscoveryMode() = %q, want fake", s.DiscoveryMode())
+	}
+
+	rec := &eventRecorder{}
+	s.OnProcessEvent(rec.record)
+	s.StartPeriodicScan()
+	defer s.Stop()
+
+	// A short-lived "claude -p" that starts and exits between full scans.
+	api.addProcess(claudeProc(200))
+	w.ch <- ProcEvent{Kind: ProcExec, PID: 200}
+	evs := rec.waitFor(t, 1)
+	if evs[0].Type != ProcessStarted || evs[0].Process.PID != 200 || !evs[0].Process.IsNew {
+		t.Fatalf("got %+v, want new started 200", evs[0])
+	}
+	if evs[0].Process.CWD != "/tmp" || len(evs[0].Process.Args) != 3 {
+		t.Errorf("exec'd process not enriched: %+v", evs[0].Process)
+	}
+
+	// Non-Claude execs and exits of unknown PIDs are ignored.
+	w.ch <- ProcEvent{Kind: ProcExec, PID: 1}
+	w.ch <- ProcEvent{Kind: ProcExit, PID: 12345}
+
+	api.removeProcess(200)
+	w.ch <- ProcEvent{Kind: ProcExit, PID: 200}
+	evs = rec.waitFor(t, 2)
+	if evs[1].Type != ProcessExited || evs[1].Process.PID != 200 {
+		t.Fatalf("got %+v, want exited 200", evs[1])
+	}
+	if len(rec.snapshot()) != 2 {
+		t.Errorf("unexpected extra events: %+v", rec.snapshot())
+	}
+
+	p := findPID(s.GetProcesses(), 200)
+	if p == nil || !p.Exited {
+		t.Error("exited short-lived process should remain listed as exited")
+	}
+}
+
+func TestScanner_WatcherExecIntoNonClaudeIsExit(t *testing.T) {
+	api := newMockAPI()
+	api.addProcess(claudeProc(300))
+
+	s := NewScanner(api, time.Hour)
+	s.Scan()
+
+	rec := &eventRecorder{}
+	s.OnProcessEvent(rec.record)
+
+	// PID 300 exec's into something that isn't Claude Code.
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 300, BinaryName: "bash"},
+		args: []string{"bash"},
+	})
+	s.handleProcEvent(ProcEvent{Kind: ProcExec, PID: 300})
+
+	evs := rec.snapshot()
+	if len(evs) != 1 || evs[0].Type != ProcessExited {
+		t.Fatalf("got %+v, want exited 300", evs)
+	}
+}
+
+func TestScanner_WatcherRepeatExecKeepsSettingsEnv(t *testing.T) {
+	settings := writeTempSettings(t, `{"env": {"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317"}}`)
+	api := newMockAPI()
+	s := NewScanner(api, time.Hour)
+	s.globalConfigPaths = []string{settings}
+	s.Scan()
+
+	// procdiff reports a new PID more than once; the repeat must not drop
+	// the env vars merged in from settings.
+	api.addProcess(claudeProc(600))
+	s.handleProcEvent(ProcEvent{Kind: ProcExec, PID: 600})
+	s.handleProcEvent(ProcEvent{Kind: ProcExec, PID: 600})
+
+	p := findPID(s.GetProcesses(), 600)
+	if p == nil {
+		t.Fatal("exec'd process not tracked")
+	}
+	if got := p.EnvVars["OTEL_EXPORTER_OTLP_ENDPOINT"]; got != "http://localhost:4317" {
+		t.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT = %q, want http://localhost:4317", got)
+	}
+	if got := p.EnvSources["OTEL_EXPORTER_OTLP_ENDPOINT"]; got != EnvSourceUser {
+		t.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT source = %q, want %q", got, EnvSourceUser)
+	}
+}
+
+func TestScanner_WatcherResyncRunsFullScan(t *testing.T) {
+	api := newMockAPI()
+	s := NewScanner(api, time.Hour)
+	s.Scan()
+
+	rec := &eventRecorder{}
+	s.OnProcessEvent(rec.record)
+
+	api.addProcess(claudeProc(400))
+	s.handleProcEvent(ProcEvent{Kind: ProcResync})
+
+	evs := rec.snapshot()
+	if len(evs) != 1 || evs[0].Process.PID != 400 {
+		t.Fatalf("got %+v, want started 400 from resync scan", evs)
+	}
+}
+
+func TestScanner_WatcherFailureFallsBackToPolling(t *testing.T) {
+	api := newMockAPI()
+	s := NewScanner(api, 10*time.Millisecond)
+	w := newFakeWatcher()
+	s.SetWatcher(w, time.Hour)
+
+	rec := &eventRecorder{}
+	s.OnProcessEvent(rec.record)
+	s.StartPeriodicScan()
+
+	// The watcher dies; the fallback interval is an hour, so the process
+	// is only found if the scanner reverts to its normal interval.
+	close(w.ch)
+	api.addProcess(claudeProc(500))
+	evs := rec.waitFor(t, 1)
+	if evs[0].Process.PID != 500 {
+		t.Fatalf("got %+v, want started 500", evs[0])
+	}
+
+	s.Stop()
+	select {
+	case <-w.closed:
+	default:
+		t.Error("Stop() should close the watcher")
+	}
+}
+
+func TestScanner_WatcherRefreshesTrees(t *testing.T) {
+	api := newMockAPI()
+	root := claudeProc(300)
+	root.stat = &ProcessStat{PID: 300, PPID: 1}
+	api.addProcess(root)
+
+	// Full scans are an hour apart; the trees follow the normal interval.
+	s := NewScanner(api, 10*time.Millisecond)
+	s.SetWatcher(newFakeWatcher(), time.Hour)
+	s.StartPeriodicScan()
+	defer s.Stop()
+
+	children := func() []ChildProcess {
+		if p := findPID(s.GetProcesses(), 300); p != nil {
+			return p.Children
+		}
+		return nil
+	}
+	waitFor := func(what string, ok func([]ChildProcess) bool) []ChildProcess {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if c := children(); ok(c) {
+				return c
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s, children %+v", what, children())
+		return nil
+	}
+
+	api.addProcess(&mockProcess{
+		info: &RawProcessInfo{PID: 301, BinaryName: "bash"},
+		args: []string{"/bin/bash", "-c", "go test ./..."},
+		stat: &ProcessStat{PID: 301, PPID: 300, StartTime: time.Now(), CPUTime: time.Second},
+	})
+	c := waitFor("the new child", func(c []ChildProcess) bool { return len(c) == 1 })
+	if c[0].PID != 301 || c[0].Kind != ChildShellTool {
+		t.Errorf("child = %+v, want PID 301 as a tool process", c[0])
+	}
+
+	api.removeProcess(301)
+	waitFor("the child's exit", func(c []ChildProcess) bool { return len(c) == 0 })
+}
@@ -33,14 +33,30 @@ type Store interface {
 	// GetAggregatedCost returns the sum of TotalCost across all sessions.
 	GetAggregatedCost() float64
 
-	// UpdatePID associates a PID with the given session.
-	UpdatePID(sessionID string, pid int)
+	// UpdatePID associates a process, identified by PID and start time,
+	// with the given session. A zero startTime means it is unknown.
+	UpdatePID(sessionID string, pid int, startTime time.Time)
 
-	// MarkExited marks all sessions associated with the given PID as exited.
-	MarkExited(pid int)
+	// MarkExited marks all sessions associated with the given process as
+	// exited. Sessions bound to an earlier process that had the same PID
+	// are left alone.
+	MarkExited(pid int, startTime time.Time)
 
 	// UpdateMetadata updates the session metadata for the given session.
 	UpdateMetadata(sessionID string, meta SessionMetadata)
+
+	// UpdateCWD records the working directory of the session's process.
+	UpdateCWD(sessionID string, cwd string)
+
+	// UpdateGit replaces the git context of the given session.
+	UpdateGit(sessionID string, git GitInfo)
+
+	// MarkEstimated flags the session as backfilled from its transcript.
+	MarkEstimated(sessionID string)
+
+	// UpdateInvocation records how the session's process was started and
+	// the application that launched it.
+	UpdateInvocation(sessionID, mode, parentApp string)
 }
 
 // EventListener is a callback invoked after a new event is stored.
@@ -107,7 +123,10 @@ func metricKey(name string, attrs map[string]string) string {
 	}
 	keys := make([]string, 0, len(attrs))
 	for k := range attrs {
-		keys = append(keys, k)
+		// HistoricalAttr marks a data point, not a separate series.
+		if k != HistoricalAttr {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 	parts := make([]string, 0, len(keys))
@@ -168,11 +187,29 @@ func (ms *MemoryStore) AddMetric(sessionID string, m Metric) {
 	}
 
 	// Update aggregated session fields based on metric type.
+	historical := m.Attributes[HistoricalAttr] != ""
 	switch m.Name {
 	case "claude_code.cost.usage":
 		s.TotalCost += delta
+		if !s.modelCostsFromMetrics {
+			// Metrics supersede any cost taken from events.
+			s.ModelCosts = make(map[string]float64)
+			s.modelCostsFromMetrics = true
+		}
+		model := modelOrUnknown(m.Attributes["model"])
+		s.ModelCosts[model] += delta
+		if historical {
+			s.HistoricalCost += delta
+			if s.HistoricalModelCosts == nil {
+				s.HistoricalModelCosts = make(map[string]float64)
+			}
+			s.HistoricalModelCosts[model] += delta
+		}
 	case "claude_code.token.usage":
 		s.TotalTokens += int64(delta)
+		if historical {
+			s.HistoricalTokens += int64(delta)
+		}
 	case "claude_code.active_time.total":
 		s.ActiveTime += time.Duration(delta * float64(time.Second))
 	}
@@ -246,6 +283,14 @@ func (ms *MemoryStore) AddEvent(sessionID string, e Event) {
 
 	// Extract cache tokens from api_request events.
 	if e.Name == "claude_code.api_request" {
+		if !s.modelCostsFromMetrics {
+			if cost, err := strconv.ParseFloat(e.Attributes["cost_usd"], 64); err == nil {
+				if s.ModelCosts == nil {
+					s.ModelCosts = make(map[string]float64)
+				}
+				s.ModelCosts[modelOrUnknown(e.Attributes["model"])] += cost
+			}
+		}
 		if v, ok := e.Attributes["cache_read_tokens"]; ok {
 			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
 				s.CacheReadTokens += n
@@ -326,18 +371,22 @@ func (ms *MemoryStore) GetAggregatedCost() float64 {
 	return total
 }
 
-// UpdatePID associates a PID with the given session.
-func (ms *MemoryStore) UpdatePID(sessionID string, pid int) {
+// UpdatePID associates a process, identified by PID and start time, with
+// the given session. A zero startTime means it is unknown.
+func (ms *MemoryStore) UpdatePID(sessionID string, pid int, startTime time.Time) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
 	s := ms.getOrCreateSession(sessionID)
 	s.PID = pid
+	s.PIDStartTime = startTime
 }
 
-// MarkExited marks all sessions associated with the given PID as exited.
-// Sessions with PID 0 (uncorrelated) are never marked by this method.
-func (ms *MemoryStore) MarkExited(pid int) {
+// MarkExited marks all sessions associated with the given process as exited.
+// Sessions with PID 0 (uncorrelated) are never marked by this method. When
+// both the session and the caller know the process start time, they must
+// match, so a recycled PID never marks an unrelated session exited.
+func (ms *MemoryStore) MarkExited(pid int, startTime time.Time) {
 	if pid == 0 {
 		return
 	}
@@ -346,9 +395,13 @@ func (ms *MemoryStore) MarkExited(pid int) {
 	defer ms.mu.Unlock()
 
 	for _, s := range ms.sessions {
-		if s.PID == pid {
-			s.Exited = true
+		if s.PID != pid {
+			continue
+		}
+		if !startTime.IsZero() && !s.PIDStartTime.IsZero() && !s.PIDStartTime.Equal(startTime) {
+			continue
 		}
+		s.Exited = true
 	}
 }
 
@@ -374,6 +427,55 @@ func (ms *MemoryStore) UpdateMetadata(sessionID string, meta SessionMetadata) {
 	}
 }
 
+// UpdateCWD records the working directory of the session's process.
+func (ms *MemoryStore) UpdateCWD(sessionID string, cwd string) {
+	sessionID = resolveSessionID(sessionID)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.getOrCreateSession(sessionID).CWD = cwd
+}
+
+// UpdateGit replaces the git context of the given session. Unlike
+// UpdateMetadata it overwrites every field, so a branch switch or a
+// cleaned working tree is reflected.
+func (ms *MemoryStore) UpdateGit(sessionID string, git GitInfo) {
+	sessionID = resolveSessionID(sessionID)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.getOrCreateSession(sessionID).Metadata.Git = git
+}
+
+// MarkEstimated flags the session as backfilled from its transcript
+// rather than fed by OTLP.
+func (ms *MemoryStore) MarkEstimated(sessionID string) {
+	sessionID = resolveSessionID(sessionID)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.getOrCreateSession(sessionID).Estimated = true
+}
+
+// UpdateInvocation records the invocation mode and parent application of
+// the session's process. An empty mode leaves the existing values alone.
+func (ms *MemoryStore) UpdateInvocation(sessionID, mode, parentApp string) {
+	if mode == "" {
+		return
+	}
+	sessionID = resolveSessionID(sessionID)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s := ms.getOrCreateSession(sessionID)
+	s.Mode = mode
+	s.ParentApp = parentApp
+}
+
 // copySession returns a deep copy of a SessionData to prevent callers
 // from mutating internal state.
 func (ms *MemoryStore) copySession(s *SessionData) *SessionData {
@@ -397,5 +499,26 @@ func (ms *MemoryStore) copySession(s *SessionData) *SessionData {
 		}
 	}
 
+	if len(s.ModelCosts) > 0 {
+		cp.ModelCosts = make(map[string]float64, len(s.ModelCosts))
+		for k, v := range s.ModelCosts {
+			cp.ModelCosts[k] = v
+		}
+	}
+	if len(s.HistoricalModelCosts) > 0 {
+		cp.HistoricalModelCosts = make(map[string]float64, len(s.HistoricalModelCosts))
+		for k, v := range s.HistoricalModelCosts {
+			cp.HistoricalModelCosts[k] = v
+		}
+	}
+
 	return &cp
 }
+
+// modelOrUnknown returns model, or "unknown" if it is empty.
+func modelOrUnknown(model string) string {
+	if model == "" {
+		return "unknown"
+	}
+	return model
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"math"
 	"sync"
 	"testing"
 	"time"
@@ -211,7 +212,7 @@ func TestStateStore_UpdatePID(t *testing.T) {
 		Timestamp: time.Now(),
 	})
 
-	store.UpdatePID("sess-001", 4821)
+	store.UpdatePID("sess-001", 4821, time.Time{})
 
 	s := store.GetSession("sess-001")
 	if s == nil {
@@ -230,9 +231,9 @@ func TestStateStore_MarkExited(t *testing.T) {
 		Value:     2.50,
 		Timestamp: time.Now(),
 	})
-	store.UpdatePID("sess-001", 4821)
+	store.UpdatePID("sess-001", 4821, time.Time{})
 
-	store.MarkExited(4821)
+	store.MarkExited(4821, time.Time{})
 
 	s := store.GetSession("sess-001")
 	if s == nil {
@@ -320,7 +321,7 @@ func TestStateStore_MarkExited_IgnoresZeroPID(t *testing.T) {
 	})
 
 	// Should not mark any session exited with PID 0.
-	store.MarkExited(0)
+	store.MarkExited(0, time.Time{})
 
 	s := store.GetSession("sess-001")
 	if s == nil {
@@ -976,3 +977,183 @@ func TestSessionHelpers(t *testing.T) {
 		}
 	})
 }
+
+func TestStateStore_MarkExited_PIDReuse(t *testing.T) {
+	store := NewMemoryStore()
+
+	oldStart := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	newStart := oldStart.Add(3 * time.Hour)
+
+	store.UpdatePID("sess-old", 4821, oldStart)
+	store.UpdatePID("sess-new", 4821, newStart)
+
+	// The recycled PID's new process exits: only its own session is marked.
+	store.MarkExited(4821, newStart)
+
+	if s := store.GetSession("sess-old"); s.Exited {
+		t.Error("session of the earlier process with the same PID should not be marked exited")
+	}
+	s := store.GetSession("sess-new")
+	if !s.Exited {
+		t.Error("session of the exiting process should be marked exited")
+	}
+	if !s.PIDStartTime.Equal(newStart) {
+		t.Errorf("PIDStartTime = %v, want %v", s.PIDStartTime, newStart)
+	}
+
+	// An unknown start time falls back to matching on PID alone.
+	store.MarkExited(4821, time.Time{})
+	if s := store.GetSession("sess-old"); !s.Exited {
+		t.Error("MarkExited without a start time should match on PID")
+	}
+}
+
+func TestStateStore_UpdateGit(t *testing.T) {
+	store := NewMemoryStore()
+
+	store.UpdateMetadata("sess-1", SessionMetadata{ServiceVersion: "2.1.0"})
+	store.UpdateCWD("sess-1", "/home/u/src/cc-top")
+	store.UpdateGit("sess-1", GitInfo{Repo: "cc-top", Branch: "main", Dirty: true})
+
+	s := store.GetSession("sess-1")
+	if s.CWD != "/home/u/src/cc-top" {
+		t.Errorf("CWD = %q", s.CWD)
+	}
+	if s.Metadata.Git.Branch != "main" || !s.Metadata.Git.Dirty {
+		t.Errorf("Git = %+v", s.Metadata.Git)
+	}
+
+	// Resource attributes arriving later must not clear the git context,
+	// and a new git context replaces the old one entirely.
+	store.UpdateMetadata("sess-1", SessionMetadata{OSType: "linux"})
+	store.UpdateGit("sess-1", GitInfo{Repo: "cc-top", Branch: "fix"})
+
+	s = store.GetSession("sess-1")
+	if s.Metadata.ServiceVersion != "2.1.0" || s.Metadata.OSType != "linux" {
+		t.Errorf("Metadata = %+v", s.Metadata)
+	}
+	if s.Metadata.Git.Branch != "fix" || s.Metadata.Git.Dirty {
+		t.Errorf("Git after update = %+v, want clean fix", s.Metadata.Git)
+	}
+}
+
+func TestStateStore_UpdateInvocation(t *testing.T) {
+	store := NewMemoryStore()
+
+	store.UpdateInvocation("sess-1", "headless", "cron")
+	s := store.GetSession("sess-1")
+	if s.Mode != "headless" || s.ParentApp != "cron" {
+		t.Errorf("Mode, ParentApp = %q, %q; want headless, cron", s.Mode, s.ParentApp)
+	}
+
+	// An unclassified process must not erase what is known.
+	store.UpdateInvocation("sess-1", "", "")
+	if s := store.GetSession("sess-1"); s.Mode != "headless" {
+		t.Errorf("Mode = %q after empty update, want headless", s.Mode)
+	}
+}
+
+func TestStateStore_ModelCosts(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	// Events alone attribute cost by model.
+	store.AddEvent("sess-1", Event{Name: "claude_code.api_request", Timestamp: now,
+		Attributes: map[string]string{"model": "claude-opus-4-6", "cost_usd": "0.30"}})
+	if got := store.GetSession("sess-1").ModelCosts["claude-opus-4-6"]; math.Abs(got-0.30) > 1e-9 {
+		t.Errorf("event-based opus cost = %f, want 0.30", got)
+	}
+
+	// Once cost metrics arrive they replace the event-based figures, and
+	// interleaved models are attributed separately.
+	for _, m := range []Metric{
+		{Name: "claude_code.cost.usage", Value: 0.30, Attributes: map[string]string{"model": "claude-opus-4-6"}},
+		{Name: "claude_code.cost.usage", Value: 0.02, Attributes: map[string]string{"model": "claude-haiku-4-5"}},
+		{Name: "claude_code.cost.usage", Value: 0.50, Attributes: map[string]string{"model": "claude-opus-4-6"}},
+		{Name: "claude_code.cost.usage", Value: 0.05, Attributes: map[string]string{"model": "claude-haiku-4-5"}},
+	} {
+		m.Timestamp = now
+		store.AddMetric("sess-1", m)
+	}
+	store.AddEvent("sess-1", Event{Name: "claude_code.api_request", Timestamp: now,
+		Attributes: map[string]string{"model": "claude-haiku-4-5", "cost_usd": "0.03"}})
+
+	s := store.GetSession("sess-1")
+	if got := s.ModelCosts["claude-opus-4-6"]; math.Abs(got-0.50) > 1e-9 {
+		t.Errorf("opus cost = %f, want 0.50", got)
+	}
+	if got := s.ModelCosts["claude-haiku-4-5"]; math.Abs(got-0.05) > 1e-9 {
+		t.Errorf("haiku cost = %f, want 0.05", got)
+	}
+	if s.Model != "claude-haiku-4-5" {
+		t.Errorf("Model = %q, want the last model seen", s.Model)
+	}
+
+	// The returned map is a copy.
+	s.ModelCosts["claude-opus-4-6"] = 99
+	if store.GetSession("sess-1").ModelCosts["claude-opus-4-6"] == 99 {
+		t.Error("GetSession should return a copy of ModelCosts")
+	}
+}
+
+func TestStateStore_HistoricalMetrics(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	historical := map[string]string{"model": "claude-opus-4-6", HistoricalAttr: "true"}
+	for _, m := range []Metric{
+		{Name: "claude_code.cost.usage", Value: 5.00, Attributes: historical},
+		{Name: "claude_code.token.usage", Value: 1000, Attributes: historical},
+		// Live data points continue the same counters.
+		{Name: "claude_code.cost.usage", Value: 5.50, Attributes: map[string]string{"model": "claude-opus-4-6"}},
+		{Name: "claude_code.token.usage", Value: 1200, Attributes: map[string]string{"model": "claude-opus-4-6"}},
+	} {
+		m.Timestamp = now
+		store.AddMetric("sess-1", m)
+	}
+
+	s := store.GetSession("sess-1")
+	if math.Abs(s.TotalCost-5.50) > 1e-9 || math.Abs(s.HistoricalCost-5.00) > 1e-9 {
+		t.Errorf("cost total %f, historical %f, want 5.50 and 5.00", s.TotalCost, s.HistoricalCost)
+	}
+	if got := s.HistoricalModelCosts["claude-opus-4-6"]; math.Abs(got-5.00) > 1e-9 {
+		t.Errorf("historical opus cost = %f, want 5.00", got)
+	}
+	if s.TotalTokens != 1200 || s.HistoricalTokens != 1000 {
+		t.Errorf("tokens total %d, historical %d, want 1200 and 1000", s.TotalTokens, s.HistoricalTokens)
+	}
+}
+
+func TestSessionData_CostSince(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	s := SessionData{Metrics: []Metric{
+		{Name: "claude_code.cost.usage", Value: 2.00, Timestamp: base.Add(-time.Hour), Attributes: map[string]string{"model": "opus"}},
+		{Name: "claude_code.cost.usage", Value: 3.00, Timestamp: base.Add(time.Hour), Attributes: map[string]string{"model": "opus"}},
+		{Name: "claude_code.cost.usage", Value: 0.25, Timestamp: base.Add(time.Hour), Attributes: map[string]string{"model": "haiku"}},
+		// A counter reset counts the new value in full.
+		{Name: "claude_code.cost.usage", Value: 0.50, Timestamp: base.Add(2 * time.Hour), Attributes: map[string]string{"model": "opus"}},
+		{Name: "claude_code.token.usage", Value: 1000, Timestamp: base.Add(2 * time.Hour)},
+	}}
+
+	if got := s.CostSince(base, nil); math.Abs(got-1.75) > 1e-9 {
+		t.Errorf("CostSince(all) = %f, want 1.75", got)
+	}
+	opus := func(m string) bool { return m == "opus" }
+	if got := s.CostSince(base, opus); math.Abs(got-1.50) > 1e-9 {
+		t.Errorf("CostSince(opus) = %f, want 1.50", got)
+	}
+	if got := s.CostSince(base.Add(-2*time.Hour), nil); math.Abs(got-3.75) > 1e-9 {
+		t.Errorf("CostSince(before first point) = %f, want 3.75", got)
+	}
+
+	// Without cost metrics, api_request events are used.
+	ev := SessionData{Events: []Event{
+		{Name: "claude_code.api_request", Timestamp: base.Add(-time.Minute), Attributes: map[string]string{"cost_usd": "1.00"}},
+		{Name: "claude_code.api_request", Timestamp: base.Add(time.Minute), Attributes: map[string]string{"cost_usd": "0.40"}},
+	}}
+	if got := ev.CostSince(base, nil); math.Abs(got-0.40) > 1e-9 {
+		t.Errorf("event-based CostSince = %f, want 0.40", got)
+	}
+	if got := ev.CostSince(base, opus); got != 0 {
+		t.Errorf("event-based CostSince(opus) = %f, want 0 for events without a model", got)
+	}
+}
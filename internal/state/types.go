@@ -1,30 +1,70 @@
 package state
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // UnknownSessionID is the bucket used for metrics/events that arrive
 // without a session.id attribute.
 const UnknownSessionID = "unknown"
 
+// SourceAttr is set on metrics and events that cc-top synthesized itself
+// rather than received over OTLP. SourceTranscript marks data backfilled
+// from Claude Code transcript files.
+const (
+	SourceAttr       = "cc_top.source"
+	SourceTranscript = "transcript"
+)
+
+// HistoricalAttr is set on synthesized metrics that describe spend from
+// before cc-top was watching the session, such as a transcript backfill.
+// Their value counts towards the session's totals but not towards rates.
+const HistoricalAttr = "cc_top.historical"
+
 // SessionData holds all data for a single Claude Code session.
 type SessionData struct {
-	SessionID   string
-	PID         int // 0 if uncorrelated
-	Terminal    string
-	CWD         string
-	Model       string
+	SessionID string
+	PID       int // 0 if uncorrelated
+	// PIDStartTime is when the process with PID started; together they
+	// identify the process even if the PID is later recycled. Zero if unknown.
+	PIDStartTime        time.Time
+	Terminal            string
+	CWD                 string
+	Model               string
 	TotalCost           float64
 	TotalTokens         int64
 	CacheReadTokens     int64
 	CacheCreationTokens int64
 	ActiveTime          time.Duration
-	LastEventAt time.Time
-	StartedAt   time.Time
-	Exited      bool
-	IsNew       bool // "New" badge for one scan cycle
-	FastMode    bool
-	OrgID       string
-	UserUUID    string
+	LastEventAt         time.Time
+	StartedAt           time.Time
+	Exited              bool
+	IsNew               bool // "New" badge for one scan cycle
+	FastMode            bool
+	OrgID               string
+	UserUUID            string
+	// Estimated is set when the session's data was synthesized from its
+	// transcript file rather than received over OTLP.
+	Estimated bool
+	// Mode is how the session's process was started: "interactive",
+	// "headless", "sdk" or "ide". Empty until the process is correlated.
+	Mode string
+	// ParentApp names the application that launched the process, such as
+	// "VS Code" or "cron". Empty if unknown.
+	ParentApp string
+	// ModelCosts splits TotalCost by the model attribute of the session's
+	// claude_code.cost.usage data points. Until the session sends a cost
+	// metric it is built from the cost_usd of its api_request events.
+	ModelCosts map[string]float64
+	// modelCostsFromMetrics is set once ModelCosts is built from metrics.
+	modelCostsFromMetrics bool
+	// HistoricalCost, HistoricalModelCosts and HistoricalTokens are the
+	// parts of TotalCost, ModelCosts and TotalTokens that came from data
+	// points marked with HistoricalAttr.
+	HistoricalCost       float64
+	HistoricalModelCosts map[string]float64
+	HistoricalTokens     int64
 
 	Metrics []Metric
 	Events  []Event
@@ -43,6 +83,23 @@ type SessionMetadata struct {
 	OSType         string
 	OSVersion      string
 	HostArch       string
+
+	// Git describes the repository the session's working directory is in.
+	Git GitInfo
+}
+
+// GitInfo describes the git working tree a session runs in. A zero value
+// means the CWD is unknown or not inside a repository.
+type GitInfo struct {
+	RepoRoot string // absolute path of the working tree root
+	Repo     string // repository name
+	Branch   string // empty when HEAD is detached
+	Head     string // full commit hash; empty on an unborn branch
+	Dirty    bool   // staged or unstaged changes to tracked files
+
+	// CommonDir is the repository's shared git directory; linked
+	// worktrees of one repository have the same CommonDir.
+	CommonDir string
 }
 
 // Status returns the current activity status of the session based on
@@ -66,6 +123,53 @@ func (s *SessionData) Status() SessionStatus {
 	}
 }
 
+// CostSince returns the session's spend from since onwards, from the
+// deltas of its claude_code.cost.usage counters, or from the cost_usd of
+// its api_request events if it has received no cost metrics. Data points
+// without a timestamp count as current. If match is non-nil, only cost
+// attributed to models it accepts is included.
+func (s *SessionData) CostSince(since time.Time, match func(model string) bool) float64 {
+	accept := func(model string) bool {
+		return match == nil || match(modelOrUnknown(model))
+	}
+
+	var total float64
+	prev := make(map[string]float64)
+	hasMetrics := false
+	for _, m := range s.Metrics {
+		if m.Name != "claude_code.cost.usage" {
+			continue
+		}
+		hasMetrics = true
+		key := metricKey(m.Name, m.Attributes)
+		last, seen := prev[key]
+		prev[key] = m.Value
+		delta := m.Value
+		if seen && m.Value >= last {
+			delta = m.Value - last
+		}
+		if (m.Timestamp.IsZero() || !m.Timestamp.Before(since)) && accept(m.Attributes["model"]) {
+			total += delta
+		}
+	}
+	if hasMetrics {
+		return total
+	}
+
+	for _, e := range s.Events {
+		if e.Name != "claude_code.api_request" || (!e.Timestamp.IsZero() && e.Timestamp.Before(since)) {
+			continue
+		}
+		if !accept(e.Attributes["model"]) {
+			continue
+		}
+		if cost, err := strconv.ParseFloat(e.Attributes["cost_usd"], 64); err == nil {
+			total += cost
+		}
+	}
+	return total
+}
+
 // Metric represents a received OTLP metric data point.
 type Metric struct {
 	Name       string
@@ -86,8 +190,8 @@ type Event struct {
 type SessionStatus string
 
 const (
-	StatusActive SessionStatus = "active"  // events within 30s
-	StatusIdle   SessionStatus = "idle"    // 30s-5min since last event
-	StatusDone   SessionStatus = "done"    // >5min since last event
-	StatusExited SessionStatus = "exited"  // process gone
+	StatusActive SessionStatus = "active" // events within 30s
+	StatusIdle   SessionStatus = "idle"   // 30s-5min since last event
+	StatusDone   SessionStatus = "done"   // >5min since last event
+	StatusExited SessionStatus = "exited" // process gone
 )
@@ -8,9 +8,13 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/nixlim/cc-top/internal/events"
 	"github.com/nixlim/cc-top/internal/state"
 )
 
+// topTurnsLimit is the number of turns reported in DashboardStats.TopTurns.
+const topTurnsLimit = 10
+
 // Calculator computes aggregate statistics from state store data.
 type Calculator struct {
 	pricing map[string][4]float64 // model -> [input, output, cacheRead, cacheCreation] per 1M tokens
@@ -49,6 +53,14 @@ func (c *Calculator) Compute(sessions []state.SessionData) DashboardStats {
 	stats.TokenBreakdown = c.computeTokenBreakdown(sessions)
 	stats.CacheSavingsUSD = c.computeCacheSavings(sessions)
 	stats.MCPToolUsage = c.computeMCPToolUsage(sessions)
+	stats.RepoBreakdown = c.computeRepoBreakdown(sessions)
+	stats.TopTurns = c.computeTopTurns(sessions, topTurnsLimit)
+	stats.Domains = c.computeDomainInventory(sessions)
+	for i := range sessions {
+		if sessions[i].Estimated {
+			stats.EstimatedSessions++
+		}
+	}
 
 	return stats
 }
@@ -211,16 +223,30 @@ func (c *Calculator) computeAvgAPILatency(sessions []state.SessionData) float64
 	return totalMS / float64(count) / 1000.0 // Convert ms to seconds.
 }
 
-// computeModelBreakdown aggregates cost and tokens by model from
-// api_request events. Returns sorted by cost descending.
+// computeModelBreakdown aggregates cost and tokens by model. Tokens come
+// from api_request events. Cost comes from each session's ModelCosts, the
+// same figures the burn rate panel uses, or from the events' cost_usd for
+// sessions without them. Returns sorted by cost descending.
 func (c *Calculator) computeModelBreakdown(sessions []state.SessionData) []ModelStats {
 	type modelAgg struct {
 		cost   float64
 		tokens int64
 	}
 	models := make(map[string]*modelAgg)
+	get := func(model string) *modelAgg {
+		agg, ok := models[model]
+		if !ok {
+			agg = &modelAgg{}
+			models[model] = agg
+		}
+		return agg
+	}
 
 	for i := range sessions {
+		costFromEvents := len(sessions[i].ModelCosts) == 0
+		for model, cost := range sessions[i].ModelCosts {
+			get(model).cost += cost
+		}
 		for _, e := range sessions[i].Events {
 			if e.Name != "claude_code.api_request" {
 				continue
@@ -230,13 +256,9 @@ func (c *Calculator) computeModelBreakdown(sessions []state.SessionData) []Model
 				continue
 			}
 
-			agg, ok := models[model]
-			if !ok {
-				agg = &modelAgg{}
-				models[model] = agg
-			}
+			agg := get(model)
 
-			if costStr, ok := e.Attributes["cost_usd"]; ok {
+			if costStr, ok := e.Attributes["cost_usd"]; ok && costFromEvents {
 				if cost, err := strconv.ParseFloat(costStr, 64); err == nil {
 					agg.cost += cost
 				}
@@ -272,6 +294,132 @@ func (c *Calculator) computeModelBreakdown(sessions []state.SessionData) []Model
 	return result
 }
 
+// computeRepoBreakdown groups sessions by git repository and branch.
+// Sessions outside a repository (or whose CWD is unknown) are skipped.
+// Returns sorted by cost descending, then by repository and branch.
+func (c *Calculator) computeRepoBreakdown(sessions []state.SessionData) []RepoStats {
+	type repoKey struct {
+		repo   string
+		branch string
+	}
+	repos := make(map[repoKey]*RepoStats)
+
+	for i := range sessions {
+		git := sessions[i].Metadata.Git
+		if git.Repo == "" {
+			continue
+		}
+		k := repoKey{repo: git.Repo, branch: git.Branch}
+		agg, ok := repos[k]
+		if !ok {
+			agg = &RepoStats{Repo: git.Repo, Branch: git.Branch}
+			repos[k] = agg
+		}
+		added, removed := c.computeLinesOfCode(sessions[i : i+1])
+		agg.Sessions++
+		agg.TotalCost += sessions[i].TotalCost
+		agg.TotalTokens += sessions[i].TotalTokens
+		agg.LinesAdded += added
+		agg.LinesRemoved += removed
+	}
+
+	result := make([]RepoStats, 0, len(repos))
+	for _, agg := range repos {
+		result = append(result, *agg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCost != result[j].TotalCost {
+			return result[i].TotalCost > result[j].TotalCost
+		}
+		if result[i].Repo != result[j].Repo {
+			return result[i].Repo < result[j].Repo
+		}
+		return result[i].Branch < result[j].Branch
+	})
+	return result
+}
+
+// computeTopTurns segments each session's events into turns with
+// events.GroupTurns and returns the limit most expensive, by cost then
+// tokens. Activity before a session's first prompt is not a turn and is
+// left out.
+func (c *Calculator) computeTopTurns(sessions []state.SessionData, limit int) []TurnCost {
+	var turns []TurnCost
+	for i := range sessions {
+		for _, t := range events.GroupTurns(sessions[i].Events) {
+			if t.Number == 0 {
+				continue
+			}
+			turns = append(turns, TurnCost{
+				SessionID:   sessions[i].SessionID,
+				Number:      t.Number,
+				Prompt:      t.PromptLabel(),
+				StartedAt:   t.Start,
+				WallTime:    t.WallTime(),
+				CostUSD:     t.CostUSD,
+				Tokens:      t.Tokens(),
+				APIRequests: t.APIRequests,
+				ToolCalls:   t.ToolResults,
+				Errors:      t.Errors,
+			})
+		}
+	}
+
+	sort.SliceStable(turns, func(i, j int) bool {
+		if turns[i].CostUSD != turns[j].CostUSD {
+			return turns[i].CostUSD > turns[j].CostUSD
+		}
+		return turns[i].Tokens > turns[j].Tokens
+	})
+	if len(turns) > limit {
+		turns = turns[:limit]
+	}
+	return turns
+}
+
+// computeDomainInventory counts the external domains contacted in
+// tool_result events, as found by events.EgressDomains, with the number of
+// sessions that contacted each and when they were first and last seen.
+// Returns sorted by count descending, then by domain.
+func (c *Calculator) computeDomainInventory(sessions []state.SessionData) []DomainStats {
+	domains := make(map[string]*DomainStats)
+	for i := range sessions {
+		seen := make(map[string]bool)
+		for _, e := range sessions[i].Events {
+			for _, d := range events.EgressDomains(e) {
+				agg, ok := domains[d]
+				if !ok {
+					agg = &DomainStats{Domain: d, FirstSeen: e.Timestamp, LastSeen: e.Timestamp}
+					domains[d] = agg
+				}
+				agg.Count++
+				if !seen[d] {
+					seen[d] = true
+					agg.Sessions++
+				}
+				if e.Timestamp.Before(agg.FirstSeen) {
+					agg.FirstSeen = e.Timestamp
+				}
+				if e.Timestamp.After(agg.LastSeen) {
+					agg.LastSeen = e.Timestamp
+				}
+			}
+		}
+	}
+
+	result := make([]DomainStats, 0, len(domains))
+	for _, agg := range domains {
+		result = append(result, *agg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Domain < result[j].Domain
+	})
+	return result
+}
+
 // computeTopTools ranks tools by frequency from tool_result events.
 // Returns sorted by count descending.
 func (c *Calculator) computeTopTools(sessions []state.SessionData) []ToolUsage {
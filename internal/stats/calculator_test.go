@@ -422,6 +422,50 @@ func TestStatsCalc_ModelBreakdown(t *testing.T) {
 	}
 }
 
+func TestStatsCalc_ModelBreakdownUsesModelCosts(t *testing.T) {
+	sessions := []state.SessionData{
+		{
+			SessionID: "sess-001",
+			// Cost metrics split by model take precedence over event costs.
+			ModelCosts: map[string]float64{"opus-4.6": 1.20, "haiku-4.5": 0.10},
+			Events: []state.Event{
+				{
+					Name: "claude_code.api_request",
+					Attributes: map[string]string{
+						"model":         "opus-4.6",
+						"cost_usd":      "1.00",
+						"input_tokens":  "1000",
+						"output_tokens": "500",
+					},
+				},
+			},
+		},
+		{
+			SessionID: "sess-002",
+			Events: []state.Event{
+				{
+					Name:       "claude_code.api_request",
+					Attributes: map[string]string{"model": "haiku-4.5", "cost_usd": "0.05"},
+				},
+			},
+		},
+	}
+
+	calc := NewCalculator(nil)
+	stats := calc.Compute(sessions)
+
+	if len(stats.ModelBreakdown) != 2 {
+		t.Fatalf("expected 2 models in breakdown, got %+v", stats.ModelBreakdown)
+	}
+	opus, haiku := stats.ModelBreakdown[0], stats.ModelBreakdown[1]
+	if opus.Model != "opus-4.6" || math.Abs(opus.TotalCost-1.20) > 0.001 || opus.TotalTokens != 1500 {
+		t.Errorf("opus = %+v, want cost 1.20 from ModelCosts and 1500 tokens", opus)
+	}
+	if haiku.Model != "haiku-4.5" || math.Abs(haiku.TotalCost-0.15) > 0.001 {
+		t.Errorf("haiku = %+v, want cost 0.15", haiku)
+	}
+}
+
 func TestStatsCalc_TopTools(t *testing.T) {
 	sessions := []state.SessionData{
 		{
@@ -1117,3 +1161,130 @@ func makeAPIEvents(requests, errors int) []state.Event {
 	}
 	return events
 }
+
+func TestStatsCalc_RepoBreakdown(t *testing.T) {
+	loc := func(added float64) []state.Metric {
+		return []state.Metric{{
+			Name:       "claude_code.lines_of_code.count",
+			Value:      added,
+			Attributes: map[string]string{"type": "added"},
+		}}
+	}
+	sessions := []state.SessionData{
+		{
+			SessionID: "sess-001", TotalCost: 1.50, TotalTokens: 1000, Metrics: loc(10),
+			Metadata: state.SessionMetadata{Git: state.GitInfo{Repo: "cc-top", Branch: "main"}},
+		},
+		{
+			SessionID: "sess-002", TotalCost: 0.50, TotalTokens: 500, Metrics: loc(5),
+			Metadata: state.SessionMetadata{Git: state.GitInfo{Repo: "cc-top", Branch: "main"}},
+		},
+		{
+			SessionID: "sess-003", TotalCost: 3.00, TotalTokens: 2000,
+			Metadata: state.SessionMetadata{Git: state.GitInfo{Repo: "cc-top", Branch: "feature"}},
+		},
+		{SessionID: "sess-004", TotalCost: 9.00, Estimated: true}, // not in a repository
+	}
+
+	ds := NewCalculator(nil).Compute(sessions)
+	if ds.EstimatedSessions != 1 {
+		t.Errorf("EstimatedSessions = %d, want 1", ds.EstimatedSessions)
+	}
+	got := ds.RepoBreakdown
+	if len(got) != 2 {
+		t.Fatalf("expected 2 repo/branch rows, got %+v", got)
+	}
+	if got[0].Branch != "feature" || got[0].TotalCost != 3.00 {
+		t.Errorf("first row = %+v, want feature at $3.00", got[0])
+	}
+	main := got[1]
+	if main.Repo != "cc-top" || main.Branch != "main" || main.Sessions != 2 {
+		t.Errorf("second row = %+v, want cc-top/main with 2 sessions", main)
+	}
+	if main.TotalCost != 2.00 || main.TotalTokens != 1500 || main.LinesAdded != 15 {
+		t.Errorf("main totals = %+v", main)
+	}
+}
+
+func TestStatsCalc_TopTurns(t *testing.T) {
+	now := time.Now()
+	ev := func(name string, offset time.Duration, attrs map[string]string) state.Event {
+		return state.Event{Name: "claude_code." + name, Timestamp: now.Add(offset), Attributes: attrs}
+	}
+	sessions := []state.SessionData{
+		{SessionID: "sess-001", Events: []state.Event{
+			// API activity before the first prompt is not attributed to a turn.
+			ev("api_request", 0, map[string]string{"cost_usd": "5.00"}),
+			ev("user_prompt", time.Second, map[string]string{"prompt_length": "40"}),
+			ev("api_request", 2*time.Second, map[string]string{"cost_usd": "0.30", "input_tokens": "1000", "output_tokens": "100"}),
+			ev("tool_result", 3*time.Second, map[string]string{"tool_name": "Bash", "success": "false"}),
+			ev("api_request", 4*time.Second, map[string]string{"cost_usd": "0.20", "input_tokens": "900"}),
+			ev("user_prompt", time.Minute, map[string]string{"prompt": "write docs"}),
+			ev("api_request", time.Minute+time.Second, map[string]string{"cost_usd": "0.05"}),
+		}},
+		{SessionID: "sess-002", Events: []state.Event{
+			ev("user_prompt", 0, map[string]string{"prompt.id": "p1"}),
+			ev("api_error", time.Second, map[string]string{"prompt.id": "p1"}),
+			ev("api_request", 2*time.Second, map[string]string{"prompt.id": "p1", "cost_usd": "1.10"}),
+		}},
+	}
+
+	got := NewCalculator(nil).Compute(sessions).TopTurns
+	if len(got) != 3 {
+		t.Fatalf("expected 3 turns, got %+v", got)
+	}
+	if got[0].SessionID != "sess-002" || got[0].CostUSD != 1.10 || got[0].Errors != 1 {
+		t.Errorf("most expensive turn = %+v, want sess-002 at $1.10 with 1 error", got[0])
+	}
+	second := got[1]
+	if second.SessionID != "sess-001" || second.Number != 1 || second.Prompt != "(40 chars)" {
+		t.Errorf("second turn = %+v", second)
+	}
+	if second.CostUSD < 0.499 || second.CostUSD > 0.501 || second.Tokens != 2000 {
+		t.Errorf("second turn cost/tokens = %f/%d, want 0.50/2000", second.CostUSD, second.Tokens)
+	}
+	if second.APIRequests != 2 || second.ToolCalls != 1 || second.Errors != 1 || second.WallTime != 3*time.Second {
+		t.Errorf("second turn activity = %+v", second)
+	}
+	if got[2].Prompt != "write docs" {
+		t.Errorf("third turn prompt = %q, want logged prompt text", got[2].Prompt)
+	}
+}
+
+func TestStatsCalc_DomainInventory(t *testing.T) {
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	fetch := func(url string, minute int) state.Event {
+		return state.Event{Name: "claude_code.tool_result", Timestamp: base.Add(time.Duration(minute) * time.Minute),
+			Attributes: map[string]string{"tool_name": "WebFetch", "tool_parameters": `{"url": "` + url + `"}`}}
+	}
+	sessions := []state.SessionData{
+		{SessionID: "sess-001", Events: []state.Event{
+			fetch("https://go.dev/doc", 5),
+			fetch("https://go.dev/ref/spec", 1),
+			fetch("https://example.com", 3),
+		}},
+		{SessionID: "sess-002", Events: []state.Event{
+			fetch("https://go.dev/blog", 9),
+			{Name: "claude_code.tool_result", Timestamp: base, Attributes: map[string]string{"tool_name": "Bash"}},
+		}},
+	}
+
+	got := NewCalculator(nil).Compute(sessions).Domains
+	if len(got) != 2 {
+		t.Fatalf("expected 2 domains, got %+v", got)
+	}
+	goDev := got[0]
+	if goDev.Domain != "go.dev" || goDev.Count != 3 || goDev.Sessions != 2 {
+		t.Errorf("first domain = %+v, want go.dev contacted 3 times by 2 sessions", goDev)
+	}
+	if !goDev.FirstSeen.Equal(base.Add(time.Minute)) || !goDev.LastSeen.Equal(base.Add(9*time.Minute)) {
+		t.Errorf("go.dev seen %v to %v", goDev.FirstSeen, goDev.LastSeen)
+	}
+	if got[1].Domain != "example.com" || got[1].Count != 1 || got[1].Sessions != 1 {
+		t.Errorf("second domain = %+v", got[1])
+	}
+
+	if d := NewCalculator(nil).Compute(nil).Domains; len(d) != 0 {
+		t.Errorf("expected no domains without sessions, got %+v", d)
+	}
+}
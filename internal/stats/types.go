@@ -1,5 +1,7 @@
 package stats
 
+import "time"
+
 // DashboardStats holds aggregate statistics computed from session data.
 type DashboardStats struct {
 	LinesAdded    int
@@ -21,6 +23,10 @@ type DashboardStats struct {
 	TokenBreakdown    map[string]int64   // input, output, cacheRead, cacheCreation
 	CacheSavingsUSD   float64
 	MCPToolUsage      map[string]int     // "server:tool" -> count
+	RepoBreakdown     []RepoStats        // per repository and branch
+	EstimatedSessions int                // sessions backfilled from transcripts
+	TopTurns          []TurnCost         // most expensive prompts, costliest first
+	Domains           []DomainStats      // external domains contacted, most contacted first
 }
 
 // ModelStats holds per-model cost and token data.
@@ -30,6 +36,43 @@ type ModelStats struct {
 	TotalTokens int64
 }
 
+// TurnCost holds the cost and activity attributed to one user prompt:
+// every api_request, tool call and error up to the next prompt.
+type TurnCost struct {
+	SessionID   string
+	Number      int    // 1-based prompt number within the session
+	Prompt      string // prompt text when logged, else "(N chars)"
+	StartedAt   time.Time
+	WallTime    time.Duration
+	CostUSD     float64
+	Tokens      int64
+	APIRequests int
+	ToolCalls   int
+	Errors      int
+}
+
+// DomainStats holds how often an external domain was contacted by
+// WebFetch, WebSearch and remote MCP calls, and by how many sessions.
+type DomainStats struct {
+	Domain    string
+	Count     int
+	Sessions  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// RepoStats holds per-repository, per-branch session totals. Branch is
+// empty for sessions on a detached HEAD.
+type RepoStats struct {
+	Repo         string
+	Branch       string
+	Sessions     int
+	TotalCost    float64
+	TotalTokens  int64
+	LinesAdded   int
+	LinesRemoved int
+}
+
 // ToolUsage holds tool frequency data.
 type ToolUsage struct {
 	ToolName string
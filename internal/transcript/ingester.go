@@ -0,0 +1,430 @@
+// Package transcript backfills cc-top sessions from the JSONL transcript
+// files Claude Code writes under ~/.claude/projects. It is used for
+// processes that aren't sending OTLP telemetry: each API response in the
+// transcript is turned into the api_request event and cumulative cost and
+// token metrics that OTLP would have delivered, with cost computed from
+// the configured pricing. Everything it synthesizes is tagged with
+// state.SourceAttr so the UI can mark it as estimated, and the cost and
+// tokens of responses from before the transcript was first read with
+// state.HistoricalAttr, so a backfill doesn't count as a burst of spend.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// Defaults for Ingester timing.
+const (
+	DefaultInterval    = 5 * time.Second
+	DefaultGracePeriod = 30 * time.Second
+	DefaultLookback    = time.Hour
+)
+
+// Filter decides whether a session may be backfilled. It is consulted once
+// per session, after the grace period, with the session ID and the working
+// directory recorded in its transcript.
+type Filter func(sessionID, cwd string) bool
+
+// Ingester tails transcript files and feeds synthesized telemetry into the
+// state store for sessions without OTLP data.
+type Ingester struct {
+	store    state.Store
+	dir      string
+	pricing  map[string][4]float64
+	interval time.Duration
+	grace    time.Duration
+	lookback time.Duration
+	filter   Filter
+
+	mu      sync.Mutex
+	started time.Time
+	files   map[string]*fileState
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// fileState tracks one transcript file.
+type fileState struct {
+	sessionID string
+	firstSeen time.Time
+	decided   bool
+	ingest    bool
+	offset    int64
+	marked    bool // session flagged as estimated
+	// firstRead is when the file was first read; responses from before
+	// then are historical.
+	firstRead time.Time
+
+	seen   map[string]bool      // message IDs already counted
+	cost   map[string]float64   // model -> cumulative cost
+	tokens map[tokenKey]float64 // cumulative tokens by type and model
+
+	// pending is the latest line of the API response being written; it
+	// is counted once the transcript moves on or stops growing, as only
+	// the response's last line carries its final usage.
+	pending *record
+}
+
+type tokenKey struct {
+	typ   string
+	model string
+}
+
+// IngesterOption configures an Ingester.
+type IngesterOption func(*Ingester)
+
+// WithInterval sets how often transcript files are polled.
+func WithInterval(d time.Duration) IngesterOption {
+	return func(i *Ingester) {
+		i.interval = d
+	}
+}
+
+// WithGracePeriod sets how long a newly seen session is given to start
+// delivering OTLP before it is backfilled from its transcript.
+func WithGracePeriod(d time.Duration) IngesterOption {
+	return func(i *Ingester) {
+		i.grace = d
+	}
+}
+
+// WithLookback sets how recently a transcript must have been modified,
+// relative to the first poll, for it to be picked up. Older transcripts
+// are picked up only once they are written to again.
+func WithLookback(d time.Duration) IngesterOption {
+	return func(i *Ingester) {
+		i.lookback = d
+	}
+}
+
+// WithFilter adds a check sessions must pass before being backfilled, in
+// addition to having no OTLP data in the store.
+func WithFilter(f Filter) IngesterOption {
+	return func(i *Ingester) {
+		i.filter = f
+	}
+}
+
+// NewIngester creates an Ingester reading transcripts under dir (an empty
+// dir means ~/.claude/projects). pricing maps model name to [input, output,
+// cacheRead, cacheCreation] price per 1M tokens.
+func NewIngester(store state.Store, dir string, pricing map[string][4]float64, opts ...IngesterOption) *Ingester {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	i := &Ingester{
+		store:    store,
+		dir:      dir,
+		pricing:  pricing,
+		interval: DefaultInterval,
+		grace:    DefaultGracePeriod,
+		lookback: DefaultLookback,
+		files:    make(map[string]*fileState),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// DefaultDir returns ~/.claude/projects, or "" if the home directory is
+// unknown.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// Start begins polling in a background goroutine.
+func (i *Ingester) Start(ctx context.Context) {
+	ctx, i.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(i.done)
+		ticker := time.NewTicker(i.interval)
+		defer ticker.Stop()
+
+		i.PollAt(time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				i.PollAt(time.Now())
+			}
+		}
+	}()
+}
+
+// Stop halts polling.
+func (i *Ingester) Stop() {
+	if i.cancel != nil {
+		i.cancel()
+		<-i.done
+	}
+}
+
+// PollAt scans the transcript directory once, as of now, and ingests any
+// new lines from eligible sessions.
+func (i *Ingester) PollAt(now time.Time) {
+	if i.dir == "" {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.started.IsZero() {
+		i.started = now
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(i.dir, "*", "*.jsonl"))
+	for _, path := range paths {
+		fs, ok := i.files[path]
+		if !ok {
+			fi, err := os.Stat(path)
+			if err != nil || fi.ModTime().Before(i.started.Add(-i.lookback)) {
+				continue
+			}
+			fs = &fileState{
+				sessionID: strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+				firstSeen: now,
+				seen:      make(map[string]bool),
+				cost:      make(map[string]float64),
+				tokens:    make(map[tokenKey]float64),
+			}
+			i.files[path] = fs
+		}
+
+		if !fs.decided {
+			if now.Sub(fs.firstSeen) < i.grace {
+				continue
+			}
+			fs.decided = true
+			fs.ingest = i.eligible(fs.sessionID, peekCWD(path))
+		}
+		if fs.ingest {
+			i.readNew(path, fs, now)
+		}
+	}
+}
+
+// eligible reports whether a session should be backfilled: the store
+// must hold no OTLP data for it and the filter, if any, must agree.
+func (i *Ingester) eligible(sessionID, cwd string) bool {
+	if s := i.store.GetSession(sessionID); s != nil && !s.Estimated {
+		if len(s.Metrics) > 0 || len(s.Events) > 0 {
+			return false
+		}
+	}
+	return i.filter == nil || i.filter(sessionID, cwd)
+}
+
+// readNew ingests complete lines appended since the last read, as of now.
+func (i *Ingester) readNew(path string, fs *fileState, now time.Time) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if fs.firstRead.IsZero() {
+		fs.firstRead = now
+	}
+
+	if fi, err := f.Stat(); err == nil && fi.Size() < fs.offset {
+		fs.offset = 0 // truncated or replaced
+	}
+	if _, err := f.Seek(fs.offset, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		// No complete line yet: the pending response is finished.
+		i.flushPending(fs)
+		return
+	}
+	fs.offset += int64(end + 1)
+
+	for _, line := range bytes.Split(data[:end], []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		if rec, ok := parseRecord(line); ok {
+			i.ingestRecord(fs, &rec)
+		}
+	}
+}
+
+// ingestRecord converts one transcript record into store updates.
+func (i *Ingester) ingestRecord(fs *fileState, rec *record) {
+	// Resumed sessions copy earlier sessions' messages into the new file;
+	// those were already counted under their own session.
+	if rec.SessionID != "" && rec.SessionID != fs.sessionID {
+		return
+	}
+
+	if _, ok := rec.apiUsage(); ok {
+		if fs.pending != nil && fs.pending.Message.ID == rec.Message.ID {
+			fs.pending = rec // a later content block of the same response
+			return
+		}
+		i.flushPending(fs)
+		if !fs.seen[rec.Message.ID] {
+			fs.seen[rec.Message.ID] = true
+			fs.pending = rec
+		}
+		return
+	}
+	i.flushPending(fs)
+
+	if text, ok := rec.promptText(); ok {
+		i.markEstimated(fs, rec.CWD)
+		i.store.AddEvent(fs.sessionID, state.Event{
+			Name: "claude_code.user_prompt",
+			Attributes: map[string]string{
+				"prompt_length":  strconv.Itoa(utf8.RuneCountInString(text)),
+				state.SourceAttr: state.SourceTranscript,
+			},
+			Timestamp: rec.Timestamp,
+		})
+	}
+}
+
+// flushPending counts the pending API response, if any.
+func (i *Ingester) flushPending(fs *fileState) {
+	rec := fs.pending
+	if rec == nil {
+		return
+	}
+	fs.pending = nil
+	i.markEstimated(fs, rec.CWD)
+	i.addAPIRequest(fs, rec, rec.Message.Usage)
+}
+
+// markEstimated flags the session and records its CWD the first time data
+// is synthesized for it.
+func (i *Ingester) markEstimated(fs *fileState, cwd string) {
+	if fs.marked {
+		return
+	}
+	fs.marked = true
+	i.store.MarkEstimated(fs.sessionID)
+	if cwd != "" {
+		if s := i.store.GetSession(fs.sessionID); s == nil || s.CWD == "" {
+			i.store.UpdateCWD(fs.sessionID, cwd)
+		}
+	}
+}
+
+// addAPIRequest emits an api_request event plus cumulative cost and token
+// counters, mirroring what Claude Code exports over OTLP.
+func (i *Ingester) addAPIRequest(fs *fileState, rec *record, u *usage) {
+	model := rec.Message.Model
+	cost := i.cost(model, u)
+	historical := rec.Timestamp.Before(fs.firstRead)
+	metricAttrs := func(attrs map[string]string) map[string]string {
+		attrs[state.SourceAttr] = state.SourceTranscript
+		if historical {
+			attrs[state.HistoricalAttr] = "true"
+		}
+		return attrs
+	}
+
+	i.store.AddEvent(fs.sessionID, state.Event{
+		Name: "claude_code.api_request",
+		Attributes: map[string]string{
+			"model":                 model,
+			"input_tokens":          strconv.FormatInt(u.InputTokens, 10),
+			"output_tokens":         strconv.FormatInt(u.OutputTokens, 10),
+			"cache_read_tokens":     strconv.FormatInt(u.CacheReadInputTokens, 10),
+			"cache_creation_tokens": strconv.FormatInt(u.CacheCreationInputTokens, 10),
+			"cost_usd":              strconv.FormatFloat(cost, 'f', 6, 64),
+			state.SourceAttr:        state.SourceTranscript,
+		},
+		Timestamp: rec.Timestamp,
+	})
+
+	fs.cost[model] += cost
+	i.store.AddMetric(fs.sessionID, state.Metric{
+		Name:       "claude_code.cost.usage",
+		Value:      fs.cost[model],
+		Attributes: metricAttrs(map[string]string{"model": model}),
+		Timestamp:  rec.Timestamp,
+	})
+
+	for _, t := range []struct {
+		typ string
+		n   int64
+	}{
+		{"input", u.InputTokens},
+		{"output", u.OutputTokens},
+		{"cacheRead", u.CacheReadInputTokens},
+		{"cacheCreation", u.CacheCreationInputTokens},
+	} {
+		if t.n == 0 {
+			continue
+		}
+		k := tokenKey{typ: t.typ, model: model}
+		fs.tokens[k] += float64(t.n)
+		i.store.AddMetric(fs.sessionID, state.Metric{
+			Name:       "claude_code.token.usage",
+			Value:      fs.tokens[k],
+			Attributes: metricAttrs(map[string]string{"type": t.typ, "model": model}),
+			Timestamp:  rec.Timestamp,
+		})
+	}
+}
+
+// cost prices one response from the configured per-1M-token pricing.
+// Unknown models cost 0.
+func (i *Ingester) cost(model string, u *usage) float64 {
+	p, ok := i.pricing[model]
+	if !ok {
+		return 0
+	}
+	return (float64(u.InputTokens)*p[0] +
+		float64(u.OutputTokens)*p[1] +
+		float64(u.CacheReadInputTokens)*p[2] +
+		float64(u.CacheCreationInputTokens)*p[3]) / 1_000_000.0
+}
+
+// peekCWDLines bounds how many lines are read looking for a CWD.
+const peekCWDLines = 50
+
+// peekCWD returns the working directory recorded near the start of a
+// transcript, or "" if none is found.
+func peekCWD(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for n := 0; n < peekCWDLines && sc.Scan(); n++ {
+		if rec, ok := parseRecord(sc.Bytes()); ok && rec.CWD != "" {
+			return rec.CWD
+		}
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package transcript
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/alerts"
+	"github.com/nixlim/cc-top/internal/burnrate"
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+var testPricing = map[string][4]float64{
+	"claude-sonnet-4-5-20250929": {3.00, 15.00, 0.30, 3.75},
+}
+
+func assistantLine(session, msgID string, in, out, cacheRead int64) string {
+	return fmt.Sprintf(`{"type":"assistant","sessionId":%q,"cwd":"/work/proj","timestamp":"2026-01-01T10:00:00Z",`+
+		`"message":{"id":%q,"model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"hi"}],`+
+		`"usage":{"input_tokens":%d,"output_tokens":%d,"cache_read_input_tokens":%d,"cache_creation_input_tokens":0}}}`+"\n",
+		session, msgID, in, out, cacheRead)
+}
+
+func userLine(session, prompt string) string {
+	return fmt.Sprintf(`{"type":"user","sessionId":%q,"cwd":"/work/proj","timestamp":"2026-01-01T09:59:59Z",`+
+		`"message":{"role":"user","content":%q}}`+"\n", session, prompt)
+}
+
+// writeTranscript creates dir/-work-proj/<session>.jsonl with the given lines.
+func writeTranscript(t *testing.T, dir, session string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, "-work-proj", session+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, lines...)
+	return path
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, l := range lines {
+		if _, err := f.WriteString(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// pollPastGrace polls once to discover files, again after the grace
+// period, and once more so the last response read is counted.
+func pollPastGrace(ing *Ingester, now time.Time) time.Time {
+	ing.PollAt(now)
+	now = now.Add(DefaultGracePeriod)
+	ing.PollAt(now)
+	ing.PollAt(now)
+	return now
+}
+
+func TestIngester_BackfillsSessionWithoutTelemetry(t *testing.T) {
+	dir := t.TempDir()
+	writeTranscript(t, dir, "sess-1",
+		userLine("sess-1", "fix the tests"),
+		// One API response written as two content-block lines; only the
+		// last has the final output token count.
+		assistantLine("sess-1", "msg_1", 1000, 1, 5000),
+		assistantLine("sess-1", "msg_1", 1000, 200, 5000),
+		`not json`+"\n",
+	)
+
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing)
+
+	now := time.Now()
+	ing.PollAt(now)
+	if store.GetSession("sess-1") != nil {
+		t.Fatal("session should not be backfilled during the grace period")
+	}
+	ing.PollAt(now.Add(DefaultGracePeriod))
+	ing.PollAt(now.Add(DefaultGracePeriod + time.Second))
+
+	s := store.GetSession("sess-1")
+	if s == nil {
+		t.Fatal("session not backfilled")
+	}
+	if !s.Estimated {
+		t.Error("backfilled session should be marked estimated")
+	}
+	if s.CWD != "/work/proj" {
+		t.Errorf("CWD = %q, want /work/proj", s.CWD)
+	}
+	// 1000*3 + 200*15 + 5000*0.30 per 1M = 0.0075
+	if math.Abs(s.TotalCost-0.0075) > 1e-9 {
+		t.Errorf("TotalCost = %f, want 0.0075", s.TotalCost)
+	}
+	if s.TotalTokens != 6200 {
+		t.Errorf("TotalTokens = %d, want 6200", s.TotalTokens)
+	}
+	if s.CacheReadTokens != 5000 {
+		t.Errorf("CacheReadTokens = %d, want 5000", s.CacheReadTokens)
+	}
+	if len(s.Events) != 2 {
+		t.Fatalf("got %d events, want prompt + one api_request", len(s.Events))
+	}
+	for _, e := range s.Events {
+		if e.Attributes[state.SourceAttr] != state.SourceTranscript {
+			t.Errorf("event %s not tagged with transcript source", e.Name)
+		}
+	}
+	if got := s.Events[0].Attributes["prompt_length"]; got != "13" {
+		t.Errorf("prompt_length = %q, want 13", got)
+	}
+}
+
+func TestIngester_TailsAppendedLines(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTranscript(t, dir, "sess-1", assistantLine("sess-1", "msg_1", 100, 0, 0))
+
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing)
+	now := pollPastGrace(ing, time.Now())
+
+	// A partially written line is left for the next poll.
+	line := assistantLine("sess-1", "msg_2", 200, 0, 0)
+	appendLines(t, path, line[:20])
+	ing.PollAt(now.Add(time.Second))
+	if got := store.GetSession("sess-1").TotalTokens; got != 100 {
+		t.Fatalf("TotalTokens after partial line = %d, want 100", got)
+	}
+
+	appendLines(t, path, line[20:])
+	ing.PollAt(now.Add(2 * time.Second))
+	ing.PollAt(now.Add(3 * time.Second))
+	if got := store.GetSession("sess-1").TotalTokens; got != 300 {
+		t.Errorf("TotalTokens after completed line = %d, want 300", got)
+	}
+}
+
+func TestIngester_ResponseSpanningPolls(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTranscript(t, dir, "sess-1", assistantLine("sess-1", "msg_1", 100, 0, 0))
+
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing)
+	now := time.Now()
+	ing.PollAt(now)
+	now = now.Add(DefaultGracePeriod)
+	ing.PollAt(now)
+	if s := store.GetSession("sess-1"); s != nil {
+		t.Fatalf("response still being written was counted: %+v", s)
+	}
+
+	// The response's next content block carries its final usage, and the
+	// transcript then moves on to a prompt.
+	appendLines(t, path, assistantLine("sess-1", "msg_1", 100, 50, 0), userLine("sess-1", "next"))
+	ing.PollAt(now.Add(time.Second))
+	s := store.GetSession("sess-1")
+	if s == nil || s.TotalTokens != 150 {
+		t.Fatalf("want the response counted once with its final usage, got %+v", s)
+	}
+	var requests int
+	for _, e := range s.Events {
+		if e.Name == "claude_code.api_request" {
+			requests++
+			if e.Attributes["output_tokens"] != "50" {
+				t.Errorf("output_tokens = %q, want 50", e.Attributes["output_tokens"])
+			}
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %d api_request events, want 1", requests)
+	}
+}
+
+func TestIngester_SkipsSessionsWithOTLP(t *testing.T) {
+	dir := t.TempDir()
+	writeTranscript(t, dir, "sess-otlp", assistantLine("sess-otlp", "msg_1", 1000, 0, 0))
+
+	store := state.NewMemoryStore()
+	store.AddMetric("sess-otlp", state.Metric{Name: "claude_code.cost.usage", Value: 0.5})
+
+	ing := NewIngester(store, dir, testPricing)
+	pollPastGrace(ing, time.Now())
+
+	s := store.GetSession("sess-otlp")
+	if s.Estimated || s.TotalTokens != 0 || len(s.Events) != 0 {
+		t.Errorf("OTLP session should not be backfilled: %+v", s)
+	}
+}
+
+func TestIngester_Filter(t *testing.T) {
+	dir := t.TempDir()
+	writeTranscript(t, dir, "sess-1", assistantLine("sess-1", "msg_1", 1000, 0, 0))
+
+	var gotCWD string
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing, WithFilter(func(sessionID, cwd string) bool {
+		gotCWD = cwd
+		return false
+	}))
+	pollPastGrace(ing, time.Now())
+
+	if gotCWD != "/work/proj" {
+		t.Errorf("filter got cwd %q, want /work/proj", gotCWD)
+	}
+	if store.GetSession("sess-1") != nil {
+		t.Error("filtered session should not be backfilled")
+	}
+}
+
+func TestIngester_IgnoresCopiedAndSyntheticMessages(t *testing.T) {
+	dir := t.TempDir()
+	writeTranscript(t, dir, "sess-new",
+		// History copied from the resumed session.
+		assistantLine("sess-old", "msg_old", 5000, 0, 0),
+		`{"type":"assistant","sessionId":"sess-new","message":{"id":"msg_s","model":"<synthetic>","usage":{"input_tokens":9}}}`+"\n",
+		assistantLine("sess-new", "msg_new", 10, 0, 0),
+	)
+
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing)
+	pollPastGrace(ing, time.Now())
+
+	if got := store.GetSession("sess-new").TotalTokens; got != 10 {
+		t.Errorf("TotalTokens = %d, want 10", got)
+	}
+	if store.GetSession("sess-old") != nil {
+		t.Error("copied history should not create the earlier session")
+	}
+}
+
+func TestIngester_LookbackSkipsOldTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTranscript(t, dir, "sess-old", assistantLine("sess-old", "msg_1", 10, 0, 0))
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	store := state.NewMemoryStore()
+	ing := NewIngester(store, dir, testPricing)
+	now := pollPastGrace(ing, time.Now())
+	if store.GetSession("sess-old") != nil {
+		t.Fatal("stale transcript should be skipped")
+	}
+
+	// Written to again: it is picked up from the beginning.
+	appendLines(t, path, assistantLine("sess-old", "msg_2", 20, 0, 0))
+	pollPastGrace(ing, now)
+	if got := store.GetSession("sess-old"); got == nil || got.TotalTokens != 30 {
+		t.Errorf("reactivated transcript not fully ingested: %+v", got)
+	}
+}
+
+func TestIngester_BackfillIsHistorical(t *testing.T) {
+	// $300 of responses from before cc-top started.
+	var lines []string
+	for n := range 100 {
+		lines = append(lines, assistantLine("sess-1", fmt.Sprintf("msg_%d", n), 1_000_000, 0, 0))
+	}
+	dir := t.TempDir()
+	path := writeTranscript(t, dir, "sess-1", lines...)
+
+	store := state.NewMemoryStore()
+	calc := burnrate.NewCalculator(burnrate.DefaultThresholds())
+	engine := alerts.NewEngine(store, config.DefaultConfig(), calc)
+	ing := NewIngester(store, dir, testPricing)
+
+	now := time.Now()
+	engine.EvaluateAt(now)
+	now = pollPastGrace(ing, now)
+	engine.EvaluateAt(now)
+
+	s := store.GetSession("sess-1")
+	if s == nil || math.Abs(s.TotalCost-300) > 1e-6 || s.HistoricalCost != s.TotalCost {
+		t.Fatalf("want $300 backfilled as historical, got %+v", s)
+	}
+	for _, a := range engine.Alerts() {
+		if a.Rule == alerts.RuleCostSurge {
+			t.Errorf("backfill raised %s: %s", a.Rule, a.Message)
+		}
+	}
+	if br, _ := calc.Session("sess-1"); br.HourlyRate != 0 || br.TotalCost != s.TotalCost {
+		t.Errorf("session burn rate = %+v, want no rate over the full total", br)
+	}
+
+	// A response written after the backfill is live spend.
+	live := strings.Replace(assistantLine("sess-1", "msg_live", 1_000_000, 0, 0),
+		"2026-01-01T10:00:00Z", now.Add(time.Second).UTC().Format(time.RFC3339), 1)
+	appendLines(t, path, live)
+	ing.PollAt(now.Add(2 * time.Second))
+	ing.PollAt(now.Add(3 * time.Second))
+	if s = store.GetSession("sess-1"); math.Abs(s.TotalCost-303) > 1e-6 || math.Abs(s.HistoricalCost-300) > 1e-6 {
+		t.Errorf("after a live response: total %f, historical %f, want 303 and 300", s.TotalCost, s.HistoricalCost)
+	}
+}
@@ -0,0 +1,70 @@
+package transcript
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// record is the subset of a transcript JSONL line that ingestion uses.
+// Claude Code writes one line per message (assistant messages may span
+// several lines, one per content block, repeating the same message ID;
+// the usage on the last of them is final).
+type record struct {
+	Type      string    `json:"type"`
+	SessionID string    `json:"sessionId"`
+	CWD       string    `json:"cwd"`
+	Timestamp time.Time `json:"timestamp"`
+	IsMeta    bool      `json:"isMeta"`
+	Message   struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+		Usage   *usage          `json:"usage"`
+	} `json:"message"`
+}
+
+// usage holds the token counts reported for one API response.
+type usage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+}
+
+// syntheticModel marks locally generated assistant messages that were
+// never sent to the API.
+const syntheticModel = "<synthetic>"
+
+// parseRecord decodes one transcript line. ok is false for malformed lines.
+func parseRecord(line []byte) (rec record, ok bool) {
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return record{}, false
+	}
+	return rec, true
+}
+
+// apiUsage reports whether rec is a billed API response and returns its
+// usage.
+func (rec *record) apiUsage() (*usage, bool) {
+	if rec.Type != "assistant" || rec.Message.Usage == nil || rec.Message.ID == "" {
+		return nil, false
+	}
+	if rec.Message.Model == "" || rec.Message.Model == syntheticModel {
+		return nil, false
+	}
+	return rec.Message.Usage, true
+}
+
+// promptText returns the text of a user-typed prompt. Tool results are
+// also "user" records, but their content is an array of blocks rather
+// than a string.
+func (rec *record) promptText() (string, bool) {
+	if rec.Type != "user" || rec.IsMeta || len(rec.Message.Content) == 0 {
+		return "", false
+	}
+	var text string
+	if err := json.Unmarshal(rec.Message.Content, &text); err != nil {
+		return "", false
+	}
+	return text, true
+}
@@ -3,7 +3,10 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/nixlim/cc-top/internal/alerts"
@@ -15,6 +18,17 @@ var severityIcons = map[string]string{
 	"warning":  "!?",
 }
 
+// snoozeChoices are the durations offered when snoozing an alert.
+var snoozeChoices = []struct {
+	label string
+	d     time.Duration
+}{
+	{"15 minutes", 15 * time.Minute},
+	{"1 hour", time.Hour},
+	{"4 hours", 4 * time.Hour},
+	{"1 day", 24 * time.Hour},
+}
+
 // renderAlertsPanel renders the bottom alerts bar.
 func (m Model) renderAlertsPanel(w, h int) string {
 	contentW := w - 4
@@ -128,6 +142,17 @@ func renderAlertLine(a alerts.Alert, maxW int, selectedSession string) string {
 		style = alertWarningStyle
 	}
 
+	// Acknowledged and snoozed alerts are dimmed and tagged.
+	stateTag := ""
+	switch a.State {
+	case alerts.StateAcknowledged:
+		style = dimStyle
+		stateTag = "[ack] "
+	case alerts.StateSnoozed:
+		style = dimStyle
+		stateTag = "[zz " + a.SnoozedUntil.Format("15:04") + "] "
+	}
+
 	// Highlight alerts for the selected session.
 	sessionTag := ""
 	if a.SessionID != "" {
@@ -137,7 +162,7 @@ func renderAlertLine(a alerts.Alert, maxW int, selectedSession string) string {
 		}
 	}
 
-	msg := icon + " " + sessionTag + a.Rule + ": " + a.Message
+	msg := icon + " " + stateTag + sessionTag + a.Rule + ": " + a.Message
 
 	if len(msg) > maxW && maxW > 3 {
 		msg = msg[:maxW-3] + "..."
@@ -145,3 +170,48 @@ func renderAlertLine(a alerts.Alert, maxW int, selectedSession string) string {
 
 	return style.Render(msg)
 }
+
+// alertStateLabel describes an alert's lifecycle state for the detail view.
+func alertStateLabel(a alerts.Alert) string {
+	switch a.State {
+	case alerts.StateSnoozed:
+		return "snoozed until " + a.SnoozedUntil.Format("2006-01-02 15:04")
+	case alerts.StateResolved:
+		return "resolved at " + a.ResolvedAt.Format("2006-01-02 15:04:05")
+	}
+	return a.State
+}
+
+// handleSnoozeKey handles the snooze duration chooser: 1-4 snooze the
+// alert for the matching duration, Esc cancels.
+func (m Model) handleSnoozeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Escape) {
+		m.snoozeAlert = 0
+		return m, nil
+	}
+	for i, c := range snoozeChoices {
+		if msg.String() != fmt.Sprint(i+1) {
+			continue
+		}
+		if err := m.alerts.Snooze(m.snoozeAlert, time.Now().Add(c.d)); err != nil {
+			m.startupMessage = fmt.Sprintf("Error snoozing alert: %v", err)
+		}
+		m.snoozeAlert = 0
+		return m, nil
+	}
+	return m, nil
+}
+
+// overlaySnoozePrompt renders the snooze duration chooser over the layout.
+func (m Model) overlaySnoozePrompt(base string) string {
+	lines := []string{panelTitleStyle.Render("Snooze alert for"), ""}
+	for i, c := range snoozeChoices {
+		lines = append(lines, fmt.Sprintf("[%d] %s", i+1, c.label))
+	}
+	lines = append(lines, "", dimStyle.Render("Esc: Cancel"))
+	dialog := detailOverlayStyle.Render(strings.Join(lines, "\n"))
+
+	x := max((m.width-lipgloss.Width(dialog))/2, 0)
+	y := max((m.height-lipgloss.Height(dialog))/2, 0)
+	return placeOverlay(x, y, dialog, base)
+}
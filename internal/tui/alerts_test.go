@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+
 	"github.com/nixlim/cc-top/internal/alerts"
 	"github.com/nixlim/cc-top/internal/config"
 )
@@ -145,3 +147,48 @@ func TestRenderAlertsPanel_NilProvider(t *testing.T) {
 		t.Error("alerts panel with nil provider should show 'None'")
 	}
 }
+
+func TestAlertsPanel_AcknowledgeAndSnooze(t *testing.T) {
+	cfg := config.DefaultConfig()
+	mockAlerts := &mockAlertProvider{
+		alerts: []alerts.Alert{
+			{ID: 1, State: alerts.StateFiring, Rule: "CostSurge", Severity: "critical", Message: "cost", SessionID: "sess-001"},
+			{ID: 2, State: alerts.StateFiring, Rule: "ErrorStorm", Severity: "critical", Message: "errors", SessionID: "sess-002"},
+		},
+	}
+	m := NewModel(cfg, WithAlertProvider(mockAlerts), WithStartView(ViewDashboard))
+	m.width = 120
+	m.height = 40
+
+	press := func(r rune) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+
+	press('a')
+	press('A')
+	if st := mockAlerts.alerts[0].State; st != alerts.StateAcknowledged {
+		t.Fatalf("A should acknowledge the selected alert, state = %s", st)
+	}
+
+	press('j')
+	press('z')
+	if !strings.Contains(stripAnsi(m.View()), "Snooze alert for") {
+		t.Fatal("z should open the snooze chooser")
+	}
+	before := time.Now()
+	press('2')
+	a := mockAlerts.alerts[1]
+	if a.State != alerts.StateSnoozed || a.SnoozedUntil.Before(before.Add(time.Hour)) || a.SnoozedUntil.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("2 should snooze the selected alert for an hour, got %+v", a)
+	}
+	if m.snoozeAlert != 0 {
+		t.Error("the chooser should close after snoozing")
+	}
+
+	panel := stripAnsi(m.renderAlertsPanel(120, 4))
+	if !strings.Contains(panel, "[ack] [sess-001] CostSurge") || !strings.Contains(panel, "[zz "+a.SnoozedUntil.Format("15:04")+"]") {
+		t.Errorf("panel should tag acknowledged and snoozed alerts:\n%s", panel)
+	}
+}
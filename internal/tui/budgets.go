@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nixlim/cc-top/internal/budget"
+)
+
+// budgetsMaxRows caps the number of budgets shown on the dashboard.
+const budgetsMaxRows = 5
+
+// budgetsPanelHeight returns the height of the budgets panel for n
+// budgets: a title plus one gauge per budget, inside a border. It is 0
+// when there are no budgets.
+func budgetsPanelHeight(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return min(n, budgetsMaxRows) + 3
+}
+
+// renderBudgetsPanel renders a gauge for each budget.
+func (m Model) renderBudgetsPanel(w, h int) string {
+	contentW := max(w-4, 10)
+
+	lines := []string{panelTitleStyle.Render("Budgets")}
+	nameW := 0
+	for _, st := range m.cachedBudgets {
+		nameW = max(nameW, len(st.Name))
+	}
+	nameW = min(nameW, 16)
+
+	for i, st := range m.cachedBudgets {
+		if i == budgetsMaxRows {
+			break
+		}
+		lines = append(lines, budgetGaugeStyle(st).Render(formatBudgetGauge(st, nameW, contentW)))
+	}
+
+	return renderBorderedPanel(strings.Join(lines, "\n"), w, h)
+}
+
+// formatBudgetGauge renders one budget as a bar with its consumption and
+// forecast, fitting the bar and detail to width.
+func formatBudgetGauge(st budget.Status, nameW, width int) string {
+	detail := fmt.Sprintf(" %3.0f%%  $%.2f/$%.2f %s", st.Percent(), st.Spent, st.Limit, periodLabel(st.Period))
+	switch {
+	case st.Exhausted():
+		detail += "  exhausted"
+	case !st.ExhaustsAt.IsZero():
+		detail += "  out " + st.ExhaustsAt.In(st.PeriodStart.Location()).Format("Jan 2 15:04")
+	}
+	// Spend from before cc-top started is not known.
+	if st.CountedFrom.After(st.PeriodStart) {
+		detail += "  since " + st.CountedFrom.In(st.PeriodStart.Location()).Format("Jan 2 15:04")
+	}
+
+	name := fmt.Sprintf("%-*s ", nameW, truncateStr(st.Name, nameW))
+	// Leave room for the smallest bar; the detail is ASCII so it can be
+	// truncated by bytes.
+	detail = truncateStr(detail, max(width-len(name)-7, 0))
+	barW := min(max(width-len(name)-len(detail)-2, 5), 30)
+	filled := min(int(st.Percent()/100*float64(barW)+0.5), barW)
+	bar := "[" + strings.Repeat("█", filled) + strings.Repeat("░", barW-filled) + "]"
+	return name + bar + detail
+}
+
+// budgetGaugeStyle colours a gauge green below the budget's first alert
+// tier, yellow once a tier is reached and red when exhausted.
+func budgetGaugeStyle(st budget.Status) lipgloss.Style {
+	switch {
+	case st.Exhausted():
+		return costRedStyle
+	case st.Tier() > 0:
+		return costYellowStyle
+	default:
+		return costGreenStyle
+	}
+}
+
+// periodLabel abbreviates a budget period.
+func periodLabel(period string) string {
+	switch period {
+	case "day":
+		return "/day"
+	case "week":
+		return "/wk"
+	default:
+		return "/mo"
+	}
+}
+
+// computeBudgets retrieves fresh budget statuses from the provider.
+// Called only from the tick handler.
+func (m Model) computeBudgets() []budget.Status {
+	if m.budgets == nil {
+		return nil
+	}
+	return m.budgets.Budgets()
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/budget"
+	"github.com/nixlim/cc-top/internal/config"
+)
+
+type mockBudgetProvider struct {
+	statuses []budget.Status
+}
+
+func (m *mockBudgetProvider) Budgets() []budget.Status {
+	return m.statuses
+}
+
+func TestFormatBudgetGauge(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	st := budget.Status{
+		Name: "team", Period: "month", Limit: 500, Spent: 310,
+		PeriodStart: start, ExhaustsAt: time.Date(2026, 10, 24, 15, 30, 0, 0, time.UTC),
+	}
+	got := formatBudgetGauge(st, 4, 80)
+	for _, want := range []string{"team [", " 62%", "$310.00/$500.00 /mo", "out Oct 24 15:30"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("gauge %q missing %q", got, want)
+		}
+	}
+
+	st.Spent = 520
+	if got := formatBudgetGauge(st, 4, 80); !strings.Contains(got, "exhausted") || strings.Contains(got, "out ") {
+		t.Errorf("exhausted gauge = %q", got)
+	}
+
+	// Counting from the period start is not called out; counting from a
+	// later restart is.
+	st.CountedFrom = start
+	if got := formatBudgetGauge(st, 4, 100); strings.Contains(got, "since") {
+		t.Errorf("gauge counted from the period start = %q", got)
+	}
+	st.CountedFrom = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	if got := formatBudgetGauge(st, 4, 100); !strings.Contains(got, "since Oct 16 09:00") {
+		t.Errorf("gauge counted from a restart = %q, want since Oct 16 09:00", got)
+	}
+}
+
+func TestBudgetsPanel_Dashboard(t *testing.T) {
+	cfg := config.DefaultConfig()
+	provider := &mockBudgetProvider{statuses: []budget.Status{
+		{Name: "daily", Period: "day", Limit: 20, Spent: 5},
+		{Name: "team", Period: "month", Limit: 500, Spent: 310, AlertAt: []int{50}},
+	}}
+	m := NewModel(cfg, WithStartView(ViewDashboard), WithBudgetProvider(provider))
+	m.width = 120
+	m.height = 40
+
+	result, _ := m.Update(tickMsg(time.Now()))
+	m = result.(Model)
+	out := stripAnsi(m.View())
+	if !strings.Contains(out, "Budgets") || !strings.Contains(out, "$310.00/$500.00") {
+		t.Errorf("dashboard should show the budgets panel, got:\n%s", out)
+	}
+
+	// Without budgets the panel and its space disappear.
+	m2 := NewModel(cfg, WithStartView(ViewDashboard))
+	m2.width = 120
+	m2.height = 40
+	result, _ = m2.Update(tickMsg(time.Now()))
+	m2 = result.(Model)
+	if strings.Contains(stripAnsi(m2.View()), "Budgets") {
+		t.Error("budgets panel should be hidden when no budgets are configured")
+	}
+}
+
+func TestBudgetsPanel_SkippedWhenShort(t *testing.T) {
+	d := panelDimensions{eventStreamH: 6}
+	d.fitBudgets(budgetsPanelHeight(5))
+	if d.budgetsH != 0 || d.eventStreamH != 6 {
+		t.Errorf("short terminal: budgetsH=%d eventStreamH=%d, want the panel skipped", d.budgetsH, d.eventStreamH)
+	}
+
+	d = panelDimensions{eventStreamH: 30}
+	d.fitBudgets(budgetsPanelHeight(2))
+	if d.budgetsH != 5 || d.eventStreamH != 25 {
+		t.Errorf("tall terminal: budgetsH=%d eventStreamH=%d, want 5 and 25", d.budgetsH, d.eventStreamH)
+	}
+}
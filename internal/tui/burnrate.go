@@ -3,12 +3,20 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/nixlim/cc-top/internal/burnrate"
 )
 
+// sparklineSpan is the span of recent spend drawn in the burn rate panel,
+// one character per minute.
+const sparklineSpan = time.Hour
+
+// sparkBlocks are the sparkline levels, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
 // renderBurnRatePanel renders the burn rate odometer panel showing total cost,
 // hourly rate, trend, and token velocity.
 func (m Model) renderBurnRatePanel(w, h int) string {
@@ -57,6 +65,14 @@ func (m Model) renderBurnRatePanel(w, h int) string {
 	projLine := fmt.Sprintf("Projected Spend: $%.2f/day  $%.2f/mon", br.DailyProjection, br.MonthlyProjection)
 	lines = append(lines, dimStyle.Render(projLine))
 
+	// Recent spend across all sessions, when viewing them all.
+	if m.selectedSession == "" && len(m.cachedSeries) > 0 {
+		const label = "Last hour: "
+		if spark := sparkline(m.cachedSeries, contentW-len(label)); spark != "" {
+			lines = append(lines, dimStyle.Render(label)+colorStyle.Render(spark))
+		}
+	}
+
 	// Per-model cost breakdown (shown when multiple models are present).
 	if len(br.PerModel) > 1 {
 		shown := br.PerModel
@@ -92,6 +108,43 @@ func (m Model) computeBurnRate() burnrate.BurnRate {
 	return m.burnRate.GetGlobal()
 }
 
+// computeSeries retrieves the recent spend for the sparkline from the
+// provider. Called only from the tick handler, after computeBurnRate.
+func (m Model) computeSeries() []burnrate.Point {
+	if m.burnRate == nil {
+		return nil
+	}
+	return m.burnRate.Series(sparklineSpan)
+}
+
+// sparkline draws the cost of the last width points, scaled to the
+// largest. Points without spend are drawn at the lowest level. It returns
+// "" when nothing has been spent.
+func sparkline(points []burnrate.Point, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(points) > width {
+		points = points[len(points)-width:]
+	}
+	var peak float64
+	for _, p := range points {
+		peak = max(peak, p.Cost)
+	}
+	if peak <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range points {
+		level := 0
+		if p.Cost > 0 {
+			level = 1 + int(p.Cost/peak*float64(len(sparkBlocks)-2)+0.5)
+		}
+		b.WriteRune(sparkBlocks[min(level, len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
 // getRateColor returns the color classification for a given hourly rate.
 func (m Model) getRateColor(hourlyRate float64) burnrate.RateColor {
 	if hourlyRate < m.cfg.Display.CostColorGreenBelow {
@@ -3,6 +3,7 @@ package tui
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nixlim/cc-top/internal/burnrate"
 	"github.com/nixlim/cc-top/internal/config"
@@ -310,3 +311,66 @@ func TestRenderBurnRatePanel_SessionAware(t *testing.T) {
 		t.Error("session panel should contain 'Cost (session):' label")
 	}
 }
+
+func TestSparkline(t *testing.T) {
+	points := func(costs ...float64) []burnrate.Point {
+		result := make([]burnrate.Point, len(costs))
+		for i, c := range costs {
+			result[i].Cost = c
+		}
+		return result
+	}
+	tests := []struct {
+		name   string
+		points []burnrate.Point
+		width  int
+		want   string
+	}{
+		{"scaled to the peak", points(0, 0.1, 0.5, 1), 10, "▁▃▅█"},
+		{"keeps the latest points", points(1, 0, 0.5, 1), 2, "▅█"},
+		{"nothing spent", points(0, 0, 0), 10, ""},
+		{"no points", nil, 10, ""},
+		{"no room", points(1), 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.points, tt.width); got != tt.want {
+				t.Errorf("sparkline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderBurnRatePanel_Sparkline(t *testing.T) {
+	cfg := config.DefaultConfig()
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mockBR := &mockBurnRateProvider{
+		global: burnrate.BurnRate{TotalCost: 3.00},
+		perSess: map[string]burnrate.BurnRate{
+			"sess-001": {TotalCost: 1.00},
+		},
+		series: []burnrate.Point{
+			{At: start, Cost: 0},
+			{At: start.Add(time.Minute), Cost: 1.00},
+			{At: start.Add(2 * time.Minute), Cost: 2.00},
+		},
+	}
+
+	m := NewModel(cfg, WithBurnRateProvider(mockBR))
+	m.width = 120
+	m.height = 40
+	m.cachedBurnRate = m.computeBurnRate()
+	m.cachedSeries = m.computeSeries()
+
+	stripped := stripAnsi(m.renderBurnRatePanel(60, 12))
+	if !strings.Contains(stripped, "Last hour: ▁▅█") {
+		t.Errorf("global panel should draw the recent spend, got:\n%s", stripped)
+	}
+
+	// The series covers all sessions, so a single session's panel omits it.
+	m.selectedSession = "sess-001"
+	m.cachedBurnRate = m.computeBurnRate()
+	if stripped := stripAnsi(m.renderBurnRatePanel(60, 12)); strings.Contains(stripped, "Last hour") {
+		t.Errorf("session panel should not draw the global spend, got:\n%s", stripped)
+	}
+}
@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nixlim/cc-top/internal/alerts"
+)
+
+// computeDelivery returns the delivery status of alert notifiers.
+func (m Model) computeDelivery() []alerts.DeliveryStatus {
+	if m.delivery == nil {
+		return nil
+	}
+	return m.delivery.DeliveryStatus()
+}
+
+// renderDeliverySection renders how each notifier's deliveries are going.
+func (m Model) renderDeliverySection() string {
+	lines := []string{panelTitleStyle.Render("Notifications")}
+	lines = append(lines, fmt.Sprintf("  %-24s %6s %7s %8s %7s  %s",
+		"Notifier", "Sent", "Failed", "Dropped", "Queued", "Last"))
+	lines = append(lines, dimStyle.Render("  "+strings.Repeat("─", 75)))
+	for _, st := range m.cachedDelivery {
+		lines = append(lines, fmt.Sprintf("  %-24s %6d %7d %8d %7d  %s",
+			truncateStr(st.Kind+" "+st.Name, 24), st.Sent, st.Failed, st.Dropped, st.Pending, lastDelivery(st)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastDelivery describes a notifier's most recent delivery outcome.
+func lastDelivery(st alerts.DeliveryStatus) string {
+	switch {
+	case !st.LastFailure.IsZero() && st.LastFailure.After(st.LastSuccess):
+		return alertCriticalStyle.Render("failed " + st.LastFailure.Format("15:04:05") + ": " + st.LastError)
+	case !st.LastSuccess.IsZero():
+		return "ok " + st.LastSuccess.Format("15:04:05")
+	}
+	return dimStyle.Render("-")
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/cc-top/internal/alerts"
+	"github.com/nixlim/cc-top/internal/config"
+)
+
+type mockDeliveryProvider struct {
+	statuses []alerts.DeliveryStatus
+}
+
+func (m *mockDeliveryProvider) DeliveryStatus() []alerts.DeliveryStatus {
+	return m.statuses
+}
+
+func TestDeliverySection_Stats(t *testing.T) {
+	at := time.Date(2026, 10, 18, 12, 3, 4, 0, time.Local)
+	provider := &mockDeliveryProvider{statuses: []alerts.DeliveryStatus{
+		{Kind: "webhook", Name: "slack", Sent: 12, LastSuccess: at},
+		{Kind: "webhook", Name: "pager", Sent: 3, Failed: 1, Dropped: 2, Pending: 1,
+			LastSuccess: at.Add(-time.Hour), LastFailure: at, LastError: "webhook pager: 502 Bad Gateway"},
+	}}
+	m := NewModel(config.DefaultConfig(), WithStartView(ViewStats), WithDeliveryStatusProvider(provider))
+	m.width = 140
+	m.height = 200
+
+	if strings.Contains(stripAnsi(m.View()), "Notifications") {
+		t.Error("the section should wait for the first tick")
+	}
+
+	updated, _ := m.Update(tickMsg(time.Now()))
+	m = updated.(Model)
+	view := stripAnsi(m.View())
+	for _, want := range []string{
+		"Notifications",
+		"webhook slack                12       0        0       0  ok 12:03:04",
+		"webhook pager                 3       1        2       1  failed 12:03:04: webhook pager: 502 Bad Gateway",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("stats view should contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestDeliverySection_HiddenWithoutNotifiers(t *testing.T) {
+	m := NewModel(config.DefaultConfig(), WithStartView(ViewStats),
+		WithDeliveryStatusProvider(&mockDeliveryProvider{}))
+	m.width = 140
+	m.height = 200
+	updated, _ := m.Update(tickMsg(time.Now()))
+	if strings.Contains(stripAnsi(updated.(Model).View()), "Notifications") {
+		t.Error("no notifier tracks delivery, so the section should be hidden")
+	}
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nixlim/cc-top/internal/enforce"
+)
+
+// resumePrompt returns the paused session to ask about: the oldest one
+// whose prompt has not been dismissed.
+func (m Model) resumePrompt() (enforce.Paused, bool) {
+	if m.view == ViewStartup {
+		return enforce.Paused{}, false
+	}
+	for _, p := range m.cachedPaused {
+		if !m.resumeDismissed[p.SessionID] {
+			return p, true
+		}
+	}
+	return enforce.Paused{}, false
+}
+
+// handleResumePromptKey handles Y/N/Esc in the resume prompt. Y resumes
+// the session; N or Esc leaves it paused until the prompt is reopened.
+func (m Model) handleResumePromptKey(msg tea.KeyMsg, p enforce.Paused) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Confirm):
+		if err := m.enforcement.Resume(p.SessionID); err != nil {
+			m.startupMessage = fmt.Sprintf("Error resuming session: %v", err)
+			m.dismissResume(p.SessionID)
+			return m, nil
+		}
+		m.cachedPaused = m.enforcement.Paused()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Deny), key.Matches(msg, m.keys.Escape):
+		m.dismissResume(p.SessionID)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// dismissResume hides the resume prompt for a paused session.
+func (m *Model) dismissResume(sessionID string) {
+	if m.resumeDismissed == nil {
+		m.resumeDismissed = make(map[string]bool)
+	}
+	m.resumeDismissed[sessionID] = true
+}
+
+// reopenResumePrompts shows the resume prompt again for every session
+// still paused by enforcement.
+func (m Model) reopenResumePrompts() (tea.Model, tea.Cmd) {
+	m.resumeDismissed = nil
+	return m, nil
+}
+
+// overlayResumePrompt renders the resume prompt for p over the layout.
+func (m Model) overlayResumePrompt(base string, p enforce.Paused) string {
+	title := fmt.Sprintf("Session paused by policy %q", p.Policy)
+	if p.Policy == enforce.PolicyManual {
+		title = "Session paused on request"
+	}
+	dialog := killDialogStyle.Render(fmt.Sprintf(
+		"%s\n\n"+
+			"Session: %s\nPID: %d\nCWD: %s\nReason: %s\nPaused: %s ago\n\n"+
+			"[Y] Resume  [n/Esc] Keep paused (u to review)",
+		title,
+		truncateID(p.SessionID, 12),
+		p.PID,
+		shortenHome(p.CWD),
+		p.Reason,
+		formatDuration(time.Since(p.At))))
+
+	x := max((m.width-lipgloss.Width(dialog))/2, 0)
+	y := max((m.height-lipgloss.Height(dialog))/2, 0)
+	return placeOverlay(x, y, dialog, base)
+}
+
+// computePaused retrieves the sessions paused by enforcement from the
+// provider. Called only from the tick handler.
+func (m Model) computePaused() []enforce.Paused {
+	if m.enforcement == nil {
+		return nil
+	}
+	return m.enforcement.Paused()
+}
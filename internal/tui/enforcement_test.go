@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/enforce"
+)
+
+type mockEnforcementProvider struct {
+	paused  []enforce.Paused
+	resumed []string
+}
+
+func (m *mockEnforcementProvider) Paused() []enforce.Paused {
+	return m.paused
+}
+
+func (m *mockEnforcementProvider) Resume(sessionID string) error {
+	for i, p := range m.paused {
+		if p.SessionID == sessionID {
+			m.paused = append(m.paused[:i], m.paused[i+1:]...)
+			m.resumed = append(m.resumed, sessionID)
+			return nil
+		}
+	}
+	return enforce.ErrNotPaused
+}
+
+func newEnforcementTestModel(provider *mockEnforcementProvider) Model {
+	m := NewModel(config.DefaultConfig(), WithStartView(ViewDashboard), WithEnforcementProvider(provider))
+	m.width = 120
+	m.height = 40
+	result, _ := m.Update(tickMsg(time.Now()))
+	return result.(Model)
+}
+
+func TestResumePrompt_Approve(t *testing.T) {
+	provider := &mockEnforcementProvider{paused: []enforce.Paused{
+		{SessionID: "sess-001", PID: 1234, Policy: "pause-over-budget",
+			Reason: "budget team exhausted: $510.00 of $500.00 this month", At: time.Now().Add(-2 * time.Minute)},
+	}}
+	m := newEnforcementTestModel(provider)
+
+	out := stripAnsi(m.View())
+	for _, want := range []string{`Session paused by policy "pause-over-budget"`, "budget team exhausted", "[Y] Resume"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("view should contain %q", want)
+		}
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = result.(Model)
+	if len(provider.resumed) != 1 || provider.resumed[0] != "sess-001" {
+		t.Fatalf("Y should resume sess-001, resumed %v", provider.resumed)
+	}
+	if strings.Contains(stripAnsi(m.View()), "Session paused by policy") {
+		t.Error("prompt should close once the session is resumed")
+	}
+}
+
+func TestResumePrompt_DismissAndReopen(t *testing.T) {
+	provider := &mockEnforcementProvider{paused: []enforce.Paused{
+		{SessionID: "sess-001", PID: 1234, Policy: "pause", At: time.Now()},
+	}}
+	m := newEnforcementTestModel(provider)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = result.(Model)
+	if _, ok := m.resumePrompt(); ok {
+		t.Fatal("n should dismiss the prompt")
+	}
+	if len(provider.resumed) != 0 {
+		t.Fatal("dismissing should leave the session paused")
+	}
+	if !strings.Contains(stripAnsi(m.View()), "u:Paused(1)") {
+		t.Error("header should point at the paused session")
+	}
+
+	// The dismissal survives refreshes.
+	result, _ = m.Update(tickMsg(time.Now()))
+	m = result.(Model)
+	if _, ok := m.resumePrompt(); ok {
+		t.Fatal("prompt should stay dismissed after a tick")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	m = result.(Model)
+	if p, ok := m.resumePrompt(); !ok || p.SessionID != "sess-001" {
+		t.Error("u should reopen the prompt")
+	}
+}
@@ -13,6 +13,7 @@ type KeyMap struct {
 	Escape      key.Binding
 	Filter      key.Binding
 	KillSwitch  key.Binding
+	Resume      key.Binding
 	ScrollUp    key.Binding
 	ScrollDown  key.Binding
 	Enable      key.Binding
@@ -21,7 +22,15 @@ type KeyMap struct {
 	Confirm     key.Binding
 	Deny        key.Binding
 	FocusAlerts key.Binding
+	Acknowledge key.Binding
+	Snooze      key.Binding
 	FocusEvents key.Binding
+	ProcessTree key.Binding
+	GroupRepo   key.Binding
+	Search      key.Binding
+	NextMatch   key.Binding
+	PrevMatch   key.Binding
+	ToggleAll   key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings for cc-top.
@@ -59,6 +68,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+k"),
 			key.WithHelp("ctrl+k", "kill switch"),
 		),
+		Resume: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "review paused sessions"),
+		),
 		ScrollUp: key.NewBinding(
 			key.WithKeys("pgup", "K"),
 			key.WithHelp("pgup/K", "scroll up"),
@@ -91,9 +104,41 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("a"),
 			key.WithHelp("a", "focus alerts"),
 		),
+		Acknowledge: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "acknowledge alert"),
+		),
+		Snooze: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "snooze alert"),
+		),
 		FocusEvents: key.NewBinding(
 			key.WithKeys("e"),
 			key.WithHelp("e", "focus events"),
 		),
+		ProcessTree: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "process tree"),
+		),
+		GroupRepo: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "group by repo"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search timeline"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		ToggleAll: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "collapse/expand all turns"),
+		),
 	}
 }
@@ -14,7 +14,7 @@ import (
 // it sends SIGSTOP and shows the confirmation dialog. If no session is
 // selected, it selects the first active session.
 func (m Model) initiateKillSwitch() (tea.Model, tea.Cmd) {
-	sessions := m.getSessions()
+	sessions := m.displaySessions()
 	if len(sessions) == 0 {
 		return m, nil
 	}
@@ -69,7 +69,7 @@ func (m Model) initiateKillSwitch() (tea.Model, tea.Cmd) {
 	m.killTargetInfo = fmt.Sprintf("Session: %s\nPID: %d\nCWD: %s",
 		truncateID(target.SessionID, 12),
 		target.PID,
-		target.CWD)
+		shortenHome(target.CWD))
 
 	return m, nil
 }
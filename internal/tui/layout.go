@@ -2,6 +2,7 @@ package tui
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -11,6 +12,7 @@ import (
 // the total terminal size. The layout places:
 //   - Session List on the left (40% width)
 //   - Burn Rate top right (60% width, 8 rows)
+//   - Budgets below it, when budgets are configured (see fitBudgets)
 //   - Event Stream center right (60% width, remaining rows minus alert bar)
 //   - Alerts bar at the bottom (full width, 3 rows)
 //
@@ -18,6 +20,7 @@ import (
 type panelDimensions struct {
 	sessionListW, sessionListH int
 	burnRateW, burnRateH       int
+	budgetsW, budgetsH         int // zero height when no budgets are shown
 	eventStreamW, eventStreamH int
 	alertsW, alertsH           int
 	headerH                    int
@@ -107,6 +110,18 @@ func computeDimensions(totalW, totalH int) panelDimensions {
 	return d
 }
 
+// fitBudgets makes room for a budgets panel of the given height below the
+// burn rate panel by shrinking the event stream, as long as the event
+// stream keeps at least half its height.
+func (d *panelDimensions) fitBudgets(h int) {
+	if h == 0 || d.eventStreamH-h < max(d.eventStreamH/2, 3) {
+		return
+	}
+	d.budgetsW = d.eventStreamW
+	d.budgetsH = h
+	d.eventStreamH -= h
+}
+
 // Style definitions for the TUI panels.
 var (
 	// headerStyle is used for the top status bar.
@@ -206,6 +221,11 @@ var (
 			Foreground(lipgloss.Color("15")).
 			Background(lipgloss.Color("62"))
 
+	// searchMatchStyle highlights timeline lines matching the search.
+	searchMatchStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("226"))
+
 	// detailOverlayStyle wraps the detail overlay dialog.
 	detailOverlayStyle = lipgloss.NewStyle().
 				Border(lipgloss.RoundedBorder()).
@@ -253,6 +273,7 @@ func stripAnsi(s string) string {
 // renderDashboard composes the main dashboard view from four panels.
 func (m Model) renderDashboard() string {
 	dims := computeDimensions(m.width, m.height)
+	dims.fitBudgets(budgetsPanelHeight(len(m.cachedBudgets)))
 
 	// Build header.
 	header := m.renderHeader(dims)
@@ -263,8 +284,12 @@ func (m Model) renderDashboard() string {
 	eventStream := m.renderEventStreamPanel(dims.eventStreamW, dims.eventStreamH)
 	alertsBar := m.renderAlertsPanel(dims.alertsW, dims.alertsH)
 
-	// Right column: burn rate on top, event stream below.
+	// Right column: burn rate on top, then budgets if any, event stream below.
 	rightCol := lipgloss.JoinVertical(lipgloss.Left, burnRatePanel, eventStream)
+	if dims.budgetsH > 0 {
+		budgets := m.renderBudgetsPanel(dims.budgetsW, dims.budgetsH)
+		rightCol = lipgloss.JoinVertical(lipgloss.Left, burnRatePanel, budgets, eventStream)
+	}
 
 	// Main content: session list left, right column right.
 	mainContent := lipgloss.JoinHorizontal(lipgloss.Top, sessionList, rightCol)
@@ -298,6 +323,11 @@ func (m Model) renderDashboard() string {
 		layout = m.overlayDetail(layout)
 	}
 
+	// Overlay the snooze duration chooser if active.
+	if m.snoozeAlert != 0 {
+		layout = m.overlaySnoozePrompt(layout)
+	}
+
 	return layout
 }
 
@@ -332,9 +362,13 @@ func (m Model) headerHelp() string {
 	case FocusEvents:
 		return "Enter:Detail  Esc:Back  a:Alerts  Tab:Stats  q:Quit "
 	case FocusAlerts:
-		return "Enter:Detail  Esc:Back  e:Events  Tab:Stats  q:Quit "
+		return "Enter:Detail  A:Ack  z:Snooze  Esc:Back  e:Events  Tab:Stats  q:Quit "
 	default:
-		return "a:Alerts  e:Events  Tab:Stats  q:Quit  f:Filter  Ctrl+K:Kill "
+		help := "a:Alerts  e:Events  p:Procs  g:Group  Tab:Stats  q:Quit  f:Filter  Ctrl+K:Kill "
+		if n := len(m.cachedPaused); n > 0 {
+			help = "u:Paused(" + strconv.Itoa(n) + ")  " + help
+		}
+		return help
 	}
 }
 
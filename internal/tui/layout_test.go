@@ -48,6 +48,7 @@ func (m *mockStateProvider) GetAggregatedCost() float64 {
 type mockBurnRateProvider struct {
 	global  burnrate.BurnRate
 	perSess map[string]burnrate.BurnRate
+	series  []burnrate.Point
 }
 
 func (m *mockBurnRateProvider) Get(sessionID string) burnrate.BurnRate {
@@ -61,6 +62,10 @@ func (m *mockBurnRateProvider) GetGlobal() burnrate.BurnRate {
 	return m.global
 }
 
+func (m *mockBurnRateProvider) Series(time.Duration) []burnrate.Point {
+	return m.series
+}
+
 type mockEventProvider struct {
 	events []events.FormattedEvent
 }
@@ -103,6 +108,25 @@ func (m *mockAlertProvider) ActiveForSession(sessionID string) []alerts.Alert {
 	return result
 }
 
+func (m *mockAlertProvider) Acknowledge(id uint64) error {
+	return m.setState(id, alerts.StateAcknowledged, time.Time{})
+}
+
+func (m *mockAlertProvider) Snooze(id uint64, until time.Time) error {
+	return m.setState(id, alerts.StateSnoozed, until)
+}
+
+func (m *mockAlertProvider) setState(id uint64, st string, until time.Time) error {
+	for i := range m.alerts {
+		if m.alerts[i].ID == id {
+			m.alerts[i].State = st
+			m.alerts[i].SnoozedUntil = until
+			return nil
+		}
+	}
+	return alerts.ErrNotActive
+}
+
 type mockStatsProvider struct {
 	global  stats.DashboardStats
 	perSess map[string]stats.DashboardStats
@@ -340,8 +364,18 @@ func TestModel_SessionNavigation(t *testing.T) {
 	if m3.selectedSession != "sess-002" {
 		t.Errorf("after Enter, selectedSession = %q, want %q", m3.selectedSession, "sess-002")
 	}
+	if m3.view != ViewSession {
+		t.Errorf("after Enter, view = %d, want ViewSession (%d)", m3.view, ViewSession)
+	}
+
+	// Escape leaves the session view with the session still selected.
+	result, _ = m3.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	m3 = result.(Model)
+	if m3.view != ViewDashboard || m3.selectedSession != "sess-002" {
+		t.Errorf("after Esc in session view, view = %d selected = %q; want dashboard with sess-002", m3.view, m3.selectedSession)
+	}
 
-	// Escape returns to global.
+	// Escape on the dashboard returns to global.
 	result, _ = m3.Update(tea.KeyMsg{Type: tea.KeyEscape})
 	m4 := result.(Model)
 	if m4.selectedSession != "" {
@@ -1,12 +1,14 @@
 // Package tui implements the Bubble Tea TUI for cc-top.
 //
-// The TUI has three top-level views: Startup, Dashboard, and Stats.
+// The TUI has four top-level views: Startup, Dashboard, Stats and Session.
 // The Dashboard view arranges four panels: Session List (left),
 // Burn Rate (top right), Event Stream (center right), and Alerts (bottom).
-// The Stats view is a full-screen display of aggregate statistics.
+// The Stats view is a full-screen display of aggregate statistics, and
+// the Session view a full-screen turn-by-turn timeline of one session.
 package tui
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -14,8 +16,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/nixlim/cc-top/internal/alerts"
+	"github.com/nixlim/cc-top/internal/budget"
 	"github.com/nixlim/cc-top/internal/burnrate"
 	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/enforce"
 	"github.com/nixlim/cc-top/internal/events"
 	"github.com/nixlim/cc-top/internal/scanner"
 	"github.com/nixlim/cc-top/internal/state"
@@ -32,6 +36,8 @@ const (
 	ViewDashboard
 	// ViewStats shows the full-screen stats dashboard.
 	ViewStats
+	// ViewSession shows the full-screen timeline of the selected session.
+	ViewSession
 )
 
 // PanelFocus represents which dashboard panel currently has keyboard focus.
@@ -61,6 +67,20 @@ type StateProvider interface {
 type BurnRateProvider interface {
 	Get(sessionID string) burnrate.BurnRate
 	GetGlobal() burnrate.BurnRate
+	// Series returns the spend across all sessions over span, ending now.
+	Series(span time.Duration) []burnrate.Point
+}
+
+// BudgetProvider is the interface for reading budget consumption.
+type BudgetProvider interface {
+	Budgets() []budget.Status
+}
+
+// EnforcementProvider is the interface for sessions paused by budget
+// enforcement and resuming them.
+type EnforcementProvider interface {
+	Paused() []enforce.Paused
+	Resume(sessionID string) error
 }
 
 // EventProvider is the interface for reading formatted events.
@@ -69,10 +89,18 @@ type EventProvider interface {
 	RecentForSession(sessionID string, limit int) []events.FormattedEvent
 }
 
-// AlertProvider is the interface for reading active alerts.
+// AlertProvider is the interface for reading and acting on active alerts.
 type AlertProvider interface {
 	Active() []alerts.Alert
 	ActiveForSession(sessionID string) []alerts.Alert
+	Acknowledge(id uint64) error
+	Snooze(id uint64, until time.Time) error
+}
+
+// DeliveryStatusProvider is the interface for reading how alert
+// notifications are being delivered.
+type DeliveryStatusProvider interface {
+	DeliveryStatus() []alerts.DeliveryStatus
 }
 
 // StatsProvider is the interface for reading dashboard statistics.
@@ -107,17 +135,21 @@ type Model struct {
 	cfg config.Config
 
 	// Providers (dependency-injected, may be nil during tests).
-	state    StateProvider
-	burnRate BurnRateProvider
-	events   EventProvider
-	alerts   AlertProvider
-	stats    StatsProvider
-	scanner  ScannerProvider
-	settings SettingsWriter
+	state       StateProvider
+	burnRate    BurnRateProvider
+	budgets     BudgetProvider
+	enforcement EnforcementProvider
+	events      EventProvider
+	alerts      AlertProvider
+	delivery    DeliveryStatusProvider
+	stats       StatsProvider
+	scanner     ScannerProvider
+	settings    SettingsWriter
 
 	// Session selection.
 	selectedSession string // empty = global view
 	sessionCursor   int    // cursor position in session list
+	groupByRepo     bool   // group the session list by repository and branch
 
 	// Event stream state.
 	eventScrollPos int
@@ -133,12 +165,21 @@ type Model struct {
 	killTargetPID  int
 	killTargetInfo string
 
-	// Cached burn rate (updated on tick, not on every render).
+	// Enforcement resume prompt state.
+	cachedPaused    []enforce.Paused
+	resumeDismissed map[string]bool // paused sessions whose prompt was dismissed
+
+	// Cached burn rate, budgets and notification delivery status (updated
+	// on tick, not on every render).
 	cachedBurnRate burnrate.BurnRate
+	cachedSeries   []burnrate.Point
+	cachedBudgets  []budget.Status
+	cachedDelivery []alerts.DeliveryStatus
 
 	// Alert scroll state.
 	alertScrollPos int
-	alertCursor    int // cursor position within visible alerts
+	alertCursor    int    // cursor position within visible alerts
+	snoozeAlert    uint64 // alert whose snooze duration is being chosen (0 = none)
 
 	// Panel focus and detail overlay.
 	panelFocus      PanelFocus
@@ -147,10 +188,18 @@ type Model struct {
 	detailContent   string // full text to display in the overlay
 	detailTitle     string // title for the detail overlay
 	detailScrollPos int    // scroll position within the detail overlay
+	detailPID       int    // PID whose process tree the overlay shows (0 = none)
 
 	// Stats view scroll.
 	statsScrollPos int
 
+	// Session view state.
+	turnCursor       int          // index of the turn under the cursor
+	collapsedTurns   map[int]bool // turn index -> collapsed
+	sessionScrollPos int          // first visible timeline line
+	searchInput      bool         // whether the search prompt is accepting input
+	searchQuery      string       // current timeline search
+
 	// Refresh rate.
 	refreshRate time.Duration
 
@@ -190,6 +239,16 @@ func WithBurnRateProvider(b BurnRateProvider) ModelOption {
 	return func(m *Model) { m.burnRate = b }
 }
 
+// WithBudgetProvider sets the budget provider.
+func WithBudgetProvider(b BudgetProvider) ModelOption {
+	return func(m *Model) { m.budgets = b }
+}
+
+// WithEnforcementProvider sets the enforcement provider.
+func WithEnforcementProvider(e EnforcementProvider) ModelOption {
+	return func(m *Model) { m.enforcement = e }
+}
+
 // WithEventProvider sets the event provider.
 func WithEventProvider(e EventProvider) ModelOption {
 	return func(m *Model) { m.events = e }
@@ -200,6 +259,11 @@ func WithAlertProvider(a AlertProvider) ModelOption {
 	return func(m *Model) { m.alerts = a }
 }
 
+// WithDeliveryStatusProvider sets the notification delivery status provider.
+func WithDeliveryStatusProvider(d DeliveryStatusProvider) ModelOption {
+	return func(m *Model) { m.delivery = d }
+}
+
 // WithStatsProvider sets the stats provider.
 func WithStatsProvider(s StatsProvider) ModelOption {
 	return func(m *Model) { m.stats = s }
@@ -250,6 +314,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		// Refresh cached burn rate on tick (not on every render).
 		m.cachedBurnRate = m.computeBurnRate()
+		m.cachedSeries = m.computeSeries()
+		m.cachedBudgets = m.computeBudgets()
+		m.cachedDelivery = m.computeDelivery()
+		m.cachedPaused = m.computePaused()
+		if m.detailOverlay && m.detailPID > 0 {
+			m.detailContent = m.formatProcessTree(m.detailPID)
+		}
 		return m, m.tickCmd()
 
 	case tea.KeyMsg:
@@ -266,6 +337,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleKillConfirmKey(msg)
 	}
 
+	// Then the prompt to resume a session paused by enforcement.
+	if p, ok := m.resumePrompt(); ok {
+		return m.handleResumePromptKey(msg, p)
+	}
+
 	// Detail overlay takes priority when active.
 	if m.detailOverlay {
 		return m.handleDetailOverlayKey(msg)
@@ -276,6 +352,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleFilterMenuKey(msg)
 	}
 
+	// Then the snooze duration chooser.
+	if m.snoozeAlert != 0 {
+		return m.handleSnoozeKey(msg)
+	}
+
 	// Global key bindings (available in all views).
 	switch {
 	case key.Matches(msg, m.keys.Quit):
@@ -286,9 +367,14 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case key.Matches(msg, m.keys.KillSwitch):
-		if m.view == ViewDashboard || m.view == ViewStats {
+		if m.view == ViewDashboard || m.view == ViewStats || m.view == ViewSession {
 			return m.initiateKillSwitch()
 		}
+
+	case key.Matches(msg, m.keys.Resume) && !m.searchInput:
+		if m.view == ViewDashboard || m.view == ViewStats || m.view == ViewSession {
+			return m.reopenResumePrompts()
+		}
 	}
 
 	// View-specific key handling.
@@ -299,6 +385,8 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleDashboardKey(msg)
 	case ViewStats:
 		return m.handleStatsKey(msg)
+	case ViewSession:
+		return m.handleSessionViewKey(msg)
 	}
 
 	return m, nil
@@ -398,17 +486,18 @@ func (m Model) handleSessionsPanelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case key.Matches(msg, m.keys.Down):
-		sessions := m.getSessions()
+		sessions := m.displaySessions()
 		if m.sessionCursor < len(sessions)-1 {
 			m.sessionCursor++
 		}
 		return m, nil
 
 	case key.Matches(msg, m.keys.Enter):
-		sessions := m.getSessions()
+		sessions := m.displaySessions()
 		if m.sessionCursor >= 0 && m.sessionCursor < len(sessions) {
 			m.selectedSession = sessions[m.sessionCursor].SessionID
 			m.eventFilter.SessionID = m.selectedSession
+			m.openSessionView()
 		}
 		return m, nil
 
@@ -417,6 +506,14 @@ func (m Model) handleSessionsPanelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.eventFilter.SessionID = ""
 		return m, nil
 
+	case key.Matches(msg, m.keys.ProcessTree):
+		return m.openProcessTree()
+
+	case key.Matches(msg, m.keys.GroupRepo):
+		m.groupByRepo = !m.groupByRepo
+		m.sessionCursor = 0
+		return m, nil
+
 	case key.Matches(msg, m.keys.ScrollDown):
 		m.autoScroll = false
 		m.eventScrollPos++
@@ -496,6 +593,20 @@ func (m Model) handleAlertsPanelKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case key.Matches(msg, m.keys.Acknowledge):
+		if m.alertCursor >= 0 && m.alertCursor < len(activeAlerts) {
+			if err := m.alerts.Acknowledge(activeAlerts[m.alertCursor].ID); err != nil {
+				m.startupMessage = fmt.Sprintf("Error acknowledging alert: %v", err)
+			}
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Snooze):
+		if m.alertCursor >= 0 && m.alertCursor < len(activeAlerts) {
+			m.snoozeAlert = activeAlerts[m.alertCursor].ID
+		}
+		return m, nil
+
 	case key.Matches(msg, m.keys.Escape):
 		m.panelFocus = FocusSessions
 		return m, nil
@@ -512,6 +623,7 @@ func (m Model) handleDetailOverlayKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.detailContent = ""
 		m.detailTitle = ""
 		m.detailScrollPos = 0
+		m.detailPID = 0
 		return m, nil
 
 	case key.Matches(msg, m.keys.Up), key.Matches(msg, m.keys.ScrollUp):
@@ -558,6 +670,12 @@ func (m Model) formatAlertDetail(a alerts.Alert) string {
 		lines = append(lines, "Session:   (global)")
 	}
 	lines = append(lines, "Fired at:  "+a.FiredAt.Format("2006-01-02 15:04:05"))
+	if a.State != "" {
+		lines = append(lines, "State:     "+alertStateLabel(a))
+	}
+	if !a.LastSeen.IsZero() {
+		lines = append(lines, "Last seen: "+a.LastSeen.Format("2006-01-02 15:04:05"))
+	}
 	lines = append(lines, "")
 	lines = append(lines, "Message:")
 	lines = append(lines, a.Message)
@@ -652,6 +770,14 @@ func (m Model) View() string {
 		output = m.renderDashboard()
 	case ViewStats:
 		output = m.renderStats()
+	case ViewSession:
+		output = m.renderSessionView()
+		if m.killConfirm {
+			output = m.overlayKillDialog(output)
+		}
+	}
+	if p, ok := m.resumePrompt(); ok && !m.killConfirm {
+		output = m.overlayResumePrompt(output, p)
 	}
 
 	// Clamp output to terminal height so the header is never pushed off-screen.
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nixlim/cc-top/internal/scanner"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// openProcessTree shows the process tree overlay for the selected session,
+// or the session under the cursor when none is selected. The overlay
+// content is refreshed on every tick while it stays open.
+func (m Model) openProcessTree() (tea.Model, tea.Cmd) {
+	sessions := m.displaySessions()
+
+	var target *state.SessionData
+	for i := range sessions {
+		if m.selectedSession != "" && sessions[i].SessionID == m.selectedSession {
+			target = &sessions[i]
+			break
+		}
+	}
+	if target == nil && m.sessionCursor >= 0 && m.sessionCursor < len(sessions) {
+		target = &sessions[m.sessionCursor]
+	}
+	if target == nil {
+		return m, nil
+	}
+
+	if target.PID <= 0 {
+		m.startupMessage = "No PID available for this session"
+		return m, nil
+	}
+
+	m.detailOverlay = true
+	m.detailTitle = fmt.Sprintf("Process Tree (PID %d)", target.PID)
+	m.detailPID = target.PID
+	m.detailContent = m.formatProcessTree(target.PID)
+	m.detailScrollPos = 0
+	return m, nil
+}
+
+// formatProcessTree builds the overlay content listing the children of the
+// Claude Code process with the given PID.
+func (m Model) formatProcessTree(pid int) string {
+	if m.scanner == nil {
+		return "Process scanner not available."
+	}
+
+	// A recycled PID may be listed twice (exited and live); prefer the
+	// live process.
+	var proc *scanner.ProcessInfo
+	procs := m.scanner.Processes()
+	for i := range procs {
+		if procs[i].PID == pid && (proc == nil || proc.Exited) {
+			proc = &procs[i]
+		}
+	}
+	if proc == nil {
+		return fmt.Sprintf("PID %d is not a known Claude Code process.", pid)
+	}
+	if proc.Exited {
+		return fmt.Sprintf("PID %d has exited.", pid)
+	}
+
+	now := time.Now()
+	var mcp, tools, other int
+	for _, c := range proc.Children {
+		switch c.Kind {
+		case scanner.ChildMCPServer:
+			mcp++
+		case scanner.ChildShellTool:
+			tools++
+		default:
+			other++
+		}
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("claude  PID %d  %s", proc.PID, shortenHome(proc.CWD)))
+	lines = append(lines, fmt.Sprintf("MCP servers: %d  Tool commands: %d  Other: %d", mcp, tools, other))
+	lines = append(lines, "")
+
+	if len(proc.Children) == 0 {
+		lines = append(lines, "No child processes.")
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, fmt.Sprintf("%-5s %7s %7s %6s %2s  %s", "KIND", "PID", "AGE", "CPU%", "ST", "COMMAND"))
+	for _, c := range proc.Children {
+		indent := strings.Repeat("  ", c.Depth-1)
+		age := "-"
+		if !c.StartedAt.IsZero() {
+			age = formatDuration(c.Age(now))
+		}
+		st := c.State
+		if st == "" {
+			st = "?"
+		}
+		lines = append(lines, fmt.Sprintf("%-5s %7d %7s %6.1f %2s  %s%s",
+			c.Kind, c.PID, age, c.CPUPercent, st, indent, truncateStr(c.CommandLine(), 80)))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/scanner"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+func newProcessTreeModel(sessions []state.SessionData, procs []scanner.ProcessInfo) Model {
+	cfg := config.DefaultConfig()
+	m := NewModel(cfg,
+		WithStartView(ViewDashboard),
+		WithStateProvider(&mockStateProvider{sessions: sessions}),
+		WithScannerProvider(&mockScannerProvider{processes: procs}),
+	)
+	m.width = 120
+	m.height = 40
+	return m
+}
+
+func TestModel_ProcessTreeOverlay(t *testing.T) {
+	now := time.Now()
+	sessions := []state.SessionData{{SessionID: "sess-001", PID: 4821}}
+	procs := []scanner.ProcessInfo{{
+		PID: 4821,
+		CWD: "/home/user/project",
+		Children: []scanner.ChildProcess{
+			{PID: 4900, PPID: 4821, Depth: 1, Kind: scanner.ChildMCPServer, Args: []string{"npx", "mcp-server-git"}, State: "S", StartedAt: now.Add(-5 * time.Minute)},
+			{PID: 4950, PPID: 4821, Depth: 1, Kind: scanner.ChildShellTool, Args: []string{"/bin/bash", "-c", "go test ./..."}, State: "S"},
+			{PID: 4951, PPID: 4950, Depth: 2, Kind: scanner.ChildShellTool, Args: []string{"go", "test", "./..."}, State: "R", CPUPercent: 87.5},
+		},
+	}}
+	m := newProcessTreeModel(sessions, procs)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = result.(Model)
+
+	if !m.detailOverlay {
+		t.Fatal("'p' should open the process tree overlay")
+	}
+	if m.detailPID != 4821 {
+		t.Errorf("detailPID = %d, want 4821", m.detailPID)
+	}
+	if !strings.Contains(m.detailTitle, "Process Tree") {
+		t.Errorf("detailTitle = %q, want it to mention Process Tree", m.detailTitle)
+	}
+	for _, want := range []string{"MCP servers: 1", "Tool commands: 2", "mcp-server-git", "go test ./...", "87.5", "5m0s"} {
+		if !strings.Contains(m.detailContent, want) {
+			t.Errorf("process tree should contain %q, got:\n%s", want, m.detailContent)
+		}
+	}
+
+	// Closing the overlay stops tick refreshes.
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(Model)
+	if m.detailOverlay || m.detailPID != 0 {
+		t.Error("Esc should close the process tree overlay and clear detailPID")
+	}
+}
+
+func TestModel_ProcessTreeRefreshesOnTick(t *testing.T) {
+	sessions := []state.SessionData{{SessionID: "sess-001", PID: 4821}}
+	sp := &mockScannerProvider{processes: []scanner.ProcessInfo{{PID: 4821}}}
+	m := NewModel(config.DefaultConfig(),
+		WithStartView(ViewDashboard),
+		WithStateProvider(&mockStateProvider{sessions: sessions}),
+		WithScannerProvider(sp),
+	)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = result.(Model)
+	if !strings.Contains(m.detailContent, "No child processes") {
+		t.Fatalf("expected empty tree, got:\n%s", m.detailContent)
+	}
+
+	sp.processes = []scanner.ProcessInfo{{
+		PID:      4821,
+		Children: []scanner.ChildProcess{{PID: 5000, Depth: 1, BinaryName: "gopls"}},
+	}}
+	result, _ = m.Update(tickMsg(time.Now()))
+	m = result.(Model)
+	if !strings.Contains(m.detailContent, "gopls") {
+		t.Errorf("tick should refresh the process tree, got:\n%s", m.detailContent)
+	}
+}
+
+func TestModel_ProcessTreeNoPID(t *testing.T) {
+	sessions := []state.SessionData{{SessionID: "sess-otel-only"}}
+	m := newProcessTreeModel(sessions, nil)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = result.(Model)
+	if m.detailOverlay {
+		t.Error("process tree should not open for a session without a PID")
+	}
+	if m.startupMessage != "No PID available for this session" {
+		t.Errorf("startupMessage = %q", m.startupMessage)
+	}
+}
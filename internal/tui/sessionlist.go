@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/nixlim/cc-top/internal/gitctx"
 	"github.com/nixlim/cc-top/internal/state"
 )
 
@@ -33,6 +35,17 @@ func (m Model) renderSessionListPanel(w, h int) string {
 	title := panelTitleStyle.Render("Sessions")
 	if m.selectedSession != "" {
 		title += dimStyle.Render(" [" + truncateID(m.selectedSession, 8) + "]")
+		for _, s := range sessions {
+			if s.SessionID != m.selectedSession {
+				continue
+			}
+			if inv := formatInvocation(&s); inv != "" {
+				title += dimStyle.Render(" " + inv)
+			}
+			if s.Estimated {
+				title += dimStyle.Render(" estimated from transcript")
+			}
+		}
 	} else {
 		title += dimStyle.Render(" [Global]")
 	}
@@ -54,11 +67,19 @@ func (m Model) renderSessionListPanel(w, h int) string {
 	lines = append(lines, dimStyle.Render(strings.Repeat("─", min(contentW, len(header)))))
 
 	// Sort sessions: telemetry-enabled first, then non-telemetry greyed out.
-	telemetrySessions, noTelemetrySessions := splitSessionsByTelemetry(sessions)
+	telemetrySessions, noTelemetrySessions := m.splitSessionsForDisplay(sessions)
 
 	rowIdx := 0
-	// Render telemetry-enabled sessions.
-	for _, s := range telemetrySessions {
+	// Render telemetry-enabled sessions, with a header before each
+	// repository/branch group when grouping is on.
+	var group string
+	for i, s := range telemetrySessions {
+		if m.groupByRepo {
+			if g := gitGroupKey(s.Metadata.Git); i == 0 || g != group {
+				group = g
+				lines = append(lines, dimStyle.Render(formatGroupHeader(telemetrySessions[i:])))
+			}
+		}
 		line := formatSessionRow(&s, contentW)
 		if rowIdx == m.sessionCursor {
 			line = selectedStyle.Render(line)
@@ -84,6 +105,13 @@ func (m Model) renderSessionListPanel(w, h int) string {
 		}
 	}
 
+	for _, s := range sessions {
+		if s.Estimated {
+			lines = append(lines, dimStyle.Render("~ cost estimated from transcript"))
+			break
+		}
+	}
+
 	// Truncate to fit available height.
 	if len(lines) > contentH {
 		lines = lines[:contentH]
@@ -96,12 +124,12 @@ func (m Model) renderSessionListPanel(w, h int) string {
 // formatSessionHeader returns the column header string.
 func formatSessionHeader(maxW int) string {
 	if maxW >= 90 {
-		return fmt.Sprintf("%-6s %-8s %-8s %-15s %-6s %-8s %-5s %-8s %-6s",
-			"PID", "Session", "Term", "CWD", "Model", "Status", "Cost", "Tokens", "Time")
+		return fmt.Sprintf("%-6s %-8s %-8s %-4s %-15s %-6s %-8s %-5s %-8s %-6s",
+			"PID", "Session", "Term", "Mode", "Repo/CWD", "Model", "Status", "Cost", "Tokens", "Time")
 	}
 	if maxW >= 60 {
-		return fmt.Sprintf("%-6s %-8s %-8s %-12s %-6s %-5s",
-			"PID", "Session", "Term", "CWD", "Status", "Cost")
+		return fmt.Sprintf("%-6s %-8s %-8s %-4s %-12s %-6s %-5s",
+			"PID", "Session", "Term", "Mode", "Repo/CWD", "Status", "Cost")
 	}
 	return fmt.Sprintf("%-6s %-8s %-6s %-5s",
 		"PID", "Session", "Status", "Cost")
@@ -116,25 +144,54 @@ func formatSessionRow(s *state.SessionData, maxW int) string {
 
 	sessionID := truncateID(s.SessionID, 8)
 	terminal := truncateStr(s.Terminal, 8)
-	cwd := truncateCWD(s.CWD, 15)
+	mode := modeLabel(s.Mode)
+	cwd := sessionLocation(s, 15)
 	model := truncateStr(s.Model, 6)
 	statusStr := renderStatus(s.Status())
 	cost := fmt.Sprintf("$%.2f", s.TotalCost)
+	if s.Estimated {
+		cost = "~" + cost
+	}
 	tokens := formatNumber(s.TotalTokens)
 	activeTime := formatDuration(s.ActiveTime)
 
 	if maxW >= 90 {
-		return fmt.Sprintf("%-6s %-8s %-8s %-15s %-6s %-8s %5s %8s %6s",
-			pid, sessionID, terminal, cwd, model, statusStr, cost, tokens, activeTime)
+		return fmt.Sprintf("%-6s %-8s %-8s %-4s %-15s %-6s %-8s %5s %8s %6s",
+			pid, sessionID, terminal, mode, cwd, model, statusStr, cost, tokens, activeTime)
 	}
 	if maxW >= 60 {
-		return fmt.Sprintf("%-6s %-8s %-8s %-12s %-6s %5s",
-			pid, sessionID, terminal, truncateCWD(s.CWD, 12), statusStr, cost)
+		return fmt.Sprintf("%-6s %-8s %-8s %-4s %-12s %-6s %5s",
+			pid, sessionID, terminal, mode, sessionLocation(s, 12), statusStr, cost)
 	}
 	return fmt.Sprintf("%-6s %-8s %-6s %5s",
 		pid, sessionID, statusStr, cost)
 }
 
+// modeLabel abbreviates an invocation mode for the Mode column.
+func modeLabel(mode string) string {
+	switch mode {
+	case "interactive":
+		return "tty"
+	case "headless":
+		return "-p"
+	case "":
+		return "—"
+	}
+	return truncateStr(mode, 4)
+}
+
+// formatInvocation describes how a session was started, e.g.
+// "headless via cron". Returns "" if the mode is unknown.
+func formatInvocation(s *state.SessionData) string {
+	if s.Mode == "" {
+		return ""
+	}
+	if s.ParentApp == "" {
+		return s.Mode
+	}
+	return s.Mode + " via " + s.ParentApp
+}
+
 // renderStatus returns a styled string for the session status.
 func renderStatus(s state.SessionStatus) string {
 	switch s {
@@ -151,6 +208,49 @@ func renderStatus(s state.SessionStatus) string {
 	}
 }
 
+// sessionLocation returns the git label for sessions inside a repository,
+// otherwise the truncated CWD.
+func sessionLocation(s *state.SessionData, maxLen int) string {
+	if s.Metadata.Git.Repo == "" {
+		return truncateCWD(s.CWD, maxLen)
+	}
+	return formatGitLabel(s.Metadata.Git, maxLen)
+}
+
+// formatGitLabel renders git context as "repo@branch", using the short
+// commit hash on a detached HEAD, with a trailing "*" when the working
+// tree is dirty. The dirty marker survives truncation.
+func formatGitLabel(g state.GitInfo, maxLen int) string {
+	ref := g.Branch
+	if ref == "" {
+		ref = gitctx.Info{Head: g.Head}.ShortHead()
+	}
+	label := g.Repo
+	if ref != "" {
+		label += "@" + ref
+	}
+	if !g.Dirty {
+		return truncateStr(label, maxLen)
+	}
+	if maxLen <= 1 {
+		return "*"
+	}
+	return truncateStr(label, maxLen-1) + "*"
+}
+
+// shortenHome replaces the user's home directory prefix of path with ~
+// for display. Paths are stored absolute.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home || strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
 // truncateCWD shortens a path by replacing the home directory with ~
 // and using ellipsis for long paths.
 func truncateCWD(cwd string, maxLen int) string {
@@ -158,12 +258,7 @@ func truncateCWD(cwd string, maxLen int) string {
 		return "—"
 	}
 
-	// Replace home directory with ~.
-	home, err := os.UserHomeDir()
-	if err == nil && strings.HasPrefix(cwd, home) {
-		cwd = "~" + cwd[len(home):]
-	}
-
+	cwd = shortenHome(cwd)
 	if len(cwd) <= maxLen {
 		return cwd
 	}
@@ -223,6 +318,70 @@ func splitSessionsByTelemetry(sessions []state.SessionData) (withTelemetry, with
 	return
 }
 
+// splitSessionsForDisplay orders sessions as the session list shows them:
+// telemetry-enabled sessions first, grouped by repository and branch when
+// grouping is on, then sessions without telemetry. The cursor indexes into
+// this order.
+func (m Model) splitSessionsForDisplay(sessions []state.SessionData) (withTelemetry, withoutTelemetry []state.SessionData) {
+	withTelemetry, withoutTelemetry = splitSessionsByTelemetry(sessions)
+	if m.groupByRepo {
+		sort.SliceStable(withTelemetry, func(i, j int) bool {
+			gi, gj := withTelemetry[i].Metadata.Git, withTelemetry[j].Metadata.Git
+			// Sessions outside a repository sort last.
+			if (gi.Repo == "") != (gj.Repo == "") {
+				return gi.Repo != ""
+			}
+			return gitGroupKey(gi) < gitGroupKey(gj)
+		})
+	}
+	return withTelemetry, withoutTelemetry
+}
+
+// displaySessions returns sessions in session list order.
+func (m Model) displaySessions() []state.SessionData {
+	withTelemetry, withoutTelemetry := m.splitSessionsForDisplay(m.getSessions())
+	return append(withTelemetry, withoutTelemetry...)
+}
+
+// gitGroupKey identifies the repository/branch group of a session.
+// Repositories are told apart by their git directory, so unrelated
+// checkouts with the same name stay apart while linked worktrees group
+// together; the name comes first so that groups sort by it.
+func gitGroupKey(g state.GitInfo) string {
+	if g.Repo == "" {
+		return ""
+	}
+	dir := g.CommonDir
+	if dir == "" {
+		dir = g.RepoRoot
+	}
+	return g.Repo + "\x00" + dir + "\x00" + g.Branch
+}
+
+// formatGroupHeader renders the header for the group starting at the first
+// of sessions, with its session count and total cost.
+func formatGroupHeader(sessions []state.SessionData) string {
+	key := gitGroupKey(sessions[0].Metadata.Git)
+	count := 0
+	cost := 0.0
+	for _, s := range sessions {
+		if gitGroupKey(s.Metadata.Git) != key {
+			break
+		}
+		count++
+		cost += s.TotalCost
+	}
+
+	name := "no repository"
+	if g := sessions[0].Metadata.Git; g.Repo != "" {
+		name = g.Repo + " @ " + g.Branch
+		if g.Branch == "" {
+			name = g.Repo + " (detached)"
+		}
+	}
+	return fmt.Sprintf("── %s · %d · $%.2f ──", name, count, cost)
+}
+
 // hasTelemetry returns true if a session has received any telemetry data.
 func hasTelemetry(s *state.SessionData) bool {
 	return len(s.Metrics) > 0 || len(s.Events) > 0 || !s.LastEventAt.IsZero()
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+
 	"github.com/nixlim/cc-top/internal/config"
 	"github.com/nixlim/cc-top/internal/state"
 )
@@ -38,6 +40,12 @@ func TestTruncateCWD(t *testing.T) {
 			maxLen: 20,
 			want:   "~/projects",
 		},
+		{
+			name:   "sibling of home kept",
+			cwd:    home + "-other/x",
+			maxLen: 60,
+			want:   home + "-other/x",
+		},
 		{
 			name:   "long path truncated",
 			cwd:    "/very/long/path/to/some/deeply/nested/directory",
@@ -263,3 +271,135 @@ func TestFormatSessionRow_NoPID(t *testing.T) {
 		t.Error("session with PID 0 should show em-dash")
 	}
 }
+
+func TestFormatGitLabel(t *testing.T) {
+	tests := []struct {
+		name   string
+		git    state.GitInfo
+		maxLen int
+		want   string
+	}{
+		{"clean branch", state.GitInfo{Repo: "cc-top", Branch: "main"}, 15, "cc-top@main"},
+		{"dirty branch", state.GitInfo{Repo: "cc-top", Branch: "main", Dirty: true}, 15, "cc-top@main*"},
+		{"detached", state.GitInfo{Repo: "cc-top", Head: "3f786850e387550fdab8"}, 15, "cc-top@3f78685"},
+		{"dirty marker kept when truncated", state.GitInfo{Repo: "cc-top", Branch: "feature/long-name", Dirty: true}, 12, "cc-top@fea.*"},
+		{"unborn, no head", state.GitInfo{Repo: "cc-top"}, 15, "cc-top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGitLabel(tt.git, tt.maxLen); got != tt.want {
+				t.Errorf("formatGitLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSessionRow_ShowsGitContext(t *testing.T) {
+	s := &state.SessionData{
+		SessionID: "sess-001",
+		CWD:       "/Users/test/project",
+		Metadata:  state.SessionMetadata{Git: state.GitInfo{Repo: "project", Branch: "dev", Dirty: true}},
+	}
+	for _, w := range []int{100, 70} {
+		if row := formatSessionRow(s, w); !strings.Contains(row, "project@dev*") {
+			t.Errorf("row at width %d = %q, want git label", w, row)
+		}
+	}
+}
+
+func TestSessionList_GroupByRepo(t *testing.T) {
+	now := time.Now()
+	git := func(repo, branch string) state.SessionMetadata {
+		return state.SessionMetadata{Git: state.GitInfo{Repo: repo, Branch: branch}}
+	}
+	mock := &mockStateProvider{sessions: []state.SessionData{
+		{SessionID: "sess-a1", LastEventAt: now, TotalCost: 1, Metadata: git("alpha", "main")},
+		{SessionID: "sess-nogit", LastEventAt: now},
+		{SessionID: "sess-b1", LastEventAt: now, TotalCost: 2, Metadata: git("beta", "main")},
+		{SessionID: "sess-a2", LastEventAt: now, TotalCost: 3, Metadata: git("alpha", "main")},
+		{SessionID: "sess-idle"}, // no telemetry
+	}}
+	m := NewModel(config.DefaultConfig(), WithStateProvider(mock), WithStartView(ViewDashboard))
+	m.width = 160
+	m.height = 40
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	m = result.(Model)
+	if !m.groupByRepo {
+		t.Fatal("g should enable grouping")
+	}
+
+	var got []string
+	for _, s := range m.displaySessions() {
+		got = append(got, s.SessionID)
+	}
+	want := []string{"sess-a1", "sess-a2", "sess-b1", "sess-nogit", "sess-idle"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("display order = %v, want %v", got, want)
+	}
+
+	panel := m.renderSessionListPanel(100, 20)
+	if !strings.Contains(panel, "alpha @ main · 2 · $4.00") {
+		t.Errorf("panel missing alpha group header:\n%s", panel)
+	}
+	if !strings.Contains(panel, "no repository") {
+		t.Errorf("panel missing no-repository group header:\n%s", panel)
+	}
+
+	// The cursor follows display order, so Enter selects the row shown.
+	m.sessionCursor = 1
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if sel := result.(Model).selectedSession; sel != "sess-a2" {
+		t.Errorf("selected %q, want sess-a2", sel)
+	}
+}
+
+func TestGitGroupKey(t *testing.T) {
+	work := state.GitInfo{Repo: "api", Branch: "main", RepoRoot: "/work/api", CommonDir: "/work/api/.git"}
+	fork := state.GitInfo{Repo: "api", Branch: "main", RepoRoot: "/oss/api", CommonDir: "/oss/api/.git"}
+	worktree := state.GitInfo{Repo: "api", Branch: "main", RepoRoot: "/work/api-hotfix", CommonDir: "/work/api/.git"}
+	if gitGroupKey(work) == gitGroupKey(fork) {
+		t.Error("different repositories with the same name should not group together")
+	}
+	if gitGroupKey(work) != gitGroupKey(worktree) {
+		t.Error("a linked worktree should group with its repository")
+	}
+	if gitGroupKey(state.GitInfo{}) != "" {
+		t.Error("sessions outside a repository should have an empty key")
+	}
+}
+
+func TestSessionList_EstimatedSession(t *testing.T) {
+	s := &state.SessionData{SessionID: "sess-est", TotalCost: 1.5, Estimated: true}
+	if row := formatSessionRow(s, 100); !strings.Contains(row, "~$1.50") {
+		t.Errorf("estimated session cost should be marked with ~: %q", row)
+	}
+
+	mock := &mockStateProvider{sessions: []state.SessionData{*s}}
+	m := NewModel(config.DefaultConfig(), WithStateProvider(mock), WithStartView(ViewDashboard))
+	m.selectedSession = "sess-est"
+	panel := m.renderSessionListPanel(100, 20)
+	if !strings.Contains(panel, "estimated from transcript") {
+		t.Errorf("panel should explain the estimate:\n%s", panel)
+	}
+}
+
+func TestSessionList_InvocationMode(t *testing.T) {
+	s := &state.SessionData{SessionID: "sess-cron", PID: 4821, Mode: "headless", ParentApp: "cron"}
+	for _, w := range []int{100, 70} {
+		if row := formatSessionRow(s, w); !strings.Contains(row, " -p ") {
+			t.Errorf("row at width %d should show the headless mode: %q", w, row)
+		}
+	}
+	if row := formatSessionRow(&state.SessionData{SessionID: "sess-new"}, 100); !strings.Contains(row, "—") {
+		t.Errorf("row for an unclassified session should show a dash: %q", row)
+	}
+
+	mock := &mockStateProvider{sessions: []state.SessionData{*s}}
+	m := NewModel(config.DefaultConfig(), WithStateProvider(mock), WithStartView(ViewDashboard))
+	m.selectedSession = "sess-cron"
+	panel := m.renderSessionListPanel(100, 20)
+	if !strings.Contains(panel, "headless via cron") {
+		t.Errorf("panel title should describe the invocation:\n%s", panel)
+	}
+}
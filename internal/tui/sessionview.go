@@ -0,0 +1,349 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nixlim/cc-top/internal/events"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// sessionViewChrome is the number of lines the session view uses outside
+// the timeline: header bar, summary, separator and footer.
+const sessionViewChrome = 4
+
+// openSessionView switches to the full-screen timeline of the selected
+// session, with the cursor on its latest turn.
+func (m *Model) openSessionView() {
+	m.view = ViewSession
+	m.collapsedTurns = make(map[int]bool)
+	m.searchInput = false
+	m.searchQuery = ""
+	m.sessionScrollPos = 0
+	m.turnCursor = max(len(m.sessionTurns())-1, 0)
+	m.followTurnCursor()
+}
+
+// sessionTurns groups the selected session's events into turns.
+func (m Model) sessionTurns() []events.Turn {
+	s := m.sessionViewData()
+	if s == nil {
+		return nil
+	}
+	return events.GroupTurns(s.Events)
+}
+
+// sessionViewData returns the session shown in the session view.
+func (m Model) sessionViewData() *state.SessionData {
+	if m.state == nil || m.selectedSession == "" {
+		return nil
+	}
+	return m.state.GetSession(m.selectedSession)
+}
+
+// handleSessionViewKey handles keys in the session view.
+func (m Model) handleSessionViewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searchInput {
+		return m.handleSearchInputKey(msg)
+	}
+
+	turns := m.sessionTurns()
+
+	switch {
+	case key.Matches(msg, m.keys.Escape):
+		if m.searchQuery != "" {
+			m.searchQuery = ""
+			return m, nil
+		}
+		m.view = ViewDashboard
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.turnCursor > 0 {
+			m.turnCursor--
+		}
+		m.followTurnCursor()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.turnCursor < len(turns)-1 {
+			m.turnCursor++
+		}
+		m.followTurnCursor()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Enter):
+		if m.turnCursor < len(turns) {
+			m.collapsedTurns[m.turnCursor] = !m.collapsedTurns[m.turnCursor]
+		}
+		m.followTurnCursor()
+		return m, nil
+
+	case key.Matches(msg, m.keys.ToggleAll):
+		collapse := false
+		for i := range turns {
+			if !m.collapsedTurns[i] {
+				collapse = true
+				break
+			}
+		}
+		for i := range turns {
+			m.collapsedTurns[i] = collapse
+		}
+		m.followTurnCursor()
+		return m, nil
+
+	case key.Matches(msg, m.keys.ScrollDown):
+		m.sessionScrollPos += m.timelineHeight()
+		return m, nil
+
+	case key.Matches(msg, m.keys.ScrollUp):
+		m.sessionScrollPos = max(m.sessionScrollPos-m.timelineHeight(), 0)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Search):
+		m.searchInput = true
+		m.searchQuery = ""
+		return m, nil
+
+	case key.Matches(msg, m.keys.NextMatch):
+		m.jumpToMatch(turns, m.turnCursor+1, 1)
+		return m, nil
+
+	case key.Matches(msg, m.keys.PrevMatch):
+		m.jumpToMatch(turns, m.turnCursor-1, -1)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleSearchInputKey edits the search query while the prompt is open.
+// Enter runs the search from the cursor; Esc abandons it.
+func (m Model) handleSearchInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searchInput = false
+		m.jumpToMatch(m.sessionTurns(), m.turnCursor, 1)
+	case tea.KeyEsc:
+		m.searchInput = false
+		m.searchQuery = ""
+	case tea.KeyBackspace:
+		if r := []rune(m.searchQuery); len(r) > 0 {
+			m.searchQuery = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.searchQuery += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// jumpToMatch moves the cursor to the first turn matching the search,
+// starting at from and stepping by dir, wrapping around. The matching
+// turn is expanded.
+func (m *Model) jumpToMatch(turns []events.Turn, from, dir int) {
+	if m.searchQuery == "" || len(turns) == 0 {
+		return
+	}
+	sessionID := m.selectedSession
+	for n := 0; n < len(turns); n++ {
+		i := ((from+dir*n)%len(turns) + len(turns)) % len(turns)
+		if turnMatches(sessionID, turns[i], m.searchQuery) {
+			m.turnCursor = i
+			m.collapsedTurns[i] = false
+			m.followTurnCursor()
+			return
+		}
+	}
+}
+
+// timelineHeight returns the number of timeline lines that fit on screen.
+func (m Model) timelineHeight() int {
+	return max(m.height-sessionViewChrome, 1)
+}
+
+// followTurnCursor scrolls the timeline so the cursor's turn header is
+// visible.
+func (m *Model) followTurnCursor() {
+	_, headers := m.timelineLines(m.sessionTurns())
+	if m.turnCursor >= len(headers) {
+		return
+	}
+	line := headers[m.turnCursor]
+	h := m.timelineHeight()
+	if line < m.sessionScrollPos {
+		m.sessionScrollPos = line
+	}
+	if line >= m.sessionScrollPos+h {
+		m.sessionScrollPos = line - h + 1
+	}
+}
+
+// renderSessionView renders the full-screen session timeline.
+func (m Model) renderSessionView() string {
+	var sb strings.Builder
+
+	viewLabel := " [Session] " + truncateID(m.selectedSession, 8)
+	help := "Enter:Fold  c:All  /:Search  n/N:Match  Esc:Back  q:Quit "
+	padding := max(m.width-len(" cc-top")-len(viewLabel)-len(help), 0)
+	sb.WriteString(headerStyle.Width(m.width).Render(
+		" cc-top" + viewLabel + strings.Repeat(" ", padding) + help))
+	sb.WriteByte('\n')
+
+	s := m.sessionViewData()
+	if s == nil {
+		sb.WriteString(dimStyle.Render("  Session not found"))
+		sb.WriteByte('\n')
+		return sb.String()
+	}
+
+	turns := events.GroupTurns(s.Events)
+	sb.WriteString(formatSessionSummary(s, len(turns)))
+	sb.WriteByte('\n')
+	sb.WriteString(dimStyle.Render(strings.Repeat("─", max(m.width, 1))))
+	sb.WriteByte('\n')
+
+	lines, _ := m.timelineLines(turns)
+	if len(lines) == 0 {
+		lines = []string{dimStyle.Render("  No events for this session yet")}
+	}
+
+	h := m.timelineHeight()
+	start := min(m.sessionScrollPos, max(len(lines)-h, 0))
+	end := min(start+h, len(lines))
+	for _, line := range lines[start:end] {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	for i := end - start; i < h; i++ {
+		sb.WriteByte('\n')
+	}
+
+	sb.WriteString(m.sessionViewFooter(turns, start, end, len(lines)))
+	return sb.String()
+}
+
+// formatSessionSummary renders the line under the session view header.
+func formatSessionSummary(s *state.SessionData, turns int) string {
+	parts := []string{fmt.Sprintf("%d turns", turns)}
+	if s.Model != "" {
+		parts = append(parts, s.Model)
+	}
+	if inv := formatInvocation(s); inv != "" {
+		parts = append(parts, inv)
+	}
+	if loc := sessionLocation(s, 40); loc != "—" {
+		parts = append(parts, loc)
+	}
+	cost := fmt.Sprintf("$%.2f", s.TotalCost)
+	if s.Estimated {
+		cost = "~" + cost
+	}
+	parts = append(parts, cost, formatNumber(s.TotalTokens)+" tok", formatDuration(s.ActiveTime)+" active")
+	return "  " + strings.Join(parts, " · ")
+}
+
+// sessionViewFooter renders the search prompt, or the search status and
+// scroll position.
+func (m Model) sessionViewFooter(turns []events.Turn, start, end, total int) string {
+	if m.searchInput {
+		return "/" + m.searchQuery + "█"
+	}
+	var parts []string
+	if m.searchQuery != "" {
+		n := 0
+		for _, t := range turns {
+			if turnMatches(m.selectedSession, t, m.searchQuery) {
+				n++
+			}
+		}
+		parts = append(parts, fmt.Sprintf("search %q: %d of %d turns", m.searchQuery, n, len(turns)))
+	}
+	if total > end-start {
+		parts = append(parts, fmt.Sprintf("lines %d-%d of %d  PgUp/PgDn:Scroll", start+1, end, total))
+	}
+	return dimStyle.Render(strings.Join(parts, "  "))
+}
+
+// timelineLines renders every turn as a header line followed, unless
+// collapsed, by one line per event. It also returns the line index of
+// each turn's header.
+func (m Model) timelineLines(turns []events.Turn) (lines []string, headers []int) {
+	width := max(m.width, 20)
+	query := strings.ToLower(m.searchQuery)
+
+	for i, t := range turns {
+		headers = append(headers, len(lines))
+		collapsed := m.collapsedTurns[i]
+
+		header := truncateStr(formatTurnHeader(t, collapsed), width)
+		switch {
+		case i == m.turnCursor:
+			header = cursorStyle.Render(header)
+		case query != "" && turnMatches(m.selectedSession, t, m.searchQuery):
+			header = searchMatchStyle.Render(header)
+		}
+		lines = append(lines, header)
+
+		if collapsed {
+			continue
+		}
+		for _, e := range t.Events {
+			line := truncateStr(formatTimelineEvent(m.selectedSession, e), width)
+			switch {
+			case query != "" && strings.Contains(strings.ToLower(line), query):
+				line = searchMatchStyle.Render(line)
+			case e.Name == "claude_code.api_error":
+				line = alertCriticalStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, headers
+}
+
+// formatTurnHeader summarises a turn on one line: its prompt and totals.
+func formatTurnHeader(t events.Turn, collapsed bool) string {
+	marker := "▾"
+	if collapsed {
+		marker = "▸"
+	}
+	label := "before first prompt"
+	if t.Number > 0 {
+		label = fmt.Sprintf("#%d %s", t.Number, t.PromptLabel())
+	}
+	stats := fmt.Sprintf("%d req  %d tools  %d err  %s tok  $%.2f  %s",
+		t.APIRequests, t.ToolResults, t.Errors, formatNumber(t.Tokens()), t.CostUSD, formatDuration(t.WallTime()))
+	return fmt.Sprintf("%s %s  %-40s  %s", marker, t.Start.Format("15:04:05"), truncateStr(label, 40), stats)
+}
+
+// formatTimelineEvent renders one event of an expanded turn.
+func formatTimelineEvent(sessionID string, e state.Event) string {
+	fe := events.FormatEvent(sessionID, e)
+	// The session is already in the header; drop FormatEvent's "[id] ".
+	text := fe.Formatted
+	if strings.HasPrefix(text, "[") {
+		if _, rest, ok := strings.Cut(text, "] "); ok {
+			text = rest
+		}
+	}
+	return fmt.Sprintf("    %s  %-13s %s", e.Timestamp.Format("15:04:05"), fe.EventType, text)
+}
+
+// turnMatches reports whether the turn's header or any of its events
+// contains query, case-insensitively.
+func turnMatches(sessionID string, t events.Turn, query string) bool {
+	query = strings.ToLower(query)
+	if strings.Contains(strings.ToLower(formatTurnHeader(t, false)), query) {
+		return true
+	}
+	for _, e := range t.Events {
+		if strings.Contains(strings.ToLower(formatTimelineEvent(sessionID, e)), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nixlim/cc-top/internal/config"
+	"github.com/nixlim/cc-top/internal/state"
+)
+
+// newSessionViewModel returns a dashboard model with one session of two
+// turns, opened in the session view.
+func newSessionViewModel(t *testing.T) Model {
+	t.Helper()
+	base := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+
+	sess := state.SessionData{
+		SessionID: "sess-timeline",
+		PID:       4242,
+		TotalCost: 0.12,
+		Events: []state.Event{
+			{Name: "claude_code.user_prompt", Timestamp: at(0), Attributes: map[string]string{"prompt.id": "p1", "prompt": "fix the flaky test"}},
+			{Name: "claude_code.api_request", Timestamp: at(3), Attributes: map[string]string{
+				"prompt.id": "p1", "model": "claude-sonnet-4-5", "input_tokens": "1200", "output_tokens": "300", "cost_usd": "0.04", "duration_ms": "2500",
+			}},
+			{Name: "claude_code.tool_result", Timestamp: at(9), Attributes: map[string]string{"prompt.id": "p1", "tool_name": "Bash", "success": "true", "duration_ms": "400"}},
+			{Name: "claude_code.user_prompt", Timestamp: at(120), Attributes: map[string]string{"prompt.id": "p2", "prompt_length": "31"}},
+			{Name: "claude_code.api_error", Timestamp: at(125), Attributes: map[string]string{"prompt.id": "p2", "status_code": "529", "error": "overloaded", "attempt": "1"}},
+		},
+	}
+	mock := &mockStateProvider{sessions: []state.SessionData{sess}}
+	m := NewModel(config.DefaultConfig(), WithStateProvider(mock), WithStartView(ViewDashboard))
+	m.width = 140
+	m.height = 30
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	if m.view != ViewSession {
+		t.Fatalf("Enter on a session should open the session view, view = %d", m.view)
+	}
+	return m
+}
+
+func TestSessionView_Timeline(t *testing.T) {
+	m := newSessionViewModel(t)
+
+	if m.turnCursor != 1 {
+		t.Errorf("cursor should start on the latest turn, got %d", m.turnCursor)
+	}
+
+	view := m.View()
+	for _, want := range []string{
+		"[Session] sess-tim",
+		"2 turns",
+		"#1 fix the flaky test",
+		"1 req  1 tools  0 err",
+		"#2 (31 chars)",
+		"claude-sonnet-4-5",
+		"Bash ✓",
+		"529 overloaded",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("session view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Collapsing the first turn hides its events but keeps its header.
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	result, _ = result.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	view = m.View()
+	if strings.Contains(view, "Bash ✓") {
+		t.Error("collapsed turn should hide its events")
+	}
+	if !strings.Contains(view, "▸") || !strings.Contains(view, "#1 fix the flaky test") {
+		t.Errorf("collapsed turn header should remain:\n%s", view)
+	}
+
+	// 'c' toggles every turn.
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = result.(Model)
+	if !m.collapsedTurns[0] || !m.collapsedTurns[1] {
+		t.Errorf("'c' should collapse all turns, got %v", m.collapsedTurns)
+	}
+
+	// Esc returns to the dashboard.
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	if result.(Model).view != ViewDashboard {
+		t.Error("Esc should return to the dashboard")
+	}
+}
+
+func TestSessionView_Search(t *testing.T) {
+	m := newSessionViewModel(t)
+
+	// Collapse everything, then search for a tool in the first turn.
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	result, _ = result.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = result.(Model)
+	if !m.searchInput {
+		t.Fatal("'/' should open the search prompt")
+	}
+	for _, r := range "bash" {
+		result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = result.(Model)
+	}
+	if !strings.Contains(m.View(), "/bash") {
+		t.Error("search prompt should show the query")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	if m.searchInput || m.turnCursor != 0 {
+		t.Errorf("Enter should jump to the matching turn: input %v cursor %d", m.searchInput, m.turnCursor)
+	}
+	if m.collapsedTurns[0] {
+		t.Error("the matching turn should be expanded")
+	}
+	if !strings.Contains(m.View(), `search "bash": 1 of 2 turns`) {
+		t.Errorf("footer should report matches:\n%s", m.View())
+	}
+
+	// 'n' wraps around to the same single match.
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if c := result.(Model).turnCursor; c != 0 {
+		t.Errorf("'n' with one match should stay on turn 0, got %d", c)
+	}
+
+	// The first Esc clears the search, the second leaves the view.
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	m = result.(Model)
+	if m.searchQuery != "" || m.view != ViewSession {
+		t.Errorf("Esc should clear the search first: query %q view %d", m.searchQuery, m.view)
+	}
+}
+
+func TestSessionView_ScrollFollowsCursor(t *testing.T) {
+	var evts []state.Event
+	base := time.Now()
+	for i := 0; i < 20; i++ {
+		evts = append(evts, state.Event{Name: "claude_code.user_prompt", Timestamp: base.Add(time.Duration(i) * time.Minute)})
+		for j := 0; j < 3; j++ {
+			evts = append(evts, state.Event{Name: "claude_code.api_request", Timestamp: base.Add(time.Duration(i)*time.Minute + time.Second)})
+		}
+	}
+	mock := &mockStateProvider{sessions: []state.SessionData{{SessionID: "sess-long", Events: evts}}}
+	m := NewModel(config.DefaultConfig(), WithStateProvider(mock), WithStartView(ViewDashboard))
+	m.width = 120
+	m.height = 20
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	if !strings.Contains(m.View(), "#20 ") {
+		t.Errorf("the latest turn should be visible on open:\n%s", m.View())
+	}
+
+	for i := 0; i < 19; i++ {
+		result, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+		m = result.(Model)
+	}
+	if m.sessionScrollPos != 0 || !strings.Contains(m.View(), "#1 ") {
+		t.Errorf("moving to the first turn should scroll to the top, scroll = %d", m.sessionScrollPos)
+	}
+}
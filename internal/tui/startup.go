@@ -45,12 +45,20 @@ func (m Model) renderStartup() string {
 			row := formatProcessRow(p, statusInfo)
 			sb.WriteString(row)
 			sb.WriteByte('\n')
+			if p.Container != nil {
+				sb.WriteString(dimStyle.Render(formatContainer(p)))
+				sb.WriteByte('\n')
+			}
+			for _, line := range formatDiagnosis(statusInfo) {
+				sb.WriteString(dimStyle.Render(line))
+				sb.WriteByte('\n')
+			}
 
 			// Count by status.
 			switch statusInfo.Status {
 			case scanner.TelemetryConnected, scanner.TelemetryWaiting:
 				connected++
-			case scanner.TelemetryWrongPort, scanner.TelemetryConsoleOnly:
+			case scanner.TelemetryWrongPort, scanner.TelemetryConsoleOnly, scanner.TelemetryUnreachable:
 				misconfigured++
 			case scanner.TelemetryOff:
 				noTelemetry++
@@ -131,19 +139,24 @@ func (m Model) getProcessStatus(p scanner.ProcessInfo) scanner.StatusInfo {
 func formatProcessRow(p scanner.ProcessInfo, status scanner.StatusInfo) string {
 	cwd := truncateCWD(p.CWD, 20)
 	terminal := truncateStr(p.Terminal, 10)
-	if terminal == "" {
+	if p.Container != nil {
+		terminal = truncateStr(p.Container.Label(), 10)
+	} else if terminal == "" {
 		terminal = "(headless)"
 	}
 
 	telIcon := formatTelemetryIcon(status.Status)
-	otlpDest := formatOTLPDest(p)
+	otlpDest := formatSignalDest(status.Signals)
+	if otlpDest == "" {
+		otlpDest = formatOTLPDest(p)
+	}
 	statusLabel := status.Label
 
 	var style = dimStyle
 	switch status.Status {
 	case scanner.TelemetryConnected, scanner.TelemetryWaiting:
 		style = activeStyle
-	case scanner.TelemetryWrongPort, scanner.TelemetryConsoleOnly:
+	case scanner.TelemetryWrongPort, scanner.TelemetryConsoleOnly, scanner.TelemetryUnreachable:
 		style = idleStyle
 	case scanner.TelemetryOff:
 		style = dimStyle
@@ -160,7 +173,7 @@ func formatTelemetryIcon(status scanner.TelemetryStatus) string {
 	switch status {
 	case scanner.TelemetryConnected, scanner.TelemetryWaiting:
 		return "OK ON"
-	case scanner.TelemetryWrongPort:
+	case scanner.TelemetryWrongPort, scanner.TelemetryUnreachable:
 		return "!! ON"
 	case scanner.TelemetryConsoleOnly:
 		return "!! ON"
@@ -185,3 +198,70 @@ func formatOTLPDest(p scanner.ProcessInfo) string {
 	}
 	return endpoint
 }
+
+// formatSignalDest returns the OTLP destination ports from a per-signal
+// diagnosis: ":4317" when every OTLP signal goes to the same port, or one
+// "m:"/"l:" entry per signal when they differ. Returns "" without a
+// diagnosis.
+func formatSignalDest(signals []scanner.SignalDiagnosis) string {
+	var parts []string
+	same := true
+	for _, d := range signals {
+		if !d.OTLP {
+			continue
+		}
+		if len(parts) > 0 && d.Port != signals[0].Port {
+			same = false
+		}
+		parts = append(parts, fmt.Sprintf("%c:%d", d.Signal[0], d.Port))
+	}
+	switch {
+	case len(parts) == 0:
+		return ""
+	case same && len(parts) == len(signals):
+		return fmt.Sprintf(":%d", signals[0].Port)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatContainer returns the indented line describing the container a
+// process runs in: its label, runtime, and the container path when it was
+// translated to a host path.
+func formatContainer(p scanner.ProcessInfo) string {
+	c := p.Container
+	var kind []string
+	if c.Runtime != "" {
+		kind = append(kind, c.Runtime)
+	}
+	if c.Devcontainer {
+		kind = append(kind, "devcontainer")
+	}
+	line := "         container: " + c.Label()
+	if len(kind) > 0 {
+		line += " (" + strings.Join(kind, ", ") + ")"
+	}
+	if c.HostCWD != "" && c.CWD != "" {
+		line += "  " + c.CWD + " -> " + shortenHome(p.CWD)
+	}
+	return line
+}
+
+// formatDiagnosis returns the indented problem and fix lines shown under a
+// process whose telemetry does not reach cc-top.
+func formatDiagnosis(status scanner.StatusInfo) []string {
+	const indent = "         "
+	var lines []string
+	if status.Fix != "" {
+		lines = append(lines, indent+"fix: "+status.Fix)
+	}
+	for _, d := range status.Signals {
+		if d.OK {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%s: %s", indent, d.Signal, d.Problem))
+		if d.Fix != "" {
+			lines = append(lines, indent+"  fix: "+d.Fix)
+		}
+	}
+	return lines
+}
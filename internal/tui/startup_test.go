@@ -190,3 +190,86 @@ func TestFormatOTLPDest(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderStartup_SignalDiagnosis(t *testing.T) {
+	proc := scanner.ProcessInfo{
+		PID:         7301,
+		Terminal:    "iTerm2",
+		CWD:         "/Users/test/web",
+		EnvReadable: true,
+		EnvVars: map[string]string{
+			"CLAUDE_CODE_ENABLE_TELEMETRY":     "1",
+			"OTEL_METRICS_EXPORTER":            "otlp",
+			"OTEL_LOGS_EXPORTER":               "otlp",
+			"OTEL_EXPORTER_OTLP_PROTOCOL":      "http/protobuf",
+			"OTEL_EXPORTER_OTLP_ENDPOINT":      "http://localhost:4318",
+			"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT": "http://localhost:4317",
+		},
+	}
+	status := scanner.ClassifyTelemetry(proc, scanner.ReceiverPorts{GRPC: 4317, HTTP: 4318}, false)
+
+	m := NewModel(config.DefaultConfig(), WithStartView(ViewStartup), WithScannerProvider(&mockScannerProvider{
+		processes: []scanner.ProcessInfo{proc},
+		statuses:  map[int]scanner.StatusInfo{7301: status},
+	}))
+	m.width = 160
+	m.height = 40
+
+	view := m.renderStartup()
+	for _, want := range []string{
+		"m:4318 l:4317",
+		"logs: http/protobuf sent to cc-top's gRPC port 4317",
+		"fix: set OTEL_EXPORTER_OTLP_LOGS_PROTOCOL=grpc",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("startup view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "metrics:") {
+		t.Error("a signal that reaches cc-top should not be diagnosed")
+	}
+}
+
+func TestRenderStartup_Container(t *testing.T) {
+	proc := scanner.ProcessInfo{
+		PID:         8110,
+		Terminal:    "VS Code",
+		CWD:         "~/src/app",
+		EnvReadable: true,
+		EnvVars: map[string]string{
+			"CLAUDE_CODE_ENABLE_TELEMETRY": "1",
+			"OTEL_EXPORTER_OTLP_ENDPOINT":  "http://localhost:4317",
+		},
+		Container: &scanner.ContainerInfo{
+			Runtime:      "docker",
+			Name:         "app-dev",
+			Devcontainer: true,
+			NetNamespace: true,
+			CWD:          "/workspaces/app",
+			HostCWD:      "/home/u/src/app",
+			HostGateway:  "172.17.0.1",
+		},
+	}
+	status := scanner.ClassifyTelemetry(proc, scanner.ReceiverPorts{GRPC: 4317, HTTP: 4318, Bind: "0.0.0.0"}, false)
+
+	m := NewModel(config.DefaultConfig(), WithStartView(ViewStartup), WithScannerProvider(&mockScannerProvider{
+		processes: []scanner.ProcessInfo{proc},
+		statuses:  map[int]scanner.StatusInfo{8110: status},
+	}))
+	m.width = 160
+	m.height = 40
+
+	view := m.renderStartup()
+	for _, want := range []string{
+		"app-dev",
+		"container: app-dev (docker, devcontainer)  /workspaces/app -> ~/src/app",
+		"Unreachable",
+		"endpoint unreachable from container app-dev",
+		"fix: set OTEL_EXPORTER_OTLP_ENDPOINT=http://172.17.0.1:4317",
+		"1 misconfigured",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("startup view missing %q:\n%s", want, view)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/nixlim/cc-top/internal/events"
 	"github.com/nixlim/cc-top/internal/stats"
 )
 
@@ -43,11 +44,21 @@ func (m Model) renderStats() string {
 		m.renderAPISection(ds),
 		m.renderTokenBreakdownSection(ds),
 		m.renderModelBreakdown(ds),
+		m.renderRepoBreakdown(ds),
+		m.renderTopTurns(ds),
 		m.renderTopTools(ds),
+		m.renderDomains(ds),
+	}
+	if len(m.cachedDelivery) > 0 {
+		sections = append(sections, m.renderDeliverySection())
 	}
 
 	// Join sections and apply scroll.
 	allLines := []string{}
+	if ds.EstimatedSessions > 0 {
+		allLines = append(allLines, dimStyle.Render(fmt.Sprintf(
+			"  Includes %d session(s) estimated from transcript (no OTLP telemetry)", ds.EstimatedSessions)), "")
+	}
 	for _, section := range sections {
 		allLines = append(allLines, section)
 		allLines = append(allLines, "")
@@ -166,6 +177,78 @@ func (m Model) renderModelBreakdown(ds stats.DashboardStats) string {
 	return strings.Join(lines, "\n")
 }
 
+// renderRepoBreakdown renders cost, tokens and lines changed by
+// repository and branch.
+func (m Model) renderRepoBreakdown(ds stats.DashboardStats) string {
+	title := panelTitleStyle.Render("Repository Breakdown")
+	lines := []string{title}
+
+	if len(ds.RepoBreakdown) == 0 {
+		lines = append(lines, dimStyle.Render("  No repository data"))
+	} else {
+		lines = append(lines, fmt.Sprintf("  %-16s %-18s %4s %10s %10s %12s",
+			"Repo", "Branch", "Sess", "Cost", "Tokens", "Lines +/-"))
+		lines = append(lines, dimStyle.Render("  "+strings.Repeat("─", 75)))
+		for _, rs := range ds.RepoBreakdown {
+			branch := rs.Branch
+			if branch == "" {
+				branch = "(detached)"
+			}
+			lines = append(lines, fmt.Sprintf("  %-16s %-18s %4d $%9.2f %10s %12s",
+				truncateStr(rs.Repo, 16), truncateStr(branch, 18), rs.Sessions, rs.TotalCost,
+				formatNumber(rs.TotalTokens), fmt.Sprintf("+%d/-%d", rs.LinesAdded, rs.LinesRemoved)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTopTurns renders the most expensive prompts with the API
+// requests, tool calls and errors each one caused.
+func (m Model) renderTopTurns(ds stats.DashboardStats) string {
+	title := panelTitleStyle.Render("Most Expensive Prompts")
+	lines := []string{title}
+
+	if len(ds.TopTurns) == 0 {
+		lines = append(lines, dimStyle.Render("  No prompt data"))
+	} else {
+		lines = append(lines, fmt.Sprintf("  %-8s %4s %-32s %9s %10s %4s %5s %4s %7s",
+			"Session", "#", "Prompt", "Cost", "Tokens", "Req", "Tools", "Err", "Time"))
+		lines = append(lines, dimStyle.Render("  "+strings.Repeat("─", 91)))
+		for _, t := range ds.TopTurns {
+			lines = append(lines, fmt.Sprintf("  %-8s %4d %-32s $%8.2f %10s %4d %5d %4d %7s",
+				truncateID(t.SessionID, 8), t.Number, truncateStr(t.Prompt, 32), t.CostUSD,
+				formatNumber(t.Tokens), t.APIRequests, t.ToolCalls, t.Errors, formatDuration(t.WallTime)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDomains renders the external domains contacted. With an egress
+// allowlist configured, domains not on it are flagged.
+func (m Model) renderDomains(ds stats.DashboardStats) string {
+	title := panelTitleStyle.Render("Network Egress")
+	lines := []string{title}
+
+	if len(ds.Domains) == 0 {
+		lines = append(lines, dimStyle.Render("  No domains contacted"))
+	} else {
+		allowlist := m.cfg.Alerts.Egress.Allowlist
+		lines = append(lines, fmt.Sprintf("  %-32s %6s %4s %-14s %-14s",
+			"Domain", "Calls", "Sess", "First seen", "Last seen"))
+		lines = append(lines, dimStyle.Render("  "+strings.Repeat("─", 74)))
+		for _, d := range ds.Domains {
+			line := fmt.Sprintf("  %-32s %6d %4d %-14s %-14s",
+				truncateStr(d.Domain, 32), d.Count, d.Sessions,
+				d.FirstSeen.Local().Format("Jan 2 15:04"), d.LastSeen.Local().Format("Jan 2 15:04"))
+			if len(allowlist) > 0 && !events.DomainAllowed(d.Domain, allowlist) {
+				line = alertWarningStyle.Render(line + "  not allowlisted")
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // renderTopTools renders the top tools ranked by frequency.
 func (m Model) renderTopTools(ds stats.DashboardStats) string {
 	title := panelTitleStyle.Render("Top Tools")
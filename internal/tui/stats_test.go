@@ -3,6 +3,7 @@ package tui
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nixlim/cc-top/internal/config"
 	"github.com/nixlim/cc-top/internal/stats"
@@ -206,3 +207,71 @@ func TestRenderTopTools_Empty(t *testing.T) {
 		t.Error("empty top tools should show 'No tool data'")
 	}
 }
+
+func TestRenderRepoBreakdown(t *testing.T) {
+	m := NewModel(config.DefaultConfig())
+
+	if section := m.renderRepoBreakdown(stats.DashboardStats{}); !strings.Contains(section, "No repository data") {
+		t.Error("empty repo breakdown should show 'No repository data'")
+	}
+
+	ds := stats.DashboardStats{RepoBreakdown: []stats.RepoStats{
+		{Repo: "cc-top", Branch: "main", Sessions: 2, TotalCost: 1.25, TotalTokens: 12000, LinesAdded: 40, LinesRemoved: 3},
+		{Repo: "cc-top", Sessions: 1},
+	}}
+	section := m.renderRepoBreakdown(ds)
+	for _, want := range []string{"cc-top", "main", "$     1.25", "12,000", "+40/-3", "(detached)"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("repo breakdown missing %q:\n%s", want, section)
+		}
+	}
+}
+
+func TestRenderTopTurns(t *testing.T) {
+	m := NewModel(config.DefaultConfig())
+
+	if section := m.renderTopTurns(stats.DashboardStats{}); !strings.Contains(section, "No prompt data") {
+		t.Error("empty top turns should show 'No prompt data'")
+	}
+
+	ds := stats.DashboardStats{TopTurns: []stats.TurnCost{
+		{SessionID: "sess-abcdef123", Number: 7, Prompt: "refactor the receiver", CostUSD: 1.75, Tokens: 250000,
+			APIRequests: 12, ToolCalls: 30, Errors: 2, WallTime: 4 * time.Minute},
+	}}
+	section := m.renderTopTurns(ds)
+	for _, want := range []string{"Most Expensive Prompts", "sess-abc", "refactor the receiver", "$    1.75", "250,000", "4m0s"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("top turns missing %q:\n%s", want, section)
+		}
+	}
+}
+
+func TestRenderDomains(t *testing.T) {
+	cfg := config.DefaultConfig()
+	m := NewModel(cfg)
+
+	if section := m.renderDomains(stats.DashboardStats{}); !strings.Contains(section, "No domains contacted") {
+		t.Error("empty inventory should show 'No domains contacted'")
+	}
+
+	seen := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	ds := stats.DashboardStats{Domains: []stats.DomainStats{
+		{Domain: "go.dev", Count: 12, Sessions: 3, FirstSeen: seen, LastSeen: seen.Add(time.Hour)},
+		{Domain: "pastebin.com", Count: 1, Sessions: 1, FirstSeen: seen, LastSeen: seen},
+	}}
+	section := m.renderDomains(ds)
+	for _, want := range []string{"Network Egress", "go.dev", "12", "Oct 18 09:30", "Oct 18 10:30", "pastebin.com"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("domains section missing %q:\n%s", want, section)
+		}
+	}
+	if strings.Contains(section, "not allowlisted") {
+		t.Error("domains should not be flagged without an allowlist")
+	}
+
+	cfg.Alerts.Egress.Allowlist = []string{"go.dev"}
+	section = NewModel(cfg).renderDomains(ds)
+	if n := strings.Count(section, "not allowlisted"); n != 1 {
+		t.Errorf("expected only pastebin.com flagged, got %d flags:\n%s", n, section)
+	}
+}